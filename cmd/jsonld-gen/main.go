@@ -0,0 +1,67 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command jsonld-gen generates Go constants for the terms defined in a JSON-LD context.
+//
+// Usage:
+//
+//	jsonld-gen -context https://www.w3.org/ns/credentials/v2 -package vocab -out vocab/terms.go
+//
+// If -context is a local file, its contents are embedded into the generated code.
+// Otherwise, the generated documents reference the context by URL.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"net/url"
+	"os"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+func main() {
+	contextLocation := flag.String("context", "", "URL or path of the JSON-LD context")
+	packageName := flag.String("package", "main", "package name of the generated file")
+	outputPath := flag.String("out", "", "output file (default: stdout)")
+	flag.Parse()
+
+	if *contextLocation == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var localContext interface{} = *contextLocation
+	if u, err := url.Parse(*contextLocation); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		rd, err := ld.NewDefaultDocumentLoader(nil).LoadDocument(*contextLocation)
+		if err != nil {
+			log.Fatalf("failed to load context: %v", err)
+		}
+		localContext = rd.Document
+	}
+
+	var buf bytes.Buffer
+	if err := ld.GenerateGoConstants(&buf, *packageName, localContext, nil); err != nil {
+		log.Fatalf("failed to generate Go constants: %v", err)
+	}
+
+	if *outputPath == "" {
+		_, _ = os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := os.WriteFile(*outputPath, buf.Bytes(), 0o644); err != nil { //nolint:gosec
+		log.Fatalf("failed to write %s: %v", *outputPath, err)
+	}
+}
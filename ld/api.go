@@ -14,15 +14,69 @@
 
 package ld
 
+import "fmt"
+
 // JsonLdApi exposes internal functions used by JsonLdProcessor.
 // See http://www.w3.org/TR/json-ld-api/ for detailed description of
 // underlying algorithms
 //
 // Warning: using this interface directly is highly discouraged. Please use JsonLdProcessor instead.
 type JsonLdApi struct { //nolint:stylecheck
+	// maxDepth limits nesting of arrays and objects when there are no options
+	// to take JsonLdOptions.MaxDepth from, such as in GenerateNodeMap.
+	maxDepth int
+	depth    int
+
+	// referenceCounts, when set, collects the number of references to each node
+	// seen by GenerateNodeMap.
+	referenceCounts map[string]int
+
+	// unmappedMembers, when set, collects the top-level members of the document
+	// which Expand drops because they don't map to an absolute IRI or keyword.
+	unmappedMembers map[string]interface{}
+
+	// coercions, when set, collects the coercions applied by Expand, see AnalyzeCoercions.
+	coercions *coercionAnalyzer
 }
 
-// NewJsonLdApi creates a new instance of JsonLdApi.
+// NewJsonLdApi creates a new instance of JsonLdApi. Expand and Compact limit nesting
+// of documents according to JsonLdOptions.MaxDepth of the given options and active context,
+// GenerateNodeMap uses DefaultMaxDepth.
 func NewJsonLdApi() *JsonLdApi { //nolint:stylecheck
-	return &JsonLdApi{}
+	return &JsonLdApi{
+		maxDepth: DefaultMaxDepth,
+	}
+}
+
+// newJsonLdApi creates a new instance of JsonLdApi which enforces the limits
+// configured in the given options.
+func newJsonLdApi(opts *JsonLdOptions) *JsonLdApi {
+	return &JsonLdApi{
+		maxDepth: opts.MaxDepth,
+	}
+}
+
+// maxDepthOf returns the nesting limit set in the given options,
+// or the limit of this instance if there are no options.
+func (api *JsonLdApi) maxDepthOf(opts *JsonLdOptions) int {
+	if opts == nil {
+		return api.maxDepth
+	}
+	return opts.MaxDepth
+}
+
+// descend registers a step into a nested array or object and fails
+// if the nesting exceeds the given limit. Zero limit means no limit.
+// Each successful call must be followed by a call to ascend.
+func (api *JsonLdApi) descend(limit int) error {
+	if limit > 0 && api.depth >= limit {
+		return NewJsonLdError(MaxDepthExceeded, fmt.Sprintf("document is nested deeper than %d levels", limit))
+	}
+	api.depth++
+	return nil
+}
+
+// ascend registers a step out of a nested array or object.
+func (api *JsonLdApi) ascend() {
+	api.depth--
 }
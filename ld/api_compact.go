@@ -28,6 +28,14 @@ import (
 func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element interface{},
 	compactArrays bool) (interface{}, error) {
 
+	switch element.(type) {
+	case []interface{}, map[string]interface{}:
+		if err := api.descend(api.maxDepthOf(activeCtx.options)); err != nil {
+			return nil, err
+		}
+		defer api.ascend()
+	}
+
 	if elementList, isList := element.([]interface{}); isList {
 		result := make([]interface{}, 0)
 		for _, item := range elementList {
@@ -50,7 +58,7 @@ func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element
 	// use any scoped context on activeProperty
 	td := activeCtx.GetTermDefinition(activeProperty)
 	if ctx, hasCtx := td["@context"]; hasCtx {
-		newCtx, err := activeCtx.parse(ctx, make([]string, 0), false, true, false, true)
+		newCtx, err := activeCtx.parse(ctx, make([]string, 0), false, true, false, true, false)
 		if err != nil {
 			return nil, err
 		}
@@ -94,9 +102,9 @@ func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element
 		}
 
 		// apply property-scoped context after reverting term-scoped context
-		propertyScopedCtx := inputCtx.GetTermDefinition(activeProperty)["@context"]
-		if propertyScopedCtx != nil {
-			newCtx, err := activeCtx.parse(propertyScopedCtx, nil, false, true, false, true)
+		propertyScopedCtx, hasPropertyScopedCtx := inputCtx.GetTermDefinition(activeProperty)["@context"]
+		if hasPropertyScopedCtx {
+			newCtx, err := activeCtx.parse(propertyScopedCtx, nil, false, true, false, true, false)
 			if err != nil {
 				return nil, err
 			}
@@ -124,7 +132,7 @@ func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element
 			for _, tt := range types {
 				td := inputCtx.GetTermDefinition(tt)
 				if ctx, hasCtx := td["@context"]; hasCtx {
-					newCtx, err := activeCtx.parse(ctx, nil, false, false, false, false)
+					newCtx, err := activeCtx.parse(ctx, nil, false, false, false, false, false)
 					if err != nil {
 						return nil, err
 					}
@@ -202,14 +210,21 @@ func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element
 
 			if expandedProperty == "@reverse" {
 
-				compactedObject, _ := api.Compact(activeCtx, "@reverse", expandedValue, compactArrays)
-				compactedValue := compactedObject.(map[string]interface{})
+				compactedObject, err := api.Compact(activeCtx, "@reverse", expandedValue, compactArrays)
+				if err != nil {
+					return nil, err
+				}
+				compactedValue, _ := compactedObject.(map[string]interface{})
 
-				for _, property := range GetKeys(compactedValue) {
+				for _, property := range GetOrderedKeys(compactedValue) {
 					value := compactedValue[property]
 
 					if activeCtx.IsReverseProperty(property) {
-						useArray := activeCtx.HasContainerMapping(property, "@set") || !compactArrays
+						// values of reverse properties with a @set container (including @index/@set)
+						// are always arrays, unless they have been grouped into an index map
+						_, isIndexMap := value.(map[string]interface{})
+						isIndexMap = isIndexMap && activeCtx.HasContainerMapping(property, "@index")
+						useArray := (activeCtx.HasContainerMapping(property, "@set") || !compactArrays) && !isIndexMap
 
 						AddValue(result, property, value, useArray, false, true, false)
 
@@ -368,7 +383,8 @@ func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element
 
 				// graph object compaction
 				if isGraph {
-					asArray := !compactArrays || isSetContainer
+					// graph objects which are values of @graph are always kept in an array
+					asArray := !compactArrays || isSetContainer || expandedProperty == "@graph"
 					if isGraphContainer && (isIDContainer || isIndexContainer && IsSimpleGraph(expandedItemMap)) {
 						var mapObject map[string]interface{}
 						if v, present := nestResult[itemActiveProperty]; present {
@@ -386,6 +402,13 @@ func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element
 						var mapKey string
 						if v, found := expandedItemMap[k]; found {
 							mapKey = v.(string)
+							if isIDContainer {
+								// graph names are compacted relative to the base, like @id values
+								mapKey, err = activeCtx.CompactIri(mapKey, nil, false, false)
+								if err != nil {
+									return nil, err
+								}
+							}
 						} else {
 							mapKey, err = activeCtx.CompactIri("@none", nil, false, false)
 							if err != nil {
@@ -435,7 +458,10 @@ func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element
 							if err != nil {
 								return nil, err
 							}
-							compactedItemMap[idAlias] = val
+							compactedItemMap[idAlias], err = activeCtx.CompactIri(val.(string), nil, false, false)
+							if err != nil {
+								return nil, err
+							}
 						}
 
 						// include @index from expanded graph, if any
@@ -523,12 +549,11 @@ func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element
 						if err != nil {
 							return nil, err
 						}
-						compactedItemMap := compactedItem.(map[string]interface{})
-						if compactedItemValue, containsValue := compactedItemMap[idKey]; containsValue {
-							mapKey = compactedItemValue.(string)
+						// the value of @id is already compacted (as a CURIE or a relative IRI)
+						compactedItemMap, isMap := compactedItem.(map[string]interface{})
+						if compactedItemValue, containsValue := compactedItemMap[idKey].(string); isMap && containsValue {
+							mapKey = compactedItemValue
 							delete(compactedItemMap, idKey)
-						} else {
-							mapKey = ""
 						}
 					} else if isTypeContainer {
 						typeKey, err := activeCtx.CompactIri("@type", nil, false, false)
@@ -536,40 +561,31 @@ func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element
 							return nil, err
 						}
 
-						compactedItemMap := compactedItem.(map[string]interface{})
-						var types []interface{}
-						if compactedItemValue, containsValue := compactedItemMap[typeKey]; containsValue {
-							var isArray bool
-							types, isArray = compactedItemValue.([]interface{})
-							if !isArray {
-								types = []interface{}{compactedItemValue}
-							}
-
+						// node references without types may already be compacted into strings,
+						// they go under @none
+						if compactedItemMap, isMap := compactedItem.(map[string]interface{}); isMap {
+							types := Arrayify(compactedItemMap[typeKey])
 							delete(compactedItemMap, typeKey)
 							if len(types) > 0 {
-								mapKey = types[0].(string)
-								types = types[1:]
+								mapKey, _ = types[0].(string)
+								if len(types) > 1 {
+									AddValue(compactedItemMap, typeKey, types[1:], false, false, false, false)
+								}
 							}
-						} else {
-							types = make([]interface{}, 0)
-						}
 
-						// if compactedItem contains a single entry whose key maps to @id, re-compact without @type
-						if len(compactedItemMap) == 1 {
-							if idVal, hasID := expandedItemMap["@id"]; hasID {
-								compactedItem, err = api.Compact(activeCtx, itemActiveProperty,
-									map[string]interface{}{
-										"@id": idVal,
-									}, compactArrays)
-								if err != nil {
-									return nil, err
+							// if compactedItem contains a single entry whose key maps to @id, re-compact without @type
+							if len(compactedItemMap) == 1 {
+								if idVal, hasID := expandedItemMap["@id"]; hasID {
+									compactedItem, err = api.Compact(activeCtx, itemActiveProperty,
+										map[string]interface{}{
+											"@id": idVal,
+										}, compactArrays)
+									if err != nil {
+										return nil, err
+									}
 								}
 							}
 						}
-
-						if len(types) > 0 {
-							AddValue(compactedItemMap, typeKey, types, false, false, false, false)
-						}
 					}
 
 					if mapKey == "" {
@@ -591,12 +607,59 @@ func (api *JsonLdApi) Compact(activeCtx *Context, activeProperty string, element
 			}
 		}
 
+		if activeCtx.options != nil && activeCtx.options.OmitEmpty != 0 {
+			if err := omitEmptyValues(activeCtx, result, activeCtx.options.OmitEmpty); err != nil {
+				return nil, err
+			}
+		}
+
 		return result, nil
 	}
 
 	return element, nil
 }
 
+// omitEmptyValues removes properties with empty values, as selected by omit, from a compacted
+// node object. Keyword entries are kept, but properties of nested objects (@nest) are processed, too.
+func omitEmptyValues(activeCtx *Context, result map[string]interface{}, omit EmptyValue) error {
+	for key, value := range result {
+		expandedKey, err := activeCtx.ExpandIri(key, false, true, nil, nil)
+		if err != nil {
+			return err
+		}
+		if expandedKey == "@nest" {
+			if nestedMap, isMap := value.(map[string]interface{}); isMap {
+				if err = omitEmptyValues(activeCtx, nestedMap, omit); err != nil {
+					return err
+				}
+				// drop nested objects which only had empty properties
+				if len(nestedMap) == 0 {
+					delete(result, key)
+				}
+			}
+			continue
+		}
+		if !IsKeyword(expandedKey) && isEmptyValue(value, omit) {
+			delete(result, key)
+		}
+	}
+	return nil
+}
+
+// isEmptyValue returns true if the given value belongs to one of the classes of empty values in omit.
+func isEmptyValue(value interface{}, omit EmptyValue) bool {
+	switch v := value.(type) {
+	case nil:
+		return omit&EmptyNull != 0
+	case []interface{}:
+		return len(v) == 0 && omit&EmptyArray != 0
+	case map[string]interface{}:
+		return len(v) == 0 && omit&EmptyObject != 0
+	default:
+		return false
+	}
+}
+
 // checkNestProperty ensures that the value of `@nest` in the term definition must
 // either be "@nest", or a term which resolves to "@nest".
 func (api *JsonLdApi) checkNestProperty(activeCtx *Context, nestProperty string) error {
@@ -0,0 +1,720 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactReversePropertyContainers(t *testing.T) {
+	expanded := `[{
+		"@id": "http://example.com/parent",
+		"@reverse": {
+			"http://example.com/parent": [
+				{"@id": "http://example.com/c1", "@index": "one"},
+				{"@id": "http://example.com/c2", "@index": "two"}
+			]
+		}
+	}]`
+
+	tests := []struct {
+		name          string
+		container     interface{}
+		compactArrays bool
+		expected      string
+	}{
+		{
+			name:          "@set",
+			container:     "@set",
+			compactArrays: true,
+			expected: `[
+				{"@id": "http://example.com/c1", "@index": "one"},
+				{"@id": "http://example.com/c2", "@index": "two"}
+			]`,
+		},
+		{
+			name:          "@index",
+			container:     "@index",
+			compactArrays: true,
+			expected:      `{"one": {"@id": "http://example.com/c1"}, "two": {"@id": "http://example.com/c2"}}`,
+		},
+		{
+			name:          "@index and @set",
+			container:     []interface{}{"@index", "@set"},
+			compactArrays: true,
+			expected:      `{"one": [{"@id": "http://example.com/c1"}], "two": [{"@id": "http://example.com/c2"}]}`,
+		},
+		{
+			name:          "@index without compactArrays",
+			container:     "@index",
+			compactArrays: false,
+			expected:      `{"one": {"@id": "http://example.com/c1"}, "two": {"@id": "http://example.com/c2"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input, expectedValue interface{}
+			require.NoError(t, json.Unmarshal([]byte(expanded), &input))
+			require.NoError(t, json.Unmarshal([]byte(tt.expected), &expectedValue))
+
+			context := map[string]interface{}{
+				"@version": 1.1,
+				"children": map[string]interface{}{
+					"@reverse":   "http://example.com/parent",
+					"@container": tt.container,
+				},
+			}
+
+			proc := NewJsonLdProcessor()
+			opts := NewJsonLdOptions("")
+			opts.CompactArrays = tt.compactArrays
+			compacted, err := proc.Compact(input, context, opts)
+			require.NoError(t, err)
+
+			node := compacted
+			if graph, hasGraph := compacted["@graph"]; hasGraph {
+				node = graph.([]interface{})[0].(map[string]interface{})
+			}
+			assert.Equal(t, expectedValue, node["children"])
+
+			// compacted form must expand back into the original document
+			reexpanded, err := proc.Expand(compacted, NewJsonLdOptions(""))
+			require.NoError(t, err)
+			assert.True(t, DeepCompare(input, reexpanded, false))
+		})
+	}
+}
+
+func TestCompactGraphContainersWithoutCompactArrays(t *testing.T) {
+	expanded := `[{
+		"@id": "http://example.com/s",
+		"http://example.com/input": [{
+			"@graph": [{"@id": "http://example.com/n", "http://example.com/v": [{"@value": "x"}]}]
+		}]
+	}]`
+	namedGraph := `[{
+		"@id": "http://example.com/s",
+		"http://example.com/input": [{
+			"@id": "http://example.com/graphs/g",
+			"@graph": [{"@id": "http://example.com/n", "http://example.com/v": [{"@value": "x"}]}]
+		}]
+	}]`
+	indexedGraph := `[{
+		"@id": "http://example.com/s",
+		"http://example.com/input": [{
+			"@index": "i",
+			"@graph": [{"@id": "http://example.com/n", "http://example.com/v": [{"@value": "x"}]}]
+		}]
+	}]`
+	twoNodeGraph := `[{
+		"@id": "http://example.com/s",
+		"http://example.com/input": [{
+			"@graph": [
+				{"@id": "http://example.com/m", "http://example.com/v": [{"@value": "y"}]},
+				{"@id": "http://example.com/n", "http://example.com/v": [{"@value": "x"}]}
+			]
+		}]
+	}]`
+	graphNode := `{"@id": "http://example.com/n", "http://example.com/v": ["x"]}`
+
+	tests := []struct {
+		name      string
+		input     string
+		container interface{}
+		expected  string
+		// lossy is set where re-expansion wraps the compacted value
+		// in another graph object, as the expansion algorithm requires
+		lossy bool
+	}{
+		{
+			name:     "no container",
+			expected: `[{"@graph": [` + graphNode + `]}]`,
+		},
+		{
+			name:      "@graph",
+			container: "@graph",
+			expected:  `[` + graphNode + `]`,
+		},
+		{
+			name:      "@graph and @set",
+			container: []interface{}{"@graph", "@set"},
+			expected:  `[` + graphNode + `]`,
+		},
+		{
+			name:      "@graph and @id",
+			container: []interface{}{"@graph", "@id"},
+			expected:  `{"@none": [` + graphNode + `]}`,
+		},
+		{
+			name:      "@graph and @index",
+			container: []interface{}{"@graph", "@index"},
+			expected:  `{"@none": [` + graphNode + `]}`,
+		},
+		{
+			name:     "named graph",
+			input:    namedGraph,
+			expected: `[{"@id": "graphs:g", "@graph": [` + graphNode + `]}]`,
+		},
+		{
+			name:      "named graph in @graph",
+			input:     namedGraph,
+			container: "@graph",
+			expected:  `[{"@id": "graphs:g", "@graph": [` + graphNode + `]}]`,
+			lossy:     true,
+		},
+		{
+			name:      "named graph in @graph and @id",
+			input:     namedGraph,
+			container: []interface{}{"@graph", "@id"},
+			expected:  `{"graphs:g": [` + graphNode + `]}`,
+		},
+		{
+			name:      "indexed graph in @graph and @index",
+			input:     indexedGraph,
+			container: []interface{}{"@graph", "@index"},
+			expected:  `{"i": [` + graphNode + `]}`,
+		},
+		{
+			name:      "graph with several nodes in @graph",
+			input:     twoNodeGraph,
+			container: "@graph",
+			expected: `[{"@included": [{"@id": "http://example.com/m", "http://example.com/v": ["y"]}, ` +
+				graphNode + `]}]`,
+			lossy: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.input == "" {
+				tt.input = expanded
+			}
+			var input, expectedValue interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &input))
+			require.NoError(t, json.Unmarshal([]byte(tt.expected), &expectedValue))
+
+			term := map[string]interface{}{
+				"@id": "http://example.com/input",
+			}
+			if tt.container != nil {
+				term["@container"] = tt.container
+			}
+			context := map[string]interface{}{
+				"@version": 1.1,
+				"graphs":   "http://example.com/graphs/",
+				"input":    term,
+			}
+
+			proc := NewJsonLdProcessor()
+			opts := NewJsonLdOptions("")
+			opts.CompactArrays = false
+			compacted, err := proc.Compact(input, context, opts)
+			require.NoError(t, err)
+
+			node := compacted["@graph"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, expectedValue, node["input"])
+
+			if tt.lossy {
+				return
+			}
+			reexpanded, err := proc.Expand(compacted, NewJsonLdOptions(""))
+			require.NoError(t, err)
+			assert.True(t, DeepCompare(input, reexpanded, false))
+		})
+	}
+
+	t.Run("graph objects in @graph", func(t *testing.T) {
+		// values of @graph are kept in an array even if compactArrays is set
+		var input, expectedValue interface{}
+		require.NoError(t, json.Unmarshal([]byte(`[{
+			"@id": "http://example.com/s",
+			"@graph": [{
+				"@id": "http://example.com/graphs/g",
+				"@graph": [{"@id": "http://example.com/n", "http://example.com/v": [{"@value": "x"}]}]
+			}]
+		}]`), &input))
+		require.NoError(t, json.Unmarshal([]byte(`[{
+			"@id": "graphs:g",
+			"@graph": {"@id": "http://example.com/n", "http://example.com/v": "x"}
+		}]`), &expectedValue))
+
+		context := map[string]interface{}{
+			"@version": 1.1,
+			"graphs":   "http://example.com/graphs/",
+		}
+		compacted, err := NewJsonLdProcessor().Compact(input, context, nil)
+		require.NoError(t, err)
+		assert.Equal(t, expectedValue, compacted["@graph"])
+	})
+}
+
+func TestCompactBlankNodeRewriter(t *testing.T) {
+	input := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"ex":    "http://example.com/",
+			"knows": map[string]interface{}{"@id": "ex:knows", "@type": "@id"},
+		},
+		"@id": "_:alice",
+		"knows": map[string]interface{}{
+			"@id":   "_:bob",
+			"knows": "_:alice",
+		},
+	}
+	context := map[string]interface{}{
+		"ex":    "http://example.com/",
+		"knows": map[string]interface{}{"@id": "ex:knows", "@type": "@id"},
+	}
+
+	t.Run("relabel", func(t *testing.T) {
+		opts := NewJsonLdOptions("")
+		opts.BlankNodeRewriter = NewIdentifierIssuer("_:n").GetId
+		compacted, err := NewJsonLdProcessor().Compact(input, context, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "_:n0", compacted["@id"])
+		assert.Equal(t, "_:n1", compacted["knows"].(map[string]interface{})["@id"])
+		assert.Equal(t, "_:n0", compacted["knows"].(map[string]interface{})["knows"])
+	})
+
+	t.Run("skolemize", func(t *testing.T) {
+		opts := NewJsonLdOptions("")
+		opts.BlankNodeRewriter = SkolemIRIRewriter("http://example.com/")
+		compacted, err := NewJsonLdProcessor().Compact(input, context, opts)
+		require.NoError(t, err)
+		// skolem IRIs are compacted like any other IRI
+		assert.Equal(t, "ex:.well-known/genid/alice", compacted["@id"])
+		assert.Equal(t, "ex:.well-known/genid/bob", compacted["knows"].(map[string]interface{})["@id"])
+		assert.Equal(t, "ex:.well-known/genid/alice", compacted["knows"].(map[string]interface{})["knows"])
+	})
+
+	t.Run("framing", func(t *testing.T) {
+		frame := map[string]interface{}{
+			"@context": context,
+			"@type":    "ex:Person",
+		}
+		opts := NewJsonLdOptions("")
+		opts.BlankNodeRewriter = NewIdentifierIssuer("_:n").GetId
+		framed, err := NewJsonLdProcessor().Frame(map[string]interface{}{
+			"@context": context,
+			"@type":    "ex:Person",
+			"knows":    "ex:bob",
+		}, frame, opts)
+		require.NoError(t, err)
+
+		// blank nodes referenced only once are still pruned
+		assert.Equal(t, map[string]interface{}{
+			"@type": "ex:Person",
+			"knows": "ex:bob",
+		}, framed["@graph"].([]interface{})[0])
+	})
+}
+
+func TestCompactOmitEmpty(t *testing.T) {
+	context := map[string]interface{}{
+		"@vocab": "http://example.com/",
+		"data":   map[string]interface{}{"@type": "@json"},
+		"tags":   map[string]interface{}{"@container": "@set"},
+		"info":   map[string]interface{}{"@nest": "@nest"},
+	}
+	doc := map[string]interface{}{
+		"@context": context,
+		"@id":      "http://example.com/1",
+		"name":     "Alice",
+		"data":     nil,
+		"tags":     []interface{}{},
+		"friend": map[string]interface{}{
+			"@id":  "http://example.com/2",
+			"tags": []interface{}{},
+		},
+		"address": map[string]interface{}{
+			"street": []interface{}{},
+		},
+		"@nest": map[string]interface{}{
+			"info": []interface{}{},
+		},
+	}
+
+	compactWith := func(omit EmptyValue) map[string]interface{} {
+		opts := NewJsonLdOptions("")
+		opts.OmitEmpty = omit
+		compacted, err := NewJsonLdProcessor().Compact(doc, context, opts)
+		require.NoError(t, err)
+		delete(compacted, "@context")
+		return compacted
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"@id":     "http://example.com/1",
+		"name":    "Alice",
+		"data":    nil,
+		"tags":    []interface{}{},
+		"friend":  map[string]interface{}{"@id": "http://example.com/2", "tags": []interface{}{}},
+		"address": map[string]interface{}{"street": []interface{}{}},
+		"@nest":   map[string]interface{}{"info": []interface{}{}},
+	}, compactWith(0))
+
+	assert.Equal(t, map[string]interface{}{
+		"@id":     "http://example.com/1",
+		"name":    "Alice",
+		"data":    nil,
+		"friend":  map[string]interface{}{"@id": "http://example.com/2"},
+		"address": map[string]interface{}{},
+	}, compactWith(EmptyArray))
+
+	assert.Equal(t, map[string]interface{}{
+		"@id":    "http://example.com/1",
+		"name":   "Alice",
+		"friend": map[string]interface{}{"@id": "http://example.com/2"},
+	}, compactWith(EmptyAll))
+}
+
+func TestCompactTermSelector(t *testing.T) {
+	context := map[string]interface{}{
+		"nm":   "http://schema.org/name",
+		"name": "http://schema.org/name",
+		"ref":  map[string]interface{}{"@id": "http://schema.org/url", "@type": "@id"},
+		"url":  "http://schema.org/url",
+	}
+	doc := map[string]interface{}{
+		"http://schema.org/name": "Alice",
+		"http://schema.org/url": []interface{}{
+			map[string]interface{}{"@id": "http://example.com/alice"},
+			map[string]interface{}{"@value": "not an IRI"},
+		},
+	}
+
+	compactWith := func(selector TermSelector) map[string]interface{} {
+		opts := NewJsonLdOptions("")
+		opts.TermSelector = selector
+		compacted, err := NewJsonLdProcessor().Compact(doc, context, opts)
+		require.NoError(t, err)
+		delete(compacted, "@context")
+		return compacted
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"nm":  "Alice",
+		"ref": "http://example.com/alice",
+		"url": "not an IRI",
+	}, compactWith(nil))
+
+	preferred := map[string]int{"name": 0, "url": 1}
+	assert.Equal(t, map[string]interface{}{
+		"name": "Alice",
+		// the type mapping of ref matches node references, which takes precedence over the selector
+		"ref": "http://example.com/alice",
+		"url": "not an IRI",
+	}, compactWith(func(iri, a, b string) int {
+		rankA, preferA := preferred[a]
+		rankB, preferB := preferred[b]
+		switch {
+		case preferA && preferB:
+			return rankA - rankB
+		case preferA:
+			return -1
+		case preferB:
+			return 1
+		}
+		return 0
+	}))
+}
+
+func TestCompactLanguageMapNone(t *testing.T) {
+	tests := []struct {
+		name     string
+		context  map[string]interface{}
+		term     map[string]interface{}
+		input    interface{}
+		expected interface{}
+	}{
+		{
+			name:     "@none",
+			input:    map[string]interface{}{"en": "a", "@none": "b"},
+			expected: map[string]interface{}{"en": "a", "@none": "b"},
+		},
+		{
+			name:     "@none alias",
+			context:  map[string]interface{}{"none": "@none"},
+			input:    map[string]interface{}{"en": "a", "none": "b"},
+			expected: map[string]interface{}{"en": "a", "none": "b"},
+		},
+		{
+			name:     "default language",
+			context:  map[string]interface{}{"@language": "de"},
+			input:    map[string]interface{}{"en": "a", "@none": "b"},
+			expected: map[string]interface{}{"en": "a", "@none": "b"},
+		},
+		{
+			name:     "@set",
+			term:     map[string]interface{}{"@container": []interface{}{"@language", "@set"}},
+			input:    map[string]interface{}{"en": "a", "@none": []interface{}{"b", "c"}},
+			expected: map[string]interface{}{"en": []interface{}{"a"}, "@none": []interface{}{"b", "c"}},
+		},
+		{
+			name:     "term direction",
+			term:     map[string]interface{}{"@direction": "rtl"},
+			input:    map[string]interface{}{"en": "a", "@none": "b"},
+			expected: map[string]interface{}{"en": "a", "@none": "b"},
+		},
+		{
+			name:     "default direction",
+			context:  map[string]interface{}{"@direction": "ltr", "none": "@none"},
+			input:    map[string]interface{}{"en": "a", "none": "b"},
+			expected: map[string]interface{}{"en": "a", "none": "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := map[string]interface{}{"@container": "@language"}
+			for k, v := range tt.term {
+				term[k] = v
+			}
+			context := map[string]interface{}{
+				"@version": 1.1,
+				"@vocab":   "http://example.com/",
+				"label":    term,
+			}
+			for k, v := range tt.context {
+				context[k] = v
+			}
+
+			proc := NewJsonLdProcessor()
+			expanded, err := proc.Expand(map[string]interface{}{
+				"@context": context,
+				"label":    tt.input,
+			}, nil)
+			require.NoError(t, err)
+
+			compacted, err := proc.Compact(expanded, context, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, compacted["label"])
+
+			reexpanded, err := proc.Expand(compacted, nil)
+			require.NoError(t, err)
+			assert.True(t, DeepCompare(expanded, reexpanded, false))
+		})
+	}
+}
+
+func TestCompactContextVerbatim(t *testing.T) {
+	var context interface{}
+	require.NoError(t, json.Unmarshal([]byte(`[
+		"http://example.com/schema.jsonld",
+		{
+			"@version": 1.1,
+			"Person": {"@id": "http://schema.org/Person", "@context": {"nick": "http://example.com/nick"}}
+		},
+		{"@import": "http://example.com/extra.jsonld", "@propagate": true}
+	]`), &context))
+	original, err := json.Marshal(context)
+	require.NoError(t, err)
+
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = NewMapDocumentLoader(map[string]interface{}{
+		"http://example.com/schema.jsonld": `{"@context": {"@vocab": "http://schema.org/"}}`,
+		"http://example.com/extra.jsonld":  `{"@context": {"knows": {"@id": "http://schema.org/knows", "@type": "@id"}}}`,
+	})
+	doc := map[string]interface{}{
+		"@id":                     "http://example.com/alice",
+		"@type":                   "http://schema.org/Person",
+		"http://schema.org/name":  "Alice",
+		"http://schema.org/knows": map[string]interface{}{"@id": "http://example.com/bob"},
+		"http://example.com/nick": "Al",
+	}
+
+	proc := NewJsonLdProcessor()
+	compacted, err := proc.Compact(doc, context, opts)
+	require.NoError(t, err)
+	emitted, err := json.Marshal(compacted["@context"])
+	require.NoError(t, err)
+	assert.JSONEq(t, string(original), string(emitted))
+	assert.Equal(t, "http://example.com/bob", compacted["knows"])
+	assert.Equal(t, "Al", compacted["nick"])
+
+	t.Run("AppendContext", func(t *testing.T) {
+		opts := opts.Copy()
+		opts.AppendContext = map[string]interface{}{"alias": "http://schema.org/name"}
+		compacted, err := proc.Compact(doc, context, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", compacted["alias"])
+		assert.Equal(t, append(append([]interface{}{}, context.([]interface{})...), opts.AppendContext),
+			compacted["@context"])
+
+		// the extra context is used on its own if no context is given
+		compacted, err = proc.Compact(doc, nil, opts)
+		require.NoError(t, err)
+		assert.Equal(t, opts.AppendContext, compacted["@context"])
+		assert.Equal(t, "Alice", compacted["alias"])
+	})
+
+	t.Run("InlineContexts", func(t *testing.T) {
+		opts := opts.Copy()
+		opts.InlineContexts = true
+		compacted, err := proc.Compact(doc, context, opts)
+		require.NoError(t, err)
+		emitted := compacted["@context"].([]interface{})
+		require.Len(t, emitted, 3)
+		assert.Equal(t, map[string]interface{}{"@vocab": "http://schema.org/"}, emitted[0])
+		assert.Equal(t, context.([]interface{})[1:], emitted[1:])
+
+		// the original context isn't modified
+		actual, err := json.Marshal(context)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(original), string(actual))
+
+		_, err = proc.Compact(doc, "http://example.com/missing.jsonld", opts)
+		require.Error(t, err)
+	})
+
+	t.Run("InlineContexts with an array-valued remote context", func(t *testing.T) {
+		opts := opts.Copy()
+		opts.InlineContexts = true
+		opts.DocumentLoader = NewMapDocumentLoader(map[string]interface{}{
+			"http://example.com/array.jsonld": `{"@context": [
+				{"@vocab": "http://schema.org/"},
+				{"knows": {"@id": "http://schema.org/knows", "@type": "@id"}}
+			]}`,
+		})
+		expected, err := proc.Expand(doc, opts)
+		require.NoError(t, err)
+
+		for _, context := range []interface{}{
+			"http://example.com/array.jsonld",
+			[]interface{}{"http://example.com/array.jsonld", map[string]interface{}{"nick": "http://example.com/nick"}},
+		} {
+			compacted, err := proc.Compact(doc, context, opts)
+			require.NoError(t, err)
+			emitted := compacted["@context"].([]interface{})
+			assert.Equal(t, map[string]interface{}{"@vocab": "http://schema.org/"}, emitted[0])
+			assert.Equal(t, map[string]interface{}{
+				"knows": map[string]interface{}{"@id": "http://schema.org/knows", "@type": "@id"},
+			}, emitted[1])
+			assert.Equal(t, "http://example.com/bob", compacted["knows"])
+
+			// the output expands to the same document without the remote context
+			reexpanded, err := proc.Expand(compacted, NewJsonLdOptions(""))
+			require.NoError(t, err)
+			assert.Equal(t, expected, reexpanded)
+		}
+	})
+
+	t.Run("InlineContexts with relative references and @base", func(t *testing.T) {
+		loader := &countingDocumentLoader{
+			DocumentLoader: NewMapDocumentLoader(map[string]interface{}{
+				"http://example.com/contexts/main.jsonld": `{"@context": [
+					"vocab.jsonld",
+					{
+						"@base": "http://example.org/",
+						"Person": {"@id": "http://schema.org/Person", "@context": "person.jsonld"}
+					}
+				]}`,
+				"http://example.com/contexts/vocab.jsonld":  `{"@context": {"@vocab": "http://schema.org/"}}`,
+				"http://example.com/contexts/person.jsonld": `{"@context": {"nick": "http://example.com/nick"}}`,
+			}),
+		}
+		opts := NewJsonLdOptions("")
+		opts.InlineContexts = true
+		opts.DocumentLoader = loader
+
+		compacted, err := proc.Compact(doc, "http://example.com/contexts/main.jsonld", opts)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			"http://example.com/contexts/vocab.jsonld",
+			map[string]interface{}{
+				"Person": map[string]interface{}{
+					"@id":      "http://schema.org/Person",
+					"@context": "http://example.com/contexts/person.jsonld",
+				},
+			},
+		}, compacted["@context"])
+		assert.Equal(t, "Al", compacted["nick"])
+
+		// the remote contexts are loaded once
+		assert.Equal(t, 3, loader.calls)
+	})
+}
+
+func TestCompactIDAndTypeMapKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		container interface{}
+		input     interface{}
+		expected  interface{}
+	}{
+		{
+			name:      "@id map",
+			container: "@id",
+			input:     map[string]interface{}{"http://example.com/a": map[string]interface{}{"ex:p": "x"}, "none": map[string]interface{}{"ex:p": "y"}},
+			expected:  map[string]interface{}{"ex:a": map[string]interface{}{"ex:p": "x"}, "none": map[string]interface{}{"ex:p": "y"}},
+		},
+		{
+			name:      "graph @id map",
+			container: []interface{}{"@graph", "@id"},
+			input: map[string]interface{}{
+				"http://example.com/g1": map[string]interface{}{"@id": "ex:e", "ex:p": "z"},
+				"@none":                 map[string]interface{}{"@id": "ex:f", "ex:p": "w"},
+			},
+			expected: map[string]interface{}{
+				"ex:g1": map[string]interface{}{"@id": "ex:e", "ex:p": "z"},
+				"none":  map[string]interface{}{"@id": "ex:f", "ex:p": "w"},
+			},
+		},
+		{
+			name:      "@type map",
+			container: "@type",
+			input:     map[string]interface{}{"http://example.com/T1": "ex:b", "Thing": "ex:c", "@none": "ex:d"},
+			expected:  map[string]interface{}{"ex:T1": "ex:b", "Thing": "ex:c", "none": "ex:d"},
+		},
+		{
+			name:      "@type map with several types",
+			container: "@type",
+			input:     map[string]interface{}{"ex:T1": map[string]interface{}{"@id": "ex:b", "@type": "ex:T2"}},
+			expected:  map[string]interface{}{"ex:T1": map[string]interface{}{"@id": "ex:b", "@type": "ex:T2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := map[string]interface{}{
+				"@version": 1.1,
+				"ex":       "http://example.com/",
+				"none":     "@none",
+				"Thing":    "http://example.com/Thing",
+				"map":      map[string]interface{}{"@id": "http://example.com/map", "@container": tt.container},
+			}
+
+			proc := NewJsonLdProcessor()
+			expanded, err := proc.Expand(map[string]interface{}{
+				"@context": context,
+				"map":      tt.input,
+			}, nil)
+			require.NoError(t, err)
+
+			compacted, err := proc.Compact(expanded, context, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, compacted["map"])
+
+			reexpanded, err := proc.Expand(compacted, nil)
+			require.NoError(t, err)
+			assert.True(t, DeepCompare(expanded, reexpanded, false))
+		})
+	}
+}
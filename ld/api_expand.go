@@ -16,10 +16,39 @@ package ld
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 )
 
+// expandFrameDefault expands the value of @default in a frame. Scalar values are expanded
+// as values of the framed property, so they get its type coercion, language and direction,
+// falling back to the default language and direction of the frame context.
+func (api *JsonLdApi) expandFrameDefault(activeCtx *Context, activeProperty string, value interface{},
+	opts *JsonLdOptions) (interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			expandedItem, err := api.expandFrameDefault(activeCtx, activeProperty, item, opts)
+			if err != nil {
+				return nil, err
+			}
+			if expandedItem != nil {
+				result = append(result, expandedItem)
+			}
+		}
+		return result, nil
+	case map[string]interface{}, nil:
+		// value and node objects are expanded as they are
+	default:
+		if v != "@null" {
+			return activeCtx.ExpandValue(activeProperty, v)
+		}
+	}
+	return api.Expand(activeCtx, "@default", value, opts, false, nil)
+}
+
 // Expand operation expands the given input according to the steps in the Expansion algorithm:
 //
 // http://www.w3.org/TR/json-ld-api/#expansion-algorithm
@@ -39,6 +68,14 @@ func (api *JsonLdApi) Expand(activeCtx *Context, activeProperty string, element
 		frameExpansion = false
 	}
 
+	switch element.(type) {
+	case []interface{}, map[string]interface{}:
+		if err := api.descend(api.maxDepthOf(opts)); err != nil {
+			return nil, err
+		}
+		defer api.ascend()
+	}
+
 	// 3)
 	switch elem := element.(type) {
 	case []interface{}:
@@ -85,7 +122,8 @@ func (api *JsonLdApi) Expand(activeCtx *Context, activeProperty string, element
 		}
 
 		// Get any property-scoped context for activeProperty
-		propertyScopedCtx := activeCtx.GetTermDefinition(activeProperty)["@context"]
+		propertyDefinition := activeCtx.GetTermDefinition(activeProperty)
+		propertyScopedCtx, hasPropertyScopedCtx := propertyDefinition["@context"]
 
 		// second, determine if any type-scoped context should be reverted; it
 		// should only be reverted when the following are all true:
@@ -121,9 +159,9 @@ func (api *JsonLdApi) Expand(activeCtx *Context, activeProperty string, element
 			activeCtx = activeCtx.RevertToPreviousContext()
 		}
 
-		if propertyScopedCtx != nil {
-			// apply property-scoped context after reverting term-scoped context
-			newCtx, err := activeCtx.parse(propertyScopedCtx, nil, false, true, false, true)
+		if hasPropertyScopedCtx {
+			// apply property-scoped context (which may be null) after reverting term-scoped context
+			newCtx, err := activeCtx.parse(propertyScopedCtx, nil, false, true, false, true, false)
 			if err != nil {
 				return nil, err
 			}
@@ -180,7 +218,7 @@ func (api *JsonLdApi) Expand(activeCtx *Context, activeProperty string, element
 				for _, tt := range types {
 					td := typeScopedContext.GetTermDefinition(tt)
 					if ctx, hasCtx := td["@context"]; hasCtx {
-						newCtx, err := activeCtx.parse(ctx, nil, false, false, false, false)
+						newCtx, err := activeCtx.parse(ctx, nil, false, false, false, false, false)
 						if err != nil {
 							return nil, err
 						}
@@ -196,7 +234,10 @@ func (api *JsonLdApi) Expand(activeCtx *Context, activeProperty string, element
 		err = api.expandObject(activeCtx, activeProperty, expandedActiveProperty, elem, resultMap, typeKey, opts,
 			typeScopedContext, frameExpansion)
 		if err != nil {
-			return nil, err
+			return nil, withNodeID(err, nodeIDForError(activeCtx, elem))
+		}
+		if api.coercions != nil {
+			api.coercions.objectExpanded(propertyDefinition, activeProperty, resultMap)
 		}
 
 		// 8)
@@ -220,11 +261,17 @@ func (api *JsonLdApi) Expand(activeCtx *Context, activeProperty string, element
 			_, hasDirection := resultMap["@direction"]
 			typeValue, hasType := resultMap["@type"]
 			if hasDisallowedKeys {
-				return nil, NewJsonLdError(InvalidValueObject, "value object has unknown keys")
+				return nil, newValueError(InvalidValueObject, "value object has unknown keys", elem, activeProperty)
 			}
 			if (hasLanguage || hasDirection) && hasType {
-				return nil, NewJsonLdError(InvalidValueObject,
-					"value object must not include @type with either @language or @direction")
+				// a value pattern in a frame may combine @type with @language or @direction,
+				// as long as one side of the combination is match none ([])
+				noLanguage := !hasLanguage || isMatchNone(resultMap["@language"])
+				noDirection := !hasDirection || isMatchNone(resultMap["@direction"])
+				if !frameExpansion || !(isMatchNone(typeValue) || (noLanguage && noDirection)) {
+					return nil, newValueError(InvalidValueObject,
+						"value object must not include @type with either @language or @direction", elem, activeProperty)
+				}
 			}
 			// 8.2)
 			if rval == nil && typeValue != "@json" {
@@ -249,8 +296,9 @@ func (api *JsonLdApi) Expand(activeCtx *Context, activeProperty string, element
 					for _, v := range types {
 						vStr, isString := v.(string)
 						if !(isEmptyObject(v) || (isString && IsAbsoluteIri(vStr) && !strings.HasPrefix(vStr, "_:"))) {
-							return nil, NewJsonLdError(InvalidTypedValue,
-								"an element containing @value and @type must have an absolute IRI for the value of @type")
+							return nil, newValueError(InvalidTypedValue,
+								"an element containing @value and @type must have an absolute IRI for the value of @type",
+								v, activeProperty)
 						}
 					}
 				}
@@ -294,12 +342,26 @@ func (api *JsonLdApi) Expand(activeCtx *Context, activeProperty string, element
 			_, hasValue := resultMap["@value"]
 			_, hasList := resultMap["@list"]
 			_, hasID := resultMap["@id"]
-			if resultMap != nil && (len(resultMap) == 0 || hasValue || hasList) {
+			// when framing, an empty object in @graph is a wildcard pattern
+			isGraphWildcard := frameExpansion && activeProperty == "@graph" && len(resultMap) == 0
+			if resultMap != nil && ((len(resultMap) == 0 && !isGraphWildcard) || hasValue || hasList) {
 				resultMap = nil
 			} else if resultMap != nil && !frameExpansion && hasID && len(resultMap) == 1 { // 12.2)
 				resultMap = nil
 			}
 		}
+		if resultMap != nil {
+			_, hasValue := resultMap["@value"]
+			_, hasList := resultMap["@list"]
+			if !hasValue && !hasList {
+				if hasContext && opts.RetainSourceContext {
+					api.retainSourceContext(resultMap, elemCtx)
+				}
+				if err := opts.Budget.spendNodes(1); err != nil {
+					return nil, err
+				}
+			}
+		}
 		// 13)
 		if resultMap != nil {
 			return resultMap, nil
@@ -312,12 +374,110 @@ func (api *JsonLdApi) Expand(activeCtx *Context, activeProperty string, element
 		if activeProperty == "" || activeProperty == "@graph" {
 			return nil, nil
 		}
-		return activeCtx.ExpandValue(activeProperty, element)
+		expandedValue, err := activeCtx.ExpandValue(activeProperty, element)
+		if err == nil && api.coercions != nil {
+			api.coercions.valueExpanded(activeCtx, activeProperty, element, expandedValue)
+		}
+		return expandedValue, err
 	}
 }
 
+// retainSourceContext records the local context of the given expanded node object.
+// A top-level object which only wraps @graph is replaced with the graph by expansion,
+// so the context is recorded in the nodes of the graph which don't have their own.
+func (api *JsonLdApi) retainSourceContext(resultMap map[string]interface{}, elemCtx interface{}) {
+	graph, hasGraph := resultMap["@graph"]
+	if !hasGraph || len(resultMap) > 1 || api.depth > 1 {
+		resultMap[SourceContextKey] = CloneDocument(elemCtx)
+		return
+	}
+	retainGraphSourceContext(graph, elemCtx)
+}
+
+// retainGraphSourceContext records the local context of a top-level object in the given
+// expanded nodes of its @graph which don't have their own.
+func retainGraphSourceContext(graph interface{}, elemCtx interface{}) {
+	for _, node := range Arrayify(graph) {
+		if nodeMap, isMap := node.(map[string]interface{}); isMap && !IsValue(nodeMap) {
+			if _, hasSourceContext := nodeMap[SourceContextKey]; !hasSourceContext {
+				nodeMap[SourceContextKey] = CloneDocument(elemCtx)
+			}
+		}
+	}
+}
+
+// expandTraced expands the top-level elements of the input document one by one,
+// reporting each of them to opts.ExpandTracer. The members of @graph of a top-level object
+// with @context and @graph entries only are reported as top-level elements too.
+// The result is the same as the one of Expand.
+func (api *JsonLdApi) expandTraced(activeCtx *Context, input interface{}, opts *JsonLdOptions) (interface{}, error) {
+	tracer := opts.ExpandTracer
+
+	items, graphContext, isSplit := topLevelNodes(input)
+	if !isSplit {
+		tracer.NodeStarted(0)
+		expanded, err := api.Expand(activeCtx, "", input, opts, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		tracer.NodeFinished(0, expanded)
+		return expanded, nil
+	}
+
+	activeProperty := ""
+	if graphContext != nil {
+		var err error
+		if activeCtx, err = activeCtx.Parse(graphContext); err != nil {
+			return nil, err
+		}
+		// account for the top-level object and its @graph array in depth checks
+		activeProperty = "@graph"
+		api.depth = 2
+	}
+
+	resultList := make([]interface{}, 0)
+	for i, item := range items {
+		tracer.NodeStarted(i)
+		v, err := api.Expand(activeCtx, activeProperty, item, opts, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		if graphContext != nil && opts.RetainSourceContext {
+			retainGraphSourceContext(v, graphContext)
+		}
+		tracer.NodeFinished(i, v)
+
+		if vList, isList := v.([]interface{}); isList {
+			resultList = append(resultList, vList...)
+		} else if v != nil {
+			resultList = append(resultList, v)
+		}
+	}
+	return resultList, nil
+}
+
+// nodeIDForError returns the expanded @id of the given node object, if any.
+// It's used to locate errors in the input document.
+func nodeIDForError(activeCtx *Context, elem map[string]interface{}) string {
+	for key, value := range elem {
+		if expandedKey, _ := activeCtx.ExpandIri(key, false, true, nil, nil); expandedKey != "@id" {
+			continue
+		}
+		if id, isString := value.(string); isString {
+			expandedID, err := activeCtx.ExpandIri(id, true, false, nil, nil)
+			if err == nil {
+				return expandedID
+			}
+		}
+	}
+	return ""
+}
+
 func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, expandedActiveProperty string, elem map[string]interface{}, resultMap map[string]interface{}, typeKey string, opts *JsonLdOptions, typeScopedContext *Context, frameExpansion bool) error {
-	inputType := elem[typeKey]
+	var inputType interface{}
+	if typeKey != "" {
+		inputType = elem[typeKey]
+	}
 	if inputType != nil {
 		if itArray, isArray := inputType.([]interface{}); isArray {
 			if len(itArray) > 0 {
@@ -330,7 +490,8 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 			if frameExpansion {
 				inputType = nil
 			} else {
-				return NewJsonLdError(InvalidTypedValue, "@type value must be a string or array of strings")
+				return newValueError(InvalidTypedValue, "@type value must be a string or array of strings", elem[typeKey],
+					activeProperty)
 			}
 		}
 		if inputType != nil {
@@ -356,12 +517,16 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 		if err != nil {
 			return err
 		}
+		activeCtx.traceTerm(key)
 		var expandedValue interface{}
 		// 7.3)
 		if expandedProperty == "" || (!strings.Contains(expandedProperty, ":") && !IsKeyword(expandedProperty)) {
 			if activeCtx.options != nil && activeCtx.options.SafeMode {
 				return NewJsonLdError(InvalidProperty, "Dropping property that did not expand into an absolute IRI or keyword.")
 			} else {
+				if api.unmappedMembers != nil && api.depth == 1 {
+					api.unmappedMembers[key] = CloneDocument(value)
+				}
 				continue
 			}
 		}
@@ -389,7 +554,8 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 					switch v := value.(type) {
 					case map[string]interface{}:
 						if len(v) != 0 {
-							return NewJsonLdError(InvalidIDValue, "@id value must be a an empty object for framing")
+							return newValueError(InvalidIDValue, "@id value must be a an empty object for framing", value,
+								activeProperty)
 						}
 						expandedValue = []interface{}{v}
 					case []interface{}:
@@ -397,7 +563,8 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 						for _, listVal := range v {
 							vString, isString := listVal.(string)
 							if !isString {
-								return NewJsonLdError(InvalidIDValue, "@id value must be a string, an array of strings or an empty dictionary")
+								return newValueError(InvalidIDValue,
+									"@id value must be a string, an array of strings or an empty dictionary", value, activeProperty)
 							}
 							vString, err = activeCtx.ExpandIri(vString, true, true, nil, nil)
 							if err != nil {
@@ -407,10 +574,11 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 						}
 						expandedValue = expandedValueList
 					default:
-						return NewJsonLdError(InvalidIDValue, "value of @id must be a string, an array of strings or an empty dictionary")
+						return newValueError(InvalidIDValue,
+							"value of @id must be a string, an array of strings or an empty dictionary", value, activeProperty)
 					}
 				} else {
-					return NewJsonLdError(InvalidIDValue, "value of @id must be a string")
+					return newValueError(InvalidIDValue, "value of @id must be a string", value, activeProperty)
 				}
 			} else if expandedProperty == "@included" {
 				// Included blocks are treated as an array of separate object nodes sharing the same
@@ -438,6 +606,10 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 				switch v := value.(type) {
 				case []interface{}:
 					var expandedValueList []interface{}
+					if frameExpansion {
+						// keep match none ([]) patterns
+						expandedValueList = make([]interface{}, 0, len(v))
+					}
 					for _, listElem := range v {
 						listElemStr, isString := listElem.(string)
 						if !isString {
@@ -448,6 +620,7 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 						if err != nil {
 							return err
 						}
+						typeScopedContext.traceTerm(listElemStr)
 						expandedValueList = append(expandedValueList, newVal)
 					}
 					expandedValue = expandedValueList
@@ -456,6 +629,7 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 					if err != nil {
 						return err
 					}
+					typeScopedContext.traceTerm(v)
 					if containsKey {
 						expandedValue = append(Arrayify(resultMap[expandedProperty]), expandedValue)
 					}
@@ -619,6 +793,10 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 						}
 						// 7.4.11.3.3.1)
 						items := propertyValue.([]interface{})
+						if _, containsProperty := reverseMap[property]; frameExpansion && len(items) == 0 && !containsProperty {
+							// keep match none ([]) patterns
+							reverseMap[property] = items
+						}
 						for _, item := range items {
 							// 7.4.11.3.3.1.1)
 							itemMap := item.(map[string]interface{})
@@ -647,7 +825,10 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 				// nested keys
 				nests = append(nests, key)
 			} else if expandedProperty == "@default" {
-				expandedValue, _ = api.Expand(activeCtx, expandedProperty, value, opts, false, nil)
+				expandedValue, err = api.expandFrameDefault(activeCtx, activeProperty, value, opts)
+				if err != nil {
+					return err
+				}
 			} else if expandedProperty == "@explicit" ||
 				expandedProperty == "@embed" ||
 				expandedProperty == "@requireAll" ||
@@ -669,13 +850,18 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 		if ctx, hasCtx := td["@context"]; hasCtx {
 			// TODO: fix calling a private method
 			//termCtx, err = activeCtx.Parse(ctx)
-			termCtx, err = activeCtx.parse(ctx, make([]string, 0), false, true, false, true)
+			termCtx, err = activeCtx.parse(ctx, make([]string, 0), false, true, false, true, false)
 			if err != nil {
 				return err
 			}
 		}
 
 		valueMap, isMap := value.(map[string]interface{})
+		if frameExpansion && isMap && len(valueMap) == 0 {
+			// when framing, an empty object is a wildcard pattern rather than an empty map,
+			// regardless of the container
+			isMap = false
+		}
 		if termCtx.HasContainerMapping(key, "@language") && isMap {
 			var expandedValueList []interface{}
 
@@ -761,6 +947,9 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 					"@type":  "@json",
 					"@value": value,
 				}
+				if api.coercions != nil {
+					api.coercions.valueExpanded(activeCtx, key, value, expandedValue)
+				}
 			} else {
 				// 7.7)
 				expandedValue, err = api.Expand(termCtx, key, value, opts, false, nil)
@@ -872,7 +1061,13 @@ func (api *JsonLdApi) expandObject(activeCtx *Context, activeProperty string, ex
 		}
 	}
 
-	// expand each nested key
+	// expand each nested key. Members of nested objects aren't top-level members,
+	// even if they are nested in the top-level object.
+	unmappedMembers := api.unmappedMembers
+	api.unmappedMembers = nil
+	defer func() {
+		api.unmappedMembers = unmappedMembers
+	}()
 	for _, n := range nests {
 		for _, nv := range Arrayify(elem[n]) {
 			nvMap, isMap := nv.(map[string]interface{})
@@ -954,7 +1149,12 @@ func (api *JsonLdApi) expandIndexMap(activeCtx *Context, activeProperty string,
 				return nil, err
 			}
 		} else if indexKey == "@type" {
-			key = expandedKey.(string)
+			typeKey, isString := expandedKey.(string)
+			if !isString {
+				return nil, newValueError(InvalidTypeValue, "keys of a type map must expand to IRIs", key,
+					activeProperty)
+			}
+			key = typeKey
 		}
 
 		// 7.6.2.3)
@@ -964,7 +1164,18 @@ func (api *JsonLdApi) expandIndexMap(activeCtx *Context, activeProperty string,
 					"@graph": Arrayify(itemValue),
 				}
 			}
-			item := itemValue.(map[string]interface{})
+			item, isMap := itemValue.(map[string]interface{})
+			if !isMap {
+				return nil, newValueError(InvalidValueObject,
+					"values of an index map must expand to node, value, list or graph objects", itemValue,
+					activeProperty)
+			}
+			if IsList(item) && indexKey != "@index" && expandedKey != "@none" {
+				// list objects may only have @list and @index entries
+				return nil, newValueError(InvalidSetOrListObject,
+					fmt.Sprintf("list objects can't be values of a map indexed by %s", indexKey), item,
+					activeProperty)
+			}
 			if indexKey == "@type" {
 				if expandedKey == "@none" {
 					// ignore @none
@@ -979,8 +1190,8 @@ func (api *JsonLdApi) expandIndexMap(activeCtx *Context, activeProperty string,
 					item["@type"] = []interface{}{key}
 				}
 			} else if IsValue(item) && indexKey != "@language" && indexKey != "@type" && indexKey != "@index" {
-				return nil, NewJsonLdError(InvalidValueObject,
-					fmt.Sprintf("Attempt to add illegal key to value object: %s", indexKey))
+				return nil, newValueError(InvalidValueObject,
+					fmt.Sprintf("Attempt to add illegal key to value object: %s", indexKey), item, activeProperty)
 			} else if propertyIndex != "" {
 				// index is a property to be expanded, and values interpreted for that property
 				if expandedKey != "@none" {
@@ -999,3 +1210,140 @@ func (api *JsonLdApi) expandIndexMap(activeCtx *Context, activeProperty string,
 	}
 	return expandedValueList, nil
 }
+
+// isExpandedDocument returns true if the given document is an array of node objects
+// in expanded form which the Expansion algorithm would leave unchanged. This allows
+// documents which are already expanded, for example at pipeline boundaries, to skip
+// the full algorithm. Anything unusual (contexts, @graph, @reverse, relative IRIs,
+// aliases, non-canonical values etc.) makes this function return false,
+// in which case the document should be expanded as usual.
+// Documents nested deeper than maxDepth levels (unless it's zero) are also rejected,
+// so that expansion reports them as errors.
+func isExpandedDocument(input interface{}, maxDepth int) bool {
+	nodes, isList := input.([]interface{})
+	if !isList {
+		return false
+	}
+	if maxDepth <= 0 {
+		maxDepth = math.MaxInt
+	}
+	for _, node := range nodes {
+		nodeMap, isMap := node.(map[string]interface{})
+		if !isMap || !isExpandedNode(nodeMap, maxDepth-1) {
+			return false
+		}
+		// top level nodes without properties are dropped by expansion
+		if _, hasID := nodeMap["@id"]; len(nodeMap) == 0 || (hasID && len(nodeMap) == 1) {
+			return false
+		}
+	}
+	return true
+}
+
+// isExpandedNode checks the given node object. depth is the number of nesting
+// levels, including the node itself, which may still be used.
+func isExpandedNode(node map[string]interface{}, depth int) bool {
+	if depth < 1 {
+		return false
+	}
+	for key, value := range node {
+		switch key {
+		case "@id":
+			id, isString := value.(string)
+			if !isString || !IsAbsoluteIri(id) {
+				return false
+			}
+		case "@type":
+			types, isList := value.([]interface{})
+			if !isList {
+				return false
+			}
+			for _, t := range types {
+				typeStr, isString := t.(string)
+				if !isString || !IsAbsoluteIri(typeStr) {
+					return false
+				}
+			}
+		case "@index":
+			if _, isString := value.(string); !isString {
+				return false
+			}
+		default:
+			if strings.HasPrefix(key, "@") || strings.HasPrefix(key, "_:") || !IsAbsoluteIri(key) {
+				return false
+			}
+			items, isList := value.([]interface{})
+			if !isList || depth < 2 {
+				return false
+			}
+			for _, item := range items {
+				if !isExpandedItem(item, true, depth-2) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func isExpandedItem(item interface{}, allowLists bool, depth int) bool {
+	itemMap, isMap := item.(map[string]interface{})
+	if !isMap || depth < 1 {
+		return false
+	}
+	if list, hasList := itemMap["@list"]; hasList {
+		listItems, isList := list.([]interface{})
+		if !allowLists || !isList || len(itemMap) != 1 || depth < 2 {
+			return false
+		}
+		for _, listItem := range listItems {
+			if !isExpandedItem(listItem, false, depth-2) {
+				return false
+			}
+		}
+		return true
+	}
+	if _, hasValue := itemMap["@value"]; hasValue {
+		return isExpandedValue(itemMap)
+	}
+	return isExpandedNode(itemMap, depth)
+}
+
+func isExpandedValue(value map[string]interface{}) bool {
+	_, hasType := value["@type"]
+	_, hasLanguage := value["@language"]
+	if hasType && hasLanguage {
+		return false
+	}
+	for key, v := range value {
+		switch key {
+		case "@value":
+			switch v.(type) {
+			case string:
+			case float64, bool:
+				if hasLanguage {
+					return false
+				}
+			default:
+				return false
+			}
+		case "@type":
+			typeStr, isString := v.(string)
+			if !isString || strings.HasPrefix(typeStr, "_:") || !IsAbsoluteIri(typeStr) {
+				return false
+			}
+		case "@language":
+			lang, isString := v.(string)
+			if !isString || lang != strings.ToLower(lang) {
+				return false
+			}
+		case "@index":
+			if _, isString := v.(string); !isString {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsExpandedDocument(t *testing.T) {
+	files, err := filepath.Glob("testdata/*/*-out.jsonld")
+	require.NoError(t, err)
+
+	fastPathCount := 0
+	for _, file := range files {
+		f, err := os.Open(file)
+		require.NoError(t, err)
+		doc, err := DocumentFromReader(f)
+		_ = f.Close()
+		if err != nil || !isExpandedDocument(doc, 0) {
+			continue
+		}
+		fastPathCount++
+
+		// the fast path must produce the same result as the full Expansion algorithm
+		opts := NewJsonLdOptions("")
+		expanded, err := NewJsonLdApi().Expand(NewContext(nil, opts), "", CloneDocument(doc), opts, false, nil)
+		require.NoError(t, err, file)
+		assert.True(t, DeepCompare(Arrayify(expanded), doc, true), file)
+	}
+	assert.Greater(t, fastPathCount, 50)
+
+	assert.False(t, isExpandedDocument(map[string]interface{}{"@id": "http://example.com/a"}, 0))
+	assert.False(t, isExpandedDocument([]interface{}{
+		map[string]interface{}{"@id": "http://example.com/a"},
+	}, 0))
+	assert.False(t, isExpandedDocument([]interface{}{
+		map[string]interface{}{"@id": "relative", "http://example.com/p": []interface{}{}},
+	}, 0))
+	assert.False(t, isExpandedDocument([]interface{}{
+		map[string]interface{}{"http://example.com/p": []interface{}{
+			map[string]interface{}{"@value": "v", "@language": "EN"},
+		}},
+	}, 0))
+
+	doc := []interface{}{
+		map[string]interface{}{
+			"@id":   "_:b0",
+			"@type": []interface{}{"http://example.com/T"},
+			"http://example.com/p": []interface{}{
+				map[string]interface{}{"@value": "v", "@language": "en"},
+				map[string]interface{}{"@list": []interface{}{
+					map[string]interface{}{"@value": 5.0},
+					map[string]interface{}{"@id": "http://example.com/b"},
+				}},
+			},
+		},
+	}
+	assert.True(t, isExpandedDocument(doc, 0))
+	assert.True(t, isExpandedDocument(doc, 6))
+	assert.False(t, isExpandedDocument(doc, 5))
+}
+
+func BenchmarkExpand_ExpandedInput(b *testing.B) {
+	nodes := make([]interface{}, 0, 100)
+	for i := 0; i < 100; i++ {
+		nodes = append(nodes, map[string]interface{}{
+			"@id":   "http://example.com/node/" + string(rune('a'+i%26)),
+			"@type": []interface{}{"http://example.com/Thing"},
+			"http://example.com/name": []interface{}{
+				map[string]interface{}{"@value": "Node", "@language": "en"},
+			},
+			"http://example.com/next": []interface{}{
+				map[string]interface{}{"@id": "http://example.com/node/z"},
+			},
+		})
+	}
+
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := proc.Expand(nodes, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// indexMapTerms are term definitions of index maps used by FuzzExpandIndexMap.
+var indexMapTerms = []interface{}{
+	map[string]interface{}{"@id": "ex:m", "@container": "@index"},
+	map[string]interface{}{"@id": "ex:m", "@container": "@id"},
+	map[string]interface{}{"@id": "ex:m", "@container": "@type"},
+	map[string]interface{}{"@id": "ex:m", "@container": "@type", "@type": "@id"},
+	map[string]interface{}{"@id": "ex:m", "@container": "@language"},
+	map[string]interface{}{"@id": "ex:m", "@container": []interface{}{"@graph", "@index"}},
+	map[string]interface{}{"@id": "ex:m", "@container": []interface{}{"@graph", "@id"}},
+	map[string]interface{}{"@id": "ex:m", "@container": "@index", "@index": "ex:prop"},
+	map[string]interface{}{"@id": "ex:m", "@container": "@index", "@index": "ex:prop", "@type": "@id"},
+}
+
+func FuzzExpandIndexMap(f *testing.F) {
+	for _, seed := range []string{
+		`{"a": "v"}`,
+		`{"a": {"@value": "v"}, "@none": [1, true]}`,
+		`{"ex:a": {"@id": "ex:b"}, "a": [{"@list": ["x"]}]}`,
+		`{"en": ["v", null], "@none": {"@set": ["w"]}}`,
+		`{"a": {"@graph": {"ex:p": "v"}}, "b": {"@type": "ex:T"}}`,
+		`{"a": {"@value": "v", "@language": "en"}}`,
+		`{"a": [[{"@list": []}]]}`,
+	} {
+		for i := range indexMapTerms {
+			f.Add(uint8(i), []byte(seed))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, term uint8, value []byte) {
+		var v interface{}
+		if err := json.Unmarshal(value, &v); err != nil {
+			return
+		}
+		doc := map[string]interface{}{
+			"@context": map[string]interface{}{
+				"@version": 1.1,
+				"ex":       "http://example.org/",
+				"m":        indexMapTerms[int(term)%len(indexMapTerms)],
+			},
+			"@id": "ex:s",
+			"m":   v,
+		}
+		_, err := NewJsonLdProcessor().Expand(doc, nil)
+		if err != nil {
+			jsonLDError := new(JsonLdError)
+			require.ErrorAs(t, err, &jsonLDError)
+		}
+	})
+}
+
+func TestExpandIndexMapErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		term  interface{}
+		value interface{}
+		code  ErrorCode
+	}{
+		"list in id map": {
+			term:  indexMapTerms[1],
+			value: map[string]interface{}{"ex:a": map[string]interface{}{"@list": []interface{}{"x"}}},
+			code:  InvalidSetOrListObject,
+		},
+		"list in type map": {
+			term:  indexMapTerms[2],
+			value: map[string]interface{}{"ex:T": map[string]interface{}{"@list": []interface{}{"x"}}},
+			code:  InvalidSetOrListObject,
+		},
+		"list in property index map": {
+			term:  indexMapTerms[7],
+			value: map[string]interface{}{"a": map[string]interface{}{"@list": []interface{}{"x"}}},
+			code:  InvalidSetOrListObject,
+		},
+		"value in property index map": {
+			term:  indexMapTerms[7],
+			value: map[string]interface{}{"a": "v"},
+			code:  InvalidValueObject,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewJsonLdProcessor().Expand(map[string]interface{}{
+				"@context": map[string]interface{}{
+					"@version": 1.1,
+					"ex":       "http://example.org/",
+					"m":        tc.term,
+				},
+				"m": tc.value,
+			}, nil)
+			jsonLDError := new(JsonLdError)
+			require.ErrorAs(t, err, &jsonLDError)
+			assert.Equal(t, tc.code, jsonLDError.Code)
+		})
+	}
+
+	// lists are allowed in plain index maps and under @none
+	for _, term := range []interface{}{indexMapTerms[0], indexMapTerms[1]} {
+		_, err := NewJsonLdProcessor().Expand(map[string]interface{}{
+			"@context": map[string]interface{}{"@version": 1.1, "ex": "http://example.org/", "m": term},
+			"m":        map[string]interface{}{"@none": map[string]interface{}{"@list": []interface{}{"x"}}},
+		}, nil)
+		assert.NoError(t, err)
+	}
+}
@@ -16,6 +16,7 @@ package ld
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -23,6 +24,21 @@ import (
 type EmbedNode struct {
 	parent   interface{}
 	property string
+	// parentID is the @id of the parent node object, or an empty string
+	// if the parent is an array, a list object or a top level container.
+	parentID string
+}
+
+// newEmbedNode records an embed of a node under the given property of parent.
+func newEmbedNode(parent interface{}, property string) *EmbedNode {
+	embed := &EmbedNode{
+		parent:   parent,
+		property: property,
+	}
+	if parentMap, isMap := parent.(map[string]interface{}); isMap {
+		embed.parentID, _ = parentMap["@id"].(string)
+	}
+	return embed
 }
 
 type StackNode struct {
@@ -43,6 +59,11 @@ type FramingContext struct {
 	graphStack   []string // TODO: is this field needed?
 	subjectStack []*StackNode
 	bnodeMap     map[string]interface{}
+	maxDepth     int
+	maxNodes     int
+	nodeCount    int
+
+	mismatchHandler func(mismatch *FrameMismatch)
 }
 
 // NewFramingContext creates and returns as new framing context.
@@ -67,6 +88,9 @@ func NewFramingContext(opts *JsonLdOptions) *FramingContext {
 		context.explicit = opts.Explicit
 		context.requireAll = opts.RequireAll
 		context.omitDefault = opts.OmitDefault
+		context.maxDepth = opts.FrameMaxDepth
+		context.maxNodes = opts.FrameMaxNodes
+		context.mismatchHandler = opts.FrameMismatchHandler
 	}
 
 	return context
@@ -123,6 +147,7 @@ func (api *JsonLdApi) Frame(input interface{}, frame []interface{}, opts *JsonLd
 			bnodesToClear = append(bnodesToClear, id)
 		}
 	}
+	sort.Strings(bnodesToClear)
 	return framedVal.([]interface{}), bnodesToClear, nil
 }
 
@@ -225,6 +250,12 @@ func (api *JsonLdApi) matchFrame(state *FramingContext, subjects []string,
 		output := make(map[string]interface{})
 		output["@id"] = id
 
+		state.nodeCount++
+		if state.maxNodes > 0 && state.nodeCount > state.maxNodes {
+			return nil, NewJsonLdError(FramingLimitExceeded,
+				fmt.Sprintf("framed output exceeds the maximum of %d nodes", state.maxNodes))
+		}
+
 		// keep track of objects having blank nodes
 		if strings.HasPrefix(id, "_:") {
 			AddValue(state.bnodeMap, id, output, true, false, true, false)
@@ -243,16 +274,20 @@ func (api *JsonLdApi) matchFrame(state *FramingContext, subjects []string,
 		// with graph name in state. Requires sorting of subjects.
 		if embed == EmbedLast {
 			if _, containsID := state.uniqueEmbeds[state.graph][id]; containsID {
-				removeEmbed(state, id)
-			}
-			state.uniqueEmbeds[state.graph][id] = &EmbedNode{
-				parent:   parent,
-				property: property,
+				if err := removeEmbed(state, id); err != nil {
+					return nil, err
+				}
 			}
+			state.uniqueEmbeds[state.graph][id] = newEmbedNode(parent, property)
 		}
 
 		subject := matches[id].(map[string]interface{})
 
+		if state.maxDepth > 0 && len(state.subjectStack) >= state.maxDepth {
+			return nil, NewJsonLdError(FramingLimitExceeded,
+				fmt.Sprintf("framing depth exceeds the maximum of %d at node %s", state.maxDepth, id))
+		}
+
 		state.subjectStack = append(state.subjectStack, &StackNode{
 			subject: subject,
 			graph:   state.graph,
@@ -324,27 +359,12 @@ func (api *JsonLdApi) matchFrame(state *FramingContext, subjects []string,
 					addFrameOutput(output, prop, list)
 
 					// add list objects
-					for _, listitem := range listValue.([]interface{}) {
-						if IsSubjectReference(listitem) {
-							// recurse into subject reference
-							itemid := listitem.(map[string]interface{})["@id"].(string)
-
-							var subframe map[string]interface{}
-							if containsProp && IsList(framePropVal.([]interface{})[0]) {
-								subframe = framePropVal.([]interface{})[0].(map[string]interface{})["@list"].([]interface{})[0].(map[string]interface{})
-							} else {
-								subframe = flags
-							}
-							res, err := api.matchFrame(state, []string{itemid}, subframe, list, "@list")
-							if err != nil {
-								return nil, err
-							}
-							list = res.(map[string]interface{})
-						} else {
-							// include other values automatically (TODO:
-							// may need Clone(n)
-							addFrameOutput(list, "@list", listitem)
-						}
+					subframe := flags
+					if containsProp {
+						subframe = listSubframe(framePropVal.([]interface{})[0], flags)
+					}
+					if err = api.frameList(state, listValue.([]interface{}), subframe, flags, list); err != nil {
+						return nil, err
 					}
 				} else {
 					var subframe map[string]interface{}
@@ -404,8 +424,13 @@ func (api *JsonLdApi) matchFrame(state *FramingContext, subjects []string,
 		// value of the associated property
 		if reverse, hasReverse := frame["@reverse"]; hasReverse {
 			for _, reverseProp := range GetOrderedKeys(reverse.(map[string]interface{})) {
-				for subject, subjectValue := range state.subjects {
-					nodeValues := Arrayify(subjectValue.(map[string]interface{})[reverseProp])
+				if sfArray, isArray := reverse.(map[string]interface{})[reverseProp].([]interface{}); isArray && len(sfArray) == 0 {
+					// match none ([]): don't embed reverse values
+					continue
+				}
+				// visit subjects in order to make the order of reverse values deterministic
+				for _, subject := range GetOrderedKeys(state.subjects) {
+					nodeValues := Arrayify(state.subjects[subject].(map[string]interface{})[reverseProp])
 					for _, v := range nodeValues {
 						if v != nil && v.(map[string]interface{})["@id"] == id {
 							// node has property referencing this subject, recurse
@@ -432,6 +457,35 @@ func (api *JsonLdApi) matchFrame(state *FramingContext, subjects []string,
 						}
 					}
 				}
+
+				// include default values for reverse properties which have no
+				// matching subjects and give @default explicitly, unless omit default is on.
+				// Unlike forward properties, reverse properties have no implicit null default,
+				// so that frames without @default produce the same output as before.
+				var next map[string]interface{}
+				if sfArray, isArray := reverse.(map[string]interface{})[reverseProp].([]interface{}); isArray && len(sfArray) > 0 {
+					next, _ = sfArray[0].(map[string]interface{})
+				} else {
+					next, _ = reverse.(map[string]interface{})[reverseProp].(map[string]interface{})
+				}
+				if next == nil {
+					next = make(map[string]interface{})
+				}
+				outputReverse, hasReverse := output["@reverse"].(map[string]interface{})
+				defaultVal, hasDefault := next["@default"]
+				if _, hasProp := outputReverse[reverseProp]; hasDefault && !hasProp &&
+					!GetFrameFlag(next, "@omitDefault", state.omitDefault) {
+					preserve := CloneDocument(defaultVal)
+					if !hasReverse {
+						outputReverse = make(map[string]interface{})
+						output["@reverse"] = outputReverse
+					}
+					outputReverse[reverseProp] = []interface{}{
+						map[string]interface{}{
+							"@preserve": Arrayify(preserve),
+						},
+					}
+				}
 			}
 		}
 
@@ -474,24 +528,16 @@ func validateFrame(frame interface{}) error {
 
 	if id, hasID := frameMap["@id"]; hasID {
 		for _, idVal := range Arrayify(id) {
-			if _, isMap := idVal.(map[string]interface{}); isMap {
-				continue
-			}
-			if strings.HasPrefix(idVal.(string), "_:") {
-				return NewJsonLdError(InvalidFrame,
-					fmt.Sprintf("Invalid JSON-LD frame syntax; invalid value of @id: %v", id))
+			if err := validateFrameIRI("@id", idVal, false); err != nil {
+				return err
 			}
 		}
 	}
 
 	if t, hasType := frameMap["@type"]; hasType {
 		for _, typeVal := range Arrayify(t) {
-			if _, isMap := typeVal.(map[string]interface{}); isMap {
-				continue
-			}
-			if strings.HasPrefix(typeVal.(string), "_:") {
-				return NewJsonLdError(InvalidFrame,
-					fmt.Sprintf("Invalid JSON-LD frame syntax; invalid value of @type: %v", t))
+			if err := validateFrameIRI("@type", typeVal, true); err != nil {
+				return err
 			}
 		}
 	}
@@ -499,6 +545,33 @@ func validateFrame(frame interface{}) error {
 	return nil
 }
 
+// validateFrameIRI checks that a value of @id or @type in a frame is either a wildcard ({})
+// or an absolute IRI. Any maps are accepted for @type, to allow values like {"@default": ...}.
+func validateFrameIRI(key string, v interface{}, allowMaps bool) error {
+	if vMap, isMap := v.(map[string]interface{}); isMap {
+		if len(vMap) == 0 || allowMaps {
+			return nil
+		}
+		return NewJsonLdError(InvalidFrame,
+			fmt.Sprintf("Invalid JSON-LD frame syntax; value of %s must be an IRI, {} or []: %v", key, v))
+	}
+	iri, isString := v.(string)
+	if !isString {
+		return NewJsonLdError(InvalidFrame,
+			fmt.Sprintf("Invalid JSON-LD frame syntax; value of %s must be an IRI, {} or []: %v", key, v))
+	}
+	if strings.HasPrefix(iri, "_:") {
+		return NewJsonLdError(InvalidFrame,
+			fmt.Sprintf("Invalid JSON-LD frame syntax; blank node identifiers are not allowed in %s: %s", key, iri))
+	}
+	if !IsAbsoluteIri(iri) {
+		return NewJsonLdError(InvalidFrame,
+			fmt.Sprintf("Invalid JSON-LD frame syntax; value of %s must be an absolute IRI "+
+				"(make sure all prefixes are defined in the frame context): %s", key, iri))
+	}
+	return nil
+}
+
 func getFrameValue(frame map[string]interface{}, name string) interface{} {
 	value := frame[name]
 	switch v := value.(type) {
@@ -575,12 +648,13 @@ func getFrameEmbed(frame map[string]interface{}, theDefault Embed) (Embed, error
 }
 
 // removeEmbed removes an existing embed with the given id.
-func removeEmbed(state *FramingContext, id string) {
+func removeEmbed(state *FramingContext, id string) error {
 	// get existing embed
 	links := state.uniqueEmbeds[state.graph]
 	embed := links[id]
-	parent := embed.parent
-	property := embed.property
+	if embed == nil {
+		return nil
+	}
 
 	// create reference to replace embed
 	subject := map[string]interface{}{
@@ -588,48 +662,33 @@ func removeEmbed(state *FramingContext, id string) {
 	}
 
 	// remove existing embed
-	if _, isArray := parent.([]interface{}); isArray {
+	switch parent := embed.parent.(type) {
+	case []interface{}:
 		// replace subject with reference
-		newVals := make([]interface{}, 0)
-		parentMap := parent.(map[string]interface{})
-		oldvals := parentMap[property].([]interface{})
-		for _, v := range oldvals {
-			vMap, isMap := v.(map[string]interface{})
-			if isMap && vMap["@id"] == id {
-				newVals = append(newVals, subject)
-			} else {
-				newVals = append(newVals, v)
+		for i, v := range parent {
+			if vMap, isMap := v.(map[string]interface{}); isMap && vMap["@id"] == id {
+				parent[i] = subject
 			}
 		}
-		parentMap[property] = newVals
-	} else {
+	case map[string]interface{}:
 		// replace subject with reference
-		parentMap := parent.(map[string]interface{})
-		_, useArray := parentMap[property]
-		RemoveValue(parentMap, property, subject, useArray)
-		AddValue(parentMap, property, subject, useArray, false, true, false)
+		_, useArray := parent[embed.property]
+		RemoveValue(parent, embed.property, subject, useArray)
+		AddValue(parent, embed.property, subject, useArray, false, true, false)
+	default:
+		return NewJsonLdError(InvalidFrame,
+			fmt.Sprintf("unable to replace embedded node %s: unexpected parent of type %T", id, embed.parent))
 	}
 	// recursively remove dependent dangling embeds
 	removeDependents(links, id)
+
+	return nil
 }
 
 // removeDependents recursively removes dependent dangling embeds.
 func removeDependents(embeds map[string]*EmbedNode, id string) {
-	// get embed keys as a separate array to enable deleting keys in map
 	for idDep, e := range embeds {
-		var p map[string]interface{}
-		if e.parent != nil {
-			var isMap bool
-			p, isMap = e.parent.(map[string]interface{})
-			if !isMap {
-				continue
-			}
-		} else {
-			p = make(map[string]interface{})
-		}
-
-		pid := p["@id"].(string)
-		if id == pid {
+		if e != nil && e.parentID != "" && e.parentID == id {
 			delete(embeds, idDep)
 			removeDependents(embeds, idDep)
 		}
@@ -697,12 +756,24 @@ func FilterSubject(state *FramingContext, subject map[string]interface{}, frame
 				// if @id is not a wildcard and is not empty, then match
 				// or not on specific value
 				frameID := Arrayify(frame["@id"])
-				if len(frameID) > 0 {
-					_, isString := frameID[0].(string)
-					if !isEmptyObject(frameID[0]) || isString {
-						return inArray(nodeValues[0], frameID), nil
+				isWildcard := len(frameID) == 0
+				for _, fid := range frameID {
+					if isEmptyObject(fid) {
+						isWildcard = true
+						break
 					}
 				}
+				if !isWildcard {
+					if len(nodeValues) == 0 {
+						return state.rejectSubject(subject, k, FrameIDMismatch)
+					}
+					for _, fid := range frameID {
+						if fidStr, isString := fid.(string); isString && fidStr == nodeValues[0] {
+							return true, nil
+						}
+					}
+					return state.rejectSubject(subject, k, FrameIDMismatch)
+				}
 				matchThis = true
 				continue
 			}
@@ -713,7 +784,7 @@ func FilterSubject(state *FramingContext, subject map[string]interface{}, frame
 				if isEmpty {
 					if len(nodeValues) > 0 {
 						// don't match on no @type
-						return false, nil
+						return state.rejectSubject(subject, k, FrameTypeMismatch)
 					}
 					matchThis = true
 				} else {
@@ -732,7 +803,10 @@ func FilterSubject(state *FramingContext, subject map[string]interface{}, frame
 								}
 							}
 						}
-						return len(r) > 0, nil
+						if len(r) == 0 {
+							return state.rejectSubject(subject, k, FrameTypeMismatch)
+						}
+						return true, nil
 					}
 				}
 			}
@@ -763,35 +837,47 @@ func FilterSubject(state *FramingContext, subject map[string]interface{}, frame
 
 		// if frame value is empty, don't match if subject has any value
 		if len(nodeValues) > 0 && isEmpty {
-			return false, nil
+			return state.rejectSubject(subject, k, FrameUnexpectedProperty)
 		}
 
 		if thisFrame == nil {
 			// node does not match if values is not empty and the value of
 			// property in frame is match none.
 			if len(nodeValues) > 0 {
-				return false, nil
+				return state.rejectSubject(subject, k, FrameUnexpectedProperty)
 			}
 			matchThis = true
-		} else if _, isMap := thisFrame.(map[string]interface{}); isMap {
+		} else if isValuePattern(thisFrame) {
+			// node matches if any of its values matches the value pattern
+			for _, nv := range nodeValues {
+				if nvMap, isMap := nv.(map[string]interface{}); isMap && IsValue(nvMap) &&
+					valueMatch(thisFrame.(map[string]interface{}), nvMap) {
+					matchThis = true
+					break
+				}
+			}
+		} else if _, isMap := thisFrame.(map[string]interface{}); isMap && !IsList(thisFrame) {
 			// node matches if values is not empty and the value of
 			// property in frame is wildcard
 			matchThis = len(nodeValues) > 0
 		} else {
-			if IsValue(thisFrame) {
-				for _, nv := range nodeValues {
-					if valueMatch(thisFrame.(map[string]interface{}), nv.(map[string]interface{})) {
-						matchThis = true
-						break
-					}
-				}
-
-			} else if IsList(thisFrame) {
-				listValue := thisFrame.(map[string]interface{})["@list"].([]interface{})[0]
-				if len(nodeValues) > 0 && IsList(nodeValues[0]) {
-					nodeListValues := nodeValues[0].(map[string]interface{})["@list"]
-
-					if IsValue(listValue) {
+			if IsList(thisFrame) && len(nodeValues) > 0 && IsList(nodeValues[0]) {
+				nodeListValues := nodeValues[0].(map[string]interface{})["@list"]
+				listFrame, _ := thisFrame.(map[string]interface{})["@list"].([]interface{})
+				if len(listFrame) == 0 {
+					// an empty list frame matches any list
+					matchThis = true
+				} else {
+					listValue := listFrame[0]
+					if IsList(listValue) {
+						// a nested list frame matches lists of lists
+						for _, lv := range nodeListValues.([]interface{}) {
+							if IsList(lv) {
+								matchThis = true
+								break
+							}
+						}
+					} else if isValuePattern(listValue) {
 						for _, lv := range nodeListValues.([]interface{}) {
 							if valueMatch(listValue.(map[string]interface{}), lv.(map[string]interface{})) {
 								matchThis = true
@@ -811,13 +897,102 @@ func FilterSubject(state *FramingContext, subject map[string]interface{}, frame
 		}
 
 		if !matchThis && requireAll {
-			return false, nil
+			return state.rejectSubject(subject, k, frameMismatchReason(k, thisFrame, nodeValues))
 		}
 
 		matchesSome = matchesSome || matchThis
 	}
 
-	return wildcard || matchesSome, nil
+	if !wildcard && !matchesSome {
+		return state.rejectSubject(subject, "", FrameNoMatchingProperty)
+	}
+	return true, nil
+}
+
+// rejectSubject reports the mismatch to the handler, if any, and returns false.
+func (state *FramingContext) rejectSubject(subject map[string]interface{}, property string,
+	reason FrameMismatchReason) (bool, error) {
+	if state.mismatchHandler != nil {
+		id, _ := subject["@id"].(string)
+		state.mismatchHandler(&FrameMismatch{
+			Subject:  id,
+			Property: property,
+			Reason:   reason,
+		})
+	}
+	return false, nil
+}
+
+// frameMismatchReason explains why the given node values didn't match the frame entry.
+func frameMismatchReason(property string, frame interface{}, nodeValues []interface{}) FrameMismatchReason {
+	switch {
+	case property == "@type":
+		return FrameTypeMismatch
+	case len(nodeValues) == 0:
+		return FrameMissingProperty
+	case isValuePattern(frame):
+		return FrameValuePatternMismatch
+	case IsList(frame):
+		return FrameListMismatch
+	default:
+		return FrameMissingProperty
+	}
+}
+
+// frameList adds the items of a list to the given output list object, embedding
+// the nodes referenced by the list according to subframe. Lists nested in the list
+// (JSON-LD 1.1 lists of lists) are framed recursively.
+func (api *JsonLdApi) frameList(state *FramingContext, items []interface{}, subframe map[string]interface{},
+	flags map[string]interface{}, list map[string]interface{}) error {
+	for _, listitem := range items {
+		if IsSubjectReference(listitem) {
+			// recurse into subject reference
+			itemid := listitem.(map[string]interface{})["@id"].(string)
+
+			nodeFrame := subframe
+			if IsList(nodeFrame) {
+				// the frame expects a nested list here
+				nodeFrame = flags
+			}
+			if _, err := api.matchFrame(state, []string{itemid}, nodeFrame, list, "@list"); err != nil {
+				return err
+			}
+		} else if IsList(listitem) {
+			nestedList := map[string]interface{}{
+				"@list": make([]interface{}, 0),
+			}
+			addFrameOutput(list, "@list", nestedList)
+
+			nestedSubframe := subframe
+			if IsList(subframe) {
+				nestedSubframe = listSubframe(subframe, flags)
+			}
+			err := api.frameList(state, listitem.(map[string]interface{})["@list"].([]interface{}), nestedSubframe,
+				flags, nestedList)
+			if err != nil {
+				return err
+			}
+		} else {
+			// include other values automatically (TODO:
+			// may need Clone(n)
+			addFrameOutput(list, "@list", listitem)
+		}
+	}
+	return nil
+}
+
+// listSubframe returns the frame for the items of a list matched by the given
+// frame value, or flags if the frame doesn't constrain the items of the list.
+func listSubframe(frameValue interface{}, flags map[string]interface{}) map[string]interface{} {
+	if IsList(frameValue) {
+		items, _ := frameValue.(map[string]interface{})["@list"].([]interface{})
+		if len(items) > 0 {
+			if subframe, isMap := items[0].(map[string]interface{}); isMap {
+				return subframe
+			}
+		}
+	}
+	return flags
 }
 
 // addFrameOutput adds framing output to the given parent.
@@ -852,46 +1027,87 @@ func nodeMatch(state *FramingContext, pattern, value map[string]interface{}, req
 //   - @values are the same, or `pattern[@value]` is a wildcard,
 //   - @types are the same or `value[@type]` is not None
 //     and `pattern[@type]` is `{}` or `value[@type]` is None
-//     and `pattern[@type]` is None or `[]`, and
-//   - @languages are the same or `value[@language]` is not None
+//     and `pattern[@type]` is None or `[]`,
+//   - @languages are the same (case-insensitive) or `value[@language]` is not None
 //     and `pattern[@language]` is `{}`, or `value[@language]` is None
-//     and `pattern[@language]` is None or `[]`
+//     and `pattern[@language]` is None or `[]`, and
+//   - @directions are the same or `value[@direction]` is not None
+//     and `pattern[@direction]` is `{}`, or `value[@direction]` is None
+//     and `pattern[@direction]` is None or `[]`
 func valueMatch(pattern, value map[string]interface{}) bool {
-	v2v := pattern["@value"]
-	t2v := pattern["@type"]
-	l2v := pattern["@language"]
+	v2, hasValue := pattern["@value"]
+	t2, hasType := pattern["@type"]
+	l2, hasLanguage := pattern["@language"]
+	d2, hasDirection := pattern["@direction"]
 
-	if v2v == nil && t2v == nil && l2v == nil {
+	if !hasValue && !hasType && !hasLanguage && !hasDirection {
 		return true
 	}
 
-	var v2 []interface{}
-	if v2v != nil {
-		v2 = Arrayify(v2v)
-	}
-	var t2 []interface{}
-	if t2v != nil {
-		t2 = Arrayify(t2v)
+	// unlike other entries, a missing @value in the pattern doesn't match any value
+	if !hasValue || !valuePatternMatch(value["@value"], v2, false) {
+		return false
 	}
-	var l2 []interface{}
-	if l2v != nil {
-		l2 = Arrayify(l2v)
+
+	if !valuePatternMatch(value["@type"], t2, false) {
+		return false
 	}
 
-	v1 := value["@value"]
-	t1 := value["@type"]
-	l1 := value["@language"]
+	if !valuePatternMatch(value["@language"], l2, true) {
+		return false
+	}
 
-	if !(inArray(v1, v2) || (len(v2) > 0 && isEmptyObject(v2[0]))) {
+	if !valuePatternMatch(value["@direction"], d2, false) {
 		return false
 	}
 
-	if !((t1 == nil && len(t2) == 0) || (inArray(t1, t2)) || (t1 != nil && len(t2) > 0 && isEmptyObject(t2[0]))) {
+	return true
+}
+
+// valuePatternMatch returns true if the given entry of a value object matches
+// the corresponding entry of a value pattern:
+//
+//   - if the pattern is not set or is match none (`[]`), v must not be set,
+//   - if the pattern contains a wildcard (`{}`), v must be set,
+//   - otherwise, v must be one of the values of the pattern.
+func valuePatternMatch(v interface{}, pattern interface{}, ignoreCase bool) bool {
+	if pattern == nil {
+		return v == nil
+	}
+	patterns := Arrayify(pattern)
+	if len(patterns) == 0 {
+		return v == nil
+	}
+	if v == nil {
 		return false
 	}
+	for _, p := range patterns {
+		if isEmptyObject(p) {
+			return true
+		}
+		if ignoreCase {
+			vStr, vIsString := v.(string)
+			pStr, pIsString := p.(string)
+			if vIsString && pIsString && strings.EqualFold(vStr, pStr) {
+				return true
+			}
+		}
+		if DeepCompare(v, p, false) {
+			return true
+		}
+	}
+	return false
+}
 
-	if !((l1 == nil && len(l2) == 0) || (inArray(l1, l2)) || (l1 != nil && len(l2) > 0 && isEmptyObject(l2[0]))) {
+// isValuePattern returns true if the given frame is a value pattern, i.e. it
+// contains at least one of @value, @language or @direction keys.
+func isValuePattern(frame interface{}) bool {
+	frameMap, isMap := frame.(map[string]interface{})
+	if !isMap {
 		return false
 	}
-	return true
+	_, hasValue := frameMap["@value"]
+	_, hasLanguage := frameMap["@language"]
+	_, hasDirection := frameMap["@direction"]
+	return hasValue || hasLanguage || hasDirection
 }
@@ -15,10 +15,12 @@
 package ld_test
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/piprate/json-gold/ld"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetFrameFlag(t *testing.T) {
@@ -76,3 +78,809 @@ func TestGetFrameFlag(t *testing.T) {
 	),
 	)
 }
+
+func TestFrameValuePatterns(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@vocab": "http://example.org/",
+		},
+		"@graph": []interface{}{
+			map[string]interface{}{
+				"@id":   "http://example.org/1",
+				"title": map[string]interface{}{"@value": "Hello", "@language": "en"},
+			},
+			map[string]interface{}{
+				"@id":   "http://example.org/2",
+				"title": map[string]interface{}{"@value": "Bonjour", "@language": "fr"},
+			},
+			map[string]interface{}{
+				"@id":   "http://example.org/3",
+				"title": map[string]interface{}{"@value": "مرحبا", "@language": "ar", "@direction": "rtl"},
+			},
+			map[string]interface{}{
+				"@id":   "http://example.org/4",
+				"title": "Plain",
+			},
+		},
+	}
+
+	frameIDs := func(t *testing.T, pattern map[string]interface{}) []interface{} {
+		t.Helper()
+
+		frame := map[string]interface{}{
+			"@context": map[string]interface{}{
+				"@vocab": "http://example.org/",
+			},
+			"title": pattern,
+		}
+		opts := NewJsonLdOptions("")
+		opts.ProcessingMode = JsonLd_1_1
+		res, err := NewJsonLdProcessor().Frame(doc, frame, opts)
+		require.NoError(t, err)
+
+		ids := make([]interface{}, 0)
+		for _, node := range res["@graph"].([]interface{}) {
+			ids = append(ids, node.(map[string]interface{})["@id"])
+		}
+		return ids
+	}
+
+	t.Run("array of languages", func(t *testing.T) {
+		assert.ElementsMatch(t,
+			[]interface{}{"http://example.org/1", "http://example.org/2"},
+			frameIDs(t, map[string]interface{}{"@value": map[string]interface{}{}, "@language": []interface{}{"en", "FR"}}),
+		)
+	})
+
+	t.Run("language wildcard", func(t *testing.T) {
+		assert.ElementsMatch(t,
+			[]interface{}{"http://example.org/1", "http://example.org/2"},
+			frameIDs(t, map[string]interface{}{"@value": map[string]interface{}{}, "@language": map[string]interface{}{}}),
+		)
+	})
+
+	t.Run("match none on language and type", func(t *testing.T) {
+		assert.ElementsMatch(t,
+			[]interface{}{"http://example.org/4"},
+			frameIDs(t, map[string]interface{}{
+				"@value":    map[string]interface{}{},
+				"@type":     []interface{}{},
+				"@language": []interface{}{},
+			}),
+		)
+	})
+
+	t.Run("pattern without @value", func(t *testing.T) {
+		// frame expansion drops such patterns, so match the expanded frame directly
+		subject := map[string]interface{}{
+			"@id": "http://example.org/1",
+			"http://example.org/title": []interface{}{
+				map[string]interface{}{"@value": "Hello", "@language": "en"},
+			},
+		}
+		frame := map[string]interface{}{
+			"http://example.org/title": []interface{}{map[string]interface{}{"@language": "en"}},
+		}
+		matches, err := FilterSubject(NewFramingContext(nil), subject, frame, false)
+		require.NoError(t, err)
+		assert.False(t, matches)
+
+		frame["http://example.org/title"] = []interface{}{
+			map[string]interface{}{"@value": map[string]interface{}{}, "@language": "en"},
+		}
+		matches, err = FilterSubject(NewFramingContext(nil), subject, frame, false)
+		require.NoError(t, err)
+		assert.True(t, matches)
+	})
+
+	t.Run("direction", func(t *testing.T) {
+		assert.ElementsMatch(t,
+			[]interface{}{"http://example.org/3"},
+			frameIDs(t, map[string]interface{}{
+				"@value":     map[string]interface{}{},
+				"@language":  map[string]interface{}{},
+				"@direction": "rtl",
+			}),
+		)
+	})
+}
+
+func TestFrameIDMatching(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"ex": "http://example.org/",
+		},
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "ex:1", "ex:name": "One"},
+			map[string]interface{}{"@id": "ex:2", "ex:name": "Two"},
+			map[string]interface{}{"@id": "ex:3", "ex:name": "Three"},
+		},
+	}
+
+	frameWithID := func(id interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"@context": map[string]interface{}{
+				"ex": "http://example.org/",
+			},
+			"@id": id,
+		}
+	}
+
+	frameIDs := func(t *testing.T, id interface{}) []interface{} {
+		t.Helper()
+
+		res, err := NewJsonLdProcessor().Frame(doc, frameWithID(id), NewJsonLdOptions(""))
+		require.NoError(t, err)
+
+		if graph, hasGraph := res["@graph"]; hasGraph {
+			ids := make([]interface{}, 0)
+			for _, node := range graph.([]interface{}) {
+				ids = append(ids, node.(map[string]interface{})["@id"])
+			}
+			return ids
+		}
+		return []interface{}{res["@id"]}
+	}
+
+	t.Run("array of CURIEs", func(t *testing.T) {
+		assert.ElementsMatch(t, []interface{}{"ex:1", "ex:3"}, frameIDs(t, []interface{}{"ex:1", "http://example.org/3"}))
+	})
+
+	t.Run("empty array", func(t *testing.T) {
+		assert.Len(t, frameIDs(t, []interface{}{}), 3)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		for _, id := range []interface{}{
+			"relative/1",
+			"_:b0",
+		} {
+			_, err := NewJsonLdProcessor().Frame(doc, frameWithID(id), NewJsonLdOptions(""))
+			jsonLDError := new(JsonLdError)
+			require.ErrorAs(t, err, &jsonLDError, "%v", id)
+			assert.Equal(t, InvalidFrame, jsonLDError.Code)
+		}
+	})
+}
+
+func TestFrameLimits(t *testing.T) {
+	// a chain of nodes ex:1 -> ex:2 -> ... -> ex:5 with a link back to ex:1
+	nodes := make([]interface{}, 0)
+	for i := 1; i <= 5; i++ {
+		nodes = append(nodes, map[string]interface{}{
+			"@id":     fmt.Sprintf("ex:%d", i),
+			"ex:next": map[string]interface{}{"@id": fmt.Sprintf("ex:%d", i%5+1)},
+		})
+	}
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{"ex": "http://example.org/"},
+		"@graph":   nodes,
+	}
+	frame := map[string]interface{}{
+		"@context": map[string]interface{}{"ex": "http://example.org/"},
+		"@id":      "ex:1",
+	}
+
+	frameWith := func(modify func(opts *JsonLdOptions)) error {
+		opts := NewJsonLdOptions("")
+		opts.Embed = EmbedAlways
+		modify(opts)
+		_, err := NewJsonLdProcessor().Frame(doc, frame, opts)
+		return err
+	}
+
+	assertLimitExceeded := func(t *testing.T, err error) {
+		t.Helper()
+		jsonLDError := new(JsonLdError)
+		require.ErrorAs(t, err, &jsonLDError)
+		assert.Equal(t, FramingLimitExceeded, jsonLDError.Code)
+	}
+
+	t.Run("no limits", func(t *testing.T) {
+		assert.NoError(t, frameWith(func(opts *JsonLdOptions) {}))
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		assert.NoError(t, frameWith(func(opts *JsonLdOptions) { opts.FrameMaxDepth = 5 }))
+		assertLimitExceeded(t, frameWith(func(opts *JsonLdOptions) { opts.FrameMaxDepth = 3 }))
+	})
+
+	t.Run("max nodes", func(t *testing.T) {
+		assert.NoError(t, frameWith(func(opts *JsonLdOptions) { opts.FrameMaxNodes = 6 }))
+		assertLimitExceeded(t, frameWith(func(opts *JsonLdOptions) { opts.FrameMaxNodes = 5 }))
+	})
+}
+
+func TestFrameContainerMapArrays(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{"ex": "http://example.org/"},
+		"@id":      "ex:s",
+		"ex:input": map[string]interface{}{
+			"@id":    "ex:g",
+			"@index": "a",
+			"@graph": map[string]interface{}{"@id": "ex:n", "ex:v": "x"},
+		},
+	}
+
+	frameWithContainer := func(container interface{}, compactArrays bool) map[string]interface{} {
+		t.Helper()
+
+		frame := map[string]interface{}{
+			"@context": map[string]interface{}{
+				"ex": "http://example.org/",
+				"input": map[string]interface{}{
+					"@id":        "http://example.org/input",
+					"@container": container,
+				},
+			},
+			"@id": "ex:s",
+		}
+		opts := NewJsonLdOptions("")
+		opts.CompactArrays = compactArrays
+		res, err := NewJsonLdProcessor().Frame(doc, frame, opts)
+		require.NoError(t, err)
+		return res["@graph"].([]interface{})[0].(map[string]interface{})
+	}
+
+	t.Run("@index @set map", func(t *testing.T) {
+		node := frameWithContainer([]interface{}{"@index", "@set"}, true)
+		assert.Equal(t, map[string]interface{}{
+			"a": []interface{}{map[string]interface{}{"@id": "ex:g"}},
+		}, node["input"])
+	})
+
+	t.Run("@index map", func(t *testing.T) {
+		node := frameWithContainer("@index", true)
+		assert.Equal(t, map[string]interface{}{
+			"a": map[string]interface{}{"@id": "ex:g"},
+		}, node["input"])
+	})
+
+	t.Run("compactArrays unset", func(t *testing.T) {
+		node := frameWithContainer([]interface{}{"@index", "@set"}, false)
+		assert.Equal(t, map[string]interface{}{
+			"a": []interface{}{map[string]interface{}{"@id": "ex:g"}},
+		}, node["input"])
+	})
+}
+
+func TestFrameScopedContextAliases(t *testing.T) {
+	context := map[string]interface{}{
+		"@version": 1.1,
+		"@vocab":   "http://example.org/",
+		"Person": map[string]interface{}{
+			"@context": map[string]interface{}{"id": "@id"},
+		},
+		"address": map[string]interface{}{
+			"@context": map[string]interface{}{"ref": "@id"},
+		},
+	}
+	doc := map[string]interface{}{
+		"@context": context,
+		"@type":    "Team",
+		"member": map[string]interface{}{
+			"@type": "Person",
+			"name":  "Alice",
+			"friend": map[string]interface{}{
+				"name": "Bob",
+			},
+		},
+		"address": map[string]interface{}{
+			"city": "London",
+		},
+	}
+	frame := map[string]interface{}{
+		"@context": context,
+		"@type":    "Team",
+	}
+
+	res, err := NewJsonLdProcessor().Frame(doc, frame, NewJsonLdOptions(""))
+	require.NoError(t, err)
+
+	// blank node identifiers are pruned regardless of the aliases of @id
+	// introduced by type-scoped and property-scoped contexts
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@type": "Team",
+			"member": map[string]interface{}{
+				"@type": "Person",
+				"name":  "Alice",
+				"friend": map[string]interface{}{
+					"name": "Bob",
+				},
+			},
+			"address": map[string]interface{}{
+				"city": "London",
+			},
+		},
+	}, res["@graph"])
+}
+
+func TestFrameDeterministicOutput(t *testing.T) {
+	// 10 organisations with 30 members each; every member knows a few others
+	nodes := make([]interface{}, 0)
+	for i := 0; i < 300; i++ {
+		knows := make([]interface{}, 0)
+		for j := 1; j <= 3; j++ {
+			knows = append(knows, map[string]interface{}{"@id": fmt.Sprintf("ex:person%03d", (i*7+j*13)%300)})
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"@id":       fmt.Sprintf("ex:person%03d", i),
+			"@type":     "ex:Person",
+			"ex:member": map[string]interface{}{"@id": fmt.Sprintf("ex:org%d", i%10)},
+			"ex:knows":  knows,
+		})
+	}
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, map[string]interface{}{"@id": fmt.Sprintf("ex:org%d", i), "@type": "ex:Org"})
+	}
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{"ex": "http://example.org/"},
+		"@graph":   nodes,
+	}
+	frame := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"ex":      "http://example.org/",
+			"members": map[string]interface{}{"@reverse": "ex:member"},
+		},
+		"@type": "ex:Org",
+		"@reverse": map[string]interface{}{
+			"ex:member": map[string]interface{}{"@embed": "@never"},
+		},
+	}
+
+	proc := NewJsonLdProcessor()
+	expected, err := proc.Frame(doc, frame, nil)
+	require.NoError(t, err)
+
+	orgs := expected["@graph"].([]interface{})
+	require.Len(t, orgs, 10)
+	members := orgs[0].(map[string]interface{})["members"].([]interface{})
+	require.Len(t, members, 30)
+	for i := 1; i < len(members); i++ {
+		assert.Less(t, members[i-1].(map[string]interface{})["@id"], members[i].(map[string]interface{})["@id"])
+	}
+
+	for i := 0; i < 20; i++ {
+		framed, err := proc.Frame(doc, frame, nil)
+		require.NoError(t, err)
+		assert.Equal(t, expected, framed)
+	}
+}
+
+func TestFrameFromIRI(t *testing.T) {
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = NewMapDocumentLoader(map[string]interface{}{
+		"http://example.com/frame.jsonld": map[string]interface{}{
+			"@context": map[string]interface{}{"ex": "http://example.org/"},
+			"@type":    "ex:Book",
+		},
+	})
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{"ex": "http://example.org/"},
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "ex:1", "@type": "ex:Book"},
+			map[string]interface{}{"@id": "ex:2", "@type": "ex:Author"},
+		},
+	}
+
+	framed, err := NewJsonLdProcessor().Frame(doc, "http://example.com/frame.jsonld", opts)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"@context": map[string]interface{}{"ex": "http://example.org/"},
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "ex:1", "@type": "ex:Book"},
+		},
+	}, framed)
+
+	_, err = NewJsonLdProcessor().Frame(doc, []interface{}{"not a frame"}, opts)
+	jsonLDError := new(JsonLdError)
+	require.ErrorAs(t, err, &jsonLDError)
+	assert.Equal(t, InvalidFrame, jsonLDError.Code)
+}
+
+func TestFrameListsOfLists(t *testing.T) {
+	context := map[string]interface{}{
+		"@version": 1.1,
+		"ex":       "http://example.org/",
+		"l":        map[string]interface{}{"@id": "ex:l", "@container": "@list"},
+	}
+	doc := map[string]interface{}{
+		"@context": context,
+		"@graph": []interface{}{
+			map[string]interface{}{
+				"@id":   "ex:s",
+				"@type": "ex:T",
+				"l": []interface{}{
+					map[string]interface{}{"@id": "ex:a"},
+					[]interface{}{
+						map[string]interface{}{"@id": "ex:b"},
+						[]interface{}{map[string]interface{}{"@id": "ex:c"}, "x"},
+					},
+				},
+			},
+			map[string]interface{}{"@id": "ex:a", "ex:name": "A"},
+			map[string]interface{}{"@id": "ex:b", "ex:name": "B"},
+			map[string]interface{}{"@id": "ex:c", "ex:name": "C"},
+		},
+	}
+
+	embedded := []interface{}{
+		map[string]interface{}{"@id": "ex:a", "ex:name": "A"},
+		[]interface{}{
+			map[string]interface{}{"@id": "ex:b", "ex:name": "B"},
+			[]interface{}{map[string]interface{}{"@id": "ex:c", "ex:name": "C"}, "x"},
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		listFrame interface{}
+		expected  interface{}
+	}{
+		"no list frame": {
+			expected: embedded,
+		},
+		"empty list frame": {
+			listFrame: map[string]interface{}{"@list": []interface{}{}},
+			expected:  embedded,
+		},
+		"nested list frame": {
+			listFrame: map[string]interface{}{"@list": []interface{}{[]interface{}{}}},
+			expected:  embedded,
+		},
+		"item frame applies to nested lists": {
+			listFrame: map[string]interface{}{"@list": []interface{}{map[string]interface{}{"@embed": "@never"}}},
+			expected: []interface{}{
+				map[string]interface{}{"@id": "ex:a"},
+				[]interface{}{
+					map[string]interface{}{"@id": "ex:b"},
+					[]interface{}{map[string]interface{}{"@id": "ex:c"}, "x"},
+				},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			frame := map[string]interface{}{
+				"@context": context,
+				"@type":    "ex:T",
+			}
+			if tc.listFrame != nil {
+				frame["l"] = tc.listFrame
+			}
+			framed, err := NewJsonLdProcessor().Frame(doc, frame, nil)
+			require.NoError(t, err)
+			graph := framed["@graph"].([]interface{})
+			require.Len(t, graph, 1)
+			assert.Equal(t, tc.expected, graph[0].(map[string]interface{})["l"])
+		})
+	}
+}
+
+func TestFrameMismatchExplanation(t *testing.T) {
+	context := map[string]interface{}{"ex": "http://example.org/"}
+	doc := map[string]interface{}{
+		"@context": context,
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "ex:1", "@type": "ex:Person", "ex:name": "Alice"},
+			map[string]interface{}{"@id": "ex:2", "@type": "ex:Organization"},
+			map[string]interface{}{"@id": "ex:3", "@type": "ex:Person", "ex:age": 42},
+			map[string]interface{}{"@id": "ex:4", "@type": "ex:Person", "ex:name": "Bob"},
+		},
+	}
+
+	explain := func(frame map[string]interface{}, requireAll bool) map[string][]FrameMismatch {
+		frame["@context"] = context
+		explanation := NewFrameExplanation()
+		opts := NewJsonLdOptions("")
+		opts.RequireAll = requireAll
+		opts.FrameMismatchHandler = explanation.Record
+		_, err := NewJsonLdProcessor().Frame(doc, frame, opts)
+		require.NoError(t, err)
+
+		rval := make(map[string][]FrameMismatch)
+		for id, mismatches := range explanation.Subjects {
+			for _, m := range mismatches {
+				rval[id] = append(rval[id], *m)
+			}
+		}
+		return rval
+	}
+
+	assert.Equal(t, map[string][]FrameMismatch{
+		"http://example.org/2": {{Subject: "http://example.org/2", Property: "@type", Reason: FrameTypeMismatch}},
+	}, explain(map[string]interface{}{"@type": "ex:Person"}, true))
+
+	assert.Equal(t, map[string][]FrameMismatch{
+		"http://example.org/2": {{Subject: "http://example.org/2", Property: "http://example.org/name", Reason: FrameMissingProperty}},
+		"http://example.org/3": {{Subject: "http://example.org/3", Property: "http://example.org/name", Reason: FrameMissingProperty}},
+		"http://example.org/4": {{Subject: "http://example.org/4", Property: "http://example.org/name", Reason: FrameValuePatternMismatch}},
+	}, explain(map[string]interface{}{"ex:name": map[string]interface{}{"@value": "Alice"}}, true))
+
+	assert.Equal(t, map[string][]FrameMismatch{
+		"http://example.org/2": {{Subject: "http://example.org/2", Reason: FrameNoMatchingProperty}},
+		"http://example.org/4": {{Subject: "http://example.org/4", Reason: FrameNoMatchingProperty}},
+	}, explain(map[string]interface{}{
+		"ex:name": map[string]interface{}{"@value": "Alice"},
+		"ex:age":  map[string]interface{}{},
+	}, false))
+
+	assert.Equal(t, map[string][]FrameMismatch{
+		"http://example.org/1": {{Subject: "http://example.org/1", Property: "http://example.org/name", Reason: FrameUnexpectedProperty}},
+		"http://example.org/4": {{Subject: "http://example.org/4", Property: "http://example.org/name", Reason: FrameUnexpectedProperty}},
+	}, explain(map[string]interface{}{"ex:name": []interface{}{}}, true))
+}
+
+func TestFrameReverseDefaults(t *testing.T) {
+	context := map[string]interface{}{
+		"@vocab":  "http://example.org/",
+		"knownBy": map[string]interface{}{"@reverse": "knows"},
+	}
+	doc := map[string]interface{}{
+		"@context": context,
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "http://example.org/alice", "@type": "Person"},
+			map[string]interface{}{"@id": "http://example.org/bob", "@type": "Person"},
+			map[string]interface{}{"@id": "http://example.org/carol", "knows": map[string]interface{}{"@id": "http://example.org/alice"}},
+		},
+	}
+	frame := map[string]interface{}{
+		"@context": context,
+		"@type":    "Person",
+		"knownBy": map[string]interface{}{
+			"@default": "nobody",
+		},
+		"@reverse": map[string]interface{}{
+			"likes": map[string]interface{}{},
+		},
+	}
+
+	// reverse properties without an explicit @default are left out
+	res, err := NewJsonLdProcessor().Frame(doc, frame, NewJsonLdOptions(""))
+	require.NoError(t, err)
+	assert.NotContains(t, res["@graph"].([]interface{})[0], "@reverse")
+	assert.NotContains(t, res["@graph"].([]interface{})[1], "@reverse")
+
+	frame["@reverse"] = map[string]interface{}{
+		"likes": map[string]interface{}{"@default": "none"},
+	}
+	res, err = NewJsonLdProcessor().Frame(doc, frame, NewJsonLdOptions(""))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id":      "http://example.org/alice",
+			"@type":    "Person",
+			"@reverse": map[string]interface{}{"likes": "none"},
+			"knownBy": map[string]interface{}{
+				"@id":   "http://example.org/carol",
+				"knows": map[string]interface{}{"@id": "http://example.org/alice"},
+			},
+		},
+		map[string]interface{}{
+			"@id":      "http://example.org/bob",
+			"@type":    "Person",
+			"@reverse": map[string]interface{}{"likes": "none"},
+			"knownBy":  "nobody",
+		},
+	}, res["@graph"])
+
+	// @omitDefault applies to reverse properties too
+	frame["@reverse"] = map[string]interface{}{
+		"likes": map[string]interface{}{"@default": "none", "@omitDefault": true},
+	}
+	opts := NewJsonLdOptions("")
+	res, err = NewJsonLdProcessor().Frame(doc, frame, opts)
+	require.NoError(t, err)
+	assert.NotContains(t, res["@graph"].([]interface{})[1], "@reverse")
+	assert.Equal(t, "nobody", res["@graph"].([]interface{})[1].(map[string]interface{})["knownBy"])
+
+	frame["@reverse"] = map[string]interface{}{
+		"likes": map[string]interface{}{"@default": "none"},
+	}
+	opts.OmitDefault = true
+	res, err = NewJsonLdProcessor().Frame(doc, frame, opts)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"@id":   "http://example.org/bob",
+		"@type": "Person",
+	}, res["@graph"].([]interface{})[1])
+}
+
+func TestFrameEmbedLastWithListParents(t *testing.T) {
+	doc := map[string]interface{}{
+		"@id": "http://example.com/a",
+		"http://example.com/list": map[string]interface{}{
+			"@list": []interface{}{
+				map[string]interface{}{
+					"@id":                  "http://example.com/x",
+					"http://example.com/q": map[string]interface{}{"@id": "http://example.com/c"},
+				},
+			},
+		},
+		"http://example.com/p": []interface{}{
+			map[string]interface{}{"@id": "http://example.com/c"},
+			map[string]interface{}{
+				"@id":                  "http://example.com/b",
+				"http://example.com/r": map[string]interface{}{"@id": "http://example.com/c"},
+			},
+		},
+	}
+	frame := map[string]interface{}{"@id": "http://example.com/a"}
+
+	opts := NewJsonLdOptions("")
+	opts.Embed = EmbedLast
+	res, err := NewJsonLdProcessor().Frame(doc, frame, opts)
+	require.NoError(t, err)
+
+	// the node referenced from the list is replaced with a reference when embedded again
+	assert.Equal(t, map[string]interface{}{
+		"@id": "http://example.com/a",
+		"http://example.com/list": map[string]interface{}{
+			"@list": []interface{}{
+				map[string]interface{}{
+					"@id":                  "http://example.com/x",
+					"http://example.com/q": map[string]interface{}{"@id": "http://example.com/c"},
+				},
+			},
+		},
+		"http://example.com/p": []interface{}{
+			map[string]interface{}{"@id": "http://example.com/c"},
+			map[string]interface{}{
+				"@id":                  "http://example.com/b",
+				"http://example.com/r": map[string]interface{}{"@id": "http://example.com/c"},
+			},
+		},
+	}, res["@graph"].([]interface{})[0])
+}
+
+func TestFrameExpansionPatterns(t *testing.T) {
+	ctx := map[string]interface{}{
+		"@vocab": "http://example.com/",
+		"label":  map[string]interface{}{"@container": "@language"},
+		"byID":   map[string]interface{}{"@container": "@id"},
+	}
+	for _, tc := range []struct {
+		frame    map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			map[string]interface{}{"@type": []interface{}{}},
+			map[string]interface{}{"@type": []interface{}{}},
+		},
+		{
+			map[string]interface{}{"p": map[string]interface{}{"@value": "a", "@type": []interface{}{}}},
+			map[string]interface{}{"http://example.com/p": []interface{}{
+				map[string]interface{}{"@value": "a", "@type": []interface{}{}},
+			}},
+		},
+		{
+			map[string]interface{}{"@reverse": map[string]interface{}{"p": []interface{}{}}},
+			map[string]interface{}{"@reverse": map[string]interface{}{"http://example.com/p": []interface{}{}}},
+		},
+		{
+			map[string]interface{}{"label": map[string]interface{}{}, "byID": map[string]interface{}{}},
+			map[string]interface{}{
+				"http://example.com/label": []interface{}{map[string]interface{}{}},
+				"http://example.com/byID":  []interface{}{map[string]interface{}{}},
+			},
+		},
+		{
+			map[string]interface{}{"@id": "http://example.com/a", "@graph": map[string]interface{}{}},
+			map[string]interface{}{"@id": "http://example.com/a", "@graph": []interface{}{map[string]interface{}{}}},
+		},
+	} {
+		tc.frame["@context"] = ctx
+		opts := NewJsonLdOptions("")
+		opts.ProcessingMode = JsonLd_1_1_Frame
+		expanded, err := NewJsonLdProcessor().Expand(tc.frame, opts)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{tc.expected}, expanded)
+	}
+
+	// an empty object is a wildcard for properties with container mappings
+	doc := map[string]interface{}{
+		"@context": ctx,
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "http://example.com/a", "label": map[string]interface{}{"en": "A"}},
+			map[string]interface{}{"@id": "http://example.com/b", "p": "B"},
+		},
+	}
+	frame := map[string]interface{}{
+		"@context": ctx,
+		"label":    map[string]interface{}{},
+	}
+	res, err := NewJsonLdProcessor().Frame(doc, frame, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"@id": "http://example.com/a", "label": map[string]interface{}{"en": "A"}},
+	}, res["@graph"])
+
+	// match none in @reverse doesn't embed any reverse values
+	frame = map[string]interface{}{
+		"@context": ctx,
+		"@id":      "http://example.com/a",
+		"@reverse": map[string]interface{}{"p": []interface{}{}},
+	}
+	res, err = NewJsonLdProcessor().Frame(doc, frame, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, res, "@reverse")
+}
+
+func TestFrameDefaultLanguage(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{"@vocab": "http://schema.org/"},
+		"@id":      "http://example.com/a",
+		"@type":    "Person",
+	}
+	frame := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@vocab":    "http://schema.org/",
+			"@language": "en",
+			"title":     map[string]interface{}{"@id": "http://schema.org/title", "@language": "fr"},
+			"code":      map[string]interface{}{"@id": "http://schema.org/code", "@language": nil},
+			"homepage":  map[string]interface{}{"@id": "http://schema.org/url", "@type": "@id"},
+		},
+		"@type":    "Person",
+		"name":     map[string]interface{}{"@default": "Unknown"},
+		"title":    map[string]interface{}{"@default": []interface{}{"Inconnu", "Anonyme"}},
+		"code":     map[string]interface{}{"@default": "X"},
+		"homepage": map[string]interface{}{"@default": "http://example.com/"},
+		"age":      map[string]interface{}{"@default": 5},
+		"nickname": map[string]interface{}{"@default": map[string]interface{}{"@value": "anon", "@language": "de"}},
+	}
+
+	res, err := NewJsonLdProcessor().Frame(doc, frame, NewJsonLdOptions(""))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id":      "http://example.com/a",
+			"@type":    "Person",
+			"name":     "Unknown",
+			"title":    []interface{}{"Inconnu", "Anonyme"},
+			"code":     "X",
+			"homepage": "http://example.com/",
+			"age":      5,
+			"nickname": map[string]interface{}{"@value": "anon", "@language": "de"},
+		},
+	}, res["@graph"])
+
+	// the default language and direction of the frame context apply to other properties
+	frame["@context"] = map[string]interface{}{
+		"@vocab":     "http://schema.org/",
+		"@language":  "ar",
+		"@direction": "rtl",
+	}
+	res, err = NewJsonLdProcessor().Frame(doc, frame, NewJsonLdOptions(""))
+	require.NoError(t, err)
+	assert.Equal(t, "Unknown", res["@graph"].([]interface{})[0].(map[string]interface{})["name"])
+}
+
+func TestFrameOmitEmpty(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{"@vocab": "http://example.com/"},
+		"@id":      "http://example.com/1",
+		"@type":    "Thing",
+		"name":     "Alice",
+	}
+	frame := map[string]interface{}{
+		"@context": map[string]interface{}{"@vocab": "http://example.com/"},
+		"@type":    "Thing",
+		"missing":  map[string]interface{}{"@default": nil},
+	}
+
+	opts := NewJsonLdOptions("")
+	opts.OmitGraph = true
+	res, err := NewJsonLdProcessor().Frame(doc, frame, opts)
+	require.NoError(t, err)
+	assert.Contains(t, res, "missing")
+	assert.Nil(t, res["missing"])
+
+	opts.OmitEmpty = EmptyNull
+	res, err = NewJsonLdProcessor().Frame(doc, frame, opts)
+	require.NoError(t, err)
+	delete(res, "@context")
+	assert.Equal(t, map[string]interface{}{
+		"@id":   "http://example.com/1",
+		"@type": "Thing",
+		"name":  "Alice",
+	}, res)
+}
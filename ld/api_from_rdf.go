@@ -90,7 +90,8 @@ func (nmn *NodeMapNode) IsWellFormedListNode() bool {
 	if keys < len(nmn.Values) {
 		return false
 	}
-	return true
+	// a list node must have both rdf:first and rdf:rest
+	return containsRdfFirst && containsRdfRest
 }
 
 // Serialize returns this node without the usages variable
@@ -111,6 +112,11 @@ func (api *JsonLdApi) FromRDF(dataset *RDFDataset, opts *JsonLdOptions) ([]inter
 	graphMap := make(map[string]map[string]*NodeMapNode)
 	graphMap["@default"] = defaultGraph
 	referencedOnceMap := make(map[string]*UsagesNode)
+	// references to blank nodes which may be compound literals, per graph
+	var compoundUsages map[string]map[string][]*UsagesNode
+	if opts.RdfDirection == RdfDirectionCompoundLiteral {
+		compoundUsages = make(map[string]map[string][]*UsagesNode)
+	}
 
 	// 3/3.1)
 	for name, graph := range dataset.Graphs {
@@ -152,7 +158,7 @@ func (api *JsonLdApi) FromRDF(dataset *RDFDataset, opts *JsonLdOptions) ([]inter
 			}
 
 			// 3.5.5)
-			value, err := RdfToObject(object, opts.UseNativeTypes)
+			value, err := rdfToObject(object, opts)
 			if err != nil {
 				return nil, err
 			}
@@ -160,6 +166,14 @@ func (api *JsonLdApi) FromRDF(dataset *RDFDataset, opts *JsonLdOptions) ([]inter
 			// 3.5.6+7)
 			MergeValue(node.Values, predicate, value)
 
+			if compoundUsages != nil && IsBlankNode(object) {
+				if compoundUsages[name] == nil {
+					compoundUsages[name] = make(map[string][]*UsagesNode)
+				}
+				compoundUsages[name][object.GetValue()] = append(compoundUsages[name][object.GetValue()],
+					NewUsagesNode(node, predicate, value))
+			}
+
 			// 3.5.8)
 			if IsBlankNode(object) || IsIRI(object) {
 				// track rdf:nil uniquely per graph
@@ -177,6 +191,10 @@ func (api *JsonLdApi) FromRDF(dataset *RDFDataset, opts *JsonLdOptions) ([]inter
 		}
 	}
 
+	for name, usages := range compoundUsages {
+		convertCompoundLiterals(graphMap[name], usages)
+	}
+
 	// 4)
 	for _, graph := range graphMap {
 		// 4.1), 4.2)
@@ -270,3 +288,67 @@ func (api *JsonLdApi) FromRDF(dataset *RDFDataset, opts *JsonLdOptions) ([]inter
 
 	return result, nil
 }
+
+// convertCompoundLiterals replaces references to compound literals (blank nodes
+// with rdf:value, rdf:direction and, optionally, rdf:language) with value objects
+// with @direction and removes the compound literal nodes from the graph.
+func convertCompoundLiterals(graph map[string]*NodeMapNode, usages map[string][]*UsagesNode) {
+	for id, nodeUsages := range usages {
+		node, found := graph[id]
+		if !found {
+			continue
+		}
+		value, isCompound := compoundLiteralValue(node)
+		if !isCompound {
+			continue
+		}
+		for _, usage := range nodeUsages {
+			delete(usage.value, "@id")
+			for k, v := range value {
+				usage.value[k] = v
+			}
+		}
+		delete(graph, id)
+	}
+}
+
+// compoundLiteralValue returns the value object represented by the given node
+// if the node is a well-formed compound literal.
+func compoundLiteralValue(node *NodeMapNode) (map[string]interface{}, bool) {
+	literal := func(property string) (string, bool) {
+		values, isList := node.Values[property].([]interface{})
+		if !isList || len(values) != 1 {
+			return "", false
+		}
+		valueObj, isMap := values[0].(map[string]interface{})
+		if !isMap || len(valueObj) != 1 {
+			return "", false
+		}
+		str, isString := valueObj["@value"].(string)
+		return str, isString
+	}
+
+	keys := 3
+	value, hasValue := literal(RDFValue)
+	direction, hasDirection := literal(RDFDirection)
+	if !hasValue || !hasDirection || (direction != "ltr" && direction != "rtl") {
+		return nil, false
+	}
+	rval := map[string]interface{}{
+		"@value":     value,
+		"@direction": direction,
+	}
+	if _, present := node.Values[RDFLanguage]; present {
+		language, isString := literal(RDFLanguage)
+		if !isString {
+			return nil, false
+		}
+		rval["@language"] = language
+		keys++
+	}
+	// compound literals must have no other properties besides @id
+	if len(node.Values) != keys {
+		return nil, false
+	}
+	return rval, true
+}
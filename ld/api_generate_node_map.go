@@ -19,11 +19,43 @@ import (
 	"strings"
 )
 
+// GenerateNodeMapWithReferences works like GenerateNodeMap on the top-level element, and
+// also returns the number of times each node is referenced: as a property value (including
+// list members and reverse properties) or as a type. Blank node identifiers in the result
+// are the ones assigned by issuer. References are counted across all graphs; nodes which
+// are never referenced don't appear in the result.
+//
+// Framing doesn't use these counts to prune blank node identifiers, because pruning depends
+// on how many times a node occurs in the framed output, not on references in the input.
+func (api *JsonLdApi) GenerateNodeMapWithReferences(element interface{}, graphMap map[string]interface{},
+	activeGraph string, issuer *IdentifierIssuer) (map[string]int, error) {
+
+	api.referenceCounts = make(map[string]int)
+	defer func() { api.referenceCounts = nil }()
+
+	if _, err := api.GenerateNodeMap(element, graphMap, activeGraph, issuer, "", "", nil); err != nil {
+		return nil, err
+	}
+	return api.referenceCounts, nil
+}
+
+// countReference records a reference to the given node, if reference counting is enabled.
+func (api *JsonLdApi) countReference(id string) {
+	if api.referenceCounts != nil {
+		api.referenceCounts[id]++
+	}
+}
+
 // GenerateNodeMap recursively flattens the subjects in the given JSON-LD expanded
 // input into a node map.
 func (api *JsonLdApi) GenerateNodeMap(element interface{}, graphMap map[string]interface{}, activeGraph string,
 	issuer *IdentifierIssuer, activeSubject interface{}, activeProperty string, list map[string]interface{}) (map[string]interface{}, error) {
 
+	if err := api.descend(api.maxDepth); err != nil {
+		return nil, err
+	}
+	defer api.ascend()
+
 	// recurse through array
 	if elementList, isList := element.([]interface{}); isList {
 		// if element is an array, process each entry in element recursively by passing item for element,
@@ -76,12 +108,21 @@ func (api *JsonLdApi) GenerateNodeMap(element interface{}, graphMap map[string]i
 			elem["@type"] = newTypes[0]
 		} else {
 			elem["@type"] = newTypes
+			for _, t := range newTypes {
+				api.countReference(t.(string))
+			}
 		}
 	}
 
+	// values and lists which aren't values of a property (free-floating ones, for example
+	// in graph objects created by graph containers) can't be represented in the node map
+	freeFloating := activeProperty == ""
+
 	if IsValue(element) {
 		if list == nil {
-			AddValue(subjectNode, activeProperty, element, true, false, false, false)
+			if !freeFloating {
+				AddValue(subjectNode, activeProperty, element, true, false, false, false)
+			}
 		} else {
 			list["@list"] = append(list["@list"].([]interface{}), element)
 		}
@@ -96,7 +137,9 @@ func (api *JsonLdApi) GenerateNodeMap(element interface{}, graphMap map[string]i
 			return nil, err
 		}
 		if list == nil {
-			AddValue(subjectNode, activeProperty, result, true, false, false, false)
+			if !freeFloating {
+				AddValue(subjectNode, activeProperty, result, true, false, false, false)
+			}
 		} else {
 			list["@list"] = append(list["@list"].([]interface{}), result)
 		}
@@ -124,10 +167,12 @@ func (api *JsonLdApi) GenerateNodeMap(element interface{}, graphMap map[string]i
 	if _, isMap := activeSubject.(map[string]interface{}); isMap {
 		// if subject is a hash, then we're processing a reverse-property relationship.
 		AddValue(node, activeProperty, activeSubject, true, false, false, false)
+		api.countReference(activeSubject.(map[string]interface{})["@id"].(string))
 	} else if activeProperty != "" {
 		ref := map[string]interface{}{
 			"@id": id,
 		}
+		api.countReference(id.(string))
 		if list == nil {
 			AddValue(subjectNode, activeProperty, ref, true, false, false, false)
 		} else {
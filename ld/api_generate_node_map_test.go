@@ -0,0 +1,62 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateNodeMapWithReferences(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@vocab": "http://example.com/",
+			"list":   map[string]interface{}{"@container": "@list"},
+			"parent": map[string]interface{}{"@reverse": "http://example.com/child"},
+		},
+		"@id":   "_:root",
+		"@type": "_:Class",
+		"knows": []interface{}{
+			map[string]interface{}{"@id": "_:friend", "name": "Bob"},
+			map[string]interface{}{"@id": "_:friend"},
+			map[string]interface{}{"@id": "http://example.com/carol"},
+		},
+		"list":   []interface{}{map[string]interface{}{"@id": "_:friend"}, "literal"},
+		"parent": map[string]interface{}{"@id": "http://example.com/dave"},
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "_:inner", "knows": map[string]interface{}{"@id": "http://example.com/carol"}},
+		},
+	}
+
+	expanded, err := NewJsonLdProcessor().Expand(doc, nil)
+	require.NoError(t, err)
+
+	nodeMap := map[string]interface{}{"@default": map[string]interface{}{}}
+	counts, err := NewJsonLdApi().GenerateNodeMapWithReferences(expanded, nodeMap, "@default", NewIdentifierIssuer("_:b"))
+	require.NoError(t, err)
+
+	// _:Class -> _:b0, _:root -> _:b1, _:inner -> _:b2, _:friend -> _:b3
+	assert.Equal(t, map[string]int{
+		"_:b0":                     1,
+		"_:b1":                     1, // subject of the reverse property
+		"_:b3":                     3,
+		"http://example.com/carol": 2,
+	}, counts)
+	assert.Contains(t, nodeMap, "_:b1")
+	assert.Contains(t, nodeMap["_:b1"], "_:b2")
+}
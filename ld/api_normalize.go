@@ -15,11 +15,15 @@
 package ld
 
 import (
+	"bytes"
 	"crypto/sha1" //nolint:gosec
 	"crypto/sha256"
 	hashPkg "hash"
+	"io"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 )
 
 const (
@@ -28,8 +32,27 @@ const (
 )
 
 func (api *JsonLdApi) Normalize(dataset *RDFDataset, opts *JsonLdOptions) (interface{}, error) {
-	algo := NewNormalisationAlgorithm(opts.Algorithm)
-	return algo.Main(dataset, opts)
+	result, err := canonicalize(dataset, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Format != "" && opts.Format != "application/n-quads" && opts.Format != "application/nquads" {
+		// other formats are produced by serializing the canonical dataset
+		serializer, err := canonicalRDFSerializer(opts, opts.Format)
+		if err != nil {
+			return nil, err
+		}
+		return serializer.Serialize(result.Dataset())
+	}
+	if opts.Format != "" {
+		var sb strings.Builder
+		if err = result.WriteNQuads(&sb); err != nil {
+			return nil, err
+		}
+		return sb.String(), nil
+	}
+
+	return result.Dataset(), nil
 }
 
 var (
@@ -43,6 +66,13 @@ type NormalisationAlgorithm struct {
 	quads            []*Quad
 	lines            []string
 	version          string
+
+	// concurrent enables concurrent hashing of independent blank node components
+	concurrent bool
+	// components maps blank node identifiers to their connected component
+	components map[string]string
+	// budget, if set, limits the number of permutations explored by hashNDegreeQuads
+	budget *Budget
 }
 
 func NewNormalisationAlgorithm(version string) *NormalisationAlgorithm {
@@ -182,31 +212,33 @@ func (na *NormalisationAlgorithm) Normalize(dataset *RDFDataset) {
 		hashPaths := make(map[string][]*IdentifierIssuer)
 
 		// 6.2) For each blank node identifier identifier in identifier list:
+		// 6.2.1) If a canonical identifier has already been issued for
+		// identifier, continue to the next identifier.
+		// Note: canonical identifiers aren't issued until step 6.3,
+		// so we can filter the list upfront.
+		pending := make([]string, 0, len(idList))
 		for _, id := range idList {
-			// 6.2.1) If a canonical identifier has already been issued for
-			// identifier, continue to the next identifier.
-			if na.canonicalIssuer.HasId(id) {
-				continue
+			if !na.canonicalIssuer.HasId(id) {
+				pending = append(pending, id)
 			}
+		}
 
-			// 6.2.2) Create temporary issuer, an identifier issuer
-			// initialized with the prefix _:b.
-			issuer := NewIdentifierIssuer("_:b")
-
-			// 6.2.3) Use the Issue Identifier algorithm, passing temporary
-			// issuer and identifier, to issue a new temporary blank node
-			// identifier for identifier.
-			issuer.GetId(id)
+		var results []*nDegreeResult
+		if na.concurrent {
+			results = na.hashNDegreeQuadsConcurrently(pending)
+		} else {
+			results = make([]*nDegreeResult, len(pending))
+			for i, id := range pending {
+				results[i] = na.hashNDegreeQuadsForIdentifier(id)
+			}
+		}
 
-			// 6.2.4) Run the Hash N-Degree Quads algorithm, passing
-			// temporary issuer, and append the result to the hash path
-			// list.
-			hash, newIssuer := na.hashNDegreeQuads(id, issuer)
-			issuerList, hasList := hashPaths[hash]
+		for _, result := range results {
+			issuerList, hasList := hashPaths[result.hash]
 			if !hasList {
 				issuerList = make([]*IdentifierIssuer, 0)
 			}
-			hashPaths[hash] = append(issuerList, newIssuer)
+			hashPaths[result.hash] = append(issuerList, result.issuer)
 		}
 
 		// 6.3) For each result in the hash path list,
@@ -265,31 +297,64 @@ func (na *NormalisationAlgorithm) Normalize(dataset *RDFDataset) {
 
 	// sort normalized output
 	sort.Sort(na)
+
+	// remove duplicate quads, so that all forms of the output agree
+	n := 0
+	for i, line := range na.lines {
+		if i > 0 && line == na.lines[n-1] {
+			continue
+		}
+		na.lines[n] = line
+		na.quads[n] = na.quads[i]
+		n++
+	}
+	na.lines = na.lines[:n]
+	na.quads = na.quads[:n]
 }
 
 func (na *NormalisationAlgorithm) Main(dataset *RDFDataset, opts *JsonLdOptions) (interface{}, error) {
 	// Steps 1 through 7.2, plus sorting
 	na.Normalize(dataset)
 
+	return na.output(opts)
+}
+
+// output returns the normalized dataset in the format given by opts.Format.
+// It must be called after Normalize.
+func (na *NormalisationAlgorithm) output(opts *JsonLdOptions) (interface{}, error) {
 	// 8) Return the normalized dataset.
 	// handle output format
 	if opts.Format != "" {
-		if opts.Format == "application/n-quads" || opts.Format == "application/nquads" {
-			rval := ""
-			for _, n := range na.lines {
-				rval += n
-			}
-			return rval, nil
-		} else {
-			return nil, NewJsonLdError(UnknownFormat, opts.Format)
+		var sb strings.Builder
+		if err := na.WriteNQuads(&sb); err != nil {
+			return nil, err
 		}
+		return sb.String(), nil
 	}
-	var rval []byte
-	for _, n := range na.lines {
-		rval = append(rval, []byte(n)...)
+
+	return na.Dataset(), nil
+}
+
+// CanonicalQuads returns the normalized quads in canonical order, with duplicates removed.
+// It must be called after Normalize.
+func (na *NormalisationAlgorithm) CanonicalQuads() []*Quad {
+	return na.quads
+}
+
+// WriteNQuads writes the canonical N-Quads, one line at a time and with duplicates removed,
+// into the given writer. It must be called after Normalize.
+func (na *NormalisationAlgorithm) WriteNQuads(w io.Writer) error {
+	for _, line := range na.lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return NewJsonLdError(IOError, err)
+		}
 	}
+	return nil
+}
 
-	return ParseNQuads(string(rval))
+// Dataset returns the normalized dataset. It must be called after Normalize.
+func (na *NormalisationAlgorithm) Dataset() *RDFDataset {
+	return canonicalQuads(na.CanonicalQuads()).Dataset()
 }
 
 // Sort interface
@@ -415,6 +480,25 @@ func (na *NormalisationAlgorithm) hashRelatedBlankNode(related string, quad *Qua
 }
 
 // 4.8) Hash N-Degree Quads
+// pathBuffers holds the path and chosen path buffers used by hashNDegreeQuads.
+// Paths are built and compared in place, instead of concatenating strings,
+// which is quadratic in the number of related blank nodes.
+type pathBuffers struct {
+	path   bytes.Buffer
+	chosen bytes.Buffer
+}
+
+var pathBuffersPool = sync.Pool{
+	New: func() interface{} { return new(pathBuffers) },
+}
+
+// pathExceeds reports whether the path is greater than the chosen path,
+// in which case the current permutation may be skipped (steps 5.4.4.3 and 5.4.5.5).
+func (pb *pathBuffers) pathExceeds() bool {
+	return pb.chosen.Len() != 0 && pb.path.Len() >= pb.chosen.Len() &&
+		bytes.Compare(pb.path.Bytes(), pb.chosen.Bytes()) > 0
+}
+
 func (na *NormalisationAlgorithm) hashNDegreeQuads(id string, issuer *IdentifierIssuer) (string, *IdentifierIssuer) {
 	// 1) Create a hash to related blank nodes map for storing hashes that
 	// identify related blank nodes.
@@ -434,13 +518,17 @@ func (na *NormalisationAlgorithm) hashNDegreeQuads(id string, issuer *Identifier
 		i++
 	}
 	sort.Strings(sortedHashes)
+
+	pb := pathBuffersPool.Get().(*pathBuffers)
+	defer pathBuffersPool.Put(pb)
+
 	for _, hash := range sortedHashes {
 		blankNodes := hashToRelated[hash]
 		// 5.1) Append the related hash to the data to hash.
 		md.Write([]byte(hash))
 
 		// 5.2) Create a string chosen path.
-		chosenPath := ""
+		pb.chosen.Reset()
 
 		// 5.3) Create an unset chosen issuer variable.
 		var chosenIssuer *IdentifierIssuer
@@ -450,11 +538,16 @@ func (na *NormalisationAlgorithm) hashNDegreeQuads(id string, issuer *Identifier
 		for permutator.HasNext() {
 			permutation := permutator.Next()
 
+			if na.budget.spendPermutations(1) != nil {
+				// the result doesn't matter anymore, as normalization fails
+				return "", issuer
+			}
+
 			// 5.4.1) Create a copy of issuer, issuer copy.
 			issuerCopy := issuer.Clone()
 
 			// 5.4.2) Create a string path.
-			path := ""
+			pb.path.Reset()
 
 			// 5.4.3) Create a recursion list, to store blank node
 			// identifiers that must be recursively processed by this
@@ -468,7 +561,7 @@ func (na *NormalisationAlgorithm) hashNDegreeQuads(id string, issuer *Identifier
 				// 5.4.4.1) If a canonical identifier has been issued for
 				// related, append it to path.
 				if na.canonicalIssuer.HasId(related) {
-					path += na.canonicalIssuer.GetId(related)
+					pb.path.WriteString(na.canonicalIssuer.GetId(related))
 				} else {
 					// 5.4.4.2) Otherwise:
 
@@ -482,13 +575,13 @@ func (na *NormalisationAlgorithm) hashNDegreeQuads(id string, issuer *Identifier
 					// 5.4.4.2.2) Use the Issue Identifier algorithm,
 					// passing issuer copy and related and append the result
 					// to path.
-					path += issuerCopy.GetId(related)
+					pb.path.WriteString(issuerCopy.GetId(related))
 				}
 				// 5.4.4.3) If chosen path is not empty and the length of
 				// path is greater than or equal to the length of chosen
 				// path and path is lexicographically greater than chosen
 				// path, then skip to the next permutation.
-				if len(chosenPath) != 0 && len(path) >= len(chosenPath) && path > chosenPath {
+				if pb.pathExceeds() {
 					skipToNextPermutation = true
 					break
 				}
@@ -508,10 +601,12 @@ func (na *NormalisationAlgorithm) hashNDegreeQuads(id string, issuer *Identifier
 
 				// 5.4.5.2) Use the Issue Identifier algorithm, passing
 				// issuer copy and related and append the result to path.
-				path += issuerCopy.GetId(related)
+				pb.path.WriteString(issuerCopy.GetId(related))
 
 				// 5.4.5.3) Append <, the hash in result, and > to path.
-				path += "<" + resultHash + ">"
+				pb.path.WriteByte('<')
+				pb.path.WriteString(resultHash)
+				pb.path.WriteByte('>')
 
 				// 5.4.5.4) Set issuer copy to the identifier issuer in
 				// result.
@@ -521,7 +616,7 @@ func (na *NormalisationAlgorithm) hashNDegreeQuads(id string, issuer *Identifier
 				// path is greater than or equal to the length of chosen
 				// path and path is lexicographically greater than chosen
 				// path, then skip to the next permutation.
-				if len(chosenPath) != 0 && len(path) >= len(chosenPath) && path > chosenPath {
+				if pb.pathExceeds() {
 					skipToNextPermutation = true
 					break
 				}
@@ -534,14 +629,15 @@ func (na *NormalisationAlgorithm) hashNDegreeQuads(id string, issuer *Identifier
 			// 5.4.6) If chosen path is empty or path is lexicographically
 			// less than chosen path, set chosen path to path and chosen
 			// issuer to issuer copy.
-			if len(chosenPath) == 0 || path < chosenPath {
-				chosenPath = path
+			if pb.chosen.Len() == 0 || bytes.Compare(pb.path.Bytes(), pb.chosen.Bytes()) < 0 {
+				pb.chosen.Reset()
+				pb.chosen.Write(pb.path.Bytes())
 				chosenIssuer = issuerCopy
 			}
 		}
 
 		// 5.5) Append chosen path to data to hash.
-		md.Write([]byte(chosenPath))
+		md.Write(pb.chosen.Bytes())
 
 		// 5.6) Replace issuer, by reference, with chosen issuer.
 		issuer = chosenIssuer
@@ -551,6 +647,111 @@ func (na *NormalisationAlgorithm) hashNDegreeQuads(id string, issuer *Identifier
 	return encodeHex(md.Sum(nil)), issuer
 }
 
+// nDegreeResult is a result of the Hash N-Degree Quads algorithm.
+type nDegreeResult struct {
+	hash   string
+	issuer *IdentifierIssuer
+}
+
+// hashNDegreeQuadsForIdentifier runs steps 6.2.2-6.2.4 of the normalization
+// algorithm for the given blank node identifier.
+func (na *NormalisationAlgorithm) hashNDegreeQuadsForIdentifier(id string) *nDegreeResult {
+	// 6.2.2) Create temporary issuer, an identifier issuer
+	// initialized with the prefix _:b.
+	issuer := NewIdentifierIssuer("_:b")
+
+	// 6.2.3) Use the Issue Identifier algorithm, passing temporary
+	// issuer and identifier, to issue a new temporary blank node
+	// identifier for identifier.
+	issuer.GetId(id)
+
+	// 6.2.4) Run the Hash N-Degree Quads algorithm, passing
+	// temporary issuer, and append the result to the hash path
+	// list.
+	hash, newIssuer := na.hashNDegreeQuads(id, issuer)
+	return &nDegreeResult{hash: hash, issuer: newIssuer}
+}
+
+// hashNDegreeQuadsConcurrently runs the Hash N-Degree Quads algorithm for the given
+// blank nodes, processing each connected component of blank nodes in a separate goroutine.
+// The algorithm only explores blank nodes of the same component and doesn't modify
+// the shared state, so the results are identical to the ones of sequential processing.
+func (na *NormalisationAlgorithm) hashNDegreeQuadsConcurrently(ids []string) []*nDegreeResult {
+	results := make([]*nDegreeResult, len(ids))
+
+	// group the blank nodes by component, preserving their order
+	groups := make(map[string][]int)
+	order := make([]string, 0)
+	for i, id := range ids {
+		component := na.componentOf(id)
+		if _, found := groups[component]; !found {
+			order = append(order, component)
+		}
+		groups[component] = append(groups[component], i)
+	}
+
+	work := make(chan []int)
+	var wg sync.WaitGroup
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(order) {
+		workers = len(order)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for indices := range work {
+				for _, i := range indices {
+					results[i] = na.hashNDegreeQuadsForIdentifier(ids[i])
+				}
+			}
+		}()
+	}
+	for _, component := range order {
+		work <- groups[component]
+	}
+	close(work)
+	wg.Wait()
+
+	return results
+}
+
+// componentOf returns an identifier of the connected component of blank nodes
+// the given blank node belongs to. Two blank nodes are connected if they occur in the same quad.
+func (na *NormalisationAlgorithm) componentOf(id string) string {
+	if na.components == nil {
+		na.components = make(map[string]string, len(na.blankNodeInfo))
+		var find func(id string) string
+		find = func(id string) string {
+			parent, found := na.components[id]
+			if !found || parent == id {
+				na.components[id] = id
+				return id
+			}
+			root := find(parent)
+			na.components[id] = root
+			return root
+		}
+		for id, info := range na.blankNodeInfo {
+			root := find(id)
+			for _, quad := range info["quads"].([]*Quad) {
+				for _, attrNode := range []Node{quad.Subject, quad.Object, quad.Graph} {
+					if attrNode != nil && IsBlankNode(attrNode) {
+						if otherRoot := find(attrNode.GetValue()); otherRoot != root {
+							na.components[otherRoot] = root
+						}
+					}
+				}
+			}
+		}
+		// flatten the structure
+		for id := range na.components {
+			find(id)
+		}
+	}
+	return na.components[id]
+}
+
 // helper to create appropriate hash object
 func (na *NormalisationAlgorithm) createHash() hashPkg.Hash {
 	if na.version == AlgorithmURDNA2015 {
@@ -0,0 +1,321 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var normalizeTestDoc = map[string]interface{}{
+	"@context": map[string]interface{}{
+		"@vocab": "http://example.org/",
+	},
+	"@id":  "http://example.org/a",
+	"name": "A",
+	"knows": []interface{}{
+		map[string]interface{}{"name": "B"},
+		map[string]interface{}{"name": "C", "knows": map[string]interface{}{"name": "B"}},
+	},
+}
+
+func TestJsonLdProcessor_NormalizeQuads(t *testing.T) {
+	proc := NewJsonLdProcessor()
+
+	opts := NewJsonLdOptions("")
+	opts.Algorithm = AlgorithmURDNA2015
+	opts.Format = "application/n-quads"
+	expected, err := proc.Normalize(normalizeTestDoc, opts)
+	require.NoError(t, err)
+
+	quads, err := proc.NormalizeQuads(normalizeTestDoc, opts)
+	require.NoError(t, err)
+
+	ds := NewRDFDataset()
+	ds.Graphs["@default"] = quads
+	serialized, err := (&NQuadRDFSerializer{}).Serialize(ds)
+	require.NoError(t, err)
+	assert.Equal(t, expected, serialized)
+
+	// with no output format, Normalize returns the normalized dataset
+	opts.Format = ""
+	res, err := proc.Normalize(normalizeTestDoc, opts)
+	require.NoError(t, err)
+	dataset, isDataset := res.(*RDFDataset)
+	require.True(t, isDataset)
+	assert.Equal(t, quads, dataset.GetQuads("@default"))
+}
+
+func TestJsonLdProcessor_NormalizeDigest(t *testing.T) {
+	proc := NewJsonLdProcessor()
+
+	opts := NewJsonLdOptions("")
+	opts.Algorithm = AlgorithmURDNA2015
+	opts.Format = "application/n-quads"
+	normalized, err := proc.Normalize(normalizeTestDoc, opts)
+	require.NoError(t, err)
+	expected := sha256.Sum256([]byte(normalized.(string)))
+
+	digest, err := proc.NormalizeDigest(normalizeTestDoc, opts, crypto.SHA256)
+	require.NoError(t, err)
+	assert.Equal(t, expected[:], digest)
+
+	_, err = proc.NormalizeDigest(normalizeTestDoc, opts, crypto.Hash(0))
+	require.Error(t, err)
+	assert.Equal(t, InvalidInput, err.(*JsonLdError).Code)
+
+	// duplicate quads are removed from all forms of the output
+	quad := NewQuad(NewIRI("http://example.org/a"), NewIRI("http://example.org/p"),
+		NewLiteral("v", "", ""), "@default")
+	ds := NewRDFDataset()
+	ds.Graphs["@default"] = []*Quad{quad, quad}
+	na := NewNormalisationAlgorithm(AlgorithmURDNA2015)
+	normalized, err = na.Main(ds, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "<http://example.org/a> <http://example.org/p> \"v\" .\n", normalized)
+	assert.Len(t, na.CanonicalQuads(), 1)
+
+	var sb strings.Builder
+	require.NoError(t, na.WriteNQuads(&sb))
+	assert.Equal(t, normalized, sb.String())
+}
+
+func TestNormalizeConcurrentNormalization(t *testing.T) {
+	inputs := make(map[string]string)
+
+	files, err := filepath.Glob("testdata/normalization/*-in.nq")
+	require.NoError(t, err)
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		require.NoError(t, err)
+		inputs[f] = string(b)
+	}
+
+	// many independent cycles of blank nodes which can't be told apart by first degree hashes
+	var sb strings.Builder
+	for i := 0; i < 50; i++ {
+		for j := 0; j < 4; j++ {
+			fmt.Fprintf(&sb, "_:c%dn%d <http://example.com/next> _:c%dn%d .\n", i, j, i, (j+1)%4)
+		}
+		fmt.Fprintf(&sb, "_:c%dn0 <http://example.com/name> \"%d\" .\n", i, i)
+	}
+	inputs["clusters"] = sb.String()
+
+	proc := NewJsonLdProcessor()
+	for name, input := range inputs {
+		for _, algorithm := range []string{AlgorithmURDNA2015, AlgorithmURGNA2012} {
+			opts := NewJsonLdOptions("")
+			opts.InputFormat = "application/n-quads"
+			opts.Format = "application/n-quads"
+			opts.Algorithm = algorithm
+
+			expected, err := proc.Normalize(input, opts)
+			require.NoError(t, err)
+
+			opts.ConcurrentNormalization = true
+			actual, err := proc.Normalize(input, opts)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, actual, "%s (%s)", name, algorithm)
+		}
+	}
+}
+
+func TestNormalizeGraphs(t *testing.T) {
+	input := `<http://example.com/s> <http://example.com/p> "default" .
+_:b0 <http://example.com/p> "one" <http://example.com/g1> .
+_:b1 <http://example.com/p> "two" <http://example.com/g2> .
+_:b2 <http://example.com/p> "three" <http://example.com/g3> .
+`
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.Algorithm = AlgorithmURDNA2015
+	opts.InputFormat = "application/n-quads"
+	opts.Format = "application/n-quads"
+	opts.NormalizeGraphs = []string{"http://example.com/g2", "http://example.com/unknown"}
+
+	normalized, err := proc.Normalize(input, opts)
+	require.NoError(t, err)
+	assert.Equal(t, `<http://example.com/s> <http://example.com/p> "default" .
+_:c14n0 <http://example.com/p> "two" <http://example.com/g2> .
+`, normalized)
+
+	// the digest of the selected graphs doesn't depend on the rest of the dataset
+	opts.NormalizeGraphs = []string{"http://example.com/g2"}
+	digest, err := proc.NormalizeDigest(input, opts, crypto.SHA256)
+	require.NoError(t, err)
+	subset, err := proc.NormalizeDigest(`_:x <http://example.com/p> "two" <http://example.com/g2> .
+<http://example.com/s> <http://example.com/p> "default" .
+`, opts, crypto.SHA256)
+	require.NoError(t, err)
+	assert.Equal(t, subset, digest)
+
+	// all graphs are normalized by default
+	opts.NormalizeGraphs = nil
+	quads, err := proc.NormalizeQuads(input, opts)
+	require.NoError(t, err)
+	assert.Len(t, quads, 4)
+}
+
+func TestNormalizeFormats(t *testing.T) {
+	nquads := `_:b0 <http://example.com/p> "one" .
+`
+	expected := `_:c14n0 <http://example.com/p> "one" .
+`
+	proc := NewJsonLdProcessor()
+
+	// InputFormat alone selects the parser, the output is a dataset
+	opts := NewJsonLdOptions("")
+	opts.InputFormat = "application/n-quads"
+	res, err := proc.Normalize(nquads, opts)
+	require.NoError(t, err)
+	assert.IsType(t, &RDFDataset{}, res)
+
+	// the input format doesn't have to match the output format
+	opts.InputFormat = "application/nquads"
+	opts.Format = "application/n-quads"
+	res, err = proc.Normalize(nquads, opts)
+	require.NoError(t, err)
+	assert.Equal(t, expected, res)
+
+	for _, tc := range []struct {
+		inputFormat, format string
+	}{
+		{"application/unknown", "application/n-quads"},
+		// Turtle can't be parsed
+		{"text/turtle", "application/n-quads"},
+		{"application/n-quads", "text/turtle"},
+		{"application/n-quads", "application/unknown"},
+	} {
+		opts.InputFormat = tc.inputFormat
+		opts.Format = tc.format
+		_, err = proc.Normalize(nquads, opts)
+		if assert.Error(t, err) {
+			assert.Equal(t, UnknownFormat, err.(*JsonLdError).Code)
+		}
+	}
+}
+
+func TestNormalizeFrameAndCompact(t *testing.T) {
+	proc := NewJsonLdProcessor()
+	context := map[string]interface{}{
+		"@vocab": "http://example.org/",
+	}
+	frame := map[string]interface{}{
+		"@context": context,
+		"@id":      "http://example.org/a",
+	}
+
+	// the same graph with different blank node labels and order of statements
+	first := `<http://example.org/a> <http://example.org/knows> _:x .
+_:x <http://example.org/name> "B" .
+<http://example.org/a> <http://example.org/knows> _:y .
+_:y <http://example.org/name> "C" .
+_:y <http://example.org/knows> _:x .
+`
+	second := `_:p <http://example.org/knows> _:q .
+<http://example.org/a> <http://example.org/knows> _:p .
+_:q <http://example.org/name> "B" .
+_:p <http://example.org/name> "C" .
+<http://example.org/a> <http://example.org/knows> _:q .
+`
+
+	opts := NewJsonLdOptions("")
+	opts.InputFormat = "application/n-quads"
+	opts.Algorithm = AlgorithmURDNA2015
+
+	framed1, err := proc.NormalizeFrame(first, frame, opts)
+	require.NoError(t, err)
+	framed2, err := proc.NormalizeFrame(second, frame, opts)
+	require.NoError(t, err)
+	assert.Equal(t, framed1, framed2)
+	assert.Equal(t, map[string]interface{}{
+		"@id": "http://example.org/a",
+		"knows": []interface{}{
+			map[string]interface{}{"name": "C", "knows": map[string]interface{}{"@id": "_:b1"}},
+			map[string]interface{}{"@id": "_:b1", "name": "B"},
+		},
+	}, framed1["@graph"].([]interface{})[0])
+
+	compacted1, err := proc.NormalizeCompact(first, context, opts)
+	require.NoError(t, err)
+	compacted2, err := proc.NormalizeCompact(second, context, opts)
+	require.NoError(t, err)
+	assert.Equal(t, compacted1, compacted2)
+	assert.Len(t, compacted1["@graph"], 3)
+
+	// JSON-LD input
+	compacted, err := proc.NormalizeCompact(normalizeTestDoc, context, nil)
+	require.NoError(t, err)
+	assert.Len(t, compacted["@graph"], 4)
+
+	// invalid options are rejected before processing
+	opts.Embed = "@sometimes"
+	_, err = proc.NormalizeFrame(first, frame, opts)
+	if assert.Error(t, err) {
+		assert.Equal(t, InvalidEmbedValue, err.(*JsonLdError).Code)
+	}
+}
+
+// denseBlankNodeGraph returns N-Quads with the given number of cliques of blank nodes,
+// where every node is connected to all other nodes of its clique. Such nodes can't be
+// told apart by first degree hashes, which makes normalization explore all permutations.
+func denseBlankNodeGraph(cliques, size int) string {
+	var sb strings.Builder
+	for i := 0; i < cliques; i++ {
+		for j := 0; j < size; j++ {
+			for k := 0; k < size; k++ {
+				if j != k {
+					fmt.Fprintf(&sb, "_:c%dn%d <http://example.com/knows> _:c%dn%d .\n", i, j, i, k)
+				}
+			}
+		}
+	}
+	return sb.String()
+}
+
+func benchmarkNormalizeDense(b *testing.B, algorithm string) {
+	input := denseBlankNodeGraph(4, 5)
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.InputFormat = "application/n-quads"
+	opts.Format = "application/n-quads"
+	opts.Algorithm = algorithm
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := proc.Normalize(input, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNormalize_DenseURDNA2015(b *testing.B) {
+	benchmarkNormalizeDense(b, AlgorithmURDNA2015)
+}
+
+func BenchmarkNormalize_DenseURGNA2012(b *testing.B) {
+	benchmarkNormalizeDense(b, AlgorithmURGNA2012)
+}
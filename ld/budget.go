@@ -0,0 +1,103 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Budget limits the work a single processor call may do, so that services processing
+// untrusted input can apply one resource budget per request instead of configuring
+// limits per subsystem. A budget is shared by all stages of the call through
+// JsonLdOptions.Budget (for example, expansion, RDF conversion and normalization
+// performed by Normalize), and by all calls given the same options, so a new budget
+// should be created for every request.
+//
+// When a limit is exceeded, the operation fails with BudgetExceeded error.
+// Zero limits are not enforced. Budget is safe for concurrent use.
+type Budget struct {
+	// MaxNodes limits the number of node objects produced by expansion.
+	MaxNodes int64
+	// MaxQuads limits the number of quads produced by RDF conversion
+	// or parsed from RDF input, such as N-Quads given to FromRDF or Normalize.
+	MaxQuads int64
+	// MaxPermutations limits the number of blank node permutations
+	// explored by normalization (see Hash N-Degree Quads algorithm).
+	MaxPermutations int64
+
+	nodes        int64
+	quads        int64
+	permutations int64
+	exceeded     atomic.Value
+}
+
+// NewBudget creates a new budget with the given limits.
+func NewBudget(maxNodes, maxQuads, maxPermutations int64) *Budget {
+	return &Budget{
+		MaxNodes:        maxNodes,
+		MaxQuads:        maxQuads,
+		MaxPermutations: maxPermutations,
+	}
+}
+
+// Used returns the amounts of the budget used so far.
+func (b *Budget) Used() (nodes, quads, permutations int64) {
+	return atomic.LoadInt64(&b.nodes), atomic.LoadInt64(&b.quads), atomic.LoadInt64(&b.permutations)
+}
+
+// Err returns the error reported when the budget was exceeded, or nil.
+func (b *Budget) Err() error {
+	if b == nil {
+		return nil
+	}
+	if err, isErr := b.exceeded.Load().(error); isErr {
+		return err
+	}
+	return nil
+}
+
+func (b *Budget) spend(counter *int64, limit int64, n int64, what string) error {
+	if b == nil {
+		return nil
+	}
+	if used := atomic.AddInt64(counter, n); limit > 0 && used > limit {
+		err := NewJsonLdError(BudgetExceeded, fmt.Sprintf("more than %d %s", limit, what))
+		b.exceeded.CompareAndSwap(nil, error(err))
+		return b.Err()
+	}
+	return nil
+}
+
+func (b *Budget) spendNodes(n int64) error {
+	if b == nil {
+		return nil
+	}
+	return b.spend(&b.nodes, b.MaxNodes, n, "nodes")
+}
+
+func (b *Budget) spendQuads(n int64) error {
+	if b == nil {
+		return nil
+	}
+	return b.spend(&b.quads, b.MaxQuads, n, "quads")
+}
+
+func (b *Budget) spendPermutations(n int64) error {
+	if b == nil {
+		return nil
+	}
+	return b.spend(&b.permutations, b.MaxPermutations, n, "permutations")
+}
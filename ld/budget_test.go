@@ -0,0 +1,127 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudget(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{"@vocab": "http://example.com/"},
+		"@id":      "http://example.com/a",
+		"name":     "A",
+		"knows": []interface{}{
+			map[string]interface{}{"name": "B"},
+			map[string]interface{}{"name": "C"},
+		},
+	}
+	proc := NewJsonLdProcessor()
+
+	requireExceeded := func(t *testing.T, err error) {
+		t.Helper()
+		require.Error(t, err)
+		assert.Equal(t, BudgetExceeded, err.(*JsonLdError).Code)
+	}
+
+	t.Run("nodes", func(t *testing.T) {
+		opts := NewJsonLdOptions("")
+		opts.Budget = NewBudget(2, 0, 0)
+		_, err := proc.Expand(doc, opts)
+		requireExceeded(t, err)
+		assert.Equal(t, err, opts.Budget.Err())
+
+		opts.Budget = NewBudget(3, 0, 0)
+		_, err = proc.Expand(doc, opts)
+		require.NoError(t, err)
+		nodes, _, _ := opts.Budget.Used()
+		assert.Equal(t, int64(3), nodes)
+	})
+
+	t.Run("quads", func(t *testing.T) {
+		opts := NewJsonLdOptions("")
+		opts.Budget = NewBudget(0, 4, 0)
+		_, err := proc.ToRDF(doc, opts)
+		requireExceeded(t, err)
+
+		opts.Budget = NewBudget(0, 5, 0)
+		_, err = proc.ToRDF(doc, opts)
+		require.NoError(t, err)
+	})
+
+	t.Run("parsed quads", func(t *testing.T) {
+		input := `<http://example.com/a> <http://example.com/p> "1" .
+<http://example.com/a> <http://example.com/p> "2" .
+<http://example.com/a> <http://example.com/p> "3" .
+`
+		opts := NewJsonLdOptions("")
+		opts.Budget = NewBudget(0, 2, 0)
+		_, err := proc.FromRDF(input, opts)
+		requireExceeded(t, err)
+
+		opts.InputFormat = "application/n-quads"
+		opts.Algorithm = AlgorithmURDNA2015
+		opts.Budget = NewBudget(0, 2, 0)
+		_, err = proc.Normalize(input, opts)
+		requireExceeded(t, err)
+
+		opts.Budget = NewBudget(0, 3, 0)
+		_, err = proc.Normalize(input, opts)
+		require.NoError(t, err)
+		_, quads, _ := opts.Budget.Used()
+		assert.Equal(t, int64(3), quads)
+
+		opts.InputFormat = ""
+		opts.Budget = NewBudget(0, 3, 0)
+		_, err = proc.FromRDF(input, opts)
+		require.NoError(t, err)
+	})
+
+	t.Run("permutations", func(t *testing.T) {
+		// two blank nodes which can't be told apart by their first degree hashes
+		input := `_:a <http://example.com/p> _:b .
+_:b <http://example.com/p> _:a .
+`
+		opts := NewJsonLdOptions("")
+		opts.InputFormat = "application/n-quads"
+		opts.Algorithm = AlgorithmURDNA2015
+		opts.Budget = NewBudget(0, 0, 1)
+		_, err := proc.Normalize(input, opts)
+		requireExceeded(t, err)
+
+		opts.Budget = NewBudget(0, 0, 0)
+		_, err = proc.Normalize(input, opts)
+		require.NoError(t, err)
+		_, _, permutations := opts.Budget.Used()
+		assert.Greater(t, permutations, int64(1))
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		opts := NewJsonLdOptions("")
+		opts.Algorithm = AlgorithmURDNA2015
+		opts.Budget = NewBudget(0, 0, 0)
+		_, err := proc.Normalize(doc, opts)
+		require.NoError(t, err)
+		nodes, quads, permutations := opts.Budget.Used()
+		assert.Equal(t, int64(3), nodes)
+		assert.Equal(t, int64(5), quads)
+		assert.Equal(t, int64(0), permutations)
+		assert.NoError(t, opts.Budget.Err())
+	})
+}
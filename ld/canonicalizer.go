@@ -0,0 +1,151 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// CanonicalizationAlgorithm canonicalizes RDF datasets. Implementations can be registered
+// with RegisterCanonicalizer and selected by name with JsonLdOptions.Algorithm.
+type CanonicalizationAlgorithm interface {
+	// Canonicalize returns the quads of the dataset with blank nodes relabelled
+	// canonically, without duplicates and sorted in the canonical N-Quads order.
+	// It may modify the dataset.
+	Canonicalize(dataset *RDFDataset, opts *JsonLdOptions) ([]*Quad, error)
+}
+
+var (
+	canonicalizersMu sync.RWMutex
+	canonicalizers   = map[string]CanonicalizationAlgorithm{
+		AlgorithmURDNA2015: &builtinCanonicalizer{version: AlgorithmURDNA2015},
+		AlgorithmURGNA2012: &builtinCanonicalizer{version: AlgorithmURGNA2012},
+	}
+)
+
+// RegisterCanonicalizer makes a canonicalization algorithm available under the given name
+// to Normalize and related operations. Registering an algorithm with the name of an existing
+// one (including URDNA2015 and URGNA2012) replaces it.
+func RegisterCanonicalizer(name string, algorithm CanonicalizationAlgorithm) {
+	canonicalizersMu.Lock()
+	defer canonicalizersMu.Unlock()
+
+	canonicalizers[name] = algorithm
+}
+
+// lookupCanonicalizer returns the canonicalization algorithm registered under the given name.
+func lookupCanonicalizer(name string) (CanonicalizationAlgorithm, bool) {
+	canonicalizersMu.RLock()
+	defer canonicalizersMu.RUnlock()
+
+	algorithm, found := canonicalizers[name]
+	return algorithm, found
+}
+
+// canonicalizerNames returns the sorted names of registered canonicalization algorithms.
+func canonicalizerNames() []string {
+	canonicalizersMu.RLock()
+	defer canonicalizersMu.RUnlock()
+
+	names := make([]string, 0, len(canonicalizers))
+	for name := range canonicalizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// builtinCanonicalizer implements CanonicalizationAlgorithm with NormalisationAlgorithm.
+type builtinCanonicalizer struct {
+	version string
+}
+
+func (bc *builtinCanonicalizer) normalize(dataset *RDFDataset, opts *JsonLdOptions) (*NormalisationAlgorithm, error) {
+	na := NewNormalisationAlgorithm(bc.version)
+	na.concurrent = opts.ConcurrentNormalization
+	na.budget = opts.Budget
+	na.Normalize(dataset)
+	if err := na.budget.Err(); err != nil {
+		return nil, err
+	}
+	return na, nil
+}
+
+// Canonicalize implements CanonicalizationAlgorithm.
+func (bc *builtinCanonicalizer) Canonicalize(dataset *RDFDataset, opts *JsonLdOptions) ([]*Quad, error) {
+	na, err := bc.normalize(dataset, opts)
+	if err != nil {
+		return nil, err
+	}
+	return na.CanonicalQuads(), nil
+}
+
+// canonicalResult gives access to the result of canonicalization.
+type canonicalResult interface {
+	CanonicalQuads() []*Quad
+	WriteNQuads(w io.Writer) error
+	Dataset() *RDFDataset
+}
+
+// canonicalQuads is a canonicalResult of an algorithm registered with RegisterCanonicalizer.
+type canonicalQuads []*Quad
+
+func (cq canonicalQuads) CanonicalQuads() []*Quad {
+	return cq
+}
+
+func (cq canonicalQuads) WriteNQuads(w io.Writer) error {
+	for _, quad := range cq {
+		var graphName string
+		if quad.Graph != nil {
+			graphName = quad.Graph.GetValue()
+		}
+		if _, err := io.WriteString(w, toNQuad(quad, graphName)); err != nil {
+			return NewJsonLdError(IOError, err)
+		}
+	}
+	return nil
+}
+
+func (cq canonicalQuads) Dataset() *RDFDataset {
+	dataset := NewRDFDataset()
+	for _, quad := range cq {
+		name := "@default"
+		if quad.Graph != nil {
+			name = quad.Graph.GetValue()
+		}
+		dataset.Graphs[name] = append(dataset.Graphs[name], quad)
+	}
+	return dataset
+}
+
+// canonicalize canonicalizes the dataset with the algorithm selected by opts.Algorithm.
+func canonicalize(dataset *RDFDataset, opts *JsonLdOptions) (canonicalResult, error) {
+	algorithm, found := lookupCanonicalizer(opts.Algorithm)
+	if !found {
+		return nil, NewJsonLdError(InvalidInput, fmt.Sprintf("Unknown normalization algorithm: %s", opts.Algorithm))
+	}
+	if builtin, isBuiltin := algorithm.(*builtinCanonicalizer); isBuiltin {
+		return builtin.normalize(dataset, opts)
+	}
+	quads, err := algorithm.Canonicalize(dataset, opts)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalQuads(quads), nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// skolemCanonicalizer replaces blank nodes with IRIs based on their original labels
+// and sorts the quads. It's only useful for testing the registry.
+type skolemCanonicalizer struct{}
+
+func (skolemCanonicalizer) Canonicalize(dataset *RDFDataset, _ *JsonLdOptions) ([]*Quad, error) {
+	skolemize := func(n Node) Node {
+		if IsBlankNode(n) {
+			return NewIRI("urn:skolem:" + strings.TrimPrefix(n.GetValue(), "_:"))
+		}
+		return n
+	}
+	var quads []*Quad
+	for _, quad := range dataset.GetQuads("@default") {
+		quads = append(quads, &Quad{
+			Subject:   skolemize(quad.Subject),
+			Predicate: quad.Predicate,
+			Object:    skolemize(quad.Object),
+		})
+	}
+	sort.Slice(quads, func(i, j int) bool {
+		return quads[i].Subject.GetValue()+quads[i].Object.GetValue() <
+			quads[j].Subject.GetValue()+quads[j].Object.GetValue()
+	})
+	return quads, nil
+}
+
+func TestRegisterCanonicalizer(t *testing.T) {
+	RegisterCanonicalizer("test-skolem", skolemCanonicalizer{})
+
+	input := `_:x <http://example.com/p> "b" .
+_:a <http://example.com/p> _:x .
+`
+	expected := `<urn:skolem:a> <http://example.com/p> <urn:skolem:x> .
+<urn:skolem:x> <http://example.com/p> "b" .
+`
+
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.InputFormat = "application/n-quads"
+	opts.Format = "application/n-quads"
+	opts.Algorithm = "test-skolem"
+	require.NoError(t, opts.Validate())
+
+	normalized, err := proc.Normalize(input, opts)
+	require.NoError(t, err)
+	assert.Equal(t, expected, normalized)
+
+	opts.Format = ""
+	normalized, err = proc.Normalize(input, opts)
+	require.NoError(t, err)
+	assert.Len(t, normalized.(*RDFDataset).GetQuads("@default"), 2)
+
+	quads, err := proc.NormalizeQuads(input, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "urn:skolem:a", quads[0].Subject.GetValue())
+
+	digest, err := proc.NormalizeDigest(input, opts, crypto.SHA256)
+	require.NoError(t, err)
+	expectedDigest := sha256.Sum256([]byte(expected))
+	assert.Equal(t, expectedDigest[:], digest)
+
+	assert.Contains(t, SupportedFeatures().NormalizationAlgorithms, "test-skolem")
+
+	opts.Algorithm = "unknown"
+	_, err = proc.Normalize(input, opts)
+	require.Error(t, err)
+	assert.Equal(t, InvalidInput, err.(*JsonLdError).Code)
+}
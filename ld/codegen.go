@@ -0,0 +1,167 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateGoConstants processes the given JSON-LD context and writes a Go source file
+// to w which declares:
+//
+//   - a string constant with the name of every term defined in the context,
+//   - an IRI constant with the expanded IRI of every term,
+//   - NewDocument() function that returns an empty compacted document
+//     which references the context.
+//
+// localContext may be a URL of a remote context, a context definition or a document
+// with a @context entry. Keyword aliases and terms with null mappings are skipped.
+func GenerateGoConstants(w io.Writer, packageName string, localContext interface{}, opts *JsonLdOptions) error {
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	}
+
+	if ctxMap, isMap := localContext.(map[string]interface{}); isMap {
+		if innerCtx, hasContext := ctxMap["@context"]; hasContext {
+			localContext = innerCtx
+		}
+	}
+
+	activeCtx, err := NewContext(nil, opts).Parse(localContext)
+	if err != nil {
+		return err
+	}
+
+	terms := make([]string, 0, len(activeCtx.termDefinitions))
+	for term, td := range activeCtx.termDefinitions {
+		tdMap, isMap := td.(map[string]interface{})
+		if !isMap {
+			continue
+		}
+		id, _ := tdMap["@id"].(string)
+		if id == "" || IsKeyword(id) {
+			continue
+		}
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by jsonld-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", packageName)
+
+	contextURL, isURL := localContext.(string)
+	if !isURL {
+		buf.WriteString("import \"encoding/json\"\n\n")
+	}
+
+	buf.WriteString("// IRI is an absolute IRI of a term defined in the context.\n")
+	buf.WriteString("type IRI string\n\n")
+
+	names := make(map[string]string, len(terms))
+	usedNames := map[string]bool{
+		"IRI":         true,
+		"ContextURL":  true,
+		"ContextJSON": true,
+		"NewDocument": true,
+	}
+	for _, term := range terms {
+		name := goIdentifier(term)
+		for i := 2; usedNames[name] || usedNames[name+"IRI"]; i++ {
+			name = goIdentifier(term) + strconv.Itoa(i)
+		}
+		usedNames[name] = true
+		usedNames[name+"IRI"] = true
+		names[term] = name
+	}
+
+	buf.WriteString("// Terms defined in the context.\n")
+	buf.WriteString("const (\n")
+	for _, term := range terms {
+		fmt.Fprintf(&buf, "\t%s = %s\n", names[term], strconv.Quote(term))
+	}
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("// IRIs of the terms defined in the context.\n")
+	buf.WriteString("const (\n")
+	for _, term := range terms {
+		id := activeCtx.termDefinitions[term].(map[string]interface{})["@id"].(string)
+		fmt.Fprintf(&buf, "\t%sIRI IRI = %s\n", names[term], strconv.Quote(id))
+	}
+	buf.WriteString(")\n\n")
+
+	if isURL {
+		buf.WriteString("// ContextURL is the URL of the context.\n")
+		fmt.Fprintf(&buf, "const ContextURL = %s\n\n", strconv.Quote(contextURL))
+		buf.WriteString("// NewDocument returns an empty compacted JSON-LD document which references the context.\n")
+		buf.WriteString("func NewDocument() map[string]interface{} {\n")
+		buf.WriteString("\treturn map[string]interface{}{\"@context\": ContextURL}\n")
+		buf.WriteString("}\n")
+	} else {
+		contextJSON, err := json.Marshal(localContext)
+		if err != nil {
+			return NewJsonLdError(InvalidInput, err)
+		}
+		buf.WriteString("// ContextJSON is the JSON representation of the context.\n")
+		fmt.Fprintf(&buf, "const ContextJSON = %s\n\n", strconv.Quote(string(contextJSON)))
+		buf.WriteString("// NewDocument returns an empty compacted JSON-LD document which embeds the context.\n")
+		buf.WriteString("func NewDocument() map[string]interface{} {\n")
+		buf.WriteString("\tvar context interface{}\n")
+		buf.WriteString("\t_ = json.Unmarshal([]byte(ContextJSON), &context)\n")
+		buf.WriteString("\treturn map[string]interface{}{\"@context\": context}\n")
+		buf.WriteString("}\n")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return NewJsonLdError(UnknownError, err)
+	}
+
+	if _, err = w.Write(src); err != nil {
+		return NewJsonLdError(IOError, err)
+	}
+	return nil
+}
+
+// goIdentifier converts a JSON-LD term into an exported Go identifier.
+// For example, "foaf:knows" becomes "FoafKnows".
+func goIdentifier(term string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range term {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	name := sb.String()
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		name = "Term" + name
+	}
+	return name
+}
@@ -0,0 +1,49 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateGoConstants(t *testing.T) {
+	ctx := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"foaf":       "http://xmlns.com/foaf/0.1/",
+			"name":       "foaf:name",
+			"foaf:knows": map[string]interface{}{"@type": "@id"},
+			"id":         "@id",
+			"ignored":    nil,
+		},
+	}
+
+	var buf bytes.Buffer
+	err := GenerateGoConstants(&buf, "vocab", ctx, nil)
+	require.NoError(t, err)
+
+	src := buf.String()
+	assert.Contains(t, src, "package vocab\n")
+	assert.Regexp(t, `Name\s+= "name"`, src)
+	assert.Regexp(t, `NameIRI\s+IRI = "http://xmlns.com/foaf/0.1/name"`, src)
+	assert.Regexp(t, `FoafKnowsIRI\s+IRI = "http://xmlns.com/foaf/0.1/knows"`, src)
+	assert.Contains(t, src, "func NewDocument() map[string]interface{}")
+	assert.NotContains(t, src, "\"@id\"")
+	assert.NotContains(t, src, "Ignored")
+}
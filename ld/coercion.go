@@ -0,0 +1,212 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CoercionReport describes how a context applies to a sample document.
+// It is intended to help context authors with vocabulary design.
+type CoercionReport struct {
+	// Coerced lists the properties whose values had a type or a language applied by the context.
+	Coerced []*CoercedProperty
+	// Failed lists the values the context couldn't coerce, in document order.
+	Failed []*CoercionFailure
+	// UnusedTerms lists the terms defined in the context which aren't used in the document.
+	UnusedTerms []string
+}
+
+// CoercedProperty describes a coercion applied to the values of a property.
+type CoercedProperty struct {
+	Term     string
+	IRI      string
+	Type     string
+	Language string
+	Count    int
+}
+
+// CoercionFailure describes a value which couldn't be coerced
+// according to the term definition of its property.
+type CoercionFailure struct {
+	Term   string
+	Value  interface{}
+	Reason string
+}
+
+// AnalyzeCoercions expands the given document with the given active context and reports
+// which properties were coerced, which values failed coercion and which terms of the context
+// weren't used by the document. Embedded, property-scoped and type-scoped contexts
+// are taken into account the same way as by expansion.
+func AnalyzeCoercions(ctx *Context, doc interface{}) (*CoercionReport, error) {
+	a := &coercionAnalyzer{
+		used:    make(map[string]bool),
+		coerced: make(map[string]*CoercedProperty),
+		report:  &CoercionReport{},
+	}
+
+	// values which fail IRI coercion are reported rather than rejected
+	opts := ctx.options.Copy()
+	opts.StrictIRICoercion = false
+	opts.WarningHandler = nil
+
+	activeCtx := CopyContext(ctx)
+	activeCtx.options = opts
+	activeCtx.tracer = a
+
+	api := newJsonLdApi(opts)
+	api.coercions = a
+	if _, err := api.Expand(activeCtx, "", CloneDocument(doc), opts, false, nil); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(a.coerced))
+	for key := range a.coerced {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		a.report.Coerced = append(a.report.Coerced, a.coerced[key])
+	}
+
+	for term, td := range ctx.termDefinitions {
+		if td != nil && !a.used[term] {
+			a.report.UnusedTerms = append(a.report.UnusedTerms, term)
+		}
+	}
+	sort.Strings(a.report.UnusedTerms)
+
+	return a.report, nil
+}
+
+// coercionAnalyzer collects the coercions applied by expansion. Term usage is taken
+// from the ExpandTracer events, coercions are reported by JsonLdApi.Expand.
+type coercionAnalyzer struct {
+	used    map[string]bool
+	coerced map[string]*CoercedProperty
+	report  *CoercionReport
+}
+
+// NodeStarted implements ExpandTracer.
+func (a *coercionAnalyzer) NodeStarted(index int) {}
+
+// NodeFinished implements ExpandTracer.
+func (a *coercionAnalyzer) NodeFinished(index int, expanded interface{}) {}
+
+// ContextApplied implements ExpandTracer.
+func (a *coercionAnalyzer) ContextApplied(url string, version string) {}
+
+// TermApplied implements ExpandTracer.
+func (a *coercionAnalyzer) TermApplied(term string, origin string) {
+	a.used[term] = true
+}
+
+func (a *coercionAnalyzer) fail(term string, value interface{}, reason string) {
+	a.report.Failed = append(a.report.Failed, &CoercionFailure{
+		Term:   term,
+		Value:  value,
+		Reason: reason,
+	})
+}
+
+func (a *coercionAnalyzer) coerce(ctx *Context, term string, typ string, lang string) {
+	key := term + "|" + typ + "|" + lang
+	cp, found := a.coerced[key]
+	if !found {
+		iri, _ := ctx.ExpandIri(term, false, true, nil, nil)
+		cp = &CoercedProperty{
+			Term:     term,
+			IRI:      iri,
+			Type:     typ,
+			Language: lang,
+		}
+		a.coerced[key] = cp
+	}
+	cp.Count++
+}
+
+// valueExpanded is called for each value of the given term which was expanded
+// with the term definition, rather than given as a value object.
+func (a *coercionAnalyzer) valueExpanded(ctx *Context, term string, value interface{}, expanded interface{}) {
+	td := ctx.GetTermDefinition(term)
+	typeMapping, _ := td["@type"].(string)
+	strValue, isString := value.(string)
+
+	switch typeMapping {
+	case "@id", "@vocab":
+		if !isString {
+			a.fail(term, value, fmt.Sprintf("%s coercion requires a string value", typeMapping))
+			return
+		}
+		if typeMapping == "@vocab" {
+			a.used[strValue] = true
+			if idx := strings.Index(strValue, ":"); idx > 0 {
+				a.used[strValue[:idx]] = true
+			}
+		}
+		a.coerce(ctx, term, typeMapping, "")
+		return
+	case "@json":
+		a.coerce(ctx, term, typeMapping, "")
+		return
+	}
+
+	if lang, hasLang := td["@language"]; hasLang && lang != nil && !isString {
+		a.fail(term, value, "language mapping applies only to strings")
+		return
+	}
+
+	expandedMap, _ := expanded.(map[string]interface{})
+	if typ, hasType := expandedMap["@type"].(string); hasType {
+		a.coerce(ctx, term, typ, "")
+	} else if lang, hasLang := expandedMap["@language"].(string); hasLang {
+		a.coerce(ctx, term, "", lang)
+	}
+}
+
+// objectExpanded is called for each object expanded as a value of the property
+// with the given term definition.
+func (a *coercionAnalyzer) objectExpanded(td map[string]interface{}, term string, expanded map[string]interface{}) {
+	typeMapping, _ := td["@type"].(string)
+
+	if value, isValue := expanded["@value"]; isValue {
+		if explicitType, hasType := expanded["@type"].(string); hasType && typeMapping != "" &&
+			typeMapping != "@none" && explicitType != typeMapping {
+			a.fail(term, value, fmt.Sprintf("explicit type %s overrides type mapping %s", explicitType,
+				typeMapping))
+		}
+		if lang, hasLang := expanded["@language"]; hasLang {
+			if langMapping, hasLangMapping := td["@language"].(string); hasLangMapping && lang != langMapping {
+				a.fail(term, value, fmt.Sprintf("explicit language %v overrides language mapping %s", lang,
+					langMapping))
+			}
+		}
+		return
+	}
+
+	_, isList := expanded["@list"]
+	_, isSet := expanded["@set"]
+	if isList || isSet {
+		return
+	}
+
+	switch typeMapping {
+	case "", "@id", "@vocab", "@none", "@json":
+	default:
+		a.fail(term, expanded, fmt.Sprintf("node object can't be coerced to %s", typeMapping))
+	}
+}
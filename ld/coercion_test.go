@@ -0,0 +1,76 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeCoercions(t *testing.T) {
+	var localCtx, doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"@language": "en",
+		"schema": "http://schema.org/",
+		"name": "schema:name",
+		"age": {"@id": "schema:age", "@type": "http://www.w3.org/2001/XMLSchema#integer"},
+		"knows": {"@id": "schema:knows", "@type": "@id"},
+		"code": {"@id": "http://example.com/code", "@language": null},
+		"unused": "http://example.com/unused"
+	}`), &localCtx))
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"@graph": [
+			{
+				"@id": "http://example.com/alice",
+				"name": "Alice",
+				"age": 42,
+				"knows": ["http://example.com/bob", 7]
+			},
+			{
+				"@id": "http://example.com/bob",
+				"name": "Bob",
+				"age": {"@value": "41", "@type": "http://www.w3.org/2001/XMLSchema#string"},
+				"code": "B1",
+				"schema:email": "bob@example.com"
+			}
+		]
+	}`), &doc))
+
+	ctx, err := NewContext(nil, nil).Parse(localCtx)
+	require.NoError(t, err)
+
+	report, err := AnalyzeCoercions(ctx, doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*CoercedProperty{
+		{Term: "age", IRI: "http://schema.org/age", Type: XSDInteger, Count: 1},
+		{Term: "knows", IRI: "http://schema.org/knows", Type: "@id", Count: 1},
+		{Term: "name", IRI: "http://schema.org/name", Language: "en", Count: 2},
+		{Term: "schema:email", IRI: "http://schema.org/email", Language: "en", Count: 1},
+	}, report.Coerced)
+
+	require.Len(t, report.Failed, 2)
+	assert.Equal(t, "knows", report.Failed[0].Term)
+	assert.Equal(t, 7.0, report.Failed[0].Value)
+	assert.Equal(t, "age", report.Failed[1].Term)
+	assert.Equal(t, "41", report.Failed[1].Value)
+	assert.Contains(t, report.Failed[1].Reason, "overrides type mapping")
+
+	assert.Equal(t, []string{"unused"}, report.UnusedTerms)
+}
@@ -0,0 +1,109 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"sort"
+	"strings"
+)
+
+// CompactionReport records how the context was used by compaction, see JsonLdOptions.CompactionReport.
+// Vocabulary owners can use it to trim unused term definitions and to find IRIs which
+// are missing from the context. A report shared by several calls accumulates their results.
+type CompactionReport struct {
+	definedTerms    map[string]bool
+	usedTerms       map[string]bool
+	uncompactedIRIs map[string]bool
+}
+
+// NewCompactionReport creates a new empty CompactionReport.
+func NewCompactionReport() *CompactionReport {
+	return &CompactionReport{
+		definedTerms:    make(map[string]bool),
+		usedTerms:       make(map[string]bool),
+		uncompactedIRIs: make(map[string]bool),
+	}
+}
+
+// UsedTerms returns the sorted list of terms (including prefixes of compact IRIs
+// and keyword aliases) which were used in the output. "@vocab" is included if some IRIs
+// were compacted relative to the vocabulary mapping.
+func (r *CompactionReport) UsedTerms() []string {
+	return sortedKeys(r.usedTerms)
+}
+
+// UnusedTerms returns the sorted list of terms defined by the contexts passed to
+// the compaction calls which weren't used in the output. Terms defined in scoped
+// contexts aren't included.
+func (r *CompactionReport) UnusedTerms() []string {
+	unused := make(map[string]bool)
+	for term := range r.definedTerms {
+		if !r.usedTerms[term] {
+			unused[term] = true
+		}
+	}
+	return sortedKeys(unused)
+}
+
+// UncompactedIRIs returns the sorted list of property, type and vocabulary-relative
+// value IRIs which were emitted as absolute IRIs because no term or prefix matched them.
+func (r *CompactionReport) UncompactedIRIs() []string {
+	return sortedKeys(r.uncompactedIRIs)
+}
+
+// addDefinedTerms records the terms of the given active context.
+func (r *CompactionReport) addDefinedTerms(ctx *Context) {
+	for term, def := range ctx.termDefinitions {
+		if def != nil {
+			r.definedTerms[term] = true
+		}
+	}
+}
+
+// recordIri records the result of compaction of the given IRI with the active context.
+func (r *CompactionReport) recordIri(ctx *Context, iri string, compacted string, relativeToVocab bool) {
+	if compacted == "" || IsKeyword(compacted) {
+		return
+	}
+	if td, _ := ctx.termDefinitions[compacted].(map[string]interface{}); td != nil {
+		r.usedTerms[compacted] = true
+		return
+	}
+	if compacted == iri {
+		if relativeToVocab && !strings.HasPrefix(iri, "_:") && IsAbsoluteIri(iri) {
+			r.uncompactedIRIs[iri] = true
+		}
+		return
+	}
+	if idx := strings.Index(compacted, ":"); idx > 0 {
+		prefix := compacted[:idx]
+		if td, _ := ctx.termDefinitions[prefix].(map[string]interface{}); td != nil {
+			r.usedTerms[prefix] = true
+			return
+		}
+	}
+	if vocab, hasVocab := ctx.values["@vocab"].(string); relativeToVocab && hasVocab && strings.HasPrefix(iri, vocab) {
+		r.usedTerms["@vocab"] = true
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
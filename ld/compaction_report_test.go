@@ -0,0 +1,61 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactionReport(t *testing.T) {
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	report := NewCompactionReport()
+	opts.CompactionReport = report
+
+	doc := map[string]interface{}{
+		"@id":                            "http://example.com/alice",
+		"@type":                          "http://schema.org/Person",
+		"http://schema.org/name":         []interface{}{"Alice", "Alicia"},
+		"http://schema.org/knows":        map[string]interface{}{"@id": "http://example.com/bob"},
+		"http://xmlns.com/foaf/0.1/nick": "ally",
+		"http://purl.org/dc/terms/title": "Dr",
+	}
+	context := map[string]interface{}{
+		"name":   "http://schema.org/name",
+		"email":  "http://schema.org/email",
+		"Person": "http://schema.org/Person",
+		"foaf":   "http://xmlns.com/foaf/0.1/",
+		"knows":  map[string]interface{}{"@id": "http://schema.org/knows", "@type": "@id"},
+	}
+
+	compacted, err := proc.Compact(doc, context, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "ally", compacted["foaf:nick"])
+
+	assert.Equal(t, []string{"Person", "foaf", "knows", "name"}, report.UsedTerms())
+	assert.Equal(t, []string{"email"}, report.UnusedTerms())
+	assert.Equal(t, []string{"http://purl.org/dc/terms/title"}, report.UncompactedIRIs())
+
+	// the report accumulates results of subsequent calls
+	_, err = proc.Compact(map[string]interface{}{
+		"http://schema.org/email": "alice@example.com",
+	}, context, opts)
+	require.NoError(t, err)
+	assert.Empty(t, report.UnusedTerms())
+}
@@ -15,6 +15,9 @@
 package ld
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
@@ -45,8 +48,31 @@ type Context struct {
 	options         *JsonLdOptions
 	termDefinitions map[string]interface{}
 	inverse         map[string]interface{}
+	// keywordAliases maps keywords to their preferred aliases. It's built together with
+	// the inverse context and doesn't depend on the containers of the aliases.
+	keywordAliases  map[string]string
 	protected       map[string]bool
 	previousContext *Context
+
+	// origins maps terms to the origin of their definitions: the URL of a remote
+	// or imported context, or "inline#n" for the n-th inline context passed to Parse.
+	origins map[string]string
+	// localOrigins holds the origins of the entries of the local context being processed.
+	localOrigins map[string]string
+
+	// tracer receives expansion events, see JsonLdOptions.ExpandTracer
+	tracer ExpandTracer
+	// compactionReport records IRI compaction results, see JsonLdOptions.CompactionReport
+	compactionReport *CompactionReport
+
+	// remoteDefinition is the definition of the remote context this context was produced by,
+	// if any. It's processed again when a document loader returns this context (see RemoteDocument).
+	remoteDefinition *remoteContextDefinition
+}
+
+// remoteContextDefinition holds the value of @context of a remote context document.
+type remoteContextDefinition struct {
+	context interface{}
 }
 
 // NewContext creates and returns a new Context object.
@@ -60,9 +86,10 @@ func NewContext(values map[string]interface{}, options *JsonLdOptions) *Context
 		options:         options,
 		termDefinitions: make(map[string]interface{}),
 		protected:       make(map[string]bool),
+		origins:         make(map[string]string),
 	}
 
-	context.values["@base"] = options.Base
+	context.values["@base"] = options.base()
 
 	for k, v := range values {
 		context.values[k] = v
@@ -98,6 +125,13 @@ func CopyContext(ctx *Context) *Context {
 		context.protected[k] = v
 	}
 
+	for k, v := range ctx.origins {
+		context.origins[k] = v
+	}
+
+	context.tracer = ctx.tracer
+	context.compactionReport = ctx.compactionReport
+
 	// do not copy c.inverse, because it will be regenerated
 
 	if ctx.previousContext != nil {
@@ -108,13 +142,18 @@ func CopyContext(ctx *Context) *Context {
 }
 
 // Parse processes a local context, retrieving any URLs as necessary, and
-// returns a new active context.
+// returns a new active context. The local context may also be given as raw JSON:
+// []byte, json.RawMessage or io.Reader.
 // Refer to http://www.w3.org/TR/json-ld-api/#context-processing-algorithms for details
 // TODO pyLD is doing a fair bit more in process_context(self, active_ctx, local_ctx, options)
 // than just parsing the context. In particular, we need to check if additional logic is required
 // to load remote scoped contexts.
 func (c *Context) Parse(localContext interface{}) (*Context, error) {
-	return c.parse(localContext, make([]string, 0), false, true, false, false)
+	localContext, err := decodeRawJSON(localContext, InvalidLocalContext, c.options.OnDuplicateKey)
+	if err != nil {
+		return nil, err
+	}
+	return c.parse(localContext, make([]string, 0), false, true, false, false, false)
 }
 
 // parse processes a local context, retrieving any URLs as necessary, and
@@ -123,8 +162,12 @@ func (c *Context) Parse(localContext interface{}) (*Context, error) {
 // If parsingARemoteContext is true, localContext represents a remote context
 // that has been parsed and sent into this method. This must be set to know
 // whether to propagate the @base key from the context to the result.
+//
+// If skipIncludedRemoteContexts is true, remote contexts which are already being processed
+// are skipped instead of being reported as a recursive inclusion. This is used when
+// validating scoped contexts in term definitions.
 func (c *Context) parse(localContext interface{}, remoteContexts []string, parsingARemoteContext, propagate,
-	protected, overrideProtected bool) (*Context, error) { //nolint:unparam
+	protected, overrideProtected, skipIncludedRemoteContexts bool) (*Context, error) { //nolint:unparam
 
 	// normalize local context to an array of @context objects
 	contexts := Arrayify(localContext)
@@ -147,6 +190,12 @@ func (c *Context) parse(localContext interface{}, remoteContexts []string, parsi
 	// 1. Initialize result to the result of cloning active context.
 	result := CopyContext(c)
 
+	// relative references in a remote context are resolved against the URL of that context
+	contextURL := ""
+	if parsingARemoteContext && len(remoteContexts) > 0 {
+		contextURL = remoteContexts[len(remoteContexts)-1]
+	}
+
 	// track the previous context
 	// if not propagating, make sure result has a previous context
 	if !propagate && result.previousContext == nil {
@@ -154,7 +203,7 @@ func (c *Context) parse(localContext interface{}, remoteContexts []string, parsi
 	}
 
 	// 3)
-	for _, context := range contexts {
+	for i, context := range contexts {
 		// 3.1)
 		if context == nil {
 			// We can't nullify if there are protected terms and we're
@@ -164,6 +213,8 @@ func (c *Context) parse(localContext interface{}, remoteContexts []string, parsi
 					"tried to nullify a context with protected terms outside of a term definition.")
 			}
 			nullCtx := NewContext(nil, c.options)
+			nullCtx.tracer = c.tracer
+			nullCtx.compactionReport = c.compactionReport
 			if !propagate {
 				nullCtx.previousContext = result
 			}
@@ -176,43 +227,73 @@ func (c *Context) parse(localContext interface{}, remoteContexts []string, parsi
 		switch ctx := context.(type) {
 		case *Context:
 			result = ctx
+			if (c.tracer != nil && ctx.tracer == nil) || (c.compactionReport != nil && ctx.compactionReport == nil) {
+				// keep tracing expansion and compaction with the processed context
+				result = CopyContext(ctx)
+				result.tracer = c.tracer
+				result.compactionReport = c.compactionReport
+			}
 		// 3.2)
 		case string:
-			uri := Resolve(result.values["@base"].(string), ctx)
+			uri := Resolve(result.contextBaseURL(contextURL), ctx)
 			// 3.2.2
+			alreadyIncluded := false
 			for _, remoteCtx := range remoteContexts {
 				if remoteCtx == uri {
-					return nil, NewJsonLdError(RecursiveContextInclusion, uri)
+					alreadyIncluded = true
+					break
 				}
 			}
+			if alreadyIncluded {
+				if skipIncludedRemoteContexts {
+					// the context is being validated already, don't process it again
+					continue
+				}
+				return nil, NewJsonLdError(RecursiveContextInclusion, uri)
+			}
 			remoteContexts = append(remoteContexts, uri)
 
 			// 3.2.3: Dereference context
 			rd, err := c.options.DocumentLoader.LoadDocument(uri)
 			if err != nil {
-				return nil, NewJsonLdError(LoadingRemoteContextFailed,
-					fmt.Errorf("dereferencing a URL did not result in a valid JSON-LD context (%s): %w", uri, err))
-			}
-			remoteContextMap, isMap := rd.Document.(map[string]interface{})
-			context, hasContextKey := remoteContextMap["@context"]
-			if !isMap || !hasContextKey {
-				// If the dereferenced document has no top-level JSON object
-				// with an @context member
-				return nil, NewJsonLdError(InvalidRemoteContext, context)
+				return nil, newRemoteContextError(uri, contextURL, i, err)
+			}
+			var context interface{}
+			if parsedCtx, isContext := rd.Document.(*Context); isContext {
+				// the loader returned a processed context: its definition is processed
+				// against the active context as usual, without decoding it again
+				if parsedCtx.remoteDefinition == nil {
+					return nil, NewJsonLdError(InvalidRemoteContext,
+						fmt.Sprintf("the processed context returned for %s wasn't produced by a remote context", uri))
+				}
+				context = CloneDocument(parsedCtx.remoteDefinition.context)
+			} else {
+				remoteContextMap, isMap := rd.Document.(map[string]interface{})
+				remoteContext, hasContextKey := remoteContextMap["@context"]
+				if !isMap || !hasContextKey {
+					// If the dereferenced document has no top-level JSON object
+					// with an @context member
+					return nil, NewJsonLdError(InvalidRemoteContext, remoteContext)
+				}
+				context = remoteContext
 			}
 
 			// 3.2.4
-			remoteContextsCpy := make([]string, 0, len(remoteContexts))
+			remoteContextsCpy := make([]string, len(remoteContexts))
 			copy(remoteContextsCpy, remoteContexts)
-			resultRef, err := result.parse(context, remoteContextsCpy, true, true, false, overrideProtected)
+			resultRef, err := result.parse(context, remoteContextsCpy, true, true, false, overrideProtected,
+				skipIncludedRemoteContexts)
 			if err != nil {
 				return nil, err
 			}
 			result = resultRef
+			result.remoteDefinition = &remoteContextDefinition{context: context}
 			// 3.2.5
 			continue
 		case map[string]interface{}:
 			contextMap = ctx
+			// the result is no longer the one of a single remote context
+			result.remoteDefinition = nil
 		default:
 			// 3.3
 			return nil, NewJsonLdError(InvalidLocalContext, context)
@@ -229,13 +310,20 @@ func (c *Context) parse(localContext interface{}, remoteContexts []string, parsi
 		pm, hasProcessingMode := c.values["processingMode"]
 
 		if versionValue, versionPresent := contextMap["@version"]; versionPresent {
-			if versionValue != 1.1 {
+			if version, isNumber := floatValue(versionValue); !isNumber || version != 1.1 {
 				return nil, NewJsonLdError(InvalidVersionValue, fmt.Sprintf("unsupported JSON-LD version: %s", versionValue))
 			}
-			if hasProcessingMode {
-				if pm.(string) == JsonLd_1_0 {
+			if hasProcessingMode && pm.(string) == JsonLd_1_0 {
+				if !c.options.UpgradeProcessingMode {
 					return nil, NewJsonLdError(ProcessingModeConflict, fmt.Sprintf("@version: %v not compatible with %s", versionValue, pm))
 				}
+				source := "an embedded context"
+				if contextURL != "" {
+					source = contextURL
+				}
+				c.options.warn(NewJsonLdError(ProcessingModeConflict,
+					fmt.Sprintf("@version: %v in %s upgraded processing mode from %s to %s", versionValue,
+						source, pm, JsonLd_1_1)))
 			}
 			result.values["processingMode"] = JsonLd_1_1
 			result.values["@version"] = versionValue
@@ -246,6 +334,27 @@ func (c *Context) parse(localContext interface{}, remoteContexts []string, parsi
 			result.values["processingMode"] = pm
 		}
 
+		if !skipIncludedRemoteContexts {
+			result.traceContext(contextURL, contextMap["@version"])
+		}
+
+		// scoped contexts of a remote context are resolved against the URL of the remote context
+		if contextURL != "" {
+			contextMap = resolveScopedContexts(contextMap, contextURL)
+		}
+
+		// remote contexts tracked while processing the term definitions of this context
+		termRemoteContexts := remoteContexts
+
+		origin := contextURL
+		if origin == "" {
+			origin = fmt.Sprintf("inline#%d", i)
+		}
+		localOrigins := make(map[string]string, len(contextMap))
+		for key := range contextMap {
+			localOrigins[key] = origin
+		}
+
 		// handle @import
 		if importValue, importFound := contextMap["@import"]; importFound {
 			if result.processingMode(1.0) {
@@ -255,34 +364,46 @@ func (c *Context) parse(localContext interface{}, remoteContexts []string, parsi
 			if !isString {
 				return nil, NewJsonLdError(InvalidImportValue, "@import must be a string")
 			}
-			uri := Resolve(result.values["@base"].(string), importStr)
+			uri := Resolve(result.contextBaseURL(contextURL), importStr)
 
-			rd, err := c.options.DocumentLoader.LoadDocument(uri)
-			if err != nil {
-				return nil, NewJsonLdError(LoadingRemoteContextFailed,
-					fmt.Errorf("dereferencing a URL did not result in a valid JSON-LD context (%s): %w", uri, err))
-			}
-			importCtxDocMap, isMap := rd.Document.(map[string]interface{})
-			context, hasContextKey := importCtxDocMap["@context"]
-			if !isMap || !hasContextKey {
-				// If the de-referenced document has no top-level JSON object
-				// with an @context member
-				return nil, NewJsonLdError(InvalidRemoteContext, context)
+			alreadyIncluded := false
+			for _, remoteCtx := range remoteContexts {
+				if remoteCtx == uri {
+					alreadyIncluded = true
+					break
+				}
 			}
+			if alreadyIncluded {
+				if !skipIncludedRemoteContexts {
+					return nil, NewJsonLdError(RecursiveContextInclusion, uri)
+				}
+				// the imported context is being validated already, don't import it again
+			} else {
+				// track the imported context, so that scoped contexts within it can't import it again
+				termRemoteContexts = append(remoteContexts[:len(remoteContexts):len(remoteContexts)], uri)
 
-			if importCtxMap, isMap := context.(map[string]interface{}); isMap {
-				if _, found := importCtxMap["@import"]; found {
-					return nil, NewJsonLdError(InvalidContextEntry,
-						fmt.Sprintf("%s must not include @import entry", importStr))
+				importCtxMap, err := c.loadImportedContext(uri, importStr, contextURL, i)
+				if err != nil {
+					return nil, err
+				}
+				importCtxMap = resolveScopedContexts(importCtxMap, uri)
+				if !skipIncludedRemoteContexts {
+					result.traceContext(uri, importCtxMap["@version"])
 				}
 
-				// merge import context into the outer context
+				// merge import context into the outer context,
+				// without modifying the (possibly cached) imported document
+				mergedCtxMap := make(map[string]interface{}, len(importCtxMap)+len(contextMap))
+				for k, v := range importCtxMap {
+					mergedCtxMap[k] = v
+					if _, overridden := contextMap[k]; !overridden {
+						localOrigins[k] = uri
+					}
+				}
 				for k, v := range contextMap {
-					importCtxMap[k] = v
+					mergedCtxMap[k] = v
 				}
-				contextMap = importCtxMap
-			} else {
-				return nil, NewJsonLdError(InvalidRemoteContext, fmt.Sprintf("%s must be an object", importStr))
+				contextMap = mergedCtxMap
 			}
 		}
 
@@ -371,23 +492,149 @@ func (c *Context) parse(localContext interface{}, remoteContexts []string, parsi
 		// all its terms to be "protected" (exceptions can be made on a
 		// per-definition basis)
 		if protectedVal, protectedPresent := contextMap["@protected"]; protectedPresent {
-			defined["@protected"] = protectedVal.(bool)
+			protectedBool, isBool := protectedVal.(bool)
+			if !isBool {
+				return nil, NewJsonLdError(InvalidProtectedValue, "@protected value must be a boolean")
+			}
+			defined["@protected"] = protectedBool
 		} else if protected {
 			defined["@protected"] = true
 		}
 
+		result.localOrigins = localOrigins
 		for key := range contextMap {
 			if _, skip := nonTermDefKeys[key]; !skip {
-				if err := result.createTermDefinition(contextMap, key, defined, overrideProtected); err != nil {
+				if err := result.createTermDefinition(contextMap, key, defined, overrideProtected, termRemoteContexts); err != nil {
+					result.localOrigins = nil
 					return nil, err
 				}
 			}
 		}
+		result.localOrigins = nil
 	}
 
 	return result, nil
 }
 
+// loadImportedContext retrieves the context referenced by @import. contextURL and position
+// identify the context which contains @import, for error reporting.
+func (c *Context) loadImportedContext(uri string, importStr string, contextURL string,
+	position int) (map[string]interface{}, error) {
+	rd, err := c.options.DocumentLoader.LoadDocument(uri)
+	if err != nil {
+		return nil, newRemoteContextError(uri, contextURL, position, err)
+	}
+	if _, isContext := rd.Document.(*Context); isContext {
+		return nil, NewJsonLdError(InvalidRemoteContext,
+			fmt.Sprintf("%s must be a JSON document; processed contexts can't be imported", importStr))
+	}
+	importCtxDocMap, isMap := rd.Document.(map[string]interface{})
+	context, hasContextKey := importCtxDocMap["@context"]
+	if !isMap || !hasContextKey {
+		// If the de-referenced document has no top-level JSON object
+		// with an @context member
+		return nil, NewJsonLdError(InvalidRemoteContext, context)
+	}
+
+	importCtxMap, isMap := context.(map[string]interface{})
+	if !isMap {
+		return nil, NewJsonLdError(InvalidRemoteContext, fmt.Sprintf("%s must be an object", importStr))
+	}
+	if _, found := importCtxMap["@import"]; found {
+		return nil, NewJsonLdError(InvalidContextEntry,
+			fmt.Sprintf("%s must not include @import entry", importStr))
+	}
+
+	return importCtxMap, nil
+}
+
+// resolveScopedContexts returns the given context definition with relative references
+// (remote contexts and @import values) in scoped contexts of its term definitions resolved
+// against baseURL. Scoped contexts are processed later, against the base IRI of the document,
+// so they have to be resolved while the URL of the context which defines them is known.
+// The original definition isn't modified.
+func resolveScopedContexts(contextMap map[string]interface{}, baseURL string) map[string]interface{} {
+	var result map[string]interface{}
+	for key, value := range contextMap {
+		td, isMap := value.(map[string]interface{})
+		if IsKeyword(key) || !isMap {
+			continue
+		}
+		scopedCtx, hasScopedCtx := td["@context"]
+		if !hasScopedCtx {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]interface{}, len(contextMap))
+			for k, v := range contextMap {
+				result[k] = v
+			}
+		}
+		tdCopy := make(map[string]interface{}, len(td))
+		for k, v := range td {
+			tdCopy[k] = v
+		}
+		tdCopy["@context"] = resolveContextReferences(scopedCtx, baseURL)
+		result[key] = tdCopy
+	}
+	if result == nil {
+		return contextMap
+	}
+	return result
+}
+
+// resolveContextReferences resolves relative references in the given local context against baseURL.
+func resolveContextReferences(localContext interface{}, baseURL string) interface{} {
+	switch ctx := localContext.(type) {
+	case string:
+		return Resolve(baseURL, ctx)
+	case []interface{}:
+		result := make([]interface{}, len(ctx))
+		for i, v := range ctx {
+			result[i] = resolveContextReferences(v, baseURL)
+		}
+		return result
+	case map[string]interface{}:
+		result := resolveScopedContexts(ctx, baseURL)
+		if importStr, isString := ctx["@import"].(string); isString {
+			resolved := make(map[string]interface{}, len(result))
+			for k, v := range result {
+				resolved[k] = v
+			}
+			resolved["@import"] = Resolve(baseURL, importStr)
+			result = resolved
+		}
+		return result
+	default:
+		return localContext
+	}
+}
+
+// contextBaseURL returns the URL which relative context references are resolved against:
+// the URL of the remote context being processed, if any, or the base IRI.
+func (c *Context) contextBaseURL(contextURL string) string {
+	if contextURL != "" {
+		return contextURL
+	}
+	base, _ := c.values["@base"].(string)
+	return base
+}
+
+// EffectiveBase returns the base IRI of the context: the document base from
+// JsonLdOptions.Base, as modified by @base entries of the processed contexts.
+// It returns an empty string if there is no base IRI (e.g. "@base": null).
+func (c *Context) EffectiveBase() string {
+	base, _ := c.values["@base"].(string)
+	return base
+}
+
+// EffectiveVocab returns the expanded vocabulary mapping (@vocab) of the context,
+// or an empty string if there is none.
+func (c *Context) EffectiveVocab() string {
+	vocab, _ := c.values["@vocab"].(string)
+	return vocab
+}
+
 // CompactValue performs value compaction on an object with @value or @id as the only property.
 // See https://www.w3.org/TR/2019/CR-json-ld11-api-20191212/#value-compaction
 func (c *Context) CompactValue(activeProperty string, value map[string]interface{}) (interface{}, error) {
@@ -539,7 +786,7 @@ func (c *Context) processingMode(version float64) bool {
 // for a term being processed in a local context as described in
 // http://www.w3.org/TR/json-ld-api/#create-term-definition
 func (c *Context) createTermDefinition(context map[string]interface{}, term string,
-	defined map[string]bool, overrideProtected bool) error {
+	defined map[string]bool, overrideProtected bool, remoteContexts []string) error {
 
 	if definedValue, inDefined := defined[term]; inDefined {
 		if definedValue {
@@ -554,7 +801,9 @@ func (c *Context) createTermDefinition(context map[string]interface{}, term stri
 	mapValue, isMap := value.(map[string]interface{})
 	idValue, hasID := mapValue["@id"]
 	if value == nil || (isMap && hasID && idValue == nil) {
+		c.resetInverse()
 		c.termDefinitions[term] = nil
+		c.setOrigin(term)
 		defined[term] = true
 		return nil
 	}
@@ -583,7 +832,7 @@ func (c *Context) createTermDefinition(context map[string]interface{}, term stri
 		if c.processingMode(1.1) && term == "@type" && hasAllowedKeysOnly && isSet {
 			// this is the only case were redefining a keyword is allowed
 		} else {
-			return NewJsonLdError(KeywordRedefinition, term)
+			return NewJsonLdError(KeywordRedefinition, c.describeOrigin(term, term))
 		}
 	} else if ignoredKeywordPattern.MatchString(term) {
 		//log.Printf("Terms beginning with '@' are reserved for future use and ignored: %s.", term)
@@ -593,6 +842,7 @@ func (c *Context) createTermDefinition(context map[string]interface{}, term stri
 	// keep reference to previous mapping for potential `@protected` check
 	prevDefinition := c.termDefinitions[term]
 
+	c.resetInverse()
 	delete(c.termDefinitions, term)
 
 	// casting the value so it doesn't have to be done below everytime
@@ -724,7 +974,7 @@ func (c *Context) createTermDefinition(context map[string]interface{}, term stri
 		if termHasColon {
 			prefix := term[0:colIndex]
 			if _, containsPrefix := context[prefix]; containsPrefix {
-				if err := c.createTermDefinition(context, prefix, defined, overrideProtected); err != nil {
+				if err := c.createTermDefinition(context, prefix, defined, overrideProtected, remoteContexts); err != nil {
 					return err
 				}
 			}
@@ -745,7 +995,11 @@ func (c *Context) createTermDefinition(context map[string]interface{}, term stri
 
 	// handle term protection
 	valProtected, protectedFound := mapValue["@protected"]
-	if (protectedFound && valProtected.(bool)) || (defined["@protected"] && !(protectedFound && !valProtected.(bool))) {
+	protectedBool, isBool := valProtected.(bool)
+	if protectedFound && !isBool {
+		return NewJsonLdError(InvalidProtectedValue, "@protected value must be a boolean")
+	}
+	if (protectedFound && protectedBool) || (defined["@protected"] && !(protectedFound && !protectedBool)) {
 		c.protected[term] = true
 		definition["protected"] = true
 	}
@@ -793,12 +1047,21 @@ func (c *Context) createTermDefinition(context map[string]interface{}, term stri
 		if isArray {
 			container = make([]interface{}, 0)
 			for _, c := range containerArray {
+				cStr, isString := c.(string)
+				if !isString {
+					return NewJsonLdError(InvalidContainerMapping, "@context @container values must be strings")
+				}
 				container = append(container, c)
-				containerValueMap[c.(string)] = true
+				containerValueMap[cStr] = true
 			}
 		} else {
+			containerStr, isString := containerVal.(string)
+			if !isString {
+				return NewJsonLdError(InvalidContainerMapping,
+					"@context @container value must be a string or an array of strings")
+			}
 			container = []interface{}{containerVal}
-			containerValueMap[containerVal.(string)] = true
+			containerValueMap[containerStr] = true
 		}
 
 		validContainers := map[string]bool{
@@ -913,6 +1176,13 @@ func (c *Context) createTermDefinition(context map[string]interface{}, term stri
 
 	// scoped contexts
 	if ctxVal, hasCtx := val["@context"]; hasCtx {
+		// 21.3) process the scoped context to make sure it's valid. Protected terms
+		// may be overridden (or nullified) by a scoped context, so allow it here.
+		remoteContextsCpy := make([]string, len(remoteContexts))
+		copy(remoteContextsCpy, remoteContexts)
+		if _, err := c.parse(ctxVal, remoteContextsCpy, false, true, false, true, true); err != nil {
+			return NewJsonLdError(InvalidScopedContext, err)
+		}
 		definition["@context"] = ctxVal
 	}
 
@@ -979,17 +1249,199 @@ func (c *Context) createTermDefinition(context map[string]interface{}, term stri
 			c.protected[term] = true
 			definition["protected"] = true
 			if !DeepCompare(prevDefinition, definition, false) {
-				return NewJsonLdError(ProtectedTermRedefinition, "invalid JSON-LD syntax; tried to redefine a protected term")
+				return NewJsonLdError(ProtectedTermRedefinition, c.describeOrigin(term,
+					"invalid JSON-LD syntax; tried to redefine a protected term"))
 			}
 		}
 	}
 
 	// 18)
 	c.termDefinitions[term] = definition
+	c.setOrigin(term)
 
 	return nil
 }
 
+// setOrigin records the origin of the term definition from the local context being processed.
+func (c *Context) setOrigin(term string) {
+	if origin := c.localOrigins[term]; origin != "" {
+		c.origins[term] = origin
+	} else {
+		delete(c.origins, term)
+	}
+}
+
+// describeOrigin appends the origins of the existing and the new definition of the given term
+// to the error message, if they are known.
+func (c *Context) describeOrigin(term string, msg string) string {
+	if origin := c.origins[term]; origin != "" {
+		msg += fmt.Sprintf("; %s was defined in %s", term, origin)
+	}
+	if origin := c.localOrigins[term]; origin != "" {
+		msg += fmt.Sprintf("; redefinition of %s in %s", term, origin)
+	}
+	return msg
+}
+
+// TermOrigin returns the origin of the definition of the given term: the URL of the remote
+// (or imported) context which defined it, or "inline#n" if it came from the n-th inline context
+// passed to Parse. An empty string is returned if the origin is unknown, for example
+// for terms added with DefineTerm.
+func (c *Context) TermOrigin(term string) string {
+	return c.origins[term]
+}
+
+// Fingerprint returns a digest of the context which only depends on its semantics:
+// the values (such as @base, @vocab and @language), term definitions and protected terms,
+// including those of the previous context restored by non-propagated contexts.
+// Contexts which produce the same results have the same fingerprint regardless of
+// how they were built (for example, the order of entries in the source documents or
+// the URLs of remote contexts). It's useful as a key for caches of processed contexts.
+func (c *Context) Fingerprint() (string, error) {
+	h := sha256.New()
+	// JSON encoding sorts map keys, which makes it canonical
+	if err := json.NewEncoder(h).Encode(c.fingerprintData()); err != nil {
+		return "", NewJsonLdError(InvalidInput, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *Context) fingerprintData() map[string]interface{} {
+	data := map[string]interface{}{
+		"values":          c.values,
+		"termDefinitions": c.termDefinitions,
+		"protected":       c.protected,
+	}
+	if c.previousContext != nil {
+		data["previousContext"] = c.previousContext.fingerprintData()
+	}
+	return data
+}
+
+// TermDefinitionSpec describes a term definition to be added to a context with DefineTerm.
+// Fields correspond to the keys of an expanded term definition; empty fields are omitted.
+// Use pointer fields to set null mappings.
+type TermDefinitionSpec struct {
+	ID        string
+	Reverse   string
+	Type      string
+	Language  *string
+	Direction *string
+	Container []string
+	Context   interface{}
+	Index     string
+	Nest      string
+	Prefix    *bool
+	Protected bool
+}
+
+// toMap returns the JSON form of the term definition.
+func (spec *TermDefinitionSpec) toMap() map[string]interface{} {
+	def := make(map[string]interface{})
+	if spec.ID != "" {
+		def["@id"] = spec.ID
+	}
+	if spec.Reverse != "" {
+		def["@reverse"] = spec.Reverse
+	}
+	if spec.Type != "" {
+		def["@type"] = spec.Type
+	}
+	if spec.Language != nil {
+		def["@language"] = *spec.Language
+	}
+	if spec.Direction != nil {
+		def["@direction"] = *spec.Direction
+	}
+	if len(spec.Container) == 1 {
+		def["@container"] = spec.Container[0]
+	} else if len(spec.Container) > 1 {
+		container := make([]interface{}, len(spec.Container))
+		for i, c := range spec.Container {
+			container[i] = c
+		}
+		def["@container"] = container
+	}
+	if spec.Context != nil {
+		def["@context"] = spec.Context
+	}
+	if spec.Index != "" {
+		def["@index"] = spec.Index
+	}
+	if spec.Nest != "" {
+		def["@nest"] = spec.Nest
+	}
+	if spec.Prefix != nil {
+		def["@prefix"] = *spec.Prefix
+	}
+	if spec.Protected {
+		def["@protected"] = true
+	}
+	return def
+}
+
+// DefineTerm returns a copy of the context with the given term (re)defined according to spec.
+// The definition goes through the same validation as term definitions in a parsed context.
+// The original context isn't modified.
+func (c *Context) DefineTerm(term string, spec TermDefinitionSpec) (*Context, error) {
+	result := CopyContext(c)
+
+	localContext := map[string]interface{}{term: spec.toMap()}
+	if err := result.createTermDefinition(localContext, term, make(map[string]bool), false, nil); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RemoveTerm returns a copy of the context without the given term.
+// Protected terms can't be removed. The original context isn't modified.
+func (c *Context) RemoveTerm(term string) (*Context, error) {
+	td := c.GetTermDefinition(term)
+	if protected, _ := td["protected"].(bool); protected {
+		return nil, NewJsonLdError(ProtectedTermRedefinition, c.describeOrigin(term,
+			fmt.Sprintf("can't remove protected term %s", term)))
+	}
+
+	result := CopyContext(c)
+	delete(result.termDefinitions, term)
+	delete(result.protected, term)
+	delete(result.origins, term)
+
+	return result, nil
+}
+
+// traceContext reports a processed context to the tracer, if any.
+func (c *Context) traceContext(url string, version interface{}) {
+	if c.tracer == nil {
+		return
+	}
+	versionStr := ""
+	if version != nil {
+		versionStr = fmt.Sprint(version)
+	}
+	c.tracer.ContextApplied(url, versionStr)
+}
+
+// traceTerm reports the term definition used to expand the given key or type to the tracer, if any.
+// For compact IRIs, the definition of the prefix is reported.
+func (c *Context) traceTerm(term string) {
+	if c.tracer == nil {
+		return
+	}
+	if td, _ := c.termDefinitions[term].(map[string]interface{}); td == nil {
+		idx := strings.Index(term, ":")
+		if idx <= 0 {
+			return
+		}
+		term = term[:idx]
+		if td, _ = c.termDefinitions[term].(map[string]interface{}); td == nil {
+			return
+		}
+	}
+	c.tracer.TermApplied(term, c.origins[term])
+}
+
 // RevertToPreviousContext reverts any type-scoped context in this active context to the previous context.
 func (c *Context) RevertToPreviousContext() *Context {
 	if c.previousContext == nil {
@@ -1023,7 +1475,7 @@ func (c *Context) ExpandIri(value string, relative bool, vocab bool, context map
 	// 2)
 	if context != nil {
 		if _, containsKey := context[value]; containsKey && !defined[value] {
-			if err := c.createTermDefinition(context, value, defined, false); err != nil {
+			if err := c.createTermDefinition(context, value, defined, false, nil); err != nil {
 				return "", err
 			}
 		}
@@ -1052,7 +1504,7 @@ func (c *Context) ExpandIri(value string, relative bool, vocab bool, context map
 		// 4.3)
 		if context != nil {
 			if _, containsPrefix := context[prefix]; containsPrefix && !defined[prefix] {
-				if err := c.createTermDefinition(context, prefix, defined, false); err != nil {
+				if err := c.createTermDefinition(context, prefix, defined, false, nil); err != nil {
 					return "", err
 				}
 			}
@@ -1101,6 +1553,14 @@ func (c *Context) ExpandIri(value string, relative bool, vocab bool, context map
 //
 // Returns the compacted term, prefix, keyword alias, or original IRI.
 func (c *Context) CompactIri(iri string, value interface{}, relativeToVocab bool, reverse bool) (string, error) {
+	rval, err := c.compactIri(iri, value, relativeToVocab, reverse)
+	if err == nil && c.compactionReport != nil {
+		c.compactionReport.recordIri(c, iri, rval, relativeToVocab)
+	}
+	return rval, err
+}
+
+func (c *Context) compactIri(iri string, value interface{}, relativeToVocab bool, reverse bool) (string, error) {
 	// 1)
 	if iri == "" {
 		return "", nil
@@ -1111,14 +1571,8 @@ func (c *Context) CompactIri(iri string, value interface{}, relativeToVocab bool
 	// term is a keyword, force relativeToVocab to True
 	if IsKeyword(iri) {
 		// look for an alias
-		if v, found := inverseCtx[iri]; found {
-			if v, found = v.(map[string]interface{})["@none"]; found {
-				if v, found = v.(map[string]interface{})["@type"]; found {
-					if v, found = v.(map[string]interface{})["@none"]; found {
-						return v.(string), nil
-					}
-				}
-			}
+		if alias, found := c.keywordAliases[iri]; found {
+			return alias, nil
 		}
 		relativeToVocab = true
 	}
@@ -1282,6 +1736,8 @@ func (c *Context) CompactIri(iri string, value interface{}, relativeToVocab bool
 							typeLanguageValue = langVal.(string)
 						}
 					} else if dir, hasDir := valueMap["@direction"]; hasDir && !hasIndex {
+						// untagged values with a direction go under @none in language maps
+						containers = append(containers, "@language", "@language@set")
 						typeLanguageValue = fmt.Sprintf("_%s", dir)
 					} else if typeVal, hasType := valueMap["@type"]; hasType {
 						// 2.7.1.2)
@@ -1329,7 +1785,7 @@ func (c *Context) CompactIri(iri string, value interface{}, relativeToVocab bool
 				}
 
 				// 2.12.1)
-				result, err := c.CompactIri(idVal.(string), nil, true, false)
+				result, err := c.compactIri(idVal.(string), nil, true, false)
 				if err != nil {
 					return "", err
 				}
@@ -1433,6 +1889,16 @@ func (c *Context) CompactIri(iri string, value interface{}, relativeToVocab bool
 		}
 	}
 
+	// rewrite blank node identifiers, if requested. If the replacement is an IRI,
+	// compact it as usual.
+	if c.options.BlankNodeRewriter != nil && strings.HasPrefix(iri, "_:") {
+		rewritten := c.options.BlankNodeRewriter(iri)
+		if !strings.HasPrefix(rewritten, "_:") {
+			return c.compactIri(rewritten, value, relativeToVocab, reverse)
+		}
+		return rewritten, nil
+	}
+
 	if !relativeToVocab {
 		return RemoveBase(c.values["@base"], iri), nil
 	}
@@ -1486,6 +1952,8 @@ func (c *Context) GetInverse() map[string]interface{} {
 
 	// 1)
 	c.inverse = make(map[string]interface{})
+	c.keywordAliases = make(map[string]string)
+	plainAliases := make(map[string]bool)
 
 	// 2)
 	defaultLanguage := "@none"
@@ -1498,6 +1966,9 @@ func (c *Context) GetInverse() map[string]interface{} {
 	// shortest and then lexicographically least
 	terms := GetKeys(c.termDefinitions)
 	sort.Sort(ShortestLeast(terms))
+	if c.options != nil && c.options.TermSelector != nil {
+		c.sortTermsBySelector(terms, c.options.TermSelector)
+	}
 
 	for _, term := range terms {
 		definitionVal := c.termDefinitions[term]
@@ -1525,6 +1996,14 @@ func (c *Context) GetInverse() map[string]interface{} {
 		// 3.3)
 		iri := definition["@id"].(string)
 
+		// prefer keyword aliases without a container
+		if IsKeyword(iri) {
+			if _, found := c.keywordAliases[iri]; !found || (containerJoin == "@none" && !plainAliases[iri]) {
+				c.keywordAliases[iri] = term
+				plainAliases[iri] = containerJoin == "@none"
+			}
+		}
+
 		// 3.4 + 3.5)
 		var containerMap map[string]interface{}
 		containerMapVal, present := c.inverse[iri]
@@ -1575,7 +2054,7 @@ func (c *Context) GetInverse() map[string]interface{} {
 			}
 		} else if hasType {
 			typeMap := typeLanguageMap["@type"].(map[string]interface{})
-			if _, hasValue := typeMap["@type"]; !hasValue {
+			if _, hasValue := typeMap[typeVal.(string)]; !hasValue {
 				typeMap[typeVal.(string)] = term
 			}
 		} else if hasLang && hasDir {
@@ -1653,6 +2132,36 @@ func (c *Context) GetInverse() map[string]interface{} {
 	return c.inverse
 }
 
+// resetInverse drops the inverse context and the keyword aliases built with it,
+// so that they are regenerated after the term definitions change.
+func (c *Context) resetInverse() {
+	c.inverse = nil
+	c.keywordAliases = nil
+}
+
+// sortTermsBySelector reorders terms which map to the same IRI according to
+// the given selector. The selector only affects the relative order of terms
+// mapped to the same IRI, as no other order matters for the inverse context.
+func (c *Context) sortTermsBySelector(terms []string, selector TermSelector) {
+	termIRI := func(term string) string {
+		if definition, isMap := c.termDefinitions[term].(map[string]interface{}); isMap {
+			iri, _ := definition["@id"].(string)
+			return iri
+		}
+		return ""
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		iriI, iriJ := termIRI(terms[i]), termIRI(terms[j])
+		if iriI != iriJ {
+			return iriI < iriJ
+		}
+		if preference := selector(iriI, terms[i], terms[j]); preference != 0 {
+			return preference < 0
+		}
+		return CompareShortestLeast(terms[i], terms[j])
+	})
+}
+
 // SelectTerm picks the preferred compaction term from the inverse context entry.
 // See http://www.w3.org/TR/json-ld-api/#term-selection
 //
@@ -1785,6 +2294,20 @@ func (c *Context) GetTermDefinition(key string) map[string]interface{} {
 	return value
 }
 
+// checkIRICoercion reports a value which can't be coerced to an IRI, because it isn't a string,
+// for a term with "@type": "@id" or "@type": "@vocab". It returns an error in StrictIRICoercion mode.
+func (c *Context) checkIRICoercion(activeProperty string, value interface{}) error {
+	if c.options == nil {
+		return nil
+	}
+	err := newValueError(InvalidTypedValue, "value of a term coerced to an IRI isn't a string", value, activeProperty)
+	if c.options.StrictIRICoercion {
+		return err
+	}
+	c.options.warn(err)
+	return nil
+}
+
 // ExpandValue expands the given value by using the coercion and keyword rules in the context.
 func (c *Context) ExpandValue(activeProperty string, value interface{}) (interface{}, error) {
 	var rval = make(map[string]interface{})
@@ -1801,6 +2324,9 @@ func (c *Context) ExpandValue(activeProperty string, value interface{}) (interfa
 				return nil, err
 			}
 		} else {
+			if err := c.checkIRICoercion(activeProperty, value); err != nil {
+				return nil, err
+			}
 			rval["@value"] = value
 		}
 		return rval, nil
@@ -1816,6 +2342,9 @@ func (c *Context) ExpandValue(activeProperty string, value interface{}) (interfa
 				return nil, err
 			}
 		} else {
+			if err := c.checkIRICoercion(activeProperty, value); err != nil {
+				return nil, err
+			}
 			rval["@value"] = value
 		}
 		return rval, nil
@@ -1849,12 +2378,14 @@ func (c *Context) ExpandValue(activeProperty string, value interface{}) (interfa
 	return rval, nil
 }
 
-// Serialize transforms the context back into JSON form.
+// Serialize transforms the context back into JSON form. Multiple container mappings
+// are sorted, so that contexts which only differ in the order of containers are
+// serialized to equal values. The order of keys in the result is up to the JSON encoder.
 func (c *Context) Serialize() (map[string]interface{}, error) {
 	ctx := make(map[string]interface{})
 
 	baseVal, hasBase := c.values["@base"]
-	if hasBase && baseVal != c.options.Base {
+	if hasBase && baseVal != c.options.base() {
 		ctx["@base"] = baseVal
 	}
 	if versionVal, hasVersion := c.values["@version"]; hasVersion {
@@ -1900,9 +2431,15 @@ func (c *Context) Serialize() (map[string]interface{}, error) {
 			}
 		} else {
 			defn := make(map[string]interface{})
-			cid, err := c.CompactIri(definition["@id"].(string), nil, false, false)
-			if err != nil {
-				return nil, err
+			id := definition["@id"].(string)
+			cid := id
+			// keyword aliases must refer to the keyword itself rather than to another alias
+			var err error
+			if !IsKeyword(id) {
+				cid, err = c.CompactIri(id, nil, false, false)
+				if err != nil {
+					return nil, err
+				}
 			}
 			reverseProperty := reverseVal.(bool)
 			if !(term == cid && !reverseProperty) {
@@ -1924,11 +2461,7 @@ func (c *Context) Serialize() (map[string]interface{}, error) {
 				}
 			}
 			if hasContainer {
-				if av, isArray := containerVal.([]string); isArray && len(av) == 1 {
-					defn["@container"] = av[0]
-				} else {
-					defn["@container"] = containerVal
-				}
+				defn["@container"] = serializeContainer(containerVal)
 			}
 			if hasLang {
 				if langVal == false {
@@ -1947,3 +2480,30 @@ func (c *Context) Serialize() (map[string]interface{}, error) {
 	}
 	return rval, nil
 }
+
+// serializeContainer returns the value of @container for a serialized term definition:
+// a single container as a string, or multiple containers as a sorted array.
+func serializeContainer(containerVal interface{}) interface{} {
+	var containers []string
+	switch v := containerVal.(type) {
+	case []string:
+		containers = append(containers, v...)
+	case []interface{}:
+		for _, c := range v {
+			if cStr, isString := c.(string); isString {
+				containers = append(containers, cStr)
+			}
+		}
+	default:
+		return containerVal
+	}
+	if len(containers) == 1 {
+		return containers[0]
+	}
+	sort.Strings(containers)
+	rval := make([]interface{}, len(containers))
+	for i, c := range containers {
+		rval[i] = c
+	}
+	return rval
+}
@@ -0,0 +1,201 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MigrationIssueKind identifies a construct of a JSON-LD 1.0 context which behaves
+// differently when the context is processed in JSON-LD 1.1 mode.
+type MigrationIssueKind string
+
+const (
+	// MigrationPrefix is reported for terms which could be used as prefixes of compact IRIs
+	// in JSON-LD 1.0, but not in 1.1. In 1.1, only simple terms with IRIs ending with a gen-delim
+	// character (one of ":/?#[]@") and terms with "@prefix": true are prefixes.
+	// The rewritten context sets "@prefix": true for such terms.
+	MigrationPrefix MigrationIssueKind = "prefix"
+	// MigrationContainer is reported for @container values which are strings. JSON-LD 1.1
+	// uses arrays to combine container types (for example, ["@index", "@set"]).
+	// The rewritten context uses the array form.
+	MigrationContainer MigrationIssueKind = "container"
+	// MigrationRelativeVocab is reported for relative @vocab values. They are invalid in
+	// JSON-LD 1.0 and resolved against the base IRI (or the current vocabulary mapping) in 1.1.
+	// If the base is known, the rewritten context contains the resolved IRI.
+	MigrationRelativeVocab MigrationIssueKind = "relative-vocab"
+	// MigrationReservedTerm is reported for terms which have the form of a keyword
+	// (for example, "@label"). Such terms are ignored in JSON-LD 1.1.
+	MigrationReservedTerm MigrationIssueKind = "reserved-term"
+)
+
+// MigrationIssue describes a single construct found by MigrateContext.
+type MigrationIssue struct {
+	Kind MigrationIssueKind
+	// Term is the affected term, or the keyword (such as @vocab) the issue relates to.
+	Term    string
+	Message string
+}
+
+// ContextMigrationReport is the result of MigrateContext.
+type ContextMigrationReport struct {
+	Issues []MigrationIssue
+	// Context is a copy of the analysed context, rewritten to keep the JSON-LD 1.0 behaviour
+	// when processed in JSON-LD 1.1 mode. It declares "@version": 1.1.
+	Context interface{}
+}
+
+// MigrateContext analyses a JSON-LD 1.0 context for constructs which behave differently
+// in JSON-LD 1.1 (see MigrationIssueKind) and returns a report along with a rewritten context.
+//
+// localContext may be a context definition, an array of context definitions or a document
+// with a @context entry. Remote contexts referenced by URL are left unchanged and aren't analysed.
+// base is used to resolve relative @vocab values; it may be empty.
+// The given context isn't modified.
+func MigrateContext(localContext interface{}, base string) (*ContextMigrationReport, error) {
+	if ctxMap, isMap := localContext.(map[string]interface{}); isMap {
+		if innerCtx, hasContext := ctxMap["@context"]; hasContext {
+			localContext = innerCtx
+		}
+	}
+
+	report := &ContextMigrationReport{
+		Issues: make([]MigrationIssue, 0),
+	}
+
+	contexts := Arrayify(localContext)
+	rewritten := make([]interface{}, 0, len(contexts))
+	for _, ctx := range contexts {
+		switch v := ctx.(type) {
+		case nil, string:
+			rewritten = append(rewritten, v)
+		case map[string]interface{}:
+			rewritten = append(rewritten, report.migrateContextDefinition(v, base))
+		default:
+			return nil, NewJsonLdError(InvalidLocalContext, ctx)
+		}
+	}
+
+	if _, isList := localContext.([]interface{}); isList {
+		report.Context = rewritten
+	} else {
+		report.Context = rewritten[0]
+	}
+
+	return report, nil
+}
+
+// migrateContextDefinition analyses a single context definition and returns its rewritten copy.
+func (r *ContextMigrationReport) migrateContextDefinition(ctx map[string]interface{}, base string) map[string]interface{} {
+	result := make(map[string]interface{}, len(ctx)+1)
+	for k, v := range ctx {
+		result[k] = v
+	}
+	result["@version"] = 1.1
+
+	if vocab, isString := ctx["@vocab"].(string); isString && !IsAbsoluteIri(vocab) {
+		msg := fmt.Sprintf("relative @vocab %q is invalid in JSON-LD 1.0 and resolved against the base IRI in 1.1", vocab)
+		if base != "" {
+			result["@vocab"] = Resolve(base, vocab)
+			msg += fmt.Sprintf("; replaced with %s", result["@vocab"])
+		}
+		r.addIssue(MigrationRelativeVocab, "@vocab", msg)
+	}
+
+	terms := make([]string, 0, len(ctx))
+	for term := range ctx {
+		if !IsKeyword(term) {
+			terms = append(terms, term)
+		}
+	}
+	sort.Strings(terms)
+
+	for _, term := range terms {
+		if ignoredKeywordPattern.MatchString(term) {
+			r.addIssue(MigrationReservedTerm, term, "terms in the form of a keyword are ignored in JSON-LD 1.1")
+			continue
+		}
+
+		var def map[string]interface{}
+		switch v := ctx[term].(type) {
+		case string:
+			def = map[string]interface{}{"@id": v}
+		case map[string]interface{}:
+			def = make(map[string]interface{}, len(v))
+			for k, val := range v {
+				def[k] = val
+			}
+		default:
+			continue
+		}
+		simpleTerm := len(def) == 1
+		changed := false
+
+		if container, isString := def["@container"].(string); isString {
+			r.addIssue(MigrationContainer, term, fmt.Sprintf(
+				"@container %q is a string; JSON-LD 1.1 uses arrays to combine container types", container))
+			def["@container"] = []interface{}{container}
+			changed = true
+		}
+
+		if losesPrefixStatus(term, def, simpleTerm) {
+			r.addIssue(MigrationPrefix, term, "the term can be used as a prefix in JSON-LD 1.0, but not in 1.1")
+			def["@prefix"] = true
+			changed = true
+		}
+
+		if changed {
+			result[term] = def
+		}
+	}
+
+	return result
+}
+
+// losesPrefixStatus returns true if the given term definition can be used as a prefix
+// of compact IRIs in JSON-LD 1.0, but not in 1.1.
+func losesPrefixStatus(term string, def map[string]interface{}, simpleTerm bool) bool {
+	if invalidPrefixPattern.MatchString(term) {
+		return false
+	}
+	if _, hasPrefix := def["@prefix"]; hasPrefix {
+		return false
+	}
+	if _, hasReverse := def["@reverse"]; hasReverse {
+		return false
+	}
+	id, isString := def["@id"].(string)
+	if !isString || id == "" || IsKeyword(id) {
+		return false
+	}
+	if !simpleTerm {
+		return true
+	}
+	if strings.HasPrefix(id, "_:") {
+		return false
+	}
+	switch id[len(id)-1] {
+	case ':', '/', '?', '#', '[', ']', '@':
+		return false
+	default:
+		return true
+	}
+}
+
+func (r *ContextMigrationReport) addIssue(kind MigrationIssueKind, term string, msg string) {
+	r.Issues = append(r.Issues, MigrationIssue{Kind: kind, Term: term, Message: msg})
+}
@@ -0,0 +1,101 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateContext(t *testing.T) {
+	ctx := map[string]interface{}{
+		"@vocab": "terms/",
+		"ex":     "http://example.org/ns",
+		"schema": "http://schema.org/",
+		"tags": map[string]interface{}{
+			"@id":        "http://example.org/tags",
+			"@container": "@set",
+		},
+		"knows":  map[string]interface{}{"@id": "http://xmlns.com/foaf/0.1/knows", "@type": "@id"},
+		"id":     "@id",
+		"@label": "http://www.w3.org/2000/01/rdf-schema#label",
+	}
+	doc := map[string]interface{}{"@context": ctx}
+
+	report, err := MigrateContext(doc, "http://example.org/")
+	require.NoError(t, err)
+
+	kinds := make(map[string]MigrationIssueKind)
+	for _, issue := range report.Issues {
+		kinds[issue.Term] = issue.Kind
+	}
+	assert.Equal(t, map[string]MigrationIssueKind{
+		"@vocab": MigrationRelativeVocab,
+		"@label": MigrationReservedTerm,
+		"ex":     MigrationPrefix,
+		"knows":  MigrationPrefix,
+		"tags":   MigrationPrefix,
+	}, kinds)
+	assert.Len(t, report.Issues, 6) // tags also has a string @container
+
+	assert.Equal(t, map[string]interface{}{
+		"@version": 1.1,
+		"@vocab":   "http://example.org/terms/",
+		"ex":       map[string]interface{}{"@id": "http://example.org/ns", "@prefix": true},
+		"schema":   "http://schema.org/",
+		"tags": map[string]interface{}{
+			"@id":        "http://example.org/tags",
+			"@container": []interface{}{"@set"},
+			"@prefix":    true,
+		},
+		"knows": map[string]interface{}{
+			"@id":     "http://xmlns.com/foaf/0.1/knows",
+			"@type":   "@id",
+			"@prefix": true,
+		},
+		"id":     "@id",
+		"@label": "http://www.w3.org/2000/01/rdf-schema#label",
+	}, report.Context)
+
+	// the original context isn't modified
+	assert.Equal(t, "@set", ctx["tags"].(map[string]interface{})["@container"])
+
+	// the rewritten context keeps using ex as a prefix in 1.1
+	expanded, err := NewJsonLdProcessor().Expand(map[string]interface{}{
+		"@context": report.Context,
+		"@id":      "ex:item",
+		"@type":    "schema:Thing",
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"@id":   "http://example.org/nsitem",
+		"@type": []interface{}{"http://schema.org/Thing"},
+	}}, expanded)
+
+	// remote contexts are kept as is
+	report, err = MigrateContext([]interface{}{"http://example.org/context.jsonld", map[string]interface{}{}}, "")
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, []interface{}{
+		"http://example.org/context.jsonld",
+		map[string]interface{}{"@version": 1.1},
+	}, report.Context)
+
+	_, err = MigrateContext(42.0, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,286 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SuggestContext inspects an expanded JSON-LD document and produces a context which
+// can be used to compact it into a more readable form. It's intended for exploratory
+// work against unfamiliar datasets, e.g. to compact the output of FromRDF:
+//
+//	expanded, err := proc.FromRDF(nquads, opts)
+//	ctx, err := ld.SuggestContext(expanded, ld.PrefixRegistry{"xsd": ld.XSDNS})
+//	compacted, err := proc.Compact(expanded, ctx, opts)
+//
+// Every property and type IRI used in the document gets a term named after its
+// local name (the part after the last '#' or '/'). If several IRIs share a local name,
+// the most frequently used IRI gets the plain name and the others get a numeric suffix.
+// Property terms get type coercion (@id, a datatype IRI, @json or @language) and
+// an @list container if all values of the property have the same shape.
+//
+// prefixHints maps prefixes to namespace IRIs (PrefixRegistry can be used here).
+// Prefixes whose namespaces are used by the document are added to the context and
+// used to shorten the datatype IRIs in term definitions.
+func SuggestContext(expandedDoc interface{}, prefixHints map[string]string) (map[string]interface{}, error) {
+	switch expandedDoc.(type) {
+	case []interface{}, map[string]interface{}:
+	default:
+		return nil, NewJsonLdError(InvalidInput,
+			fmt.Sprintf("expanded document must be an array or an object, got %T", expandedDoc))
+	}
+
+	s := &contextSuggester{
+		usages: make(map[string]*iriUsage),
+	}
+	s.collect(expandedDoc)
+
+	ctx := make(map[string]interface{})
+	declared := make(map[string]string)
+
+	// declare prefixes which cover any of the IRIs in the document
+	prefixes := make([]string, 0, len(prefixHints))
+	for prefix := range prefixHints {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		ns := prefixHints[prefix]
+		if ns == "" || IsKeyword(prefix) || strings.Contains(prefix, ":") {
+			continue
+		}
+		for iri := range s.usages {
+			if strings.HasPrefix(iri, ns) && len(iri) > len(ns) {
+				declared[prefix] = ns
+				break
+			}
+		}
+		for datatype := range s.datatypes {
+			if strings.HasPrefix(datatype, ns) && len(datatype) > len(ns) {
+				declared[prefix] = ns
+				break
+			}
+		}
+	}
+	for prefix, ns := range declared {
+		ctx[prefix] = ns
+	}
+
+	// more frequently used IRIs get first pick of term names
+	usages := make([]*iriUsage, 0, len(s.usages))
+	for _, u := range s.usages {
+		usages = append(usages, u)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].count != usages[j].count {
+			return usages[i].count > usages[j].count
+		}
+		return usages[i].iri < usages[j].iri
+	})
+
+	for _, u := range usages {
+		name := localName(u.iri)
+		if name == "" {
+			continue
+		}
+		term := name
+		for i := 2; ctx[term] != nil; i++ {
+			term = name + strconv.Itoa(i)
+		}
+
+		def := make(map[string]interface{})
+		if u.property {
+			if u.shape != "" && u.shape != mixedShape && u.shape != plainShape {
+				if strings.HasPrefix(u.shape, languageShape) {
+					def["@language"] = strings.TrimPrefix(u.shape, languageShape)
+				} else if IsKeyword(u.shape) {
+					def["@type"] = u.shape
+				} else {
+					def["@type"] = compactWithPrefixes(u.shape, declared)
+				}
+			}
+			if u.inList && !u.outsideList {
+				def["@container"] = "@list"
+			}
+		}
+		if len(def) == 0 {
+			ctx[term] = u.iri
+		} else {
+			def["@id"] = u.iri
+			ctx[term] = def
+		}
+	}
+
+	return ctx, nil
+}
+
+const (
+	// mixedShape marks properties with values of different shapes.
+	mixedShape = "mixed"
+	// languageShape is the prefix of shapes of language-tagged strings.
+	languageShape = "lang:"
+	// plainShape is the shape of native values and untyped strings.
+	plainShape = "plain"
+)
+
+// iriUsage describes how an IRI is used in a document.
+type iriUsage struct {
+	iri         string
+	count       int
+	property    bool
+	shape       string
+	inList      bool
+	outsideList bool
+}
+
+// observe merges the shape of a property value into the usage.
+func (u *iriUsage) observe(shape string) {
+	if u.shape == "" {
+		u.shape = shape
+	} else if u.shape != shape {
+		u.shape = mixedShape
+	}
+}
+
+type contextSuggester struct {
+	usages    map[string]*iriUsage
+	datatypes map[string]bool
+}
+
+func (s *contextSuggester) use(iri string) *iriUsage {
+	u, found := s.usages[iri]
+	if !found {
+		u = &iriUsage{iri: iri}
+		s.usages[iri] = u
+	}
+	u.count++
+	return u
+}
+
+func (s *contextSuggester) collect(element interface{}) {
+	switch elem := element.(type) {
+	case []interface{}:
+		for _, item := range elem {
+			s.collect(item)
+		}
+	case map[string]interface{}:
+		if IsValue(elem) {
+			return
+		}
+		for key, value := range elem {
+			switch key {
+			case "@type":
+				for _, t := range Arrayify(value) {
+					if iri, isString := t.(string); isString && !strings.HasPrefix(iri, "_:") {
+						s.use(iri)
+					}
+				}
+			case "@list", "@graph", "@included":
+				s.collect(value)
+			case "@reverse":
+				if reverseMap, isMap := value.(map[string]interface{}); isMap {
+					for _, reverseValue := range reverseMap {
+						s.collect(reverseValue)
+					}
+				}
+			default:
+				if IsKeyword(key) || strings.HasPrefix(key, "_:") {
+					continue
+				}
+				u := s.use(key)
+				u.property = true
+				for _, v := range Arrayify(value) {
+					if IsList(v) {
+						u.inList = true
+						for _, item := range Arrayify(v.(map[string]interface{})["@list"]) {
+							u.observe(s.valueShape(item))
+						}
+					} else {
+						u.outsideList = true
+						u.observe(s.valueShape(v))
+					}
+					s.collect(v)
+				}
+			}
+		}
+	}
+}
+
+// valueShape returns the type coercion (or language) which would compact the given
+// expanded value to a string, plainShape if no coercion is needed, or mixedShape if
+// the value can't be expressed this way.
+func (s *contextSuggester) valueShape(v interface{}) string {
+	vMap, isMap := v.(map[string]interface{})
+	if !isMap {
+		return mixedShape
+	}
+	if !IsValue(vMap) {
+		if _, hasID := vMap["@id"]; hasID && !IsList(vMap) {
+			return "@id"
+		}
+		return mixedShape
+	}
+	if _, hasIndex := vMap["@index"]; hasIndex {
+		return mixedShape
+	}
+	if _, hasDirection := vMap["@direction"]; hasDirection {
+		return mixedShape
+	}
+	if t, hasType := vMap["@type"].(string); hasType {
+		if !IsKeyword(t) {
+			if s.datatypes == nil {
+				s.datatypes = make(map[string]bool)
+			}
+			s.datatypes[t] = true
+		}
+		return t
+	}
+	if lang, hasLanguage := vMap["@language"].(string); hasLanguage {
+		return languageShape + lang
+	}
+	return plainShape
+}
+
+// localName returns the part of the IRI after the last '#' or '/', or an empty string
+// if it isn't usable as a term.
+func localName(iri string) string {
+	name := iri[strings.LastIndexAny(iri, "#/")+1:]
+	if name == "" || IsKeyword(name) || strings.HasPrefix(name, "@") || strings.Contains(name, ":") {
+		return ""
+	}
+	return name
+}
+
+// compactWithPrefixes shortens the IRI using the longest matching namespace
+// from the given prefix mappings.
+func compactWithPrefixes(iri string, prefixes map[string]string) string {
+	best := ""
+	for prefix, ns := range prefixes {
+		if !strings.HasPrefix(iri, ns) || len(iri) == len(ns) {
+			continue
+		}
+		if best == "" || len(ns) > len(prefixes[best]) || (len(ns) == len(prefixes[best]) && prefix < best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return iri
+	}
+	return best + ":" + strings.TrimPrefix(iri, prefixes[best])
+}
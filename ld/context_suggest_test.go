@@ -0,0 +1,112 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestContext(t *testing.T) {
+	nquads := `<http://example.com/alice> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/Person> .
+<http://example.com/alice> <http://schema.org/name> "Alice" .
+<http://example.com/alice> <http://schema.org/birthDate> "1990-01-01"^^<http://www.w3.org/2001/XMLSchema#date> .
+<http://example.com/alice> <http://schema.org/knows> <http://example.com/bob> .
+<http://example.com/alice> <http://xmlns.com/foaf/0.1/name> "Alice Smith" .
+<http://example.com/alice> <http://schema.org/description> "Ingénieure"@fr .
+<http://example.com/bob> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/Person> .
+<http://example.com/bob> <http://schema.org/name> "Bob" .
+<http://example.com/bob> <http://schema.org/birthDate> "1985-05-05"^^<http://www.w3.org/2001/XMLSchema#date> .
+<http://example.com/bob> <http://schema.org/knows> <http://example.com/alice> .
+<http://example.com/bob> <http://schema.org/knows> _:b0 .
+<http://example.com/bob> <http://schema.org/age> "35"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://example.com/bob> <http://schema.org/age> "thirty-five" .
+`
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	expanded, err := proc.FromRDF(nquads, opts)
+	require.NoError(t, err)
+
+	ctx, err := SuggestContext(expanded, PrefixRegistry{
+		"schema": "http://schema.org/",
+		"xsd":    XSDNS,
+		"dc":     "http://purl.org/dc/terms/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"schema": "http://schema.org/",
+		"xsd":    XSDNS,
+		"Person": "http://schema.org/Person",
+		"name":   "http://schema.org/name",
+		"name2":  "http://xmlns.com/foaf/0.1/name",
+		"age":    "http://schema.org/age",
+		"birthDate": map[string]interface{}{
+			"@id":   "http://schema.org/birthDate",
+			"@type": "xsd:date",
+		},
+		"knows": map[string]interface{}{
+			"@id":   "http://schema.org/knows",
+			"@type": "@id",
+		},
+		"description": map[string]interface{}{
+			"@id":       "http://schema.org/description",
+			"@language": "fr",
+		},
+	}, ctx)
+
+	compacted, err := proc.Compact(expanded, ctx, opts)
+	require.NoError(t, err)
+	graph := compacted["@graph"].([]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"@id":         "http://example.com/alice",
+		"@type":       "Person",
+		"name":        "Alice",
+		"name2":       "Alice Smith",
+		"birthDate":   "1990-01-01",
+		"knows":       "http://example.com/bob",
+		"description": "Ingénieure",
+	}, graph[0])
+
+	// the compacted document expands back to the original one
+	reexpanded, err := proc.Expand(compacted, opts)
+	require.NoError(t, err)
+	assert.True(t, DeepCompare(expanded, reexpanded, false))
+
+	// lists get an @list container
+	ctx, err = SuggestContext([]interface{}{
+		map[string]interface{}{
+			"@id": "http://example.com/s",
+			"http://example.com/items": []interface{}{
+				map[string]interface{}{"@list": []interface{}{
+					map[string]interface{}{"@value": "a"},
+					map[string]interface{}{"@value": "b"},
+				}},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"items": map[string]interface{}{
+			"@id":        "http://example.com/items",
+			"@container": "@list",
+		},
+	}, ctx)
+
+	_, err = SuggestContext("http://example.com/doc", nil)
+	assert.Error(t, err)
+}
@@ -1,7 +1,10 @@
 package ld
 
 import (
+	"encoding/json"
 	"errors"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -34,6 +37,64 @@ func TestContext_Parse(t *testing.T) {
 	})
 }
 
+func TestContext_ScopedNullContext(t *testing.T) {
+	ctx := map[string]interface{}{
+		"@protected": true,
+		"@vocab":     "http://vocab.org/",
+		"name":       "http://schema.org/name",
+		"data": map[string]interface{}{
+			"@id":      "http://example.org/data",
+			"@context": nil,
+		},
+	}
+
+	t.Run("scoped null context may override protected terms", func(t *testing.T) {
+		doc := map[string]interface{}{
+			"@context": ctx,
+			"name":     "outer",
+			"data": map[string]interface{}{
+				"name": "inner",
+			},
+		}
+		expanded, err := NewJsonLdProcessor().Expand(doc, NewJsonLdOptions(""))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"http://example.org/data": []interface{}{map[string]interface{}{}},
+				"http://schema.org/name":  []interface{}{map[string]interface{}{"@value": "outer"}},
+			},
+		}, expanded)
+	})
+
+	t.Run("embedded null context may not override protected terms", func(t *testing.T) {
+		doc := map[string]interface{}{
+			"@context": ctx,
+			"http://example.org/other": map[string]interface{}{
+				"@context": nil,
+				"name":     "inner",
+			},
+		}
+		_, err := NewJsonLdProcessor().Expand(doc, NewJsonLdOptions(""))
+		jsonLDError := new(JsonLdError)
+		require.ErrorAs(t, err, &jsonLDError)
+		assert.Equal(t, InvalidContextNullification, jsonLDError.Code)
+	})
+
+	t.Run("scoped contexts are validated when defined", func(t *testing.T) {
+		_, err := NewContext(nil, NewJsonLdOptions("")).Parse(map[string]interface{}{
+			"term": map[string]interface{}{
+				"@id": "http://example.org/term",
+				"@context": map[string]interface{}{
+					"@vocab": true,
+				},
+			},
+		})
+		jsonLDError := new(JsonLdError)
+		require.ErrorAs(t, err, &jsonLDError)
+		assert.Equal(t, InvalidScopedContext, jsonLDError.Code)
+	})
+}
+
 type errorDocumentLoader struct {
 	err error
 }
@@ -41,3 +102,585 @@ type errorDocumentLoader struct {
 func (l errorDocumentLoader) LoadDocument(u string) (*RemoteDocument, error) {
 	return nil, l.err
 }
+
+func TestContext_DefineTerm(t *testing.T) {
+	ctx, err := NewContext(nil, nil).Parse(map[string]interface{}{
+		"@version": 1.1,
+		"schema":   "http://schema.org/",
+		"name": map[string]interface{}{
+			"@id":        "schema:name",
+			"@protected": true,
+		},
+	})
+	require.NoError(t, err)
+
+	// populate the inverse context of the original context
+	term, err := ctx.CompactIri("http://example.com/age", nil, true, false)
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/age", term)
+
+	en := "en"
+	newCtx, err := ctx.DefineTerm("age", TermDefinitionSpec{
+		ID:        "http://example.com/age",
+		Type:      XSDInteger,
+		Container: []string{"@set"},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, ctx.GetTermDefinition("age"))
+	assert.Equal(t, XSDInteger, newCtx.GetTypeMapping("age"))
+	assert.True(t, newCtx.HasContainerMapping("age", "@set"))
+
+	ageValue := map[string]interface{}{"@value": "5", "@type": XSDInteger}
+	term, err = newCtx.CompactIri("http://example.com/age", ageValue, true, false)
+	require.NoError(t, err)
+	assert.Equal(t, "age", term)
+
+	newCtx, err = newCtx.DefineTerm("label", TermDefinitionSpec{ID: "schema:label", Language: &en})
+	require.NoError(t, err)
+	assert.Equal(t, "http://schema.org/label", newCtx.GetTermDefinition("label")["@id"])
+	assert.Equal(t, "en", newCtx.GetLanguageMapping("label"))
+
+	_, err = newCtx.DefineTerm("bad", TermDefinitionSpec{ID: "http://example.com/bad", Type: "relative"})
+	jsonLDError := new(JsonLdError)
+	require.ErrorAs(t, err, &jsonLDError)
+	assert.Equal(t, InvalidTypeMapping, jsonLDError.Code)
+
+	_, err = newCtx.DefineTerm("name", TermDefinitionSpec{ID: "http://example.com/name"})
+	require.ErrorAs(t, err, &jsonLDError)
+	assert.Equal(t, ProtectedTermRedefinition, jsonLDError.Code)
+
+	removedCtx, err := newCtx.RemoveTerm("age")
+	require.NoError(t, err)
+	assert.NotNil(t, newCtx.GetTermDefinition("age"))
+	assert.Nil(t, removedCtx.GetTermDefinition("age"))
+	term, err = removedCtx.CompactIri("http://example.com/age", ageValue, true, false)
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/age", term)
+
+	_, err = newCtx.RemoveTerm("name")
+	require.ErrorAs(t, err, &jsonLDError)
+	assert.Equal(t, ProtectedTermRedefinition, jsonLDError.Code)
+}
+
+func TestContext_ParseImport(t *testing.T) {
+	importedCtx := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"age": "http://schema.org/age",
+		},
+	}
+	loader := NewMapDocumentLoader(map[string]interface{}{
+		"http://example.com/contexts/main.jsonld": map[string]interface{}{
+			"@context": []interface{}{
+				"terms.jsonld",
+				map[string]interface{}{
+					"@version": 1.1,
+					"@import":  "imported.jsonld",
+					"name":     "http://schema.org/name",
+				},
+			},
+		},
+		"http://example.com/contexts/terms.jsonld": map[string]interface{}{
+			"@context": map[string]interface{}{
+				"email": "http://schema.org/email",
+			},
+		},
+		"http://example.com/contexts/imported.jsonld": importedCtx,
+		"http://example.com/contexts/self.jsonld": map[string]interface{}{
+			"@context": map[string]interface{}{
+				"@version": 1.1,
+				"@import":  "self.jsonld",
+			},
+		},
+		"http://example.com/contexts/scoped.jsonld": map[string]interface{}{
+			"@context": map[string]interface{}{
+				"@version": 1.1,
+				"knows": map[string]interface{}{
+					"@id":      "http://schema.org/knows",
+					"@context": map[string]interface{}{"@import": "scoped.jsonld"},
+				},
+			},
+		},
+	})
+	opts := NewJsonLdOptions("http://other.org/docs/doc.jsonld")
+	opts.DocumentLoader = loader
+
+	t.Run("relative references are resolved against the remote context URL", func(t *testing.T) {
+		ctx, err := NewContext(nil, opts).Parse("http://example.com/contexts/main.jsonld")
+		require.NoError(t, err)
+		assert.Equal(t, "http://schema.org/age", ctx.GetTermDefinition("age")["@id"])
+		assert.Equal(t, "http://schema.org/email", ctx.GetTermDefinition("email")["@id"])
+		assert.Equal(t, "http://schema.org/name", ctx.GetTermDefinition("name")["@id"])
+
+		// the imported document must not be modified
+		assert.Equal(t, map[string]interface{}{"age": "http://schema.org/age"}, importedCtx["@context"])
+	})
+
+	t.Run("context importing itself", func(t *testing.T) {
+		_, err := NewContext(nil, opts).Parse("http://example.com/contexts/self.jsonld")
+		jsonLDError := new(JsonLdError)
+		require.ErrorAs(t, err, &jsonLDError)
+		assert.Equal(t, RecursiveContextInclusion, jsonLDError.Code)
+	})
+
+	t.Run("scoped context importing the enclosing context", func(t *testing.T) {
+		ctx, err := NewContext(nil, opts).Parse(map[string]interface{}{
+			"@version": 1.1,
+			"@import":  "http://example.com/contexts/scoped.jsonld",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "http://schema.org/knows", ctx.GetTermDefinition("knows")["@id"])
+
+		expanded, err := NewJsonLdProcessor().Expand(map[string]interface{}{
+			"@context": "http://example.com/contexts/scoped.jsonld",
+			"knows": map[string]interface{}{
+				"knows": map[string]interface{}{"@id": "http://example.com/bob"},
+			},
+		}, opts)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"http://schema.org/knows": []interface{}{map[string]interface{}{
+				"http://schema.org/knows": []interface{}{map[string]interface{}{"@id": "http://example.com/bob"}},
+			}},
+		}}, expanded)
+	})
+}
+
+func TestContext_TermOrigins(t *testing.T) {
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = NewMapDocumentLoader(map[string]interface{}{
+		"http://example.com/base.jsonld": map[string]interface{}{
+			"@context": map[string]interface{}{
+				"@version":   1.1,
+				"@protected": true,
+				"@import":    "imported.jsonld",
+				"name":       "http://schema.org/name",
+			},
+		},
+		"http://example.com/imported.jsonld": map[string]interface{}{
+			"@context": map[string]interface{}{
+				"age": "http://schema.org/age",
+			},
+		},
+	})
+
+	ctx, err := NewContext(nil, opts).Parse([]interface{}{
+		"http://example.com/base.jsonld",
+		map[string]interface{}{"email": "http://schema.org/email"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/base.jsonld", ctx.TermOrigin("name"))
+	assert.Equal(t, "http://example.com/imported.jsonld", ctx.TermOrigin("age"))
+	assert.Equal(t, "inline#1", ctx.TermOrigin("email"))
+	assert.Equal(t, "", ctx.TermOrigin("unknown"))
+
+	_, err = ctx.Parse(map[string]interface{}{"name": "http://xmlns.com/foaf/0.1/name"})
+	jsonLDError := new(JsonLdError)
+	require.ErrorAs(t, err, &jsonLDError)
+	assert.Equal(t, ProtectedTermRedefinition, jsonLDError.Code)
+	assert.Contains(t, jsonLDError.Details, "name was defined in http://example.com/base.jsonld")
+	assert.Contains(t, jsonLDError.Details, "redefinition of name in inline#0")
+
+	_, err = ctx.Parse(map[string]interface{}{"@id": "http://example.com/id"})
+	require.ErrorAs(t, err, &jsonLDError)
+	assert.Equal(t, KeywordRedefinition, jsonLDError.Code)
+	assert.Contains(t, jsonLDError.Details, "redefinition of @id in inline#0")
+}
+
+// parsedContextLoader returns processed contexts for the URLs found in contexts.
+type parsedContextLoader struct {
+	DocumentLoader
+	contexts map[string]*Context
+	calls    int
+}
+
+func (l *parsedContextLoader) LoadDocument(u string) (*RemoteDocument, error) {
+	if ctx, found := l.contexts[u]; found {
+		return &RemoteDocument{DocumentURL: u, Document: ctx}, nil
+	}
+	l.calls++
+	return l.DocumentLoader.LoadDocument(u)
+}
+
+func TestContext_ParseProcessedRemoteContext(t *testing.T) {
+	loader := &parsedContextLoader{
+		DocumentLoader: NewMapDocumentLoader(map[string]interface{}{
+			"http://example.com/schema.jsonld": map[string]interface{}{
+				"@context": map[string]interface{}{
+					"@version": 1.1,
+					"@vocab":   "http://schema.org/",
+					"knows":    map[string]interface{}{"@id": "http://schema.org/knows", "@type": "@id"},
+				},
+			},
+			"http://example.com/protected.jsonld": map[string]interface{}{
+				"@context": map[string]interface{}{
+					"@protected": true,
+					"knows":      "http://xmlns.com/foaf/0.1/knows",
+				},
+			},
+		}),
+		contexts: make(map[string]*Context),
+	}
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = loader
+
+	parsed, err := NewContext(nil, opts).Parse("http://example.com/schema.jsonld")
+	require.NoError(t, err)
+	assert.Equal(t, 1, loader.calls)
+	loader.contexts["http://example.com/schema.jsonld"] = parsed
+
+	doc := map[string]interface{}{
+		"@context": []interface{}{
+			map[string]interface{}{"name": "http://xmlns.com/foaf/0.1/name"},
+			"http://example.com/schema.jsonld",
+		},
+		"name":  "Alice",
+		"knows": "http://example.com/bob",
+		"age":   42.0,
+	}
+	expanded, err := NewJsonLdProcessor().Expand(doc, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loader.calls)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"http://xmlns.com/foaf/0.1/name": []interface{}{map[string]interface{}{"@value": "Alice"}},
+		"http://schema.org/knows":        []interface{}{map[string]interface{}{"@id": "http://example.com/bob"}},
+		"http://schema.org/age":          []interface{}{map[string]interface{}{"@value": 42.0}},
+	}}, expanded)
+
+	// protected terms can't be redefined by processed contexts either
+	_, err = NewContext(nil, opts).Parse([]interface{}{
+		"http://example.com/protected.jsonld",
+		"http://example.com/schema.jsonld",
+	})
+	jsonLDError := new(JsonLdError)
+	require.ErrorAs(t, err, &jsonLDError)
+	assert.Equal(t, ProtectedTermRedefinition, jsonLDError.Code)
+
+	// only contexts produced by a remote context can be returned by loaders
+	inline, err := NewContext(nil, opts).Parse(map[string]interface{}{"name": "http://schema.org/name"})
+	require.NoError(t, err)
+	loader.contexts["http://example.com/inline.jsonld"] = inline
+	_, err = NewContext(nil, opts).Parse("http://example.com/inline.jsonld")
+	require.ErrorAs(t, err, &jsonLDError)
+	assert.Equal(t, InvalidRemoteContext, jsonLDError.Code)
+}
+
+func TestContext_ParseProcessedRemoteContextSemantics(t *testing.T) {
+	loader := &parsedContextLoader{
+		DocumentLoader: NewMapDocumentLoader(map[string]interface{}{
+			"http://example.com/null.jsonld": map[string]interface{}{
+				"@context": []interface{}{nil, map[string]interface{}{"title": "http://purl.org/dc/terms/title"}},
+			},
+			"http://example.com/novocab.jsonld": map[string]interface{}{
+				"@context": map[string]interface{}{"@vocab": nil, "title": "dc:title"},
+			},
+			"http://example.com/scoped.jsonld": map[string]interface{}{
+				"@context": map[string]interface{}{
+					"@version":   1.1,
+					"@propagate": false,
+					"title":      "http://purl.org/dc/terms/title",
+				},
+			},
+		}),
+		contexts: make(map[string]*Context),
+	}
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = loader
+	for _, u := range []string{
+		"http://example.com/null.jsonld",
+		"http://example.com/novocab.jsonld",
+		"http://example.com/scoped.jsonld",
+	} {
+		parsed, err := NewContext(nil, opts).Parse(u)
+		require.NoError(t, err)
+		loader.contexts[u] = parsed
+	}
+	calls := loader.calls
+	proc := NewJsonLdProcessor()
+
+	// a null context in the cached remote context clears the including context
+	expanded, err := proc.Expand(map[string]interface{}{
+		"@context": []interface{}{
+			map[string]interface{}{"@vocab": "http://schema.org/"},
+			"http://example.com/null.jsonld",
+		},
+		"title": "T",
+		"name":  "N",
+	}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"http://purl.org/dc/terms/title": []interface{}{map[string]interface{}{"@value": "T"}},
+	}}, expanded)
+
+	// @vocab is reset and compact IRIs use prefixes of the including context
+	expanded, err = proc.Expand(map[string]interface{}{
+		"@context": []interface{}{
+			map[string]interface{}{"@vocab": "http://schema.org/", "dc": "http://purl.org/dc/terms/"},
+			"http://example.com/novocab.jsonld",
+		},
+		"title": "T",
+		"name":  "N",
+	}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"http://purl.org/dc/terms/title": []interface{}{map[string]interface{}{"@value": "T"}},
+	}}, expanded)
+
+	// @propagate of the cached remote context applies to property-scoped contexts
+	expanded, err = proc.Expand(map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@version": 1.1,
+			"@vocab":   "http://schema.org/",
+			"about":    map[string]interface{}{"@context": "http://example.com/scoped.jsonld"},
+		},
+		"about": map[string]interface{}{
+			"title":  "T",
+			"author": map[string]interface{}{"title": "Dr"},
+		},
+	}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"http://schema.org/about": []interface{}{map[string]interface{}{
+			"http://purl.org/dc/terms/title": []interface{}{map[string]interface{}{"@value": "T"}},
+			"http://schema.org/author": []interface{}{map[string]interface{}{
+				"http://schema.org/title": []interface{}{map[string]interface{}{"@value": "Dr"}},
+			}},
+		}},
+	}}, expanded)
+
+	// the cached contexts were used
+	assert.Equal(t, calls, loader.calls)
+}
+
+func TestContext_SerializeKeywordAliases(t *testing.T) {
+	ctx, err := NewContext(nil, nil).Parse(map[string]interface{}{
+		"@version": 1.1,
+		"t":        "@type",
+		"type": map[string]interface{}{
+			"@id":        "@type",
+			"@container": "@set",
+		},
+	})
+	require.NoError(t, err)
+
+	serialized, err := ctx.Serialize()
+	require.NoError(t, err)
+
+	serializedCtx := serialized["@context"].(map[string]interface{})
+	assert.Equal(t, "@type", serializedCtx["t"])
+	assert.Equal(t, "@type", serializedCtx["type"].(map[string]interface{})["@id"])
+
+	// the serialized context must define the same aliases
+	reparsed, err := NewContext(nil, nil).Parse(serializedCtx)
+	require.NoError(t, err)
+	for _, term := range []string{"t", "type"} {
+		assert.Equal(t, "@type", reparsed.GetTermDefinition(term)["@id"], term)
+	}
+}
+
+func TestContext_CompactKeywordAliases(t *testing.T) {
+	t.Run("aliases with and without containers", func(t *testing.T) {
+		ctx, err := NewContext(nil, nil).Parse(map[string]interface{}{
+			"@version": 1.1,
+			"ts": map[string]interface{}{
+				"@id":        "@type",
+				"@container": "@set",
+			},
+			"type": "@type",
+			"kind": map[string]interface{}{
+				"@id":        "@type",
+				"@container": "@set",
+			},
+			"id": map[string]interface{}{
+				"@id":        "@id",
+				"@protected": true,
+			},
+		})
+		require.NoError(t, err)
+
+		alias, err := ctx.CompactIri("@type", nil, true, false)
+		require.NoError(t, err)
+		assert.Equal(t, "type", alias)
+
+		alias, err = ctx.CompactIri("@id", nil, false, false)
+		require.NoError(t, err)
+		assert.Equal(t, "id", alias)
+	})
+
+	t.Run("aliases defined after the inverse context was built", func(t *testing.T) {
+		ctx, err := NewContext(nil, nil).Parse(map[string]interface{}{
+			"ex": "http://example.com/",
+		})
+		require.NoError(t, err)
+
+		alias, err := ctx.CompactIri("@type", nil, true, false)
+		require.NoError(t, err)
+		assert.Equal(t, "@type", alias)
+
+		ctx, err = NewContext(nil, nil).Parse([]interface{}{
+			ctx,
+			map[string]interface{}{"kind": "@type"},
+		})
+		require.NoError(t, err)
+
+		alias, err = ctx.CompactIri("@type", nil, true, false)
+		require.NoError(t, err)
+		assert.Equal(t, "kind", alias)
+	})
+}
+
+func TestContext_CompactIriTypedTerms(t *testing.T) {
+	ctx, err := NewContext(nil, nil).Parse(map[string]interface{}{
+		"website": map[string]interface{}{"@id": "http://schema.org/url", "@type": "@id"},
+		"ref":     map[string]interface{}{"@id": "http://schema.org/url", "@type": "@id"},
+		"link":    map[string]interface{}{"@id": "http://schema.org/url", "@type": "@id"},
+	})
+	require.NoError(t, err)
+
+	// the shortest and then lexicographically least term is preferred for typed terms too
+	term, err := ctx.CompactIri("http://schema.org/url", map[string]interface{}{"@id": "http://example.com/"},
+		true, false)
+	require.NoError(t, err)
+	assert.Equal(t, "ref", term)
+}
+
+func TestContext_TypeNoneCoercion(t *testing.T) {
+	ctx, err := NewContext(nil, nil).Parse(map[string]interface{}{
+		"@version":   1.1,
+		"@language":  "en",
+		"@direction": "ltr",
+		"notype":     map[string]interface{}{"@id": "http://example.com/notype", "@type": "@none"},
+		"plain":      "http://example.com/plain",
+	})
+	require.NoError(t, err)
+
+	// as with other terms without type coercion, the default language and direction apply
+	expanded, err := ctx.ExpandValue("notype", "string")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"@value": "string", "@language": "en", "@direction": "ltr"}, expanded)
+
+	expanded, err = ctx.ExpandValue("plain", "string")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"@value": "string", "@language": "en", "@direction": "ltr"}, expanded)
+
+	// compaction leaves values of such terms as they are, so they expand back into the same values
+	for _, value := range []map[string]interface{}{
+		{"@value": "string"},
+		{"@value": "string", "@language": "en", "@direction": "ltr"},
+		{"@value": "2018-02-17", "@type": "http://www.w3.org/2001/XMLSchema#date"},
+	} {
+		compacted, err := ctx.CompactValue("notype", value)
+		require.NoError(t, err)
+		assert.Equal(t, value, compacted)
+	}
+}
+
+func TestContext_Fingerprint(t *testing.T) {
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = NewMapDocumentLoader(map[string]interface{}{
+		"http://example.com/context.jsonld": map[string]interface{}{
+			"@context": map[string]interface{}{
+				"@vocab": "http://schema.org/",
+				"knows":  map[string]interface{}{"@type": "@id"},
+			},
+		},
+	})
+	parse := func(localContext interface{}) *Context {
+		ctx, err := NewContext(nil, opts).Parse(localContext)
+		require.NoError(t, err)
+		return ctx
+	}
+	fingerprintOf := func(ctx *Context) string {
+		fingerprint, err := ctx.Fingerprint()
+		require.NoError(t, err)
+		return fingerprint
+	}
+
+	remote := parse("http://example.com/context.jsonld")
+	inline := parse([]interface{}{
+		map[string]interface{}{"@vocab": "http://schema.org/"},
+		map[string]interface{}{"knows": map[string]interface{}{"@type": "@id"}},
+	})
+	fingerprint := fingerprintOf(remote)
+	assert.Len(t, fingerprint, 64)
+	assert.Equal(t, fingerprint, fingerprintOf(inline))
+	assert.Equal(t, fingerprint, fingerprintOf(CopyContext(remote)))
+
+	// the inverse context doesn't affect the fingerprint
+	remote.GetInverse()
+	assert.Equal(t, fingerprint, fingerprintOf(remote))
+
+	for name, other := range map[string]*Context{
+		"term":      parse(map[string]interface{}{"@vocab": "http://schema.org/", "knows": "http://schema.org/knows"}),
+		"vocab":     parse(map[string]interface{}{"@vocab": "http://example.com/", "knows": map[string]interface{}{"@type": "@id"}}),
+		"protected": parse(map[string]interface{}{"@vocab": "http://schema.org/", "@protected": true, "knows": map[string]interface{}{"@type": "@id"}}),
+		"base":      NewContext(nil, NewJsonLdOptions("http://example.com/")),
+	} {
+		assert.NotEqual(t, fingerprint, fingerprintOf(other), name)
+	}
+
+	// values which can't be encoded are reported
+	_, err := NewContext(map[string]interface{}{"@version": math.NaN()}, nil).Fingerprint()
+	assert.Error(t, err)
+}
+
+func TestContext_ParseRawJSON(t *testing.T) {
+	raw := `{"@version": 1.1, "@vocab": "http://schema.org/", "id": "@id"}`
+
+	for name, localContext := range map[string]interface{}{
+		"bytes":      []byte(raw),
+		"RawMessage": json.RawMessage(raw),
+		"Reader":     strings.NewReader(raw),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, err := NewContext(nil, nil).Parse(localContext)
+			require.NoError(t, err)
+			assert.Equal(t, "http://schema.org/", ctx.values["@vocab"])
+			assert.Equal(t, "@id", ctx.GetTermDefinition("id")["@id"])
+		})
+	}
+
+	_, err := NewContext(nil, nil).Parse([]byte(`{"@vocab": `))
+	require.Error(t, err)
+	assert.Equal(t, InvalidLocalContext, err.(*JsonLdError).Code)
+
+	// @version must still be 1.1
+	_, err = NewContext(nil, nil).Parse([]byte(`{"@version": 1.0}`))
+	require.Error(t, err)
+	assert.Equal(t, InvalidVersionValue, err.(*JsonLdError).Code)
+}
+
+func TestContext_SerializeDeterministic(t *testing.T) {
+	localCtx := map[string]interface{}{
+		"@version": 1.1,
+		"@vocab":   "http://example.com/",
+		"ex":       "http://example.com/",
+		"byID": map[string]interface{}{
+			"@id":        "ex:byID",
+			"@container": []interface{}{"@set", "@id", "@graph"},
+		},
+		"tags": map[string]interface{}{
+			"@id":        "ex:tags",
+			"@container": []interface{}{"@set"},
+		},
+		"name": "ex:name",
+	}
+
+	ctx, err := NewContext(nil, nil).Parse(localCtx)
+	require.NoError(t, err)
+	serialized, err := ctx.Serialize()
+	require.NoError(t, err)
+
+	serializedCtx := serialized["@context"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"@graph", "@id", "@set"}, serializedCtx["byID"].(map[string]interface{})["@container"])
+	assert.Equal(t, "@set", serializedCtx["tags"].(map[string]interface{})["@container"])
+
+	// containers listed in a different order produce the same result
+	localCtx["byID"].(map[string]interface{})["@container"] = []interface{}{"@graph", "@set", "@id"}
+	for i := 0; i < 10; i++ {
+		ctx, err := NewContext(nil, nil).Parse(localCtx)
+		require.NoError(t, err)
+		other, err := ctx.Serialize()
+		require.NoError(t, err)
+		assert.Equal(t, serialized, other)
+	}
+}
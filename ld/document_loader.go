@@ -15,13 +15,20 @@
 package ld
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pquerna/cachecontrol"
@@ -38,6 +45,11 @@ const (
 )
 
 // RemoteDocument is a document retrieved from a remote source.
+//
+// When a remote context is loaded, Document may be a *Context previously returned by
+// Context.Parse for the same URL. The definition of the remote context it was produced by
+// is then processed against the active context as usual, without loading and decoding
+// it again. Contexts referenced by @import must be JSON documents.
 type RemoteDocument struct {
 	DocumentURL string
 	Document    interface{}
@@ -52,16 +64,69 @@ type DocumentLoader interface {
 // DefaultDocumentLoader is a standard implementation of DocumentLoader
 // which can retrieve documents via HTTP.
 type DefaultDocumentLoader struct {
-	httpClient *http.Client
+	httpClient    *http.Client
+	headers       http.Header
+	requestHeader func(req *http.Request)
+	rateLimiter   *HostRateLimiter
+	onDuplicate   DuplicateKeyHandler
+	useNumber     bool
+}
+
+// DefaultDocumentLoaderOption configures optional behaviour of DefaultDocumentLoader.
+type DefaultDocumentLoaderOption func(dl *DefaultDocumentLoader)
+
+// WithHeaders sets HTTP headers to be sent with every request, for example Accept-Language
+// for registries which serve localized contexts. A custom Accept header replaces
+// the default one, which prefers JSON-LD.
+func WithHeaders(headers http.Header) DefaultDocumentLoaderOption {
+	return func(dl *DefaultDocumentLoader) {
+		dl.headers = headers.Clone()
+	}
+}
+
+// WithRequestHeaders sets a callback which may modify the headers of every request
+// before it is sent, for example to pick tenant-specific headers based on req.URL.
+// The callback is called after the headers set by WithHeaders have been applied.
+func WithRequestHeaders(fn func(req *http.Request)) DefaultDocumentLoaderOption {
+	return func(dl *DefaultDocumentLoader) {
+		dl.requestHeader = fn
+	}
+}
+
+// WithRateLimiter makes the loader limit its requests to each host with the given limiter.
+func WithRateLimiter(limiter *HostRateLimiter) DefaultDocumentLoaderOption {
+	return func(dl *DefaultDocumentLoader) {
+		dl.rateLimiter = limiter
+	}
+}
+
+// WithDuplicateKeyHandler makes the loader report duplicate keys in loaded documents
+// to the given handler (see DecodeDocument). Use RejectDuplicateKeys to fail loading
+// of such documents.
+func WithDuplicateKeyHandler(onDuplicate DuplicateKeyHandler) DefaultDocumentLoaderOption {
+	return func(dl *DefaultDocumentLoader) {
+		dl.onDuplicate = onDuplicate
+	}
+}
+
+// WithJSONNumber makes the loader decode numbers in loaded documents as json.Number,
+// so that they keep their precision (see JsonLdOptions.UseJSONNumber).
+func WithJSONNumber() DefaultDocumentLoaderOption {
+	return func(dl *DefaultDocumentLoader) {
+		dl.useNumber = true
+	}
 }
 
 // NewDefaultDocumentLoader creates a new instance of DefaultDocumentLoader
-func NewDefaultDocumentLoader(httpClient *http.Client) *DefaultDocumentLoader {
+func NewDefaultDocumentLoader(httpClient *http.Client, options ...DefaultDocumentLoaderOption) *DefaultDocumentLoader {
 	rval := &DefaultDocumentLoader{httpClient: httpClient}
 
 	if rval.httpClient == nil {
 		rval.httpClient = http.DefaultClient
 	}
+	for _, opt := range options {
+		opt(rval)
+	}
 	return rval
 }
 
@@ -72,7 +137,7 @@ func DocumentFromReader(r io.Reader) (interface{}, error) {
 	dec := json.NewDecoder(r)
 
 	// If dec.UseNumber() were invoked here, all numbers would be decoded as json.Number.
-	// json-gold supports both the default and json.Number options.
+	// json-gold supports both the default and json.Number options (see WithJSONNumber).
 
 	if err := dec.Decode(&document); err != nil {
 		return nil, NewJsonLdError(LoadingDocumentFailed, err)
@@ -80,9 +145,58 @@ func DocumentFromReader(r io.Reader) (interface{}, error) {
 	return document, nil
 }
 
+// maxBodySnippetLength is the maximum number of bytes of an HTTP response body
+// to include into error details if the body isn't valid JSON.
+const maxBodySnippetLength = 256
+
+// documentFromResponse returns a document containing the contents of the given HTTP response.
+// If the response body isn't valid JSON, the error will include the content type
+// and the beginning of the body to help diagnose misconfigured servers.
+// Numbers are decoded as json.Number if useNumber is true, and duplicate keys
+// in the document are reported to onDuplicate, if set.
+func documentFromResponse(res *http.Response, useNumber bool, onDuplicate DuplicateKeyHandler) (interface{}, error) {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return documentFromBody(body, res.Request.URL.String(), res.Header.Get("Content-Type"), useNumber, onDuplicate)
+}
+
+// documentFromBody decodes the body of a response from the given URL. See documentFromResponse.
+func documentFromBody(body []byte, u string, contentType string, useNumber bool,
+	onDuplicate DuplicateKeyHandler) (interface{}, error) {
+	document, err := decodeJSON(bytes.NewReader(body), useNumber, onDuplicate)
+	if err != nil {
+		if ldErr, isLdError := err.(*JsonLdError); isLdError && ldErr.Code == DuplicateKey {
+			return nil, err
+		}
+		cause := errors.Unwrap(err)
+		if cause == nil {
+			cause = err
+		}
+		snippet := string(body)
+		if len(body) > maxBodySnippetLength {
+			snippet = string(body[:maxBodySnippetLength]) + "..."
+		}
+		return nil, fmt.Errorf("invalid JSON in response from %s (Content-Type: %q): %w; body: %q",
+			u, contentType, cause, snippet)
+	}
+	return document, nil
+}
+
 // LoadDocument returns a RemoteDocument containing the contents of the JSON resource
 // from the given URL.
 func (dl *DefaultDocumentLoader) LoadDocument(u string) (*RemoteDocument, error) {
+	return dl.loadDocument(u, nil)
+}
+
+func (dl *DefaultDocumentLoader) loadDocument(u string, chain alternateChain) (*RemoteDocument, error) {
+	chain, err := chain.follow(u)
+	if err != nil {
+		return nil, err
+	}
+
 	parsedURL, err := url.Parse(u)
 	if err != nil {
 		return nil, NewJsonLdError(LoadingDocumentFailed, fmt.Sprintf("error parsing URL: %s", u))
@@ -101,7 +215,7 @@ func (dl *DefaultDocumentLoader) LoadDocument(u string) (*RemoteDocument, error)
 		}
 		defer file.Close()
 
-		remoteDoc.Document, err = DocumentFromReader(file)
+		remoteDoc.Document, err = decodeJSON(file, dl.useNumber, dl.onDuplicate)
 		if err != nil {
 			return nil, NewJsonLdError(LoadingDocumentFailed, err)
 		}
@@ -114,6 +228,18 @@ func (dl *DefaultDocumentLoader) LoadDocument(u string) (*RemoteDocument, error)
 		// We prefer application/ld+json, but fallback to application/json
 		// or whatever is available
 		req.Header.Add("Accept", acceptHeader)
+		for name, values := range dl.headers {
+			req.Header.Del(name)
+			for _, v := range values {
+				req.Header.Add(name, v)
+			}
+		}
+		if dl.requestHeader != nil {
+			dl.requestHeader(req)
+		}
+
+		release := dl.rateLimiter.acquire(parsedURL.Host)
+		defer release()
 
 		res, err := dl.httpClient.Do(req)
 		if err != nil {
@@ -123,54 +249,225 @@ func (dl *DefaultDocumentLoader) LoadDocument(u string) (*RemoteDocument, error)
 
 		if res.StatusCode != http.StatusOK {
 			return nil, NewJsonLdError(LoadingDocumentFailed,
-				fmt.Sprintf("Bad response status code: %d", res.StatusCode))
+				&HTTPStatusError{URL: u, StatusCode: res.StatusCode})
 		}
 
 		remoteDoc.DocumentURL = res.Request.URL.String()
 
-		contentType := res.Header.Get("Content-Type")
-		linkHeader := res.Header.Get("Link")
+		var alternateURL string
+		remoteDoc.ContextURL, alternateURL, err = processLinkHeader(u, res.Header.Get("Content-Type"),
+			res.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+		if alternateURL != "" {
+			release()
+			return dl.loadDocument(alternateURL, chain)
+		}
+
+		remoteDoc.Document, err = documentFromResponse(res, dl.useNumber, dl.onDuplicate)
+		if err != nil {
+			return nil, NewJsonLdError(LoadingDocumentFailed, err)
+		}
+	}
+	return remoteDoc, nil
+}
 
-		if len(linkHeader) > 0 {
-			parsedLinkHeader := ParseLinkHeader(linkHeader)
-			contextLink := parsedLinkHeader[linkHeaderRel]
-			if contextLink != nil && contentType != ApplicationJSONLDType &&
-				(contentType == "application/json" || rApplicationJSON.MatchString(contentType)) {
+// HTTPStatusError is the cause of a LoadingDocumentFailed error returned by document loaders
+// when the server responds with an unexpected HTTP status code.
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+}
 
-				if len(contextLink) > 1 {
-					return nil, NewJsonLdError(MultipleContextLinkHeaders, nil)
-				} else if len(contextLink) == 1 {
-					remoteDoc.ContextURL = contextLink[0]["target"]
-				}
-			}
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("Bad response status code: %d", e.StatusCode)
+}
 
-			// If content-type is not application/ld+json, nor any other +json
-			// and a link with rel=alternate and type='application/ld+json' is found,
-			// use that instead
-			alternateLink := parsedLinkHeader["alternate"]
-			if len(alternateLink) > 0 &&
-				alternateLink[0]["type"] == ApplicationJSONLDType &&
-				!rApplicationJSON.MatchString(contentType) {
+// maxAlternateLinks is the maximum number of URLs requested while loading
+// a single document, including the URLs of the alternate links followed.
+const maxAlternateLinks = 10
 
-				finalURL := Resolve(u, alternateLink[0]["target"])
-				return dl.LoadDocument(finalURL)
-			}
+// alternateChain holds the URLs visited while following alternate links
+// (see processLinkHeader) during a single document load.
+type alternateChain []string
+
+// follow returns the chain extended with u. It fails if u has already been visited
+// or the chain is too long, to prevent servers from sending the loader into a loop.
+func (c alternateChain) follow(u string) (alternateChain, error) {
+	next := append(c[:len(c):len(c)], u)
+	for _, visited := range c {
+		if visited == u {
+			return nil, NewJsonLdError(LoadingDocumentFailed,
+				fmt.Sprintf("alternate link cycle detected: %s", strings.Join(next, " -> ")))
 		}
+	}
+	if len(c) >= maxAlternateLinks {
+		return nil, NewJsonLdError(LoadingDocumentFailed,
+			fmt.Sprintf("too many alternate links: %s", strings.Join(next, " -> ")))
+	}
+	return next, nil
+}
 
-		remoteDoc.Document, err = DocumentFromReader(res.Body)
-		if err != nil {
-			return nil, NewJsonLdError(LoadingDocumentFailed, err)
+// processLinkHeader inspects the Link header of a response with the given content type,
+// retrieved from URL u. It returns the URL of the context linked to a JSON document, if any,
+// or the URL of an alternate JSON-LD document which should be loaded instead.
+func processLinkHeader(u, contentType, linkHeader string) (contextURL string, alternateURL string, err error) {
+	if len(linkHeader) == 0 {
+		return "", "", nil
+	}
+
+	mt := parseMediaType(contentType)
+	links := ParseLinkHeaderTyped(linkHeader)
+	contextLink := linksWithRel(links, linkHeaderRel)
+	if contextLink != nil && !mt.isJSONLD() && mt.isJSON() {
+
+		if len(contextLink) > 1 {
+			return "", "", NewJsonLdError(MultipleContextLinkHeaders, nil)
+		} else if len(contextLink) == 1 {
+			contextURL = contextLink[0].Target
 		}
 	}
-	return remoteDoc, nil
+
+	// If content-type is not application/ld+json, nor any other +json
+	// and a link with rel=alternate and type='application/ld+json' is found,
+	// use that instead
+	alternateLink := linksWithRel(links, "alternate")
+	if len(alternateLink) > 0 &&
+		parseMediaType(alternateLink[0].Type).isJSONLD() &&
+		!mt.isJSON() {
+
+		return "", Resolve(u, alternateLink[0].Target), nil
+	}
+
+	return contextURL, "", nil
+}
+
+// mediaType is a media type with its structured syntax suffix (see RFC 6838, section 4.2.8),
+// for example "application/activity+json; charset=utf-8" has the type "application",
+// the subtype "activity+json" and the suffix "json".
+type mediaType struct {
+	typ     string
+	subtype string
+	suffix  string
+}
+
+// parseMediaType parses the value of a Content-Type header or a type link parameter.
+// Type names are case-insensitive and returned in lower case. Parameters, such as charset,
+// are ignored.
+func parseMediaType(s string) mediaType {
+	var mt mediaType
+	fullType, _, err := mime.ParseMediaType(s)
+	if err != nil {
+		// keep the type even if the parameters can't be parsed
+		fullType = strings.ToLower(strings.TrimSpace(strings.SplitN(s, ";", 2)[0]))
+	}
+	if slash := strings.IndexByte(fullType, '/'); slash > 0 {
+		mt.typ = fullType[:slash]
+		mt.subtype = fullType[slash+1:]
+	} else {
+		mt.typ = fullType
+	}
+	if plus := strings.LastIndexByte(mt.subtype, '+'); plus >= 0 {
+		mt.suffix = mt.subtype[plus+1:]
+	}
+	return mt
+}
+
+// isJSONLD reports whether the media type is application/ld+json.
+func (mt mediaType) isJSONLD() bool {
+	return mt.typ == "application" && mt.subtype == "ld+json"
+}
+
+// isJSON reports whether the media type is application/json or any type
+// with the +json structured syntax suffix, including application/ld+json.
+func (mt mediaType) isJSON() bool {
+	return (mt.typ == "application" && mt.subtype == "json") || mt.suffix == "json"
 }
 
 var rSplitOnComma = regexp.MustCompile("(?:<[^>]*?>|\"[^\"]*?\"|[^,])+")
 var rLinkHeader = regexp.MustCompile(`\s*<([^>]*?)>\s*(?:;\s*(.*))?`)
-var rApplicationJSON = regexp.MustCompile(`^application/(\w*\+)?json$`)
 var rParams = regexp.MustCompile("(.*?)=(?:(?:\"([^\"]*?)\")|([^\"]*?))\\s*(?:(?:;\\s*)|$)")
 
+// LinkHeader is a single link from an HTTP Link header (see RFC 8288).
+type LinkHeader struct {
+	// Target is the URI reference of the link, as it appears in the header.
+	Target string
+	// Rel is the relation type of the link, for example "alternate".
+	Rel string
+	// Type is the media type hint of the link, if any.
+	Type string
+	// Profile is the profile parameter of the link, if any.
+	Profile string
+	// Params holds all parameters of the link, including rel, type and profile.
+	Params map[string]string
+}
+
+// HasRel reports whether the link has the given relation type. Rel may contain
+// several relation types separated by spaces, which are compared case-insensitively.
+func (l LinkHeader) HasRel(rel string) bool {
+	for _, r := range strings.Fields(l.Rel) {
+		if strings.EqualFold(r, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLinkHeaderTyped parses a link header into a list of links, in the order
+// they appear in the header. Entries which aren't valid links are skipped.
+//
+//	Link: <http://json-ld.org/contexts/person.jsonld>; \
+//	  rel="http://www.w3.org/ns/json-ld#context"; type="application/ld+json"
+//
+//	Parses as: []LinkHeader{{
+//	  Target: "http://json-ld.org/contexts/person.jsonld",
+//	  Rel:    "http://www.w3.org/ns/json-ld#context",
+//	  Type:   "application/ld+json",
+//	  Params: map[string]string{"rel": ..., "type": ...},
+//	}}
+func ParseLinkHeaderTyped(header string) []LinkHeader {
+	var links []LinkHeader
+
+	// split on unbracketed/unquoted commas
+	for _, entry := range rSplitOnComma.FindAllString(header, -1) {
+		match := rLinkHeader.FindStringSubmatch(entry)
+		if match == nil {
+			continue
+		}
+
+		params := make(map[string]string)
+		for _, paramMatch := range rParams.FindAllStringSubmatch(match[2], -1) {
+			if paramMatch[2] == "" {
+				params[paramMatch[1]] = paramMatch[3]
+			} else {
+				params[paramMatch[1]] = paramMatch[2]
+			}
+		}
+		links = append(links, LinkHeader{
+			Target:  match[1],
+			Rel:     params["rel"],
+			Type:    params["type"],
+			Profile: params["profile"],
+			Params:  params,
+		})
+	}
+	return links
+}
+
+// linksWithRel returns the links which have the given relation type.
+func linksWithRel(links []LinkHeader, rel string) []LinkHeader {
+	var rval []LinkHeader
+	for _, link := range links {
+		if link.HasRel(rel) {
+			rval = append(rval, link)
+		}
+	}
+	return rval
+}
+
 // ParseLinkHeader parses a link header. The results will be keyed by the value of "rel".
+// See ParseLinkHeaderTyped for a typed alternative.
 //
 //	Link: <http://json-ld.org/contexts/person.jsonld>; \
 //	  rel="http://www.w3.org/ns/json-ld#context"; type="application/ld+json"
@@ -188,37 +485,14 @@ func ParseLinkHeader(header string) map[string][]map[string]string {
 
 	rval := make(map[string][]map[string]string)
 
-	// split on unbracketed/unquoted commas
-	entries := rSplitOnComma.FindAllString(header, -1)
-	if len(entries) == 0 {
-		return rval
-	}
-
-	for _, entry := range entries {
-		if !rLinkHeader.MatchString(entry) {
-			continue
-		}
-		match := rLinkHeader.FindStringSubmatch(entry)
-
+	for _, link := range ParseLinkHeaderTyped(header) {
 		result := map[string]string{
-			"target": match[1],
+			"target": link.Target,
 		}
-		params := match[2]
-		matches := rParams.FindAllStringSubmatch(params, -1)
-		for _, match := range matches {
-			if match[2] == "" {
-				result[match[1]] = match[3]
-			} else {
-				result[match[1]] = match[2]
-			}
-		}
-		rel := result["rel"]
-		relVal, hasRel := rval[rel]
-		if hasRel {
-			rval[rel] = append(relVal, result)
-		} else {
-			rval[rel] = []map[string]string{result}
+		for k, v := range link.Params {
+			result[k] = v
 		}
+		rval[link.Rel] = append(rval[link.Rel], result)
 	}
 	return rval
 }
@@ -227,8 +501,10 @@ func ParseLinkHeader(header string) map[string][]map[string]string {
 // which allows caching documents as soon as they get retrieved
 // from the underlying loader. You may also preload it with documents -
 // this is useful for testing.
+// It's safe for concurrent use if the underlying loader is.
 type CachingDocumentLoader struct {
 	nextLoader DocumentLoader
+	mu         sync.RWMutex
 	cache      map[string]*RemoteDocument
 }
 
@@ -245,21 +521,37 @@ func NewCachingDocumentLoader(nextLoader DocumentLoader) *CachingDocumentLoader
 // LoadDocument returns a RemoteDocument containing the contents of the JSON resource
 // from the given URL.
 func (cdl *CachingDocumentLoader) LoadDocument(u string) (*RemoteDocument, error) {
-	if doc, cached := cdl.cache[u]; cached {
-		return doc, nil
-	} else {
-		doc, err := cdl.nextLoader.LoadDocument(u)
-		if err != nil {
-			return nil, err
-		}
-		cdl.cache[u] = doc
+	cdl.mu.RLock()
+	doc, cached := cdl.cache[u]
+	cdl.mu.RUnlock()
+	if cached {
 		return doc, nil
 	}
+
+	doc, err := cdl.nextLoader.LoadDocument(u)
+	if err != nil {
+		return nil, err
+	}
+	cdl.mu.Lock()
+	cdl.cache[u] = doc
+	cdl.mu.Unlock()
+	return doc, nil
 }
 
 // AddDocument populates the cache with the given document (doc) for the provided URL (u).
 func (cdl *CachingDocumentLoader) AddDocument(u string, doc interface{}) {
+	cdl.mu.Lock()
 	cdl.cache[u] = &RemoteDocument{DocumentURL: u, Document: doc, ContextURL: ""}
+	cdl.mu.Unlock()
+}
+
+// Prefetch loads the documents with the given URLs into the cache, running up to concurrency
+// requests in parallel (at least one). It's meant for services which know the contexts
+// they use up front and want to avoid the latency of loading them on first use.
+// All URLs are processed even if some of them fail; the error for the first URL
+// in the list which couldn't be loaded is returned.
+func (cdl *CachingDocumentLoader) Prefetch(urls []string, concurrency int) error {
+	return prefetchDocuments(cdl, urls, concurrency)
 }
 
 // PreloadWithMapping populates the cache with a number of documents which may be loaded
@@ -276,22 +568,165 @@ func (cdl *CachingDocumentLoader) PreloadWithMapping(urlMap map[string]string) e
 		if err != nil {
 			return err
 		}
+		cdl.mu.Lock()
 		cdl.cache[srcURL] = doc
+		cdl.mu.Unlock()
 	}
 	return nil
 }
 
+// prefetchDocuments loads the given URLs with the loader, running up to concurrency
+// loads in parallel. It returns the error for the first URL which couldn't be loaded, if any.
+func prefetchDocuments(loader DocumentLoader, urls []string, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	seen := make(map[string]bool, len(urls))
+	errs := make([]error, len(urls))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, u string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			_, errs[i] = loader.LoadDocument(u)
+		}(i, u)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MapDocumentLoader serves documents from an in-memory map of URLs to documents.
+// It is primarily intended for testing.
+type MapDocumentLoader struct {
+	documents map[string]interface{}
+}
+
+// NewMapDocumentLoader creates a new instance of MapDocumentLoader.
+//
+// Document values may be either parsed JSON documents (maps, arrays, etc.),
+// raw JSON given as []byte or string, or *RemoteDocument instances.
+func NewMapDocumentLoader(documents map[string]interface{}) *MapDocumentLoader {
+	if documents == nil {
+		documents = make(map[string]interface{})
+	}
+	return &MapDocumentLoader{
+		documents: documents,
+	}
+}
+
+// LoadDocument returns a RemoteDocument containing the document registered
+// for the given URL.
+func (mdl *MapDocumentLoader) LoadDocument(u string) (*RemoteDocument, error) {
+	doc, found := mdl.documents[u]
+	if !found {
+		return nil, NewJsonLdError(LoadingDocumentFailed, fmt.Sprintf("document not found: %s", u))
+	}
+
+	switch v := doc.(type) {
+	case *RemoteDocument:
+		return v, nil
+	case []byte:
+		parsedDoc, err := DocumentFromReader(bytes.NewReader(v))
+		if err != nil {
+			return nil, err
+		}
+		return &RemoteDocument{DocumentURL: u, Document: parsedDoc}, nil
+	case string:
+		parsedDoc, err := DocumentFromReader(strings.NewReader(v))
+		if err != nil {
+			return nil, err
+		}
+		return &RemoteDocument{DocumentURL: u, Document: parsedDoc}, nil
+	default:
+		return &RemoteDocument{DocumentURL: u, Document: doc}, nil
+	}
+}
+
+// AddDocument registers the given document (doc) for the provided URL (u).
+func (mdl *MapDocumentLoader) AddDocument(u string, doc interface{}) {
+	mdl.documents[u] = doc
+}
+
+// FSDocumentLoader serves documents from a file system (see io/fs).
+// Documents are resolved by stripping the base URL from the requested URL
+// and using the remainder as a path within the file system.
+type FSDocumentLoader struct {
+	fsys    fs.FS
+	baseURL string
+}
+
+// NewFSDocumentLoader creates a new instance of FSDocumentLoader.
+//
+// Example:
+//
+//	l := ld.NewFSDocumentLoader(os.DirFS("testdata"), "https://example.com/contexts/")
+//
+// will load https://example.com/contexts/v1.jsonld from testdata/v1.jsonld.
+func NewFSDocumentLoader(fsys fs.FS, baseURL string) *FSDocumentLoader {
+	return &FSDocumentLoader{
+		fsys:    fsys,
+		baseURL: baseURL,
+	}
+}
+
+// LoadDocument returns a RemoteDocument containing the contents of the file
+// which corresponds to the given URL.
+func (fdl *FSDocumentLoader) LoadDocument(u string) (*RemoteDocument, error) {
+	if !strings.HasPrefix(u, fdl.baseURL) {
+		return nil, NewJsonLdError(LoadingDocumentFailed,
+			fmt.Sprintf("URL %s is outside of base URL %s", u, fdl.baseURL))
+	}
+
+	name := path.Clean(strings.TrimPrefix(strings.TrimPrefix(u, fdl.baseURL), "/"))
+	if !fs.ValidPath(name) {
+		return nil, NewJsonLdError(LoadingDocumentFailed, fmt.Sprintf("invalid document path: %s", name))
+	}
+
+	file, err := fdl.fsys.Open(name)
+	if err != nil {
+		return nil, NewJsonLdError(LoadingDocumentFailed, err)
+	}
+	defer file.Close()
+
+	doc, err := DocumentFromReader(file)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteDocument{DocumentURL: u, Document: doc}, nil
+}
+
 type cachedRemoteDocument struct {
 	remoteDocument *RemoteDocument
 	expireTime     time.Time
 	neverExpires   bool
+	etag           string
+	lastModified   string
 }
 
 // RFC7324CachingDocumentLoader respects RFC7324 caching headers in order to
-// cache effectively
+// cache effectively. It's safe for concurrent use.
 type RFC7324CachingDocumentLoader struct {
-	httpClient *http.Client
-	cache      map[string]*cachedRemoteDocument
+	httpClient   *http.Client
+	mu           sync.RWMutex
+	cache        map[string]*cachedRemoteDocument
+	maxStaleness time.Duration
+	rateLimiter  *HostRateLimiter
+	onDuplicate  DuplicateKeyHandler
 }
 
 // NewRFC7324CachingDocumentLoader creates a new RFC7324CachingDocumentLoader
@@ -308,15 +743,76 @@ func NewRFC7324CachingDocumentLoader(httpClient *http.Client) *RFC7324CachingDoc
 	return rval
 }
 
+// SetStaleIfError enables stale-if-error behaviour (see RFC5861). If a document
+// can't be fetched due to a network error or a 5xx response, an expired cache entry
+// is returned instead, as long as it expired no longer than maxStaleness ago.
+// Zero maxStaleness (the default) disables this behaviour.
+func (rcdl *RFC7324CachingDocumentLoader) SetStaleIfError(maxStaleness time.Duration) {
+	rcdl.maxStaleness = maxStaleness
+}
+
+// SetRateLimiter makes the loader limit its requests to each host with the given limiter.
+// Documents served from the cache aren't subject to the limits.
+func (rcdl *RFC7324CachingDocumentLoader) SetRateLimiter(limiter *HostRateLimiter) {
+	rcdl.rateLimiter = limiter
+}
+
+// SetDuplicateKeyHandler makes the loader report duplicate keys in loaded documents
+// to the given handler (see DecodeDocument).
+func (rcdl *RFC7324CachingDocumentLoader) SetDuplicateKeyHandler(onDuplicate DuplicateKeyHandler) {
+	rcdl.onDuplicate = onDuplicate
+}
+
+// staleDocument returns the document from the given expired cache entry
+// if stale-if-error behaviour is enabled and the entry is not too stale.
+func (rcdl *RFC7324CachingDocumentLoader) staleDocument(entry *cachedRemoteDocument, now time.Time) *RemoteDocument {
+	if entry == nil || rcdl.maxStaleness <= 0 {
+		return nil
+	}
+	rcdl.mu.RLock()
+	defer rcdl.mu.RUnlock()
+	if now.Sub(entry.expireTime) > rcdl.maxStaleness {
+		return nil
+	}
+	return entry.remoteDocument
+}
+
+// Prefetch loads the documents with the given URLs into the cache, running up to concurrency
+// requests in parallel (at least one). It's meant for services which know the contexts
+// they use up front and want to avoid the latency of loading them on first use.
+// All URLs are processed even if some of them fail; the error for the first URL
+// in the list which couldn't be loaded is returned.
+func (rcdl *RFC7324CachingDocumentLoader) Prefetch(urls []string, concurrency int) error {
+	return prefetchDocuments(rcdl, urls, concurrency)
+}
+
 // LoadDocument returns a RemoteDocument containing the contents of the JSON resource
 // from the given URL.
 func (rcdl *RFC7324CachingDocumentLoader) LoadDocument(u string) (*RemoteDocument, error) {
-	entry, ok := rcdl.cache[u]
+	return rcdl.loadDocument(u, nil)
+}
+
+func (rcdl *RFC7324CachingDocumentLoader) loadDocument(u string, chain alternateChain) (*RemoteDocument, error) {
+	chain, err := chain.follow(u)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
+	rcdl.mu.RLock()
+	entry, ok := rcdl.cache[u]
+	var fresh bool
+	var cachedETag, cachedLastModified string
+	if ok {
+		fresh = entry.neverExpires || entry.expireTime.After(now)
+		cachedETag = entry.etag
+		cachedLastModified = entry.lastModified
+	}
+	rcdl.mu.RUnlock()
 
 	// First we check if we hit in the cache, and the cache entry is valid
 	// We need to check if expireTime >= now, so we negate the comparison below
-	if ok && (entry.neverExpires || entry.expireTime.After(now)) {
+	if ok && fresh {
 		return entry.remoteDocument, nil
 	}
 
@@ -332,6 +828,7 @@ func (rcdl *RFC7324CachingDocumentLoader) LoadDocument(u string) (*RemoteDocumen
 	neverExpires := false
 	shouldCache := false
 	expireTime := time.Now()
+	var etag, lastModified string
 
 	protocol := parsedURL.Scheme
 	if protocol != "http" && protocol != "https" {
@@ -343,7 +840,7 @@ func (rcdl *RFC7324CachingDocumentLoader) LoadDocument(u string) (*RemoteDocumen
 			return nil, NewJsonLdError(LoadingDocumentFailed, err)
 		}
 		defer file.Close()
-		remoteDoc.Document, err = DocumentFromReader(file)
+		remoteDoc.Document, err = DecodeDocument(file, rcdl.onDuplicate)
 		if err != nil {
 			return nil, NewJsonLdError(LoadingDocumentFailed, err)
 		}
@@ -359,46 +856,74 @@ func (rcdl *RFC7324CachingDocumentLoader) LoadDocument(u string) (*RemoteDocumen
 		// or whatever is available
 		req.Header.Add("Accept", acceptHeader)
 
+		// If the expired entry has validators, ask the server to revalidate it
+		// instead of downloading the document again
+		if ok {
+			if cachedETag != "" {
+				req.Header.Set("If-None-Match", cachedETag)
+			}
+			if cachedLastModified != "" {
+				req.Header.Set("If-Modified-Since", cachedLastModified)
+			}
+		}
+
+		release := rcdl.rateLimiter.acquire(parsedURL.Host)
+		defer release()
+
 		res, err := rcdl.httpClient.Do(req)
 		if err != nil {
+			if staleDoc := rcdl.staleDocument(entry, now); staleDoc != nil {
+				return staleDoc, nil
+			}
 			return nil, NewJsonLdError(LoadingDocumentFailed, err)
 		}
 		defer res.Body.Close()
 
+		if res.StatusCode == http.StatusNotModified && ok {
+			// The cached document is still valid. Refresh its expiration time using
+			// the headers of the 304 response, treating it as a regular 200 response.
+			validatedRes := *res
+			validatedRes.StatusCode = http.StatusOK
+			reasons, resExpireTime, err := cachecontrol.CachableResponse(req, &validatedRes, cachecontrol.Options{})
+			rcdl.mu.Lock()
+			defer rcdl.mu.Unlock()
+			if err == nil && len(reasons) == 0 {
+				entry.expireTime = resExpireTime
+			} else {
+				entry.expireTime = now
+			}
+			if newETag := res.Header.Get("ETag"); newETag != "" {
+				entry.etag = newETag
+			}
+			if newLastModified := res.Header.Get("Last-Modified"); newLastModified != "" {
+				entry.lastModified = newLastModified
+			}
+			return entry.remoteDocument, nil
+		}
+
 		if res.StatusCode != http.StatusOK {
+			if res.StatusCode >= http.StatusInternalServerError {
+				if staleDoc := rcdl.staleDocument(entry, now); staleDoc != nil {
+					return staleDoc, nil
+				}
+			}
 			return nil, NewJsonLdError(LoadingDocumentFailed,
-				fmt.Sprintf("Bad response status code: %d", res.StatusCode))
+				&HTTPStatusError{URL: u, StatusCode: res.StatusCode})
 		}
 
 		remoteDoc.DocumentURL = res.Request.URL.String()
 
-		contentType := res.Header.Get("Content-Type")
-		linkHeader := res.Header.Get("Link")
-
-		if len(linkHeader) > 0 {
-			parsedLinkHeader := ParseLinkHeader(linkHeader)
-			contextLink := parsedLinkHeader[linkHeaderRel]
-			if contextLink != nil && contentType != ApplicationJSONLDType {
-				if len(contextLink) > 1 {
-					return nil, NewJsonLdError(MultipleContextLinkHeaders, nil)
-				} else if len(contextLink) == 1 {
-					remoteDoc.ContextURL = contextLink[0]["target"]
-				}
-			}
-
-			// If content-type is not application/ld+json, nor any other +json
-			// and a link with rel=alternate and type='application/ld+json' is found,
-			// use that instead
-			alternateLink := parsedLinkHeader["alternate"]
-			if len(alternateLink) > 0 &&
-				alternateLink[0]["type"] == ApplicationJSONLDType &&
-				!rApplicationJSON.MatchString(contentType) {
-
-				finalURL := Resolve(u, alternateLink[0]["target"])
-				remoteDoc, err = rcdl.LoadDocument(finalURL)
-				if err != nil {
-					return nil, NewJsonLdError(LoadingDocumentFailed, err)
-				}
+		var alternateURL string
+		remoteDoc.ContextURL, alternateURL, err = processLinkHeader(u, res.Header.Get("Content-Type"),
+			res.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+		if alternateURL != "" {
+			release()
+			remoteDoc, err = rcdl.loadDocument(alternateURL, chain)
+			if err != nil {
+				return nil, NewJsonLdError(LoadingDocumentFailed, err)
 			}
 		}
 
@@ -407,10 +932,12 @@ func (rcdl *RFC7324CachingDocumentLoader) LoadDocument(u string) (*RemoteDocumen
 		if err == nil && len(reasons) == 0 {
 			shouldCache = true
 			expireTime = resExpireTime
+			etag = res.Header.Get("ETag")
+			lastModified = res.Header.Get("Last-Modified")
 		}
 
 		if remoteDoc.Document == nil {
-			remoteDoc.Document, err = DocumentFromReader(res.Body)
+			remoteDoc.Document, err = documentFromResponse(res, false, rcdl.onDuplicate)
 			if err != nil {
 				return nil, NewJsonLdError(LoadingDocumentFailed, err)
 			}
@@ -424,8 +951,12 @@ func (rcdl *RFC7324CachingDocumentLoader) LoadDocument(u string) (*RemoteDocumen
 			remoteDocument: remoteDoc,
 			expireTime:     expireTime,
 			neverExpires:   neverExpires,
+			etag:           etag,
+			lastModified:   lastModified,
 		}
+		rcdl.mu.Lock()
 		rcdl.cache[u] = cacheEntry
+		rcdl.mu.Unlock()
 	}
 
 	return remoteDoc, nil
@@ -0,0 +1,216 @@
+//go:build js && wasm
+
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall/js"
+)
+
+// FetchDocumentLoader is a DocumentLoader for programs compiled to WebAssembly
+// (GOOS=js GOARCH=wasm) which retrieves documents with the fetch API of the host
+// environment, such as a web browser. Requests are made in CORS mode without credentials,
+// and responses are cached by the browser according to their HTTP caching headers.
+//
+// LoadDocument blocks until the response has been received, so it must not be called
+// directly from a JavaScript callback (for example, a function created with js.FuncOf),
+// as that would deadlock. Call it from a separate goroutine instead.
+type FetchDocumentLoader struct {
+	headers     http.Header
+	cacheMode   string
+	onDuplicate DuplicateKeyHandler
+}
+
+// FetchDocumentLoaderOption configures optional behaviour of FetchDocumentLoader.
+type FetchDocumentLoaderOption func(dl *FetchDocumentLoader)
+
+// WithFetchHeaders sets headers to be sent with every request. A custom Accept header replaces
+// the default one, which prefers JSON-LD. Note that browsers send a CORS preflight request
+// for cross-origin URLs if any of the headers isn't CORS-safelisted.
+func WithFetchHeaders(headers http.Header) FetchDocumentLoaderOption {
+	return func(dl *FetchDocumentLoader) {
+		dl.headers = headers.Clone()
+	}
+}
+
+// WithFetchCache sets the cache mode of requests: "default", "no-store", "reload",
+// "no-cache", "force-cache" or "only-if-cached". The default mode uses the HTTP cache
+// of the browser. "force-cache" is useful for contexts which never change.
+func WithFetchCache(mode string) FetchDocumentLoaderOption {
+	return func(dl *FetchDocumentLoader) {
+		dl.cacheMode = mode
+	}
+}
+
+// WithFetchDuplicateKeyHandler makes the loader report duplicate keys in loaded documents
+// to the given handler (see DecodeDocument). Use RejectDuplicateKeys to fail loading
+// of such documents.
+func WithFetchDuplicateKeyHandler(onDuplicate DuplicateKeyHandler) FetchDocumentLoaderOption {
+	return func(dl *FetchDocumentLoader) {
+		dl.onDuplicate = onDuplicate
+	}
+}
+
+// NewFetchDocumentLoader creates a new instance of FetchDocumentLoader.
+func NewFetchDocumentLoader(options ...FetchDocumentLoaderOption) *FetchDocumentLoader {
+	rval := &FetchDocumentLoader{cacheMode: "default"}
+
+	for _, opt := range options {
+		opt(rval)
+	}
+	return rval
+}
+
+// LoadDocument returns a RemoteDocument containing the contents of the JSON resource
+// from the given URL.
+func (dl *FetchDocumentLoader) LoadDocument(u string) (*RemoteDocument, error) {
+	return dl.loadDocument(u, nil)
+}
+
+func (dl *FetchDocumentLoader) loadDocument(u string, chain alternateChain) (*RemoteDocument, error) {
+	chain, err := chain.follow(u)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return nil, NewJsonLdError(LoadingDocumentFailed, fmt.Sprintf("error parsing URL: %s", u))
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, NewJsonLdError(LoadingDocumentFailed, fmt.Sprintf("unsupported URL scheme: %s", u))
+	}
+
+	fetch := js.Global().Get("fetch")
+	if fetch.Type() != js.TypeFunction {
+		return nil, NewJsonLdError(LoadingDocumentFailed, "fetch API is not available")
+	}
+
+	headers := map[string]interface{}{
+		"Accept": acceptHeader,
+	}
+	for name, values := range dl.headers {
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	res, err := awaitPromise(func() js.Value {
+		return fetch.Invoke(u, map[string]interface{}{
+			"method":      "GET",
+			"mode":        "cors",
+			"credentials": "omit",
+			"cache":       dl.cacheMode,
+			"redirect":    "follow",
+			"headers":     headers,
+		})
+	})
+	if err != nil {
+		return nil, NewJsonLdError(LoadingDocumentFailed, err)
+	}
+
+	if status := res.Get("status").Int(); status != http.StatusOK {
+		return nil, NewJsonLdError(LoadingDocumentFailed,
+			&HTTPStatusError{URL: u, StatusCode: status})
+	}
+
+	remoteDoc := &RemoteDocument{
+		DocumentURL: res.Get("url").String(),
+	}
+	if remoteDoc.DocumentURL == "" {
+		// responses created by service workers may have no URL
+		remoteDoc.DocumentURL = u
+	}
+
+	contentType := fetchHeader(res, "Content-Type")
+	var alternateURL string
+	remoteDoc.ContextURL, alternateURL, err = processLinkHeader(u, contentType, fetchHeader(res, "Link"))
+	if err != nil {
+		return nil, err
+	}
+	if alternateURL != "" {
+		return dl.loadDocument(alternateURL, chain)
+	}
+
+	body, err := awaitPromise(func() js.Value {
+		return res.Call("text")
+	})
+	if err != nil {
+		return nil, NewJsonLdError(LoadingDocumentFailed, err)
+	}
+
+	remoteDoc.Document, err = documentFromBody([]byte(body.String()), remoteDoc.DocumentURL, contentType, false, dl.onDuplicate)
+	if err != nil {
+		return nil, NewJsonLdError(LoadingDocumentFailed, err)
+	}
+	return remoteDoc, nil
+}
+
+// fetchHeader returns the value of the given header of a fetch Response, or "" if it's not set.
+func fetchHeader(res js.Value, name string) string {
+	value := res.Get("headers").Call("get", name)
+	if value.Type() != js.TypeString {
+		return ""
+	}
+	return value.String()
+}
+
+// awaitPromise calls fn and waits until the JavaScript promise it returns is settled.
+// Exceptions thrown by fn and rejections are returned as errors.
+func awaitPromise(fn func() js.Value) (result js.Value, err error) {
+	resultCh := make(chan js.Value, 1)
+	errCh := make(chan error, 1)
+
+	onFulfilled := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		resultCh <- args[0]
+		return nil
+	})
+	defer onFulfilled.Release()
+	onRejected := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		errCh <- js.Error{Value: args[0]}
+		return nil
+	})
+	defer onRejected.Release()
+
+	promise, err := callJS(fn)
+	if err != nil {
+		return js.Undefined(), err
+	}
+	promise.Call("then", onFulfilled, onRejected)
+
+	select {
+	case result = <-resultCh:
+		return result, nil
+	case err = <-errCh:
+		return js.Undefined(), err
+	}
+}
+
+// callJS calls fn, converting a JavaScript exception into an error.
+func callJS(fn func() js.Value) (result js.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			jsErr, isJSError := r.(js.Error)
+			if !isJSError {
+				panic(r)
+			}
+			err = jsErr
+		}
+	}()
+	return fn(), nil
+}
@@ -0,0 +1,120 @@
+//go:build js && wasm
+
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"net/http"
+	"syscall/js"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubFetch replaces the global fetch function with one which serves the given responses
+// and records the options of every request.
+func stubFetch(t *testing.T, responses map[string]map[string]interface{}) *[]js.Value {
+	t.Helper()
+
+	requests := make([]js.Value, 0)
+	original := js.Global().Get("fetch")
+	stub := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		requests = append(requests, args[1])
+		promise := js.Global().Get("Promise")
+		resp, found := responses[args[0].String()]
+		if !found {
+			return promise.Call("reject", js.Global().Get("Error").New("network error"))
+		}
+		headers := js.Global().Get("Headers").New()
+		for name, value := range resp["headers"].(map[string]interface{}) {
+			headers.Call("set", name, value)
+		}
+		res := js.Global().Get("Response").New(resp["body"], map[string]interface{}{
+			"status":  resp["status"],
+			"headers": headers,
+		})
+		return promise.Call("resolve", res)
+	})
+	js.Global().Set("fetch", stub)
+	t.Cleanup(func() {
+		js.Global().Set("fetch", original)
+		stub.Release()
+	})
+
+	return &requests
+}
+
+func TestFetchDocumentLoader(t *testing.T) {
+	requests := stubFetch(t, map[string]map[string]interface{}{
+		"http://example.com/doc.json": {
+			"status": 200,
+			"body":   `{"name": "Alice"}`,
+			"headers": map[string]interface{}{
+				"Content-Type": "application/json",
+				"Link":         `<http://example.com/context.jsonld>; rel="http://www.w3.org/ns/json-ld#context"`,
+			},
+		},
+		"http://example.com/page": {
+			"status": 200,
+			"body":   "<html></html>",
+			"headers": map[string]interface{}{
+				"Content-Type": "text/html",
+				"Link":         `<doc.jsonld>; rel="alternate"; type="application/ld+json"`,
+			},
+		},
+		"http://example.com/doc.jsonld": {
+			"status":  200,
+			"body":    `{"@id": "http://example.com/alice"}`,
+			"headers": map[string]interface{}{"Content-Type": "application/ld+json"},
+		},
+		"http://example.com/missing": {
+			"status":  404,
+			"body":    "",
+			"headers": map[string]interface{}{},
+		},
+	})
+
+	headers := http.Header{}
+	headers.Set("Accept-Language", "en")
+	dl := NewFetchDocumentLoader(WithFetchHeaders(headers), WithFetchCache("force-cache"))
+
+	rd, err := dl.LoadDocument("http://example.com/doc.json")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/doc.json", rd.DocumentURL)
+	assert.Equal(t, map[string]interface{}{"name": "Alice"}, rd.Document)
+	assert.Equal(t, "http://example.com/context.jsonld", rd.ContextURL)
+
+	require.Len(t, *requests, 1)
+	init := (*requests)[0]
+	assert.Equal(t, "cors", init.Get("mode").String())
+	assert.Equal(t, "omit", init.Get("credentials").String())
+	assert.Equal(t, "force-cache", init.Get("cache").String())
+	assert.Contains(t, init.Get("headers").Get("Accept").String(), "application/ld+json")
+	assert.Equal(t, "en", init.Get("headers").Get("Accept-Language").String())
+
+	// alternate JSON-LD documents are loaded instead of HTML pages
+	rd, err = dl.LoadDocument("http://example.com/page")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"@id": "http://example.com/alice"}, rd.Document)
+
+	for _, u := range []string{"http://example.com/missing", "http://example.com/unknown", "file:///etc/passwd"} {
+		_, err = dl.LoadDocument(u)
+		require.Error(t, err, u)
+		assert.Equal(t, LoadingDocumentFailed, err.(*JsonLdError).Code, u)
+	}
+}
@@ -16,7 +16,15 @@ package ld_test
 
 import (
 	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	. "github.com/piprate/json-gold/ld"
 	"github.com/stretchr/testify/assert"
@@ -71,6 +79,43 @@ func TestParseLinkHeader(t *testing.T) {
 	)
 }
 
+func TestParseLinkHeaderTyped(t *testing.T) {
+	links := ParseLinkHeaderTyped(`<context.jsonld>; rel="http://www.w3.org/ns/json-ld#context",` +
+		` <doc.jsonld>; rel="alternate meta"; type="application/ld+json"; profile="http://www.w3.org/ns/json-ld#expanded",` +
+		` invalid, <other.jsonld>; rel=Alternate; title="Other, with a comma"`)
+
+	assert.Equal(t, []LinkHeader{
+		{
+			Target: "context.jsonld",
+			Rel:    "http://www.w3.org/ns/json-ld#context",
+			Params: map[string]string{"rel": "http://www.w3.org/ns/json-ld#context"},
+		},
+		{
+			Target:  "doc.jsonld",
+			Rel:     "alternate meta",
+			Type:    "application/ld+json",
+			Profile: "http://www.w3.org/ns/json-ld#expanded",
+			Params: map[string]string{
+				"rel":     "alternate meta",
+				"type":    "application/ld+json",
+				"profile": "http://www.w3.org/ns/json-ld#expanded",
+			},
+		},
+		{
+			Target: "other.jsonld",
+			Rel:    "Alternate",
+			Params: map[string]string{"rel": "Alternate", "title": "Other, with a comma"},
+		},
+	}, links)
+
+	assert.True(t, links[1].HasRel("alternate"))
+	assert.True(t, links[1].HasRel("meta"))
+	assert.True(t, links[2].HasRel("alternate"))
+	assert.False(t, links[0].HasRel("alternate"))
+
+	assert.Empty(t, ParseLinkHeaderTyped(""))
+}
+
 func TestCachingDocumentLoaderLoadDocument(t *testing.T) {
 	cl := NewCachingDocumentLoader(NewDefaultDocumentLoader(nil))
 
@@ -82,3 +127,526 @@ func TestCachingDocumentLoaderLoadDocument(t *testing.T) {
 
 	assert.Equal(t, "t1", rd.Document.(map[string]interface{})["@type"])
 }
+
+func TestRFC7324CachingDocumentLoaderStaleIfError(t *testing.T) {
+	failing := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Header().Set("Content-Type", ApplicationJSONLDType)
+		_, _ = fmt.Fprint(w, `{"@type": "t1"}`)
+	}))
+	defer srv.Close()
+
+	cl := NewRFC7324CachingDocumentLoader(nil)
+	_, err := cl.LoadDocument(srv.URL)
+	require.NoError(t, err)
+
+	failing = true
+
+	// stale-if-error is disabled by default
+	_, err = cl.LoadDocument(srv.URL)
+	assert.Error(t, err)
+
+	cl.SetStaleIfError(time.Hour)
+	rd, err := cl.LoadDocument(srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "t1", rd.Document.(map[string]interface{})["@type"])
+
+	cl.SetStaleIfError(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	_, err = cl.LoadDocument(srv.URL)
+	assert.Error(t, err)
+}
+
+func TestRFC7324CachingDocumentLoaderRevalidation(t *testing.T) {
+	const etag = `"v1"`
+	requests := 0
+	revalidations := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			revalidations++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", ApplicationJSONLDType)
+		_, _ = fmt.Fprint(w, `{"@type": "t1"}`)
+	}))
+	defer srv.Close()
+
+	cl := NewRFC7324CachingDocumentLoader(nil)
+	first, err := cl.LoadDocument(srv.URL)
+	require.NoError(t, err)
+
+	second, err := cl.LoadDocument(srv.URL)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, revalidations)
+	assert.Same(t, first, second)
+	assert.Equal(t, "t1", second.Document.(map[string]interface{})["@type"])
+}
+
+func TestCachingDocumentLoaderPrefetch(t *testing.T) {
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.Header().Set("Cache-Control", "max-age=3600")
+			w.Header().Set("Content-Type", ApplicationJSONLDType)
+			_, _ = fmt.Fprintf(w, `{"@context": {"name": "http://example.com%s"}}`, r.URL.Path)
+		}
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	urls := []string{srv.URL + "/c1", srv.URL + "/c2", srv.URL + "/c3", srv.URL + "/c4", srv.URL + "/c1"}
+
+	for _, tc := range []struct {
+		name   string
+		loader interface {
+			DocumentLoader
+			Prefetch(urls []string, concurrency int) error
+		}
+	}{
+		{"caching", NewCachingDocumentLoader(NewDefaultDocumentLoader(nil))},
+		{"rfc7324", NewRFC7324CachingDocumentLoader(nil)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+			mu.Lock()
+			maxActive = 0
+			mu.Unlock()
+
+			require.NoError(t, tc.loader.Prefetch(urls, 2))
+			assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+			mu.Lock()
+			assert.Equal(t, 2, maxActive)
+			mu.Unlock()
+
+			// prefetched documents are served from the cache
+			rd, err := tc.loader.LoadDocument(srv.URL + "/c3")
+			require.NoError(t, err)
+			assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+			assert.Equal(t, "http://example.com/c3",
+				rd.Document.(map[string]interface{})["@context"].(map[string]interface{})["name"])
+
+			err = tc.loader.Prefetch([]string{srv.URL + "/missing", srv.URL + "/c5"}, 0)
+			assert.Error(t, err)
+			_, err = tc.loader.LoadDocument(srv.URL + "/c5")
+			require.NoError(t, err)
+			assert.Equal(t, int32(6), atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+func TestMapDocumentLoader(t *testing.T) {
+	dl := NewMapDocumentLoader(map[string]interface{}{
+		"http://example.com/parsed": map[string]interface{}{"@type": "t1"},
+		"http://example.com/bytes":  []byte(`{"@type": "t2"}`),
+		"http://example.com/string": `{"@type": "t3"}`,
+	})
+	dl.AddDocument("http://example.com/remote", &RemoteDocument{
+		DocumentURL: "http://example.com/redirected",
+		Document:    map[string]interface{}{"@type": "t4"},
+	})
+
+	for u, expectedType := range map[string]string{
+		"http://example.com/parsed": "t1",
+		"http://example.com/bytes":  "t2",
+		"http://example.com/string": "t3",
+		"http://example.com/remote": "t4",
+	} {
+		rd, err := dl.LoadDocument(u)
+		require.NoError(t, err)
+		assert.Equal(t, expectedType, rd.Document.(map[string]interface{})["@type"])
+	}
+
+	_, err := dl.LoadDocument("http://example.com/unknown")
+	assert.Error(t, err)
+}
+
+func TestFSDocumentLoader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"contexts/v1.jsonld": &fstest.MapFile{Data: []byte(`{"@context": {"name": "http://schema.org/name"}}`)},
+	}
+	dl := NewFSDocumentLoader(fsys, "https://example.com/")
+
+	rd, err := dl.LoadDocument("https://example.com/contexts/v1.jsonld")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/contexts/v1.jsonld", rd.DocumentURL)
+	assert.Contains(t, rd.Document.(map[string]interface{}), "@context")
+
+	_, err = dl.LoadDocument("https://example.com/contexts/v2.jsonld")
+	assert.Error(t, err)
+
+	_, err = dl.LoadDocument("https://other.com/contexts/v1.jsonld")
+	assert.Error(t, err)
+
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = dl
+	expanded, err := NewJsonLdProcessor().Expand(map[string]interface{}{
+		"@context": "https://example.com/contexts/v1.jsonld",
+		"name":     "Jane",
+	}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"http://schema.org/name": []interface{}{map[string]interface{}{"@value": "Jane"}},
+		},
+	}, expanded)
+}
+
+func TestDefaultDocumentLoaderInvalidJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, "<html><body>Please log in</body></html>")
+	}))
+	defer srv.Close()
+
+	opts := NewJsonLdOptions("")
+	_, err := NewJsonLdProcessor().Expand(map[string]interface{}{
+		"@context": srv.URL + "/context.jsonld",
+		"name":     "Jane",
+	}, opts)
+
+	jsonLDError := new(JsonLdError)
+	require.ErrorAs(t, err, &jsonLDError)
+	assert.Equal(t, LoadingRemoteContextFailed, jsonLDError.Code)
+	assert.Contains(t, err.Error(), `Content-Type: "text/html"`)
+	assert.Contains(t, err.Error(), "Please log in")
+}
+
+func TestRemoteContextErrorDetails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schema.jsonld", "/other.jsonld":
+			w.Header().Set("Content-Type", "application/ld+json")
+			_, _ = fmt.Fprint(w, `{"@context": {"name": "http://schema.org/name"}}`)
+		case "/outer.jsonld":
+			w.Header().Set("Content-Type", "application/ld+json")
+			_, _ = fmt.Fprint(w, `{"@context": ["other.jsonld", "missing.jsonld"]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	_, err := NewJsonLdProcessor().Expand(map[string]interface{}{
+		"@context": []interface{}{srv.URL + "/schema.jsonld", srv.URL + "/outer.jsonld"},
+		"name":     "Jane",
+	}, nil)
+
+	jsonLDError := new(JsonLdError)
+	require.ErrorAs(t, err, &jsonLDError)
+	assert.Equal(t, LoadingRemoteContextFailed, jsonLDError.Code)
+
+	details := new(RemoteContextErrorDetails)
+	require.ErrorAs(t, err, &details)
+	assert.Equal(t, srv.URL+"/missing.jsonld", details.URL)
+	assert.Equal(t, srv.URL+"/outer.jsonld", details.ReferencedFrom)
+	assert.Equal(t, 1, details.Position)
+	assert.Equal(t, http.StatusNotFound, details.StatusCode)
+
+	statusErr := new(HTTPStatusError)
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, srv.URL+"/missing.jsonld", statusErr.URL)
+	assert.Contains(t, err.Error(), fmt.Sprintf("(%s/missing.jsonld, position: 1, HTTP status: 404, referenced from: %s/outer.jsonld)",
+		srv.URL, srv.URL))
+}
+
+func TestDocumentLoaderAlternateLinkCycle(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		// a.html and b.html point to each other as the JSON-LD alternate
+		target := "b.html"
+		if r.URL.Path == "/b.html" {
+			target = "a.html"
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="alternate"; type="application/ld+json"`, target))
+		_, _ = fmt.Fprint(w, "<html></html>")
+	}))
+	defer srv.Close()
+
+	for _, loader := range []DocumentLoader{
+		NewDefaultDocumentLoader(nil),
+		NewRFC7324CachingDocumentLoader(nil),
+	} {
+		atomic.StoreInt32(&requests, 0)
+		_, err := loader.LoadDocument(srv.URL + "/a.html")
+
+		jsonLDError := new(JsonLdError)
+		require.ErrorAs(t, err, &jsonLDError)
+		assert.Equal(t, LoadingDocumentFailed, jsonLDError.Code)
+		assert.Contains(t, err.Error(),
+			fmt.Sprintf("%[1]s/a.html -> %[1]s/b.html -> %[1]s/a.html", srv.URL))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	}
+}
+
+func TestDocumentLoaderAlternateLinkLimit(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		// each page points to a new one as the JSON-LD alternate
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Link", fmt.Sprintf(`<page%d.html>; rel="alternate"; type="application/ld+json"`, n))
+		_, _ = fmt.Fprint(w, "<html></html>")
+	}))
+	defer srv.Close()
+
+	for _, loader := range []DocumentLoader{
+		NewDefaultDocumentLoader(nil),
+		NewRFC7324CachingDocumentLoader(nil),
+	} {
+		atomic.StoreInt32(&requests, 0)
+		_, err := loader.LoadDocument(srv.URL + "/page0.html")
+
+		jsonLDError := new(JsonLdError)
+		require.ErrorAs(t, err, &jsonLDError)
+		assert.Equal(t, LoadingDocumentFailed, jsonLDError.Code)
+		assert.Contains(t, err.Error(), "too many alternate links")
+		assert.Equal(t, int32(10), atomic.LoadInt32(&requests))
+	}
+}
+
+func TestDecodeDocumentDuplicateKeys(t *testing.T) {
+	input := `{"@context": {"name": "http://schema.org/name"}, "items": [{"@id": "a", "@id": "b"}],` +
+		` "@context": {"name": "http://example.com/name"}}`
+
+	doc, err := DocumentFromReader(strings.NewReader(input))
+	require.NoError(t, err)
+
+	type duplicate struct{ key, pointer string }
+	var duplicates []duplicate
+	strictDoc, err := DecodeDocument(strings.NewReader(input), func(key string, pointer string) error {
+		duplicates = append(duplicates, duplicate{key, pointer})
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, doc, strictDoc)
+	assert.Equal(t, []duplicate{{"@id", "/items/0"}, {"@context", ""}}, duplicates)
+
+	_, err = DecodeDocument(strings.NewReader(input), RejectDuplicateKeys)
+	if assert.Error(t, err) {
+		assert.Equal(t, DuplicateKey, err.(*JsonLdError).Code)
+	}
+
+	_, err = DecodeDocument(strings.NewReader(`{"a": [1, 2`), RejectDuplicateKeys)
+	if assert.Error(t, err) {
+		assert.Equal(t, LoadingDocumentFailed, err.(*JsonLdError).Code)
+	}
+}
+
+func TestDefaultDocumentLoaderDuplicateKeys(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/ld+json")
+		_, _ = fmt.Fprint(w, `{"@context": {"name": "http://schema.org/name", "name": "http://example.com/name"}}`)
+	}))
+	defer srv.Close()
+
+	doc := map[string]interface{}{
+		"@context": srv.URL + "/context.jsonld",
+		"name":     "Jane",
+	}
+
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = NewDefaultDocumentLoader(nil)
+	_, err := NewJsonLdProcessor().Expand(doc, opts)
+	require.NoError(t, err)
+
+	opts.DocumentLoader = NewDefaultDocumentLoader(nil, WithDuplicateKeyHandler(RejectDuplicateKeys))
+	_, err = NewJsonLdProcessor().Expand(doc, opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `duplicate key "name" in object at "/@context"`)
+}
+
+func TestDefaultDocumentLoaderJSONNumber(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/ld+json")
+		_, _ = fmt.Fprint(w, `{"@id": "http://example.com/a", "http://example.com/id": 9007199254740993}`)
+	}))
+	defer srv.Close()
+
+	opts := NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	opts.UseJSONNumber = true
+	opts.DocumentLoader = NewDefaultDocumentLoader(nil, WithJSONNumber())
+	nquads, err := NewJsonLdProcessor().ToRDF(srv.URL+"/doc.jsonld", opts)
+	require.NoError(t, err)
+	assert.Equal(t, `<http://example.com/a> <http://example.com/id> "9007199254740993"^^<http://www.w3.org/2001/XMLSchema#integer> .
+`, nquads)
+}
+
+func TestRawContextDuplicateKeys(t *testing.T) {
+	doc := map[string]interface{}{
+		"http://schema.org/name": "Jane",
+	}
+	rawContext := []byte(`{"@context": {"name": "http://schema.org/name", "name": "http://example.com/name"}}`)
+
+	opts := NewJsonLdOptions("")
+	_, err := NewJsonLdProcessor().Compact(doc, rawContext, opts)
+	require.NoError(t, err)
+
+	opts.OnDuplicateKey = RejectDuplicateKeys
+	_, err = NewJsonLdProcessor().Compact(doc, rawContext, opts)
+	require.Error(t, err)
+	assert.Equal(t, DuplicateKey, err.(*JsonLdError).Code)
+	assert.Contains(t, err.Error(), `duplicate key "name" in object at "/@context"`)
+
+	_, err = NewContext(nil, opts).Parse(rawContext)
+	require.Error(t, err)
+	assert.Equal(t, DuplicateKey, err.(*JsonLdError).Code)
+}
+
+func TestDefaultDocumentLoaderHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/ld+json")
+		_, _ = fmt.Fprintf(w, `{"accept": %q, "language": %q, "tenant": %q}`,
+			r.Header.Get("Accept"), r.Header.Get("Accept-Language"), r.Header.Get("X-Tenant"))
+	}))
+	defer srv.Close()
+
+	dl := NewDefaultDocumentLoader(nil)
+	rd, err := dl.LoadDocument(srv.URL + "/context.jsonld")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"accept":   "application/ld+json, application/json;q=0.9, application/javascript;q=0.5, text/javascript;q=0.5, text/plain;q=0.2, */*;q=0.1",
+		"language": "",
+		"tenant":   "",
+	}, rd.Document)
+
+	dl = NewDefaultDocumentLoader(nil,
+		WithHeaders(http.Header{
+			"accept-language": {"de"},
+			"Accept":          {"application/ld+json"},
+		}),
+		WithRequestHeaders(func(req *http.Request) {
+			req.Header.Set("X-Tenant", strings.TrimPrefix(req.URL.Path, "/"))
+		}),
+	)
+	rd, err = dl.LoadDocument(srv.URL + "/acme")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"accept":   "application/ld+json",
+		"language": "de",
+		"tenant":   "acme",
+	}, rd.Document)
+}
+
+func TestHostRateLimiter(t *testing.T) {
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/ld+json")
+		_, _ = w.Write([]byte(`{}`))
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	t.Run("concurrency", func(t *testing.T) {
+		dl := NewDefaultDocumentLoader(nil, WithRateLimiter(NewHostRateLimiter(0, 0, 2)))
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := dl.LoadDocument(srv.URL + "/context.jsonld")
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, 2, maxActive)
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		dl := NewRFC7324CachingDocumentLoader(nil)
+		dl.SetRateLimiter(NewHostRateLimiter(20, 2, 0))
+		start := time.Now()
+		for i := 0; i < 4; i++ {
+			// documents without caching headers are fetched every time
+			_, err := dl.LoadDocument(srv.URL + "/context.jsonld")
+			require.NoError(t, err)
+		}
+		// two requests are allowed by the burst, the other two wait for 50ms each
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+}
+
+func TestDocumentLoaderMediaTypes(t *testing.T) {
+	contextLink := `<context.jsonld>; rel="http://www.w3.org/ns/json-ld#context"; type="application/ld+json"`
+	alternateLink := `<doc.jsonld>; rel="alternate"; type="Application/LD+JSON; charset=utf-8"`
+	responses := map[string][2]string{
+		"/activity":        {"application/activity+json; charset=utf-8", contextLink},
+		"/json":            {"Application/JSON;charset=UTF-8", contextLink},
+		"/jsonld":          {`application/ld+json; profile="http://www.w3.org/ns/json-ld#compacted"`, contextLink},
+		"/html":            {"text/html; charset=utf-8", alternateLink},
+		"/activity-alt":    {"application/activity+json; charset=utf-8", alternateLink},
+		"/doc.jsonld":      {"application/ld+json", ""},
+		"/invalid-charset": {"application/json; charset", contextLink},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := responses[r.URL.Path]
+		w.Header().Set("Content-Type", resp[0])
+		if resp[1] != "" {
+			w.Header().Set("Link", resp[1])
+		}
+		_, _ = fmt.Fprintf(w, `{"@id": %q}`, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	for _, loader := range []DocumentLoader{
+		NewDefaultDocumentLoader(nil),
+		NewRFC7324CachingDocumentLoader(nil),
+	} {
+		for path, expected := range map[string][2]string{
+			"/activity":        {"/activity", "context.jsonld"},
+			"/json":            {"/json", "context.jsonld"},
+			"/jsonld":          {"/jsonld", ""},
+			"/html":            {"/doc.jsonld", ""},
+			"/activity-alt":    {"/activity-alt", ""},
+			"/invalid-charset": {"/invalid-charset", "context.jsonld"},
+		} {
+			rd, err := loader.LoadDocument(srv.URL + path)
+			require.NoError(t, err, path)
+			assert.Equal(t, expected[0], rd.Document.(map[string]interface{})["@id"], path)
+			assert.Equal(t, expected[1], rd.ContextURL, path)
+		}
+	}
+}
@@ -15,7 +15,11 @@
 package ld
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 )
 
 // ErrorCode is a JSON-LD error code as per spec.
@@ -72,20 +76,27 @@ const (
 	ProtectedTermRedefinition   ErrorCode = "protected term redefinition"
 	InvalidContextEntry         ErrorCode = "invalid context entry"
 	InvalidPropagateValue       ErrorCode = "invalid @propagate value"
+	InvalidProtectedValue       ErrorCode = "invalid @protected value"
 	InvalidBaseDirection        ErrorCode = "invalid base direction"
 	InvalidIncludedValue        ErrorCode = "invalid @included value"
 	InvalidImportValue          ErrorCode = "invalid @import value"
+	InvalidScopedContext        ErrorCode = "invalid scoped context"
 	IRIConfusedWithPrefix       ErrorCode = "IRI confused with prefix"
 
 	// non spec related errors
-	SyntaxError     ErrorCode = "syntax error"
-	NotImplemented  ErrorCode = "not implemented"
-	UnknownFormat   ErrorCode = "unknown format"
-	InvalidInput    ErrorCode = "invalid input"
-	ParseError      ErrorCode = "parse error"
-	IOError         ErrorCode = "io error"
-	InvalidProperty ErrorCode = "invalid property"
-	UnknownError    ErrorCode = "unknown error"
+	SyntaxError          ErrorCode = "syntax error"
+	NotImplemented       ErrorCode = "not implemented"
+	UnknownFormat        ErrorCode = "unknown format"
+	InvalidInput         ErrorCode = "invalid input"
+	ParseError           ErrorCode = "parse error"
+	IOError              ErrorCode = "io error"
+	InvalidProperty      ErrorCode = "invalid property"
+	FramingLimitExceeded ErrorCode = "framing limit exceeded"
+	MaxDepthExceeded     ErrorCode = "max depth exceeded"
+	DuplicateKey         ErrorCode = "duplicate key"
+	BudgetExceeded       ErrorCode = "budget exceeded"
+	BaseDirectionDropped ErrorCode = "base direction dropped"
+	UnknownError         ErrorCode = "unknown error"
 )
 
 func (e JsonLdError) Error() string {
@@ -105,3 +116,123 @@ func (e JsonLdError) Unwrap() error {
 func NewJsonLdError(code ErrorCode, details interface{}) *JsonLdError { //nolint:stylecheck
 	return &JsonLdError{Code: code, Details: details}
 }
+
+// maxValueSnippetLength is the maximum length of the offending value included
+// into the text of an error.
+const maxValueSnippetLength = 100
+
+// ValueErrorDetails describes the value which caused an error, and its location
+// in the input document. It's used as JsonLdError.Details for errors like
+// InvalidIDValue, InvalidTypedValue and InvalidValueObject.
+type ValueErrorDetails struct {
+	Message string
+	// Value is the offending value.
+	Value interface{}
+	// Property is the property which holds the offending value, if known.
+	Property string
+	// NodeID is the @id of the nearest node which contains the offending value, if known.
+	NodeID string
+}
+
+func (d *ValueErrorDetails) String() string {
+	var sb strings.Builder
+	sb.WriteString(d.Message)
+
+	valueBytes, err := json.Marshal(d.Value)
+	if err == nil {
+		snippet := string(valueBytes)
+		if len(snippet) > maxValueSnippetLength {
+			// don't split multi-byte characters
+			cut := maxValueSnippetLength
+			for cut > 0 && !utf8.RuneStart(snippet[cut]) {
+				cut--
+			}
+			snippet = snippet[:cut] + "..."
+		}
+		fmt.Fprintf(&sb, " (value: %s", snippet)
+	} else {
+		fmt.Fprintf(&sb, " (value: %v", d.Value)
+	}
+	if d.Property != "" {
+		fmt.Fprintf(&sb, ", property: %s", d.Property)
+	}
+	if d.NodeID != "" {
+		fmt.Fprintf(&sb, ", node: %s", d.NodeID)
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+// newValueError creates a JsonLdError which describes the offending value and the property it belongs to.
+func newValueError(code ErrorCode, message string, value interface{}, property string) *JsonLdError {
+	return NewJsonLdError(code, &ValueErrorDetails{
+		Message:  message,
+		Value:    value,
+		Property: property,
+	})
+}
+
+// RemoteContextErrorDetails describes a remote context which couldn't be loaded.
+// It's used as JsonLdError.Details for LoadingRemoteContextFailed errors.
+type RemoteContextErrorDetails struct {
+	// URL is the resolved URL of the context.
+	URL string
+	// ReferencedFrom is the URL of the remote context which refers to URL, if any.
+	ReferencedFrom string
+	// Position is the index of the reference in the array of contexts being processed
+	// (0 if the context isn't an array).
+	Position int
+	// StatusCode is the HTTP status code of the response, if the server returned an error.
+	StatusCode int
+	// Cause is the error returned by the document loader.
+	Cause error
+}
+
+func (d *RemoteContextErrorDetails) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "dereferencing a URL did not result in a valid JSON-LD context (%s, position: %d",
+		d.URL, d.Position)
+	if d.StatusCode != 0 {
+		fmt.Fprintf(&sb, ", HTTP status: %d", d.StatusCode)
+	}
+	if d.ReferencedFrom != "" {
+		fmt.Fprintf(&sb, ", referenced from: %s", d.ReferencedFrom)
+	}
+	fmt.Fprintf(&sb, "): %v", d.Cause)
+
+	return sb.String()
+}
+
+// Unwrap returns the error returned by the document loader.
+func (d *RemoteContextErrorDetails) Unwrap() error {
+	return d.Cause
+}
+
+// newRemoteContextError creates a LoadingRemoteContextFailed error for the context at the given URL.
+func newRemoteContextError(url, referencedFrom string, position int, cause error) *JsonLdError {
+	details := &RemoteContextErrorDetails{
+		URL:            url,
+		ReferencedFrom: referencedFrom,
+		Position:       position,
+		Cause:          cause,
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(cause, &statusErr) {
+		details.StatusCode = statusErr.StatusCode
+	}
+	return NewJsonLdError(LoadingRemoteContextFailed, details)
+}
+
+// withNodeID records the given node ID in the details of a value error,
+// unless a nearer node has already been recorded.
+func withNodeID(err error, nodeID string) error {
+	ldErr, isLdErr := err.(*JsonLdError) //nolint:errorlint
+	if !isLdErr || nodeID == "" {
+		return err
+	}
+	if details, isValueErr := ldErr.Details.(*ValueErrorDetails); isValueErr && details.NodeID == "" {
+		details.NodeID = nodeID
+	}
+	return err
+}
@@ -1,10 +1,14 @@
 package ld
 
 import (
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJsonLdError_Unwrap(t *testing.T) {
@@ -19,3 +23,46 @@ func TestJsonLdError_Unwrap(t *testing.T) {
 		assert.Nil(t, NewJsonLdError(UnknownError, nil).Unwrap())
 	})
 }
+
+func TestValueErrorDetails(t *testing.T) {
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"@context": {"id": "@id", "ex": "http://example.com/"},
+		"ex:name": "Root",
+		"id": "ex:root",
+		"ex:child": {
+			"ex:name": {"@value": "x", "@type": "ex:T", "@language": "en"}
+		},
+		"ex:other": {"@id": 5}
+	}`), &doc))
+
+	_, err := NewJsonLdProcessor().Expand(doc, NewJsonLdOptions(""))
+	require.Error(t, err)
+
+	var ldErr *JsonLdError
+	require.True(t, errors.As(err, &ldErr))
+	assert.Equal(t, InvalidValueObject, ldErr.Code)
+
+	details, isValueErr := ldErr.Details.(*ValueErrorDetails)
+	require.True(t, isValueErr)
+	assert.Equal(t, "ex:name", details.Property)
+	assert.Equal(t, "http://example.com/root", details.NodeID)
+	assert.Equal(t, map[string]interface{}{"@value": "x", "@type": "ex:T", "@language": "en"}, details.Value)
+	assert.Equal(t, "invalid value object: value object must not include @type with either @language or @direction "+
+		`(value: {"@language":"en","@type":"ex:T","@value":"x"}, property: ex:name, node: http://example.com/root)`,
+		err.Error())
+
+	delete(doc.(map[string]interface{}), "ex:child")
+	_, err = NewJsonLdProcessor().Expand(doc, NewJsonLdOptions(""))
+	require.True(t, errors.As(err, &ldErr))
+	assert.Equal(t, InvalidIDValue, ldErr.Code)
+	details = ldErr.Details.(*ValueErrorDetails)
+	assert.Equal(t, 5.0, details.Value)
+	assert.Equal(t, "ex:other", details.Property)
+	assert.Equal(t, "http://example.com/root", details.NodeID)
+
+	// long values are truncated on a character boundary
+	details = &ValueErrorDetails{Message: "invalid value", Value: "ab" + strings.Repeat("é", 60)}
+	assert.Equal(t, `invalid value (value: "ab`+strings.Repeat("é", 48)+`...)`, details.String())
+	assert.True(t, utf8.ValidString(details.String()))
+}
@@ -574,3 +574,257 @@ func ExampleJsonLdProcessor_Normalize() {
 	// <http://example.org/test#example> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example.org/vocab#Foo> .
 	// _:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example.org/vocab#Bar> .
 }
+
+func ExampleJsonLdProcessor_Frame_scopedContexts() {
+	proc := ld.NewJsonLdProcessor()
+	options := ld.NewJsonLdOptions("")
+
+	// type-scoped and property-scoped contexts give the same term different meanings
+	context := map[string]interface{}{
+		"@vocab": "http://schema.org/",
+		"Book": map[string]interface{}{
+			"@id":      "http://schema.org/Book",
+			"@context": map[string]interface{}{"name": "http://purl.org/dc/terms/title"},
+		},
+		"author": map[string]interface{}{
+			"@id":      "http://schema.org/author",
+			"@context": map[string]interface{}{"name": "http://xmlns.com/foaf/0.1/name"},
+		},
+	}
+
+	doc := map[string]interface{}{
+		"@context": context,
+		"@id":      "http://example.org/book",
+		"@type":    "Book",
+		"name":     "My Book",
+		"author": map[string]interface{}{
+			"@id":  "http://example.org/writer",
+			"name": "Writer",
+		},
+	}
+
+	frame := map[string]interface{}{
+		"@context": context,
+		"@type":    "Book",
+		"author":   map[string]interface{}{},
+	}
+
+	framedDoc, err := proc.Frame(doc, frame, options)
+	if err != nil {
+		log.Println("Error when framing JSON-LD document:", err)
+		return
+	}
+
+	graph := framedDoc["@graph"]
+	ld.PrintDocument("JSON-LD framed graph", graph)
+
+	// Output:
+	// JSON-LD framed graph
+	// [
+	//   {
+	//     "@id": "http://example.org/book",
+	//     "@type": "Book",
+	//     "author": {
+	//       "@id": "http://example.org/writer",
+	//       "name": "Writer"
+	//     },
+	//     "name": "My Book"
+	//   }
+	// ]
+}
+
+func ExampleJsonLdProcessor_Compact_graphContainer() {
+	proc := ld.NewJsonLdProcessor()
+	options := ld.NewJsonLdOptions("")
+
+	// a credential whose claim is kept in a separate (blank node) graph
+	expanded := []interface{}{
+		map[string]interface{}{
+			"@id":   "http://example.org/credential",
+			"@type": []interface{}{"http://example.org/Credential"},
+			"http://example.org/claim": []interface{}{
+				map[string]interface{}{
+					"@graph": []interface{}{
+						map[string]interface{}{
+							"@id": "http://example.org/alice",
+							"http://example.org/name": []interface{}{
+								map[string]interface{}{"@value": "Alice"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	context := map[string]interface{}{
+		"@vocab": "http://example.org/",
+		"claim":  map[string]interface{}{"@id": "http://example.org/claim", "@container": "@graph"},
+	}
+
+	compactedDoc, err := proc.Compact(expanded, context, options)
+	if err != nil {
+		log.Println("Error when compacting JSON-LD document:", err)
+		return
+	}
+
+	ld.PrintDocument("JSON-LD compacted doc", compactedDoc)
+
+	// Output:
+	// JSON-LD compacted doc
+	// {
+	//   "@context": {
+	//     "@vocab": "http://example.org/",
+	//     "claim": {
+	//       "@container": "@graph",
+	//       "@id": "http://example.org/claim"
+	//     }
+	//   },
+	//   "@id": "http://example.org/credential",
+	//   "@type": "Credential",
+	//   "claim": {
+	//     "@id": "http://example.org/alice",
+	//     "name": "Alice"
+	//   }
+	// }
+}
+
+func ExampleJsonLdProcessor_Flatten_included() {
+	proc := ld.NewJsonLdProcessor()
+	options := ld.NewJsonLdOptions("")
+
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@vocab":   "http://example.org/",
+			"included": "@included",
+		},
+		"@id":    "http://example.org/article",
+		"author": map[string]interface{}{"@id": "http://example.org/alice"},
+		"included": []interface{}{
+			map[string]interface{}{
+				"@id":  "http://example.org/alice",
+				"name": "Alice",
+			},
+		},
+	}
+
+	flattened, err := proc.Flatten(doc, nil, options)
+	if err != nil {
+		log.Println("Error when flattening JSON-LD document:", err)
+		return
+	}
+
+	ld.PrintDocument("JSON-LD flattened doc", flattened)
+
+	// Output:
+	// JSON-LD flattened doc
+	// [
+	//   {
+	//     "@id": "http://example.org/alice",
+	//     "http://example.org/name": [
+	//       {
+	//         "@value": "Alice"
+	//       }
+	//     ]
+	//   },
+	//   {
+	//     "@id": "http://example.org/article",
+	//     "http://example.org/author": [
+	//       {
+	//         "@id": "http://example.org/alice"
+	//       }
+	//     ]
+	//   }
+	// ]
+}
+
+func ExampleJsonLdProcessor_ToRDF_rdfDirection() {
+	proc := ld.NewJsonLdProcessor()
+	options := ld.NewJsonLdOptions("")
+	options.Format = "application/n-quads"
+	options.RdfDirection = ld.RdfDirectionCompoundLiteral
+
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@vocab":     "http://example.org/",
+			"@language":  "ar",
+			"@direction": "rtl",
+		},
+		"@id":   "http://example.org/book",
+		"title": "كتابي",
+	}
+
+	triples, err := proc.ToRDF(doc, options)
+	if err != nil {
+		log.Println("Error running ToRDF:", err)
+		return
+	}
+
+	temp := strings.Split(strings.TrimSpace(triples.(string)), "\n")
+	sort.Strings(temp)
+	fmt.Println(strings.Join(temp, "\n"))
+
+	// and back to JSON-LD
+	options.Format = ""
+	restored, err := proc.FromRDF(triples, options)
+	if err != nil {
+		log.Println("Error running FromRDF:", err)
+		return
+	}
+
+	ld.PrintDocument("JSON-LD doc from RDF", restored)
+
+	// Output:
+	// <http://example.org/book> <http://example.org/title> _:b0 .
+	// _:b0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#direction> "rtl" .
+	// _:b0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#language> "ar" .
+	// _:b0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#value> "كتابي" .
+	// JSON-LD doc from RDF
+	// [
+	//   {
+	//     "@id": "http://example.org/book",
+	//     "http://example.org/title": [
+	//       {
+	//         "@direction": "rtl",
+	//         "@language": "ar",
+	//         "@value": "كتابي"
+	//       }
+	//     ]
+	//   }
+	// ]
+}
+
+func ExampleJsonLdProcessor_Normalize_safeMode() {
+	proc := ld.NewJsonLdProcessor()
+	options := ld.NewJsonLdOptions("")
+	options.Format = "application/n-quads"
+	options.Algorithm = ld.AlgorithmURDNA2015
+	options.SafeMode = true
+
+	// in safe mode, data which would be silently dropped makes the operation fail,
+	// so that signatures never cover less than the document says
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"name": "http://schema.org/name",
+		},
+		"@id":     "http://example.org/alice",
+		"name":    "Alice",
+		"surname": "Smith",
+	}
+
+	_, err := proc.Normalize(doc, options)
+	fmt.Println(err)
+
+	options.SafeMode = false
+	normalizedTriples, err := proc.Normalize(doc, options)
+	if err != nil {
+		log.Println("Error running Normalize:", err)
+		return
+	}
+
+	fmt.Print(normalizedTriples)
+
+	// Output:
+	// invalid property: Dropping property that did not expand into an absolute IRI or keyword.
+	// <http://example.org/alice> <http://schema.org/name> "Alice" .
+}
@@ -0,0 +1,124 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+// ExpandTracer receives events from the expansion algorithm, see JsonLdOptions.ExpandTracer.
+// Other operations (compaction, framing, etc.) report the expansion of their input.
+// Methods are called synchronously, from the goroutine performing the operation.
+type ExpandTracer interface {
+	// NodeStarted is called before the top-level element of the input document
+	// with the given index is expanded. The index of a single top-level object is 0.
+	NodeStarted(index int)
+
+	// NodeFinished is called after the top-level element with the given index
+	// has been expanded. expanded may be nil if the element didn't produce any output.
+	NodeFinished(index int, expanded interface{})
+
+	// ContextApplied is called for each context processed during expansion.
+	// url is the URL of a remote or imported context, or "" for an embedded one.
+	// version is the value of @version in the context, if any.
+	ContextApplied(url string, version string)
+
+	// TermApplied is called when a term definition is used to expand a property or a type.
+	// origin is the URL of the context which defined the term, or "inline#n" for the n-th
+	// embedded context in a @context entry (see Context.TermOrigin).
+	TermApplied(term string, origin string)
+}
+
+// AppliedContext describes a context processed during expansion.
+type AppliedContext struct {
+	// URL of the context, or "" if it was embedded into the document
+	URL string
+	// Version is the value of @version in the context, if any
+	Version string
+}
+
+// NodeReport lists the contexts and term definitions applied to a top-level node.
+type NodeReport struct {
+	// Index of the node in the top-level array of the input document
+	Index int
+	// ID is the expanded @id of the node, if it has one
+	ID string
+	// Contexts processed while expanding the node, in order, without duplicates
+	Contexts []AppliedContext
+	// Terms maps terms used in the node to the origins of their definitions
+	Terms map[string]string
+}
+
+// ExpansionReport is an ExpandTracer which records the contexts and term definitions
+// applied to each top-level node of the input document, for example for compliance auditing.
+type ExpansionReport struct {
+	// Contexts processed before the expansion of the first node, such as
+	// JsonLdOptions.ExpandContext or the context from a Link header
+	Contexts []AppliedContext
+	// Nodes lists the top-level nodes in document order
+	Nodes []*NodeReport
+
+	current *NodeReport
+}
+
+// NewExpansionReport creates a new empty ExpansionReport.
+func NewExpansionReport() *ExpansionReport {
+	return &ExpansionReport{
+		Contexts: make([]AppliedContext, 0),
+		Nodes:    make([]*NodeReport, 0),
+	}
+}
+
+// NodeStarted implements ExpandTracer.
+func (r *ExpansionReport) NodeStarted(index int) {
+	r.current = &NodeReport{
+		Index:    index,
+		Contexts: make([]AppliedContext, 0),
+		Terms:    make(map[string]string),
+	}
+	r.Nodes = append(r.Nodes, r.current)
+}
+
+// NodeFinished implements ExpandTracer.
+func (r *ExpansionReport) NodeFinished(index int, expanded interface{}) {
+	if r.current == nil {
+		return
+	}
+	if node, isMap := expanded.(map[string]interface{}); isMap {
+		if id, isString := node["@id"].(string); isString {
+			r.current.ID = id
+		}
+	}
+	r.current = nil
+}
+
+// ContextApplied implements ExpandTracer.
+func (r *ExpansionReport) ContextApplied(url string, version string) {
+	applied := AppliedContext{URL: url, Version: version}
+
+	contexts := &r.Contexts
+	if r.current != nil {
+		contexts = &r.current.Contexts
+	}
+	for _, c := range *contexts {
+		if c == applied {
+			return
+		}
+	}
+	*contexts = append(*contexts, applied)
+}
+
+// TermApplied implements ExpandTracer.
+func (r *ExpansionReport) TermApplied(term string, origin string) {
+	if r.current != nil {
+		r.current.Terms[term] = origin
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpansionReport(t *testing.T) {
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = NewMapDocumentLoader(map[string]interface{}{
+		"http://example.com/v1": map[string]interface{}{
+			"@context": map[string]interface{}{
+				"@version": 1.1,
+				"name":     "http://schema.org/name",
+				"Person":   "http://schema.org/Person",
+			},
+		},
+		"http://example.com/base": map[string]interface{}{
+			"@context": map[string]interface{}{
+				"schema": "http://schema.org/",
+			},
+		},
+	})
+	opts.ExpandContext = "http://example.com/base"
+	report := NewExpansionReport()
+	opts.ExpandTracer = report
+
+	doc := []interface{}{
+		map[string]interface{}{
+			"@context": "http://example.com/v1",
+			"@id":      "http://example.com/alice",
+			"@type":    "Person",
+			"name":     "Alice",
+		},
+		map[string]interface{}{
+			"@context":          map[string]interface{}{"nick": "http://example.com/nick"},
+			"nick":              "Bob",
+			"schema:familyName": "Smith",
+		},
+	}
+
+	expanded, err := NewJsonLdProcessor().Expand(doc, opts)
+	require.NoError(t, err)
+	assert.Len(t, expanded, 2)
+
+	assert.Equal(t, []AppliedContext{{URL: "http://example.com/base"}}, report.Contexts)
+	assert.Equal(t, []*NodeReport{
+		{
+			Index:    0,
+			ID:       "http://example.com/alice",
+			Contexts: []AppliedContext{{URL: "http://example.com/v1", Version: "1.1"}},
+			Terms: map[string]string{
+				"name":   "http://example.com/v1",
+				"Person": "http://example.com/v1",
+			},
+		},
+		{
+			Index:    1,
+			Contexts: []AppliedContext{{URL: ""}},
+			Terms: map[string]string{
+				"nick":   "inline#0",
+				"schema": "http://example.com/base",
+			},
+		},
+	}, report.Nodes)
+
+	// tracing doesn't change the result of expansion
+	opts.ExpandTracer = nil
+	untraced, err := NewJsonLdProcessor().Expand(doc, opts)
+	require.NoError(t, err)
+	assert.Equal(t, untraced, expanded)
+}
+
+func TestExpansionReportGraph(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{"name": "http://schema.org/name"},
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "http://example.com/alice", "name": "Alice"},
+			map[string]interface{}{
+				"@context": map[string]interface{}{"nick": "http://example.com/nick"},
+				"@id":      "http://example.com/bob",
+				"nick":     "Bob",
+			},
+		},
+	}
+
+	for _, retainSourceContext := range []bool{false, true} {
+		opts := NewJsonLdOptions("")
+		opts.RetainSourceContext = retainSourceContext
+		report := NewExpansionReport()
+		opts.ExpandTracer = report
+
+		expanded, err := NewJsonLdProcessor().Expand(doc, opts)
+		require.NoError(t, err)
+
+		// the members of the top-level @graph are reported as top-level nodes
+		assert.Equal(t, []AppliedContext{{URL: ""}}, report.Contexts)
+		assert.Equal(t, []*NodeReport{
+			{
+				Index:    0,
+				ID:       "http://example.com/alice",
+				Contexts: []AppliedContext{},
+				Terms:    map[string]string{"name": "inline#0"},
+			},
+			{
+				Index:    1,
+				ID:       "http://example.com/bob",
+				Contexts: []AppliedContext{{URL: ""}},
+				Terms:    map[string]string{"nick": "inline#0"},
+			},
+		}, report.Nodes)
+
+		opts.ExpandTracer = nil
+		untraced, err := NewJsonLdProcessor().Expand(doc, opts)
+		require.NoError(t, err)
+		assert.Equal(t, untraced, expanded)
+	}
+}
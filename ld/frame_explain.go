@@ -0,0 +1,71 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+// FrameMismatchReason identifies the frame constraint which a subject failed to satisfy.
+type FrameMismatchReason string
+
+const (
+	// FrameIDMismatch means the subject's @id isn't listed in the frame.
+	FrameIDMismatch FrameMismatchReason = "id mismatch"
+	// FrameTypeMismatch means the subject doesn't have any of the types in the frame,
+	// has a type when the frame requires none, or has no type when the frame requires any.
+	FrameTypeMismatch FrameMismatchReason = "type mismatch"
+	// FrameMissingProperty means the subject has no values for a property required by the frame.
+	FrameMissingProperty FrameMismatchReason = "missing property"
+	// FrameUnexpectedProperty means the subject has values for a property which the frame
+	// requires to be absent (an empty array or null).
+	FrameUnexpectedProperty FrameMismatchReason = "unexpected property"
+	// FrameValuePatternMismatch means none of the subject's values match the value pattern in the frame.
+	FrameValuePatternMismatch FrameMismatchReason = "value pattern mismatch"
+	// FrameListMismatch means the subject's list doesn't match the list pattern in the frame.
+	FrameListMismatch FrameMismatchReason = "list mismatch"
+	// FrameNoMatchingProperty means that RequireAll is false and the subject matches
+	// none of the properties in the frame.
+	FrameNoMatchingProperty FrameMismatchReason = "no matching property"
+)
+
+// FrameMismatch explains why a subject was rejected by a frame, see JsonLdOptions.FrameMismatchHandler.
+type FrameMismatch struct {
+	// Subject is the @id of the rejected node (blank node identifiers are
+	// the ones issued during framing)
+	Subject string
+	// Property is the frame entry which the subject failed to match,
+	// or "" for FrameNoMatchingProperty
+	Property string
+	// Reason identifies the failed constraint
+	Reason FrameMismatchReason
+}
+
+// FrameExplanation records frame mismatches for each subject, which is useful
+// for understanding why a frame produced an empty or incomplete result.
+// Use its Record method as JsonLdOptions.FrameMismatchHandler.
+type FrameExplanation struct {
+	// Subjects maps subject identifiers to the reasons why they were rejected,
+	// in order of occurrence
+	Subjects map[string][]*FrameMismatch
+}
+
+// NewFrameExplanation creates a new empty FrameExplanation.
+func NewFrameExplanation() *FrameExplanation {
+	return &FrameExplanation{
+		Subjects: make(map[string][]*FrameMismatch),
+	}
+}
+
+// Record adds the given mismatch to the explanation.
+func (e *FrameExplanation) Record(mismatch *FrameMismatch) {
+	e.Subjects[mismatch.Subject] = append(e.Subjects[mismatch.Subject], mismatch)
+}
@@ -0,0 +1,168 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+// The fuzz tests below check that malformed input makes the processor return
+// a JsonLdError rather than panic. Remote documents are never retrieved.
+
+// fuzzOptions returns options with a document loader which doesn't know any documents.
+func fuzzOptions() *JsonLdOptions {
+	opts := NewJsonLdOptions("http://example.com/doc.jsonld")
+	opts.DocumentLoader = NewMapDocumentLoader(nil)
+	return opts
+}
+
+// addSeedFiles adds the contents of the files matching the given pattern to the seed corpus.
+func addSeedFiles(f *testing.F, pattern string, args ...interface{}) {
+	files, err := filepath.Glob(pattern)
+	require.NoError(f, err)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		require.NoError(f, err)
+		f.Add(append([]interface{}{b}, args...)...)
+	}
+}
+
+// unmarshalFuzzInput decodes JSON input produced by the fuzzer.
+func unmarshalFuzzInput(data []byte) (interface{}, bool) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+func requireJsonLdError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		jsonLDError := new(JsonLdError)
+		require.ErrorAs(t, err, &jsonLDError)
+	}
+}
+
+func FuzzExpand(f *testing.F) {
+	addSeedFiles(f, "testdata/expand/*-in.jsonld")
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		input, ok := unmarshalFuzzInput(data)
+		if !ok {
+			return
+		}
+		_, err := NewJsonLdProcessor().Expand(input, fuzzOptions())
+		requireJsonLdError(t, err)
+	})
+}
+
+func FuzzCompact(f *testing.F) {
+	files, err := filepath.Glob("testdata/compact/*-context.jsonld")
+	require.NoError(f, err)
+	for _, file := range files {
+		ctx, err := os.ReadFile(file)
+		require.NoError(f, err)
+		in, err := os.ReadFile(file[:len(file)-len("-context.jsonld")] + "-in.jsonld")
+		if err != nil {
+			continue
+		}
+		f.Add(in, ctx)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, contextData []byte) {
+		input, ok := unmarshalFuzzInput(data)
+		if !ok {
+			return
+		}
+		ctx, ok := unmarshalFuzzInput(contextData)
+		if !ok {
+			return
+		}
+		_, err := NewJsonLdProcessor().Compact(input, ctx, fuzzOptions())
+		requireJsonLdError(t, err)
+	})
+}
+
+func FuzzFrame(f *testing.F) {
+	files, err := filepath.Glob("testdata/frame/*-frame.jsonld")
+	require.NoError(f, err)
+	for _, file := range files {
+		frame, err := os.ReadFile(file)
+		require.NoError(f, err)
+		in, err := os.ReadFile(file[:len(file)-len("-frame.jsonld")] + "-in.jsonld")
+		if err != nil {
+			continue
+		}
+		f.Add(in, frame)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, frameData []byte) {
+		input, ok := unmarshalFuzzInput(data)
+		if !ok {
+			return
+		}
+		frame, ok := unmarshalFuzzInput(frameData)
+		if !ok {
+			return
+		}
+		_, err := NewJsonLdProcessor().Frame(input, frame, fuzzOptions())
+		requireJsonLdError(t, err)
+	})
+}
+
+func FuzzFromRDF(f *testing.F) {
+	files, err := filepath.Glob("testdata/fromRdf/*-in.nq")
+	require.NoError(f, err)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		require.NoError(f, err)
+		f.Add(string(b), false)
+		f.Add(string(b), true)
+	}
+
+	f.Fuzz(func(t *testing.T, input string, useNativeTypes bool) {
+		opts := fuzzOptions()
+		opts.UseNativeTypes = useNativeTypes
+		_, err := NewJsonLdProcessor().FromRDF(input, opts)
+		requireJsonLdError(t, err)
+	})
+}
+
+func FuzzParseNQuads(f *testing.F) {
+	files, err := filepath.Glob("testdata/toRdf/*-out.nq")
+	require.NoError(f, err)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		require.NoError(f, err)
+		f.Add(string(b))
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		dataset, err := ParseNQuads(input)
+		requireJsonLdError(t, err)
+		if err == nil {
+			// serialising a parsed dataset must not fail either
+			_, err = (&NQuadRDFSerializer{}).Serialize(dataset)
+			require.NoError(t, err)
+		}
+	})
+}
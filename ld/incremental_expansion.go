@@ -0,0 +1,242 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IncrementalExpansion holds the expansion result of a document split by top-level
+// nodes, so that it can be updated after a change of the document without expanding
+// the whole document again. It's intended for editors which re-validate documents
+// as they are edited.
+//
+// Only top-level nodes are tracked: in a document which is an array of nodes, or
+// in a document which consists of @context and @graph entries. A change inside
+// a top-level node re-expands only that node. Any other change (for example,
+// of the top-level @context, or of the number of top-level nodes) causes
+// full re-expansion, as does any change of a document of another shape.
+//
+// This API is experimental and may change in future versions.
+type IncrementalExpansion struct {
+	opts *JsonLdOptions
+
+	// graphContext is the top-level @context of documents with @graph, or nil
+	graphContext interface{}
+	// nodeCtx is the active context for expansion of top-level nodes
+	nodeCtx *Context
+	// nodes holds the input and the expanded form of each top-level node
+	nodes    []interface{}
+	expanded [][]interface{}
+	// incremental is false if the document doesn't consist of top-level nodes
+	// which can be expanded separately
+	incremental bool
+	result      []interface{}
+}
+
+// ExpandIncremental expands the given document and returns the state which allows
+// to update the result after a change of the document, see IncrementalExpansion.
+// Unlike Expand, the input must be an in-memory document rather than a URL.
+func (jldp *JsonLdProcessor) ExpandIncremental(input interface{}, opts *JsonLdOptions) (*IncrementalExpansion, error) {
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	} else {
+		opts = opts.Copy()
+	}
+	opts.ExpandTracer = nil
+
+	if _, isString := input.(string); isString {
+		return nil, NewJsonLdError(InvalidInput, "incremental expansion requires an in-memory document")
+	}
+
+	ie := &IncrementalExpansion{opts: opts}
+	if err := ie.expandAll(input); err != nil {
+		return nil, err
+	}
+	return ie, nil
+}
+
+// Result returns the current expansion result. Expanded nodes are shared between
+// results of subsequent updates, so they must not be modified.
+func (ie *IncrementalExpansion) Result() []interface{} {
+	return ie.result
+}
+
+// Update replaces the document with the given (modified) one and returns the new
+// expansion result. changed is a JSON pointer (RFC 6901) to the part of the document
+// which has changed since the previous call; "" means the whole document.
+// The caller is responsible for the accuracy of the pointer: changes outside
+// of it may not be reflected in the result.
+func (ie *IncrementalExpansion) Update(input interface{}, changed string) ([]interface{}, error) {
+	tokens, err := parseJSONPointer(changed)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodes, index, ok := ie.changedNode(input, tokens); ok {
+		expanded, err := ie.expandNode(nodes[index])
+		if err != nil {
+			return nil, err
+		}
+		ie.nodes = nodes
+		ie.expanded[index] = expanded
+		ie.result = ie.concatNodes()
+		return ie.result, nil
+	}
+
+	if err := ie.expandAll(input); err != nil {
+		return nil, err
+	}
+	return ie.result, nil
+}
+
+// changedNode returns the top-level nodes of the new document and the index of the only
+// node affected by the change, if the rest of the expansion result can be reused.
+func (ie *IncrementalExpansion) changedNode(input interface{}, tokens []string) ([]interface{}, int, bool) {
+	if !ie.incremental {
+		return nil, 0, false
+	}
+	nodes, graphContext, ok := topLevelNodes(input)
+	if !ok || len(nodes) != len(ie.nodes) || (graphContext == nil) != (ie.graphContext == nil) {
+		return nil, 0, false
+	}
+	if graphContext != nil {
+		// the change must be inside @graph, so that the context stays the same
+		if len(tokens) == 0 || tokens[0] != "@graph" {
+			return nil, 0, false
+		}
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return nil, 0, false
+	}
+	index, err := strconv.Atoi(tokens[0])
+	if err != nil || index < 0 || index >= len(nodes) || strconv.Itoa(index) != tokens[0] {
+		return nil, 0, false
+	}
+	return nodes, index, true
+}
+
+// expandAll expands the whole document, splitting the result by top-level nodes where possible.
+func (ie *IncrementalExpansion) expandAll(input interface{}) error {
+	nodes, graphContext, ok := topLevelNodes(input)
+	if !ok {
+		expanded, err := NewJsonLdProcessor().expand(input, ie.opts)
+		if err != nil {
+			return err
+		}
+		ie.nodes = nil
+		ie.expanded = nil
+		ie.graphContext = nil
+		ie.nodeCtx = nil
+		ie.incremental = false
+		ie.result = expanded
+		return nil
+	}
+
+	activeCtx, err := initialExpansionContext(ie.opts, "")
+	if err != nil {
+		return err
+	}
+	if graphContext != nil {
+		if activeCtx, err = activeCtx.Parse(graphContext); err != nil {
+			return err
+		}
+	}
+
+	state := &IncrementalExpansion{
+		opts:         ie.opts,
+		graphContext: graphContext,
+		nodeCtx:      activeCtx,
+		nodes:        nodes,
+		expanded:     make([][]interface{}, len(nodes)),
+		incremental:  true,
+	}
+	for i, node := range nodes {
+		if state.expanded[i], err = state.expandNode(node); err != nil {
+			return err
+		}
+	}
+	state.result = state.concatNodes()
+	*ie = *state
+	return nil
+}
+
+// expandNode expands a single top-level node.
+func (ie *IncrementalExpansion) expandNode(node interface{}) ([]interface{}, error) {
+	api := newJsonLdApi(ie.opts)
+	activeProperty := ""
+	// account for the enclosing array (and the top-level object) in depth checks
+	api.depth = 1
+	if ie.graphContext != nil {
+		activeProperty = "@graph"
+		api.depth = 2
+	}
+
+	expanded, err := api.Expand(ie.nodeCtx, activeProperty, node, ie.opts, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	if expandedList, isList := expanded.([]interface{}); isList {
+		return expandedList, nil
+	} else if expanded != nil {
+		return []interface{}{expanded}, nil
+	}
+	return nil, nil
+}
+
+func (ie *IncrementalExpansion) concatNodes() []interface{} {
+	result := make([]interface{}, 0, len(ie.expanded))
+	for _, expanded := range ie.expanded {
+		result = append(result, expanded...)
+	}
+	return result
+}
+
+// topLevelNodes returns the top-level nodes of a document which is either an array
+// of nodes or an object with @context and @graph entries only. In the latter case,
+// the value of @context is returned too.
+func topLevelNodes(input interface{}) ([]interface{}, interface{}, bool) {
+	switch doc := input.(type) {
+	case []interface{}:
+		return doc, nil, true
+	case map[string]interface{}:
+		ctx, hasContext := doc["@context"]
+		graph, isList := doc["@graph"].([]interface{})
+		if !hasContext || ctx == nil || !isList || len(doc) != 2 {
+			return nil, nil, false
+		}
+		return graph, ctx, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// parseJSONPointer splits the given JSON pointer (RFC 6901) into reference tokens.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, NewJsonLdError(InvalidInput, fmt.Sprintf("invalid JSON pointer: %s", pointer))
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJsonLdProcessor_ExpandIncremental(t *testing.T) {
+	proc := NewJsonLdProcessor()
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@vocab": "http://example.com/",
+			"knows":  map[string]interface{}{"@type": "@id"},
+		},
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "http://example.com/alice", "name": "Alice", "knows": "http://example.com/bob"},
+			map[string]interface{}{"@id": "http://example.com/bob", "name": "Bob"},
+		},
+	}
+	sameNode := func(a, b interface{}) bool {
+		return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+	}
+	assertExpanded := func(t *testing.T, doc interface{}, result []interface{}) {
+		t.Helper()
+		expected, err := proc.Expand(CloneDocument(doc), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expected, result)
+	}
+
+	ie, err := proc.ExpandIncremental(doc, nil)
+	require.NoError(t, err)
+	assertExpanded(t, doc, ie.Result())
+	previous := ie.Result()
+
+	// change inside a node
+	doc["@graph"].([]interface{})[1].(map[string]interface{})["name"] = "Robert"
+	result, err := ie.Update(doc, "/@graph/1/name")
+	require.NoError(t, err)
+	assertExpanded(t, doc, result)
+	assert.True(t, sameNode(previous[0], result[0]))
+	assert.False(t, sameNode(previous[1], result[1]))
+
+	// invalid changes don't affect the state
+	doc["@graph"].([]interface{})[0].(map[string]interface{})["@id"] = 5.0
+	_, err = ie.Update(doc, "/@graph/0/@id")
+	assert.Error(t, err)
+	assert.Equal(t, result, ie.Result())
+	doc["@graph"].([]interface{})[0].(map[string]interface{})["@id"] = "http://example.com/alice"
+
+	// change of the context requires full re-expansion
+	doc["@context"].(map[string]interface{})["name"] = "http://xmlns.com/foaf/0.1/name"
+	result, err = ie.Update(doc, "/@context/name")
+	require.NoError(t, err)
+	assertExpanded(t, doc, result)
+
+	// a new node
+	doc["@graph"] = append(doc["@graph"].([]interface{}), map[string]interface{}{"name": "Carol"})
+	result, err = ie.Update(doc, "/@graph/2")
+	require.NoError(t, err)
+	assertExpanded(t, doc, result)
+
+	// documents of other shapes are expanded as a whole
+	single := map[string]interface{}{"@id": "http://example.com/dave", "http://example.com/name": "Dave"}
+	result, err = ie.Update(single, "/http:~1~1example.com~1name")
+	require.NoError(t, err)
+	assertExpanded(t, single, result)
+
+	// top-level arrays
+	nodes := []interface{}{
+		map[string]interface{}{"@id": "http://example.com/a", "http://example.com/p": "a"},
+		map[string]interface{}{"@id": "http://example.com/b", "http://example.com/p": "b"},
+	}
+	result, err = ie.Update(nodes, "")
+	require.NoError(t, err)
+	assertExpanded(t, nodes, result)
+	nodes[0].(map[string]interface{})["http://example.com/p"] = "c"
+	result, err = ie.Update(nodes, "/0/http:~1~1example.com~1p")
+	require.NoError(t, err)
+	assertExpanded(t, nodes, result)
+
+	_, err = ie.Update(nodes, "0")
+	assert.Error(t, err)
+	_, err = proc.ExpandIncremental("http://example.com/doc.jsonld", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,161 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DuplicateKeyHandler is called when a JSON object being decoded contains the same key
+// more than once. pointer is the JSON Pointer (RFC 6901) of the object. If the handler
+// returns nil, the last value of the key is kept, as encoding/json does; otherwise
+// decoding fails with the returned error.
+type DuplicateKeyHandler func(key string, pointer string) error
+
+// RejectDuplicateKeys is a DuplicateKeyHandler which fails decoding of any document
+// with duplicate keys.
+func RejectDuplicateKeys(key string, pointer string) error {
+	return NewJsonLdError(DuplicateKey, fmt.Sprintf("duplicate key %q in object at %q", key, pointer))
+}
+
+// DecodeDocument returns a document containing the contents of the JSON resource,
+// streamed from the given Reader. Unlike DocumentFromReader, it detects duplicate keys
+// in JSON objects and reports them to onDuplicate. A nil handler makes DecodeDocument
+// behave like DocumentFromReader.
+//
+// Duplicate keys may change the meaning of a document without notice (for example,
+// a second @context entry silently overrides the first one), so applications processing
+// untrusted input may want to reject them.
+func DecodeDocument(r io.Reader, onDuplicate DuplicateKeyHandler) (interface{}, error) {
+	document, err := decodeJSON(r, false, onDuplicate)
+	if err != nil {
+		if _, isLdError := err.(*JsonLdError); isLdError {
+			return nil, err
+		}
+		return nil, NewJsonLdError(LoadingDocumentFailed, err)
+	}
+	return document, nil
+}
+
+// decodeJSON decodes a JSON value from the given Reader. If useNumber is true, numbers
+// are decoded as json.Number. Duplicate keys are reported to onDuplicate, if set.
+func decodeJSON(r io.Reader, useNumber bool, onDuplicate DuplicateKeyHandler) (interface{}, error) {
+	dec := json.NewDecoder(r)
+	if useNumber {
+		dec.UseNumber()
+	}
+	if onDuplicate == nil {
+		var document interface{}
+		if err := dec.Decode(&document); err != nil {
+			return nil, err
+		}
+		return document, nil
+	}
+	return decodeValue(dec, "", onDuplicate)
+}
+
+// decodeRawJSON decodes raw JSON given as []byte, json.RawMessage or io.Reader.
+// Numbers are decoded as json.Number to keep their precision. Other values
+// are returned as is. Decoding errors are reported with the given code and
+// duplicate keys are reported to onDuplicate, if set.
+func decodeRawJSON(v interface{}, code ErrorCode, onDuplicate DuplicateKeyHandler) (interface{}, error) {
+	var r io.Reader
+	switch raw := v.(type) {
+	case json.RawMessage:
+		r = bytes.NewReader(raw)
+	case []byte:
+		r = bytes.NewReader(raw)
+	case io.Reader:
+		r = raw
+	default:
+		return v, nil
+	}
+
+	decoded, err := decodeJSON(r, true, onDuplicate)
+	if err != nil {
+		if _, isLdError := err.(*JsonLdError); isLdError {
+			return nil, err
+		}
+		return nil, NewJsonLdError(code, err)
+	}
+	return decoded, nil
+}
+
+// decodeValue decodes the next JSON value from the decoder, checking objects for duplicate keys.
+func decodeValue(dec *json.Decoder, pointer string, onDuplicate DuplicateKeyHandler) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	delim, isDelim := tok.(json.Delim)
+	if !isDelim {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := make(map[string]interface{})
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			if _, duplicate := obj[key]; duplicate {
+				if err := onDuplicate(key, pointer); err != nil {
+					return nil, err
+				}
+			}
+			obj[key], err = decodeValue(dec, pointer+"/"+escapePointerToken(key), onDuplicate)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// consume the closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case '[':
+		arr := make([]interface{}, 0)
+		for dec.More() {
+			item, err := decodeValue(dec, pointer+"/"+strconv.Itoa(len(arr)), onDuplicate)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, item)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %q", delim)
+	}
+}
+
+// escapePointerToken escapes a reference token of a JSON Pointer.
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -15,9 +15,12 @@
 package ld
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
@@ -85,6 +88,104 @@ func (l *Literal) Equal(n Node) bool {
 	return true
 }
 
+// LiteralsEqual compares two literals. If semantic is false, it's a strict comparison
+// of lexical values, datatypes and language tags, same as Literal.Equal.
+// If semantic is true, language tags are compared case-insensitively and values
+// of numeric and boolean XSD datatypes are compared by value, so that
+// "1"^^xsd:integer equals "01"^^xsd:integer and "true"^^xsd:boolean equals "1"^^xsd:boolean.
+// Literals of different datatypes are never equal. Invalid lexical forms and values
+// of other datatypes are compared as strings.
+func LiteralsEqual(a, b *Literal, semantic bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !semantic {
+		return a.Equal(b)
+	}
+
+	if a.Datatype != b.Datatype || !strings.EqualFold(a.Language, b.Language) {
+		return false
+	}
+	if a.Value == b.Value {
+		return true
+	}
+
+	switch {
+	case xsdIntegerTypes[a.Datatype]:
+		if patternInteger.MatchString(a.Value) && patternInteger.MatchString(b.Value) {
+			x, _ := new(big.Int).SetString(strings.TrimPrefix(a.Value, "+"), 10)
+			y, _ := new(big.Int).SetString(strings.TrimPrefix(b.Value, "+"), 10)
+			return x.Cmp(y) == 0
+		}
+	case a.Datatype == XSDDecimal:
+		if patternDecimal.MatchString(a.Value) && patternDecimal.MatchString(b.Value) {
+			x, okX := new(big.Rat).SetString(a.Value)
+			y, okY := new(big.Rat).SetString(b.Value)
+			if okX && okY {
+				return x.Cmp(y) == 0
+			}
+		}
+	case a.Datatype == XSDDouble || a.Datatype == XSDFloat:
+		x, okX := parseXSDFloat(a.Value)
+		y, okY := parseXSDFloat(b.Value)
+		if okX && okY {
+			// NaN is equal to NaN here, otherwise such literals would never deduplicate
+			return x == y || (math.IsNaN(x) && math.IsNaN(y))
+		}
+	case a.Datatype == XSDBoolean:
+		x, okX := parseXSDBoolean(a.Value)
+		y, okY := parseXSDBoolean(b.Value)
+		if okX && okY {
+			return x == y
+		}
+	}
+
+	return false
+}
+
+// xsdIntegerTypes lists xsd:integer and the datatypes derived from it.
+var xsdIntegerTypes = map[string]bool{
+	XSDInteger:                   true,
+	XSDNS + "long":               true,
+	XSDNS + "int":                true,
+	XSDNS + "short":              true,
+	XSDNS + "byte":               true,
+	XSDNS + "nonNegativeInteger": true,
+	XSDNS + "nonPositiveInteger": true,
+	XSDNS + "positiveInteger":    true,
+	XSDNS + "negativeInteger":    true,
+	XSDNS + "unsignedLong":       true,
+	XSDNS + "unsignedInt":        true,
+	XSDNS + "unsignedShort":      true,
+	XSDNS + "unsignedByte":       true,
+}
+
+func parseXSDFloat(value string) (float64, bool) {
+	switch value {
+	case "INF", "+INF":
+		return math.Inf(1), true
+	case "-INF":
+		return math.Inf(-1), true
+	case "NaN":
+		return math.NaN(), true
+	}
+	if !patternDouble.MatchString(value) {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	return f, err == nil
+}
+
+func parseXSDBoolean(value string) (bool, bool) {
+	switch value {
+	case "true", "1":
+		return true, true
+	case "false", "0":
+		return false, true
+	}
+	return false, false
+}
+
 // IRI represents an IRI value.
 type IRI struct {
 	Value string
@@ -161,9 +262,16 @@ func IsLiteral(node Node) bool {
 
 var patternInteger = regexp.MustCompile(`^[\-+]?\d+$`)
 var patternDouble = regexp.MustCompile(`^(\+|-)?(\d+(\.\d*)?|\.\d+)([Ee](\+|-)?\d+)?$`)
+var patternDecimal = regexp.MustCompile(`^(\+|-)?(\d+(\.\d*)?|\.\d+)$`)
 
 // RdfToObject converts an RDF triple object to a JSON-LD object.
 func RdfToObject(n Node, useNativeTypes bool) (map[string]interface{}, error) {
+	return rdfToObject(n, &JsonLdOptions{UseNativeTypes: useNativeTypes})
+}
+
+// rdfToObject converts an RDF triple object to a JSON-LD object, taking
+// UseNativeTypes, UseJSONNumber and UseNativeBinary options into account.
+func rdfToObject(n Node, opts *JsonLdOptions) (map[string]interface{}, error) {
 	// If value is an an IRI or a blank node identifier, return a new
 	// JSON object consisting
 	// of a single member @id whose value is set to value.
@@ -187,7 +295,14 @@ func RdfToObject(n Node, useNativeTypes bool) (map[string]interface{}, error) {
 		// add datatype
 		datatype := literal.Datatype
 		value := literal.Value
-		if useNativeTypes {
+		if opts.UseNativeBinary && datatype == XSDBase64Binary {
+			// keep the datatype, so that the value is encoded the same way by ToRDF.
+			// Unlike xsd:hexBinary, the JSON encoding of []byte is the lexical form of the literal.
+			if data, err := base64.StdEncoding.DecodeString(value); err == nil {
+				rval["@value"] = data
+			}
+			rval["@type"] = datatype
+		} else if opts.UseNativeTypes {
 			// use native datatypes for certain xsd types
 			if datatype == XSDString {
 				// don't add xsd:string
@@ -201,6 +316,12 @@ func RdfToObject(n Node, useNativeTypes bool) (map[string]interface{}, error) {
 					// boolean type in
 					rval["@type"] = datatype
 				}
+			} else if datatype == XSDInteger && opts.UseJSONNumber && patternInteger.MatchString(value) {
+				if i, ok := new(big.Int).SetString(value, 10); ok && i.String() == value {
+					rval["@value"] = json.Number(value)
+				} else {
+					rval["@type"] = datatype
+				}
 			} else if (datatype == XSDInteger && patternInteger.MatchString(value)) /* http://www.w3.org/TR/xmlschema11-2/#integer */ ||
 				(datatype == XSDDouble && patternDouble.MatchString(value)) /* http://www.w3.org/TR/xmlschema11-2/#nt-doubleRep */ {
 				d, _ := strconv.ParseFloat(value, 64)
@@ -209,6 +330,9 @@ func RdfToObject(n Node, useNativeTypes bool) (map[string]interface{}, error) {
 						i := int64(d)
 						if fmt.Sprintf("%d", i) == value {
 							rval["@value"] = i
+						} else {
+							// the value can't be represented as a native integer
+							rval["@type"] = datatype
 						}
 					} else if datatype == XSDDouble {
 						rval["@value"] = d
@@ -228,15 +352,66 @@ func RdfToObject(n Node, useNativeTypes bool) (map[string]interface{}, error) {
 	return rval, nil
 }
 
+// encodeBinary returns the canonical lexical form of binary data for the given datatype:
+// upper case hexadecimal digits for xsd:hexBinary and base64 otherwise.
+func encodeBinary(data []byte, datatype string) string {
+	if datatype == XSDHexBinary {
+		return strings.ToUpper(hex.EncodeToString(data))
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// maxExactInteger is the absolute value from which numbers are converted to xsd:double.
+var maxExactInteger = new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)
+
+// exactInteger returns the canonical lexical form of an integer number which doesn't
+// need to go through float64: a native Go integer or, if useJSONNumber is set,
+// a json.Number without a fractional part and an absolute value below 10^21.
+func exactInteger(value interface{}, useJSONNumber bool) (string, bool) {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case json.Number:
+		if !useJSONNumber {
+			return "", false
+		}
+		r, ok := new(big.Rat).SetString(v.String())
+		if !ok || !r.IsInt() || r.Num().CmpAbs(maxExactInteger) >= 0 {
+			return "", false
+		}
+		return r.Num().String(), true
+	default:
+		return "", false
+	}
+}
+
 // objectToRDF converts a JSON-LD value object to an RDF literal or a JSON-LD string or
 // node object to an RDF resource.
-func objectToRDF(item interface{}, issuer *IdentifierIssuer, graphName string, triples []*Quad) (Node, []*Quad) {
+func objectToRDF(item interface{}, issuer *IdentifierIssuer, graphName string, triples []*Quad,
+	opts *JsonLdOptions) (Node, []*Quad, error) {
 	// convert value object to RDF
 	if IsValue(item) {
 		itemMap := item.(map[string]interface{})
 		value := itemMap["@value"]
 		datatype := itemMap["@type"]
 
+		if direction, hasDirection := itemMap["@direction"].(string); hasDirection {
+			if opts.RdfDirection == RdfDirectionCompoundLiteral {
+				node, triples := directionToRDF(itemMap, direction, issuer, graphName, triples, opts)
+				return node, triples, nil
+			}
+			err := NewJsonLdError(BaseDirectionDropped, &ValueErrorDetails{
+				Message: "@direction can't be represented in RDF unless RdfDirection is set",
+				Value:   item,
+			})
+			if opts.SafeMode {
+				return nil, triples, err
+			}
+			opts.warn(err)
+		}
+
 		if datatype == "@json" {
 			datatype = RDFJSONLiteral
 		}
@@ -253,51 +428,80 @@ func objectToRDF(item interface{}, issuer *IdentifierIssuer, graphName string, t
 			// the logic above for discovering floats and integers will fail
 			// because they would be represented as json.Number and not float64.
 			// The code below takes care of it so it doesn't matter
-			// how the document was decoded from JSON.
-			if number, isNumber := value.(json.Number); isNumber {
-				var floatErr error
-				floatVal, floatErr = number.Float64()
-				isFloat = floatErr == nil
+			// how the document was decoded from JSON. Native integers
+			// (for example, produced by FromRDF with UseNativeTypes) are handled too.
+			floatVal, isFloat = floatValue(value)
+		}
+
+		datatypeStr, _ := datatype.(string)
+		if data, isBinary := value.([]byte); isBinary {
+			// binary data is encoded as xsd:base64Binary, unless another datatype is given
+			if datatype == nil {
+				datatypeStr = XSDBase64Binary
+			}
+			return opts.QuadAllocator.NewLiteral(encodeBinary(data, datatypeStr), datatypeStr, ""), triples, nil
+		}
+		if datatypeStr != XSDDouble {
+			if canonicalInteger, isExact := exactInteger(value, opts.UseJSONNumber); isExact {
+				if datatype == nil {
+					return opts.QuadAllocator.NewLiteral(canonicalInteger, XSDInteger, ""), triples, nil
+				}
+				return opts.QuadAllocator.NewLiteral(canonicalInteger, datatypeStr, ""), triples, nil
 			}
 		}
 
-		isInteger := isFloat && floatVal == float64(int64(floatVal))
+		var isInteger bool
+		if opts.LegacyNumberFormat {
+			isInteger = isFloat && floatVal == float64(int64(floatVal))
+		} else {
+			// numbers with a fractional part or an absolute value >= 1e21 are doubles
+			isInteger = isFloat && floatVal == math.Trunc(floatVal) && math.Abs(floatVal) < 1e21
+			if floatVal == 0 {
+				// avoid negative zero
+				floatVal = 0
+			}
+		}
 
-		datatypeStr, _ := datatype.(string)
 		if isBool || isFloat {
 			// convert to XSD datatype
 			if isBool {
 				if datatype == nil {
-					return NewLiteral(strconv.FormatBool(booleanVal), XSDBoolean, ""), triples
+					return opts.QuadAllocator.NewLiteral(strconv.FormatBool(booleanVal), XSDBoolean, ""), triples, nil
 				} else {
-					return NewLiteral(strconv.FormatBool(booleanVal), datatypeStr, ""), triples
+					return opts.QuadAllocator.NewLiteral(strconv.FormatBool(booleanVal), datatypeStr, ""), triples, nil
 				}
 			} else if (isFloat && !isInteger) || XSDDouble == datatypeStr {
 				canonicalDouble := GetCanonicalDouble(floatVal)
 				if datatype == nil {
-					return NewLiteral(canonicalDouble, XSDDouble, ""), triples
+					return opts.QuadAllocator.NewLiteral(canonicalDouble, XSDDouble, ""), triples, nil
 				} else {
-					return NewLiteral(canonicalDouble, datatypeStr, ""), triples
+					return opts.QuadAllocator.NewLiteral(canonicalDouble, datatypeStr, ""), triples, nil
 				}
 			} else {
+				var canonicalInteger string
+				if opts.LegacyNumberFormat {
+					canonicalInteger = fmt.Sprintf("%d", int64(floatVal))
+				} else {
+					canonicalInteger = strconv.FormatFloat(floatVal, 'f', 0, 64)
+				}
 				if datatype == nil {
-					return NewLiteral(fmt.Sprintf("%d", int64(floatVal)), XSDInteger, ""), triples
+					return opts.QuadAllocator.NewLiteral(canonicalInteger, XSDInteger, ""), triples, nil
 				} else {
-					return NewLiteral(fmt.Sprintf("%d", int64(floatVal)), datatype.(string), ""), triples
+					return opts.QuadAllocator.NewLiteral(canonicalInteger, datatype.(string), ""), triples, nil
 				}
 			}
 		} else if langVal, hasLang := itemMap["@language"]; hasLang {
 			if datatype == nil {
-				return NewLiteral(value.(string), RDFLangString, langVal.(string)), triples
+				return opts.QuadAllocator.NewLiteral(value.(string), RDFLangString, langVal.(string)), triples, nil
 			} else {
-				return NewLiteral(value.(string), datatype.(string), langVal.(string)), triples
+				return opts.QuadAllocator.NewLiteral(value.(string), datatype.(string), langVal.(string)), triples, nil
 			}
 		} else {
 			if datatype == nil {
-				return NewLiteral(value.(string), XSDString, ""), triples
+				return opts.QuadAllocator.NewLiteral(value.(string), XSDString, ""), triples, nil
 			} else {
 				if datatype != RDFJSONLiteral {
-					return NewLiteral(value.(string), datatype.(string), ""), triples
+					return opts.QuadAllocator.NewLiteral(value.(string), datatype.(string), ""), triples, nil
 				} else {
 					var jsonLiteralValByte []byte
 					switch v := value.(type) {
@@ -306,7 +510,7 @@ func objectToRDF(item interface{}, issuer *IdentifierIssuer, graphName string, t
 					case map[string]interface{}:
 						byteVal, err := json.Marshal(v)
 						if err != nil {
-							return NewLiteral("JSON Marshal error "+err.Error(), datatype.(string), ""), triples
+							return opts.QuadAllocator.NewLiteral("JSON Marshal error "+err.Error(), datatype.(string), ""), triples, nil
 						}
 
 						jsonLiteralValByte = byteVal
@@ -314,10 +518,10 @@ func objectToRDF(item interface{}, issuer *IdentifierIssuer, graphName string, t
 
 					canonicalJSON, err := jsoncanonicalizer.Transform(jsonLiteralValByte)
 					if err != nil {
-						return NewLiteral("JSON Canonicalization error "+err.Error(), datatype.(string), ""), triples
+						return opts.QuadAllocator.NewLiteral("JSON Canonicalization error "+err.Error(), datatype.(string), ""), triples, nil
 					}
 
-					return NewLiteral(string(canonicalJSON), datatype.(string), ""), triples
+					return opts.QuadAllocator.NewLiteral(string(canonicalJSON), datatype.(string), ""), triples, nil
 				}
 			}
 		}
@@ -325,28 +529,29 @@ func objectToRDF(item interface{}, issuer *IdentifierIssuer, graphName string, t
 		// if item is a list object, initialize list_results as an empty array,
 		// and object to the result of the List Conversion algorithm, passing
 		// the value associated with the @list key from item and list_results.
-		return parseList(item.(map[string]interface{})["@list"].([]interface{}), issuer, graphName, triples)
+		return parseList(item.(map[string]interface{})["@list"].([]interface{}), issuer, graphName, triples, opts)
 	} else {
 		// convert string/node object to RDF
 		var id string
 		if itemMap, isMap := item.(map[string]interface{}); isMap {
 			id = itemMap["@id"].(string)
 			if IsRelativeIri(id) {
-				return nil, triples
+				return nil, triples, nil
 			}
 		} else {
 			id = item.(string)
 		}
 		if strings.Index(id, "_:") == 0 {
 			// NOTE: once again no need to rename existing blank nodes
-			return NewBlankNode(id), triples
+			return opts.QuadAllocator.NewBlankNode(id), triples, nil
 		} else {
-			return NewIRI(id), triples
+			return opts.QuadAllocator.NewIRI(id), triples, nil
 		}
 	}
 }
 
-func parseList(list []interface{}, issuer *IdentifierIssuer, graphName string, triples []*Quad) (Node, []*Quad) {
+func parseList(list []interface{}, issuer *IdentifierIssuer, graphName string, triples []*Quad,
+	opts *JsonLdOptions) (Node, []*Quad, error) {
 
 	var res Node
 	var last interface{}
@@ -354,31 +559,59 @@ func parseList(list []interface{}, issuer *IdentifierIssuer, graphName string, t
 	// is result is the head of the list?
 	if len(list) > 0 {
 		last = list[len(list)-1]
-		res = NewBlankNode(issuer.GetId(""))
+		res = opts.QuadAllocator.NewBlankNode(issuer.GetId(""))
 	} else {
 		res = nilIRI
 	}
 	subj := res
 
 	var obj Node
+	var err error
 	for i := 0; i < len(list)-1; i++ {
-		obj, triples = objectToRDF(list[i], issuer, graphName, triples)
-		next := NewBlankNode(issuer.GetId(""))
+		obj, triples, err = objectToRDF(list[i], issuer, graphName, triples, opts)
+		if err != nil {
+			return nil, triples, err
+		}
+		next := opts.QuadAllocator.NewBlankNode(issuer.GetId(""))
 		triples = append(triples,
-			NewQuad(subj, first, obj, graphName),
-			NewQuad(subj, rest, next, graphName),
+			opts.QuadAllocator.NewQuad(subj, first, obj, graphName),
+			opts.QuadAllocator.NewQuad(subj, rest, next, graphName),
 		)
 		subj = next
 	}
 
 	// tail of list
 	if last != nil {
-		obj, triples = objectToRDF(last, issuer, graphName, triples)
+		obj, triples, err = objectToRDF(last, issuer, graphName, triples, opts)
+		if err != nil {
+			return nil, triples, err
+		}
 		triples = append(triples,
-			NewQuad(subj, first, obj, graphName),
-			NewQuad(subj, rest, nilIRI, graphName),
+			opts.QuadAllocator.NewQuad(subj, first, obj, graphName),
+			opts.QuadAllocator.NewQuad(subj, rest, nilIRI, graphName),
 		)
 	}
 
-	return res, triples
+	return res, triples, nil
+}
+
+// directionToRDF represents a string with base direction as a compound literal:
+// a blank node with rdf:value, rdf:direction and, if the value has a language, rdf:language.
+func directionToRDF(value map[string]interface{}, direction string, issuer *IdentifierIssuer, graphName string,
+	triples []*Quad, opts *JsonLdOptions) (Node, []*Quad) {
+	alloc := opts.QuadAllocator
+	node := alloc.NewBlankNode(issuer.GetId(""))
+	str, _ := value["@value"].(string)
+	triples = append(triples,
+		alloc.NewQuad(node, alloc.NewIRI(RDFValue), alloc.NewLiteral(str, XSDString, ""), graphName),
+	)
+	if lang, hasLang := value["@language"].(string); hasLang {
+		triples = append(triples,
+			alloc.NewQuad(node, alloc.NewIRI(RDFLanguage), alloc.NewLiteral(strings.ToLower(lang), XSDString, ""), graphName),
+		)
+	}
+	triples = append(triples,
+		alloc.NewQuad(node, alloc.NewIRI(RDFDirection), alloc.NewLiteral(direction, XSDString, ""), graphName),
+	)
+	return node, triples
 }
@@ -14,8 +14,29 @@
 
 package ld
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Embed string
 
+// EmptyValue is a set of flags which identify classes of empty values
+// removed from compacted output (see JsonLdOptions.OmitEmpty).
+type EmptyValue int
+
+const (
+	// EmptyNull matches null values.
+	EmptyNull EmptyValue = 1 << iota
+	// EmptyArray matches empty arrays.
+	EmptyArray
+	// EmptyObject matches empty objects.
+	EmptyObject
+
+	// EmptyAll matches all empty values.
+	EmptyAll = EmptyNull | EmptyArray | EmptyObject
+)
+
 const (
 	JsonLd_1_0       = "json-ld-1.0"              //nolint:stylecheck
 	JsonLd_1_1       = "json-ld-1.1"              //nolint:stylecheck
@@ -24,8 +45,28 @@ const (
 	EmbedLast   = "@last"
 	EmbedAlways = "@always"
 	EmbedNever  = "@never"
+
+	// DefaultMaxDepth is the default value of JsonLdOptions.MaxDepth. Note that documents
+	// nested deeper than that are rejected by default; set MaxDepth to 0 to process them.
+	DefaultMaxDepth = 1000
+
+	// SourceContextKey is the key under which Expand records the local @context
+	// of a node when JsonLdOptions.RetainSourceContext is set. It isn't a JSON-LD
+	// keyword or an IRI, so it must be removed before the output is processed further.
+	SourceContextKey = "__ld:sourceContext"
+
+	// RdfDirectionCompoundLiteral is the value of RdfDirection which represents
+	// base direction of strings as compound literals (blank nodes with rdf:value,
+	// rdf:direction and, optionally, rdf:language).
+	RdfDirectionCompoundLiteral = "compound-literal"
 )
 
+// TermSelector compares two terms, a and b, which are both mapped to the given IRI.
+// It returns a negative number if a should be preferred for compaction, a positive
+// number if b should be preferred, and zero if the default preference (the shortest
+// and then lexicographically least term) should apply.
+type TermSelector func(iri, a, b string) int
+
 // JsonLdOptions type as specified in the JSON-LD-API specification:
 // http://www.w3.org/TR/json-ld-api/#the-jsonldoptions-type
 type JsonLdOptions struct { //nolint:stylecheck
@@ -34,6 +75,11 @@ type JsonLdOptions struct { //nolint:stylecheck
 
 	// http://www.w3.org/TR/json-ld-api/#widl-JsonLdOptions-base
 	Base string
+	// BaseOverride, if set, is used as the base IRI instead of Base. Unlike Base,
+	// it is never inferred from the input (for example, from the URL of a remote document),
+	// which is useful for processing in-memory fragments of a logical document.
+	// See also WithBase.
+	BaseOverride string
 	// http://www.w3.org/TR/json-ld-api/#widl-JsonLdOptions-compactArrays
 	CompactArrays bool
 	// http://www.w3.org/TR/json-ld-api/#widl-JsonLdOptions-expandContext
@@ -43,6 +89,17 @@ type JsonLdOptions struct { //nolint:stylecheck
 	// http://www.w3.org/TR/json-ld-api/#widl-JsonLdOptions-documentLoader
 	DocumentLoader DocumentLoader
 
+	// TermSelector, if set, is consulted when several terms of the active context
+	// could be used to compact the same IRI, before the default preference applies.
+	// The term must still match the container, type and language of the value.
+	TermSelector TermSelector
+
+	// MaxDepth limits nesting of arrays and objects in documents processed by
+	// expansion, compaction and flattening, so that maliciously deep documents
+	// fail with MaxDepthExceeded error instead of exhausting the stack.
+	// NewJsonLdOptions sets it to DefaultMaxDepth. Zero means no limit.
+	MaxDepth int
+
 	// Frame options: http://json-ld.org/spec/latest/json-ld-framing/
 
 	Embed        Embed
@@ -52,11 +109,28 @@ type JsonLdOptions struct { //nolint:stylecheck
 	OmitDefault  bool
 	OmitGraph    bool
 
+	// FrameMaxDepth limits how deeply nodes may be embedded into each other
+	// during framing. Zero means no limit.
+	FrameMaxDepth int
+	// FrameMaxNodes limits the total number of node objects produced by framing.
+	// Zero means no limit.
+	FrameMaxNodes int
+	// FrameMismatchHandler, if set, is called each time a subject is rejected by
+	// a frame or subframe, with the constraint which the subject failed to satisfy.
+	// See FrameExplanation.
+	FrameMismatchHandler func(mismatch *FrameMismatch)
+
 	// RDF conversion options: http://www.w3.org/TR/json-ld-api/#serialize-rdf-as-json-ld-algorithm
 
 	UseRdfType            bool
 	UseNativeTypes        bool
 	ProduceGeneralizedRdf bool
+	// RdfDirection selects how base direction of strings is represented in RDF.
+	// When set to RdfDirectionCompoundLiteral, ToRDF converts value objects with @direction
+	// into compound literals and FromRDF converts compound literals back into value objects.
+	// Otherwise ToRDF drops @direction, reporting BaseDirectionDropped to WarningHandler,
+	// or failing with this error in SafeMode.
+	RdfDirection string
 
 	// The following properties aren't in the spec
 
@@ -66,55 +140,383 @@ type JsonLdOptions struct { //nolint:stylecheck
 	UseNamespaces bool
 	OutputForm    string
 	SafeMode      bool
+
+	// UpgradeProcessingMode makes the processor switch from json-ld-1.0 to json-ld-1.1
+	// processing mode when a context declares "@version": 1.1, instead of failing with
+	// a processing mode conflict. The upgrade only applies to the document being processed
+	// and is reported to WarningHandler.
+	UpgradeProcessingMode bool
+
+	// WarningHandler, if set, receives warnings about conditions which the processor
+	// recovered from, such as an upgrade of the processing mode (see UpgradeProcessingMode).
+	WarningHandler func(warning *JsonLdError)
+
+	// LegacyNumberFormat makes ToRDF format native numbers the way older versions
+	// of json-gold did, instead of using canonical XSD lexical forms. It is only useful
+	// for reproducing RDF (and signatures) created with these versions.
+	LegacyNumberFormat bool
+
+	// ConcurrentNormalization makes normalization detect connected components
+	// of blank nodes and hash independent components concurrently. It speeds up
+	// normalization of datasets with many blank node clusters on multi-core machines.
+	// The output is identical to the one produced without this option.
+	ConcurrentNormalization bool
+
+	// NormalizeGraphs, if not empty, restricts normalization to the default graph
+	// and the named graphs listed here. Other named graphs are dropped from the dataset
+	// before canonicalization. Graph names are matched as they appear in the dataset,
+	// so blank node graph names are only stable when the input is N-Quads.
+	NormalizeGraphs []string
+
+	// OmitEmpty makes compaction (and framing) drop properties whose compacted values
+	// are empty according to the given flags, for example EmptyArray|EmptyObject.
+	// Keyword entries are always kept. Zero value keeps all properties.
+	OmitEmpty EmptyValue
+
+	// BlankNodeRewriter, if set, is consulted by IRI compaction to replace blank node
+	// identifiers in the output, for example with more readable labels or skolem IRIs
+	// (see SkolemIRIRewriter). It must return the same replacement for the same identifier.
+	BlankNodeRewriter func(id string) string
+
+	// ExpandTracer, if set, receives events from the expansion algorithm: top-level nodes
+	// being expanded, contexts processed and term definitions used. See ExpansionReport.
+	// Tracing is disabled by default and has no cost in that case.
+	ExpandTracer ExpandTracer
+
+	// QuadAllocator, if set, is used by ToRDF to allocate quads and nodes in blocks,
+	// which reduces the number of allocations for large documents. The produced quads
+	// are only valid until QuadAllocator.Release is called.
+	QuadAllocator *QuadAllocator
+
+	// KeepDuplicateQuads disables removal of duplicate quads during ToRDF conversion.
+	// Duplicates appear when equivalent values are represented differently in the input,
+	// for example as a native number and as a typed value. Keeping them saves some time
+	// and memory on large documents when consumers tolerate duplicates.
+	KeepDuplicateQuads bool
+
+	// SkipSorting disables sorting of node properties during ToRDF conversion.
+	// It speeds up conversion of large documents when the order of the produced
+	// quads doesn't matter. Normalization always sorts its output regardless of this option.
+	SkipSorting bool
+
+	// RetainSourceContext makes Expand (and ExpandNDJSON) record the local @context
+	// of every node object that had one in the input under SourceContextKey, for
+	// provenance purposes. The context of a top-level object which only wraps @graph
+	// is recorded in the nodes of the graph instead. Other operations ignore this option.
+	RetainSourceContext bool
+
+	// UseJSONNumber enables lossless handling of numbers decoded as json.Number
+	// (see json.Decoder.UseNumber). ToRDF converts integer json.Number values to
+	// xsd:integer literals without going through float64, so that integers above 2^53,
+	// such as 64-bit database IDs, keep their precision. With UseNativeTypes,
+	// FromRDF represents xsd:integer literals as json.Number values.
+	// Documents retrieved by DocumentLoader are decoded by the loader: create
+	// DefaultDocumentLoader with WithJSONNumber to keep the precision of their numbers.
+	UseJSONNumber bool
+
+	// UseNativeBinary makes FromRDF represent xsd:base64Binary literals as []byte values
+	// (keeping the datatype). encoding/json marshals them as base64 strings, so the JSON output
+	// keeps the lexical form. xsd:hexBinary literals are kept as strings, as their []byte values
+	// would be marshalled as base64. Regardless of this option, ToRDF encodes []byte values
+	// as xsd:base64Binary literals, or xsd:hexBinary if that is the value's type.
+	UseNativeBinary bool
+
+	// Budget, if set, limits the number of nodes, quads and normalization permutations
+	// the call may produce. See Budget for details.
+	Budget *Budget
+
+	// AppendContext, if set, is a local context which Compact appends to the context
+	// it's given, both for compaction and in the @context of the result. Remote contexts
+	// of the original context are kept as references.
+	AppendContext interface{}
+
+	// InlineContexts makes Compact replace references to remote contexts in the @context
+	// of the result with the contents of these contexts. By default, the context given
+	// to Compact is emitted verbatim (a single element array is emitted as that element).
+	InlineContexts bool
+
+	// DeduplicateBlankNodes makes FromRDF merge structurally identical blank nodes,
+	// such as repeated address nodes, before conversion. This assumes that such blank nodes
+	// denote the same resource (see RDFDataset.MergeIsomorphicBlankNodes).
+	DeduplicateBlankNodes bool
+
+	// RDFSerializers maps RDF content types to serializers used by this call
+	// for the Format and InputFormat options, in preference to those registered
+	// with RegisterRDFSerializer.
+	RDFSerializers map[string]RDFSerializer
+
+	// CompactionReport, if set, collects statistics from Compact, Flatten and Frame:
+	// which context terms were used in the output and which IRIs couldn't be compacted.
+	CompactionReport *CompactionReport
+
+	// StrictIRICoercion makes expansion fail with InvalidTypedValue when a term with
+	// "@type": "@id" or "@type": "@vocab" has a value which isn't a string, such as a number
+	// or a boolean. By default, such values are kept as literals and reported to WarningHandler.
+	StrictIRICoercion bool
+
+	// UnmappedMembersKey, if set, makes Compact keep the top-level members of the input document
+	// which expansion drops because they don't map to an absolute IRI or keyword, such as
+	// bookkeeping entries of hybrid APIs. They are re-attached to the compacted output
+	// as a JSON object under this key. Compact fails if the compacted output already has a member
+	// with this key. Disabled by default.
+	UnmappedMembersKey string
+
+	// OnDuplicateKey, if set, is called for duplicate keys in JSON objects of contexts
+	// given as raw JSON ([]byte, json.RawMessage or io.Reader) and of ExpandNDJSON input,
+	// see DuplicateKeyHandler.
+	// Use RejectDuplicateKeys to fail processing of such contexts. Documents retrieved
+	// by DocumentLoader are checked by the loader (see WithDuplicateKeyHandler).
+	OnDuplicateKey DuplicateKeyHandler
+}
+
+// SkolemIRIRewriter returns a BlankNodeRewriter which replaces blank node identifiers
+// with skolem IRIs, as described in https://www.w3.org/TR/rdf11-concepts/#section-skolemization.
+// For example, with authority "http://example.com", _:b0 becomes
+// http://example.com/.well-known/genid/b0.
+func SkolemIRIRewriter(authority string) func(id string) string {
+	prefix := strings.TrimSuffix(authority, "/") + "/.well-known/genid/"
+	return func(id string) string {
+		return prefix + strings.TrimPrefix(id, "_:")
+	}
 }
 
 // NewJsonLdOptions creates and returns new instance of JsonLdOptions with the given base.
 func NewJsonLdOptions(base string) *JsonLdOptions { //nolint:stylecheck
 	return &JsonLdOptions{
-		Base:                  base,
-		CompactArrays:         true,
-		ProcessingMode:        JsonLd_1_1,
-		DocumentLoader:        NewDefaultDocumentLoader(nil),
-		Embed:                 EmbedLast,
-		Explicit:              false,
-		RequireAll:            true,
-		FrameDefault:          false,
-		OmitDefault:           false,
-		OmitGraph:             false,
-		UseRdfType:            false,
-		UseNativeTypes:        false,
-		ProduceGeneralizedRdf: false,
-		InputFormat:           "",
-		Format:                "",
-		Algorithm:             AlgorithmURGNA2012,
-		UseNamespaces:         false,
-		OutputForm:            "",
-		SafeMode:              false,
+		Base:                    base,
+		BaseOverride:            "",
+		CompactArrays:           true,
+		ProcessingMode:          JsonLd_1_1,
+		DocumentLoader:          NewDefaultDocumentLoader(nil),
+		TermSelector:            nil,
+		MaxDepth:                DefaultMaxDepth,
+		Embed:                   EmbedLast,
+		Explicit:                false,
+		RequireAll:              true,
+		FrameDefault:            false,
+		OmitDefault:             false,
+		OmitGraph:               false,
+		FrameMaxDepth:           0,
+		FrameMaxNodes:           0,
+		FrameMismatchHandler:    nil,
+		UseRdfType:              false,
+		UseNativeTypes:          false,
+		ProduceGeneralizedRdf:   false,
+		RdfDirection:            "",
+		InputFormat:             "",
+		Format:                  "",
+		Algorithm:               AlgorithmURGNA2012,
+		UseNamespaces:           false,
+		OutputForm:              "",
+		SafeMode:                false,
+		UpgradeProcessingMode:   false,
+		WarningHandler:          nil,
+		LegacyNumberFormat:      false,
+		ConcurrentNormalization: false,
+		NormalizeGraphs:         nil,
+		OmitEmpty:               0,
+		BlankNodeRewriter:       nil,
+		ExpandTracer:            nil,
+		QuadAllocator:           nil,
+		KeepDuplicateQuads:      false,
+		SkipSorting:             false,
+		RetainSourceContext:     false,
+		UseJSONNumber:           false,
+		UseNativeBinary:         false,
+		Budget:                  nil,
+		AppendContext:           nil,
+		InlineContexts:          false,
+		DeduplicateBlankNodes:   false,
+		StrictIRICoercion:       false,
+		UnmappedMembersKey:      "",
+		OnDuplicateKey:          nil,
 	}
 }
 
 // Copy creates a deep copy of JsonLdOptions object.
 func (opt *JsonLdOptions) Copy() *JsonLdOptions {
 	return &JsonLdOptions{
-		Base:                  opt.Base,
-		CompactArrays:         opt.CompactArrays,
-		ExpandContext:         opt.ExpandContext,
-		ProcessingMode:        opt.ProcessingMode,
-		DocumentLoader:        opt.DocumentLoader,
-		Embed:                 opt.Embed,
-		Explicit:              opt.Explicit,
-		RequireAll:            opt.RequireAll,
-		FrameDefault:          opt.FrameDefault,
-		OmitDefault:           opt.OmitDefault,
-		OmitGraph:             opt.OmitGraph,
-		UseRdfType:            opt.UseRdfType,
-		UseNativeTypes:        opt.UseNativeTypes,
-		ProduceGeneralizedRdf: opt.ProduceGeneralizedRdf,
-		InputFormat:           opt.InputFormat,
-		Format:                opt.Format,
-		Algorithm:             opt.Algorithm,
-		UseNamespaces:         opt.UseNamespaces,
-		OutputForm:            opt.OutputForm,
-		SafeMode:              opt.SafeMode,
+		Base:                    opt.Base,
+		BaseOverride:            opt.BaseOverride,
+		CompactArrays:           opt.CompactArrays,
+		ExpandContext:           opt.ExpandContext,
+		ProcessingMode:          opt.ProcessingMode,
+		DocumentLoader:          opt.DocumentLoader,
+		TermSelector:            opt.TermSelector,
+		MaxDepth:                opt.MaxDepth,
+		Embed:                   opt.Embed,
+		Explicit:                opt.Explicit,
+		RequireAll:              opt.RequireAll,
+		FrameDefault:            opt.FrameDefault,
+		OmitDefault:             opt.OmitDefault,
+		OmitGraph:               opt.OmitGraph,
+		FrameMaxDepth:           opt.FrameMaxDepth,
+		FrameMaxNodes:           opt.FrameMaxNodes,
+		FrameMismatchHandler:    opt.FrameMismatchHandler,
+		UseRdfType:              opt.UseRdfType,
+		UseNativeTypes:          opt.UseNativeTypes,
+		ProduceGeneralizedRdf:   opt.ProduceGeneralizedRdf,
+		RdfDirection:            opt.RdfDirection,
+		InputFormat:             opt.InputFormat,
+		Format:                  opt.Format,
+		Algorithm:               opt.Algorithm,
+		UseNamespaces:           opt.UseNamespaces,
+		OutputForm:              opt.OutputForm,
+		SafeMode:                opt.SafeMode,
+		UpgradeProcessingMode:   opt.UpgradeProcessingMode,
+		WarningHandler:          opt.WarningHandler,
+		LegacyNumberFormat:      opt.LegacyNumberFormat,
+		ConcurrentNormalization: opt.ConcurrentNormalization,
+		NormalizeGraphs:         append([]string(nil), opt.NormalizeGraphs...),
+		OmitEmpty:               opt.OmitEmpty,
+		BlankNodeRewriter:       opt.BlankNodeRewriter,
+		ExpandTracer:            opt.ExpandTracer,
+		QuadAllocator:           opt.QuadAllocator,
+		KeepDuplicateQuads:      opt.KeepDuplicateQuads,
+		SkipSorting:             opt.SkipSorting,
+		RetainSourceContext:     opt.RetainSourceContext,
+		UseJSONNumber:           opt.UseJSONNumber,
+		UseNativeBinary:         opt.UseNativeBinary,
+		Budget:                  opt.Budget,
+		AppendContext:           CloneDocument(opt.AppendContext),
+		InlineContexts:          opt.InlineContexts,
+		DeduplicateBlankNodes:   opt.DeduplicateBlankNodes,
+		RDFSerializers:          copyRDFSerializers(opt.RDFSerializers),
+		CompactionReport:        opt.CompactionReport,
+		StrictIRICoercion:       opt.StrictIRICoercion,
+		UnmappedMembersKey:      opt.UnmappedMembersKey,
+		OnDuplicateKey:          opt.OnDuplicateKey,
+	}
+}
+
+func copyRDFSerializers(serializers map[string]RDFSerializer) map[string]RDFSerializer {
+	if serializers == nil {
+		return nil
 	}
+	rval := make(map[string]RDFSerializer, len(serializers))
+	for format, serializer := range serializers {
+		rval[format] = serializer
+	}
+	return rval
+}
+
+// WithBase returns a copy of the options with BaseOverride set to the given base IRI.
+// It allows the base to be specified for a single processor call:
+//
+//	expanded, err := proc.Expand(fragment, opts.WithBase("http://example.com/doc"))
+//
+// If opt is nil, default options are used.
+func (opt *JsonLdOptions) WithBase(base string) *JsonLdOptions {
+	var rval *JsonLdOptions
+	if opt == nil {
+		rval = NewJsonLdOptions("")
+	} else {
+		rval = opt.Copy()
+	}
+	rval.BaseOverride = base
+	return rval
+}
+
+// warn reports a warning to WarningHandler, if set.
+func (opt *JsonLdOptions) warn(warning *JsonLdError) {
+	if opt.WarningHandler != nil {
+		opt.WarningHandler(warning)
+	}
+}
+
+// base returns the base IRI of the operation, taking BaseOverride into account.
+func (opt *JsonLdOptions) base() string {
+	if opt.BaseOverride != "" {
+		return opt.BaseOverride
+	}
+	return opt.Base
+}
+
+// Validate checks the options for unsupported or contradictory settings,
+// so that applications may fail fast before any processing begins.
+// Note that input-dependent checks (for example, InputFormat used with
+// a non-string input) are performed by the processor itself.
+func (opt *JsonLdOptions) Validate() error {
+	switch opt.ProcessingMode {
+	case JsonLd_1_0, JsonLd_1_1, JsonLd_1_1_Frame:
+	default:
+		return NewJsonLdError(InvalidInput, fmt.Sprintf("unknown processing mode: %s", opt.ProcessingMode))
+	}
+
+	switch opt.Embed {
+	case EmbedLast, EmbedAlways, EmbedNever:
+	default:
+		return NewJsonLdError(InvalidEmbedValue, fmt.Sprintf("invalid value of Embed: %s", opt.Embed))
+	}
+
+	if opt.FrameMaxDepth < 0 || opt.FrameMaxNodes < 0 {
+		return NewJsonLdError(InvalidInput, "framing limits must not be negative")
+	}
+
+	if opt.MaxDepth < 0 {
+		return NewJsonLdError(InvalidInput, "max depth must not be negative")
+	}
+
+	switch opt.RdfDirection {
+	case "", RdfDirectionCompoundLiteral:
+	default:
+		return NewJsonLdError(InvalidInput, fmt.Sprintf("unsupported value of RdfDirection: %s", opt.RdfDirection))
+	}
+
+	if opt.DocumentLoader == nil {
+		return NewJsonLdError(InvalidInput, "document loader must be set")
+	}
+
+	if _, found := lookupCanonicalizer(opt.Algorithm); !found {
+		return NewJsonLdError(InvalidInput, fmt.Sprintf("unknown normalization algorithm: %s", opt.Algorithm))
+	}
+
+	if opt.InputFormat != "" && !isSupportedRDFFormat(opt, opt.InputFormat, true) {
+		return NewJsonLdError(UnknownFormat, opt.InputFormat)
+	}
+
+	if opt.Format != "" && !isSupportedRDFFormat(opt, opt.Format, false) {
+		return NewJsonLdError(UnknownFormat, opt.Format)
+	}
+
+	switch opt.OutputForm {
+	case "", "expanded", "compacted", "flattened":
+	default:
+		return NewJsonLdError(UnknownFormat, fmt.Sprintf("unknown output form: %s", opt.OutputForm))
+	}
+
+	return nil
+}
+
+// Features describes the capabilities of this build of json-gold.
+type Features struct {
+	ProcessingModes         []string `json:"processingModes"`
+	RDFFormats              []string `json:"rdfFormats"`
+	NormalizationAlgorithms []string `json:"normalizationAlgorithms"`
+}
+
+// SupportedFeatures returns the processing modes, RDF formats (including those registered
+// with RegisterRDFSerializer) and normalization algorithms (including those registered
+// with RegisterCanonicalizer) supported by json-gold.
+func SupportedFeatures() *Features {
+	return &Features{
+		ProcessingModes:         []string{JsonLd_1_0, JsonLd_1_1},
+		RDFFormats:              rdfSerializerFormats(),
+		NormalizationAlgorithms: canonicalizerNames(),
+	}
+}
+
+// isSupportedRDFFormat returns true if there is a serializer for the given format, either
+// in opts.RDFSerializers or registered with RegisterRDFSerializer. opts may be nil.
+// If parse is true, the serializer must also be able to parse the format.
+func isSupportedRDFFormat(opts *JsonLdOptions, format string, parse bool) bool {
+	serializer, found := lookupRDFSerializer(opts, format)
+	return found && (!parse || canParseRDF(serializer))
+}
+
+// canParseRDF returns false for serializers which are known not to implement parsing.
+// Other serializers are assumed to implement it.
+func canParseRDF(serializer RDFSerializer) bool {
+	_, isTurtle := serializer.(*TurtleRDFSerializer)
+	return !isTurtle
 }
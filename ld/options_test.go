@@ -1,32 +1,145 @@
 package ld
 
 import (
+	"encoding/json"
+	"runtime/debug"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJsonLdOptions_Copy(t *testing.T) {
 	expected := JsonLdOptions{
-		Base:                  "base",
-		CompactArrays:         true,
-		ProcessingMode:        JsonLd_1_1,
-		DocumentLoader:        NewDefaultDocumentLoader(nil),
-		Embed:                 EmbedLast,
-		Explicit:              true,
-		RequireAll:            true,
-		FrameDefault:          true,
-		OmitDefault:           true,
-		OmitGraph:             true,
-		UseRdfType:            true,
-		UseNativeTypes:        true,
-		ProduceGeneralizedRdf: true,
-		InputFormat:           "input",
-		Format:                "format",
-		Algorithm:             AlgorithmURGNA2012,
-		UseNamespaces:         true,
-		OutputForm:            "output",
-		SafeMode:              true,
+		Base:                    "base",
+		BaseOverride:            "override",
+		CompactArrays:           true,
+		ProcessingMode:          JsonLd_1_1,
+		DocumentLoader:          NewDefaultDocumentLoader(nil),
+		MaxDepth:                50,
+		Embed:                   EmbedLast,
+		Explicit:                true,
+		RequireAll:              true,
+		FrameDefault:            true,
+		OmitDefault:             true,
+		OmitGraph:               true,
+		FrameMaxDepth:           10,
+		FrameMaxNodes:           100,
+		UseRdfType:              true,
+		UseNativeTypes:          true,
+		ProduceGeneralizedRdf:   true,
+		RdfDirection:            RdfDirectionCompoundLiteral,
+		InputFormat:             "input",
+		Format:                  "format",
+		Algorithm:               AlgorithmURGNA2012,
+		UseNamespaces:           true,
+		OutputForm:              "output",
+		SafeMode:                true,
+		UpgradeProcessingMode:   true,
+		LegacyNumberFormat:      true,
+		ConcurrentNormalization: true,
+		NormalizeGraphs:         []string{"http://example.com/g1"},
+		OmitEmpty:               EmptyArray | EmptyObject,
+		ExpandTracer:            NewExpansionReport(),
+		KeepDuplicateQuads:      true,
+		SkipSorting:             true,
+		RetainSourceContext:     true,
+		UseJSONNumber:           true,
+		UseNativeBinary:         true,
+		Budget:                  NewBudget(1, 2, 3),
+		AppendContext:           map[string]interface{}{"name": "http://schema.org/name"},
+		InlineContexts:          true,
+		DeduplicateBlankNodes:   true,
+		RDFSerializers:          map[string]RDFSerializer{"text/turtle": &NQuadRDFSerializer{}},
+		CompactionReport:        NewCompactionReport(),
+		StrictIRICoercion:       true,
+		UnmappedMembersKey:      "_meta",
 	}
-	assert.Equal(t, expected, *expected.Copy())
+	copied := expected.Copy()
+	assert.Equal(t, expected, *copied)
+
+	copied.NormalizeGraphs[0] = "http://example.com/g2"
+	assert.Equal(t, "http://example.com/g1", expected.NormalizeGraphs[0])
+
+	delete(copied.RDFSerializers, "text/turtle")
+	assert.Contains(t, expected.RDFSerializers, "text/turtle")
+}
+
+func TestJsonLdOptions_Validate(t *testing.T) {
+	assert.NoError(t, NewJsonLdOptions("").Validate())
+
+	opts := NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	opts.InputFormat = "application/nquads"
+	assert.NoError(t, opts.Validate())
+
+	// formats of serializers for a single call are supported too
+	opts.Format = "application/x-custom"
+	opts.InputFormat = "application/x-custom"
+	opts.RDFSerializers = map[string]RDFSerializer{"application/x-custom": &NQuadRDFSerializer{}}
+	assert.NoError(t, opts.Validate())
+
+	for name, modify := range map[string]func(o *JsonLdOptions){
+		"processing mode": func(o *JsonLdOptions) { o.ProcessingMode = "json-ld-2.0" },
+		"embed":           func(o *JsonLdOptions) { o.Embed = "@sometimes" },
+		"document loader": func(o *JsonLdOptions) { o.DocumentLoader = nil },
+		"algorithm":       func(o *JsonLdOptions) { o.Algorithm = "RDFC-2.0" },
+		"input format":    func(o *JsonLdOptions) { o.InputFormat = "text/turtle" },
+		"format":          func(o *JsonLdOptions) { o.Format = "application/rdf+xml" },
+		"output form":     func(o *JsonLdOptions) { o.OutputForm = "framed" },
+		"frame max depth": func(o *JsonLdOptions) { o.FrameMaxDepth = -1 },
+		"max depth":       func(o *JsonLdOptions) { o.MaxDepth = -1 },
+		"rdf direction":   func(o *JsonLdOptions) { o.RdfDirection = "i18n-literal" },
+	} {
+		opts := NewJsonLdOptions("")
+		modify(opts)
+		assert.Error(t, opts.Validate(), name)
+	}
+}
+
+func TestSupportedFeatures(t *testing.T) {
+	features := SupportedFeatures()
+	assert.Equal(t, []string{"application/n-quads", "application/nquads", "application/trig", "text/turtle"}, features.RDFFormats)
+	assert.Contains(t, features.NormalizationAlgorithms, AlgorithmURDNA2015)
+	assert.Contains(t, features.ProcessingModes, JsonLd_1_1)
+}
+
+func TestCapabilities(t *testing.T) {
+	c := Capabilities()
+	assert.Equal(t, Version, c.Version)
+	assert.Equal(t, []string{"1.0", "1.1"}, c.SpecVersions)
+	assert.Equal(t, []string{"application/n-quads", "application/nquads"}, c.RDFInputFormats)
+	assert.Equal(t, *SupportedFeatures(), c.Features)
+
+	b, err := json.Marshal(c)
+	require.NoError(t, err)
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &m))
+	assert.Equal(t, Version, m["version"])
+	assert.Contains(t, m["normalizationAlgorithms"], AlgorithmURDNA2015)
+}
+
+func TestModuleVersion(t *testing.T) {
+	assert.Equal(t, "", moduleVersion(nil, false))
+	assert.Equal(t, "(devel)", moduleVersion(&debug.BuildInfo{
+		Main: debug.Module{Path: modulePath, Version: "(devel)"},
+	}, true))
+
+	info := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "github.com/pquerna/cachecontrol", Version: "v0.1.0"},
+			{Path: modulePath, Version: "v0.5.1"},
+		},
+	}
+	assert.Equal(t, "v0.5.1", moduleVersion(info, true))
+
+	info.Deps[1].Replace = &debug.Module{Path: "example.com/fork/json-gold", Version: "v0.5.2"}
+	assert.Equal(t, "v0.5.2", moduleVersion(info, true))
+
+	// a replacement with a local directory has no version
+	info.Deps[1].Replace = &debug.Module{Path: "../json-gold"}
+	assert.Equal(t, "v0.5.1", moduleVersion(info, true))
+
+	assert.Equal(t, "", moduleVersion(&debug.BuildInfo{Main: debug.Module{Path: "example.com/app"}}, true))
 }
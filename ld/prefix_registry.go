@@ -0,0 +1,129 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PrefixRegistry maps well-known prefixes to namespace IRIs. It's used to synthesize
+// a context for documents which use CURIEs without declaring their prefixes:
+//
+//	registry, err := ld.LoadPrefixRegistry(f) // e.g. a prefix.cc dump
+//	opts.ExpandContext = registry.ContextFor(doc)
+//	expanded, err := proc.Expand(doc, opts)
+//
+// Since ExpandContext is processed before any context of the document itself,
+// prefixes declared by the document always take precedence over the registry.
+type PrefixRegistry map[string]string
+
+// LoadPrefixRegistry reads a prefix registry from a JSON object which maps prefixes
+// to namespace IRIs (such as the "all.file.json" dump of prefix.cc), or from
+// a JSON-LD context document with such mappings (such as "all.file.jsonld").
+// Entries which aren't simple prefix mappings are ignored.
+func LoadPrefixRegistry(r io.Reader) (PrefixRegistry, error) {
+	doc, err := DocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	docMap, isMap := doc.(map[string]interface{})
+	if !isMap {
+		return nil, NewJsonLdError(InvalidInput, fmt.Sprintf("prefix registry must be a JSON object, got %T", doc))
+	}
+	if ctx, hasContext := docMap["@context"]; hasContext {
+		if docMap, isMap = ctx.(map[string]interface{}); !isMap {
+			return nil, NewJsonLdError(InvalidInput, "@context of prefix registry must be an object")
+		}
+	}
+
+	registry := make(PrefixRegistry, len(docMap))
+	for prefix, ns := range docMap {
+		if nsStr, isString := ns.(string); isString && !IsKeyword(prefix) && IsAbsoluteIri(nsStr) {
+			registry[prefix] = nsStr
+		}
+	}
+	return registry, nil
+}
+
+// ContextFor returns a context which declares all prefixes from the registry used in
+// the given document in property names, @id and @type values. The result is nil if
+// the document doesn't use any of the registered prefixes. Prefixes declared in
+// embedded contexts of the document aren't included.
+func (r PrefixRegistry) ContextFor(doc interface{}) map[string]interface{} {
+	declared := make(map[string]bool)
+	used := make(map[string]bool)
+	r.collectPrefixes(doc, declared, used)
+
+	var ctx map[string]interface{}
+	for prefix := range used {
+		if declared[prefix] {
+			continue
+		}
+		if ctx == nil {
+			ctx = make(map[string]interface{})
+		}
+		ctx[prefix] = r[prefix]
+	}
+	return ctx
+}
+
+func (r PrefixRegistry) collectPrefixes(element interface{}, declared, used map[string]bool) {
+	switch elem := element.(type) {
+	case []interface{}:
+		for _, item := range elem {
+			r.collectPrefixes(item, declared, used)
+		}
+	case map[string]interface{}:
+		for key, value := range elem {
+			switch key {
+			case "@context":
+				collectDeclaredTerms(value, declared)
+			case "@id", "@type":
+				for _, v := range Arrayify(value) {
+					if s, isString := v.(string); isString {
+						r.usePrefix(s, used)
+					}
+				}
+			default:
+				r.usePrefix(key, used)
+				r.collectPrefixes(value, declared, used)
+			}
+		}
+	}
+}
+
+// usePrefix marks the prefix of the given compact IRI as used, if it's registered.
+func (r PrefixRegistry) usePrefix(value string, used map[string]bool) {
+	idx := strings.Index(value, ":")
+	if idx <= 0 || strings.HasPrefix(value[idx+1:], "//") {
+		return
+	}
+	if prefix := value[:idx]; r[prefix] != "" {
+		used[prefix] = true
+	}
+}
+
+// collectDeclaredTerms adds the terms defined in the given (embedded) context to declared.
+func collectDeclaredTerms(ctx interface{}, declared map[string]bool) {
+	for _, c := range Arrayify(ctx) {
+		if ctxMap, isMap := c.(map[string]interface{}); isMap {
+			for term := range ctxMap {
+				declared[term] = true
+			}
+		}
+	}
+}
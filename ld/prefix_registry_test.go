@@ -0,0 +1,90 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPrefixRegistry(t *testing.T) {
+	registry, err := LoadPrefixRegistry(strings.NewReader(`{
+		"foaf": "http://xmlns.com/foaf/0.1/",
+		"dc": "http://purl.org/dc/terms/",
+		"bad": "not an IRI",
+		"@vocab": "http://example.com/"
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, PrefixRegistry{
+		"foaf": "http://xmlns.com/foaf/0.1/",
+		"dc":   "http://purl.org/dc/terms/",
+	}, registry)
+
+	registry, err = LoadPrefixRegistry(strings.NewReader(`{"@context": {"foaf": "http://xmlns.com/foaf/0.1/"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, PrefixRegistry{"foaf": "http://xmlns.com/foaf/0.1/"}, registry)
+
+	_, err = LoadPrefixRegistry(strings.NewReader(`["foaf"]`))
+	assert.Error(t, err)
+}
+
+func TestPrefixRegistry_ContextFor(t *testing.T) {
+	registry := PrefixRegistry{
+		"foaf":   "http://xmlns.com/foaf/0.1/",
+		"dc":     "http://purl.org/dc/terms/",
+		"schema": "http://schema.org/",
+		"http":   "http://example.com/http#",
+	}
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"dc": "http://example.com/my-dc#",
+		},
+		"@id":       "http://example.com/me",
+		"@type":     "foaf:Person",
+		"foaf:name": "Alice",
+		"dc:title":  "Dr",
+		"foaf:knows": map[string]interface{}{
+			"@id":           "ex:bob",
+			"http://ex/age": 42.0,
+		},
+	}
+
+	ctx := registry.ContextFor(doc)
+	assert.Equal(t, map[string]interface{}{"foaf": "http://xmlns.com/foaf/0.1/"}, ctx)
+	assert.Nil(t, registry.ContextFor(map[string]interface{}{"name": "Alice"}))
+
+	opts := NewJsonLdOptions("")
+	opts.ExpandContext = ctx
+	expanded, err := NewJsonLdProcessor().Expand(doc, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id":                            "http://example.com/me",
+			"@type":                          []interface{}{"http://xmlns.com/foaf/0.1/Person"},
+			"http://xmlns.com/foaf/0.1/name": []interface{}{map[string]interface{}{"@value": "Alice"}},
+			"http://example.com/my-dc#title": []interface{}{map[string]interface{}{"@value": "Dr"}},
+			"http://xmlns.com/foaf/0.1/knows": []interface{}{
+				map[string]interface{}{
+					"@id":           "ex:bob",
+					"http://ex/age": []interface{}{map[string]interface{}{"@value": 42.0}},
+				},
+			},
+		},
+	}, expanded)
+}
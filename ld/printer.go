@@ -0,0 +1,269 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorReset = "\x1b[0m"
+
+	diffContextLines = 3
+)
+
+// DocumentPrinter pretty-prints JSON-LD documents. It is useful for debugging
+// and for reporting differences between documents in tests.
+//
+// The zero value prints documents with all object keys sorted alphabetically.
+type DocumentPrinter struct {
+	// KeywordsFirst makes the printer output JSON-LD keywords before other keys
+	// of each object, starting with @context, @id and @type.
+	KeywordsFirst bool
+	// Color enables ANSI terminal colors in diff output.
+	Color bool
+}
+
+// FprintDocument writes a pretty-printed JSON-LD document to w,
+// preceded by msg if it isn't empty. Object keys are sorted, so the output is stable.
+func FprintDocument(w io.Writer, msg string, doc interface{}) error {
+	return (&DocumentPrinter{}).Fprint(w, msg, doc)
+}
+
+// DiffDocuments writes a unified diff of pretty-printed forms of documents a and b to w.
+// Nothing is written if the documents are identical.
+//
+// Returns true if the documents differ.
+func DiffDocuments(w io.Writer, a, b interface{}) (bool, error) {
+	return (&DocumentPrinter{}).Diff(w, a, b)
+}
+
+// Fprint writes a pretty-printed JSON-LD document to w, preceded by msg if it isn't empty.
+func (p *DocumentPrinter) Fprint(w io.Writer, msg string, doc interface{}) error {
+	out, err := p.format(doc)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if msg != "" {
+		buf.WriteString(msg)
+		buf.WriteByte('\n')
+	}
+	buf.Write(out)
+	buf.WriteByte('\n')
+
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		return NewJsonLdError(IOError, err)
+	}
+	return nil
+}
+
+// Diff writes a unified diff of pretty-printed forms of documents a and b to w.
+// Nothing is written if the documents are identical.
+//
+// Returns true if the documents differ.
+func (p *DocumentPrinter) Diff(w io.Writer, a, b interface{}) (bool, error) {
+	aOut, err := p.format(a)
+	if err != nil {
+		return false, err
+	}
+	bOut, err := p.format(b)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(aOut, bOut) {
+		return false, nil
+	}
+
+	aLines := strings.Split(string(aOut), "\n")
+	bLines := strings.Split(string(bOut), "\n")
+	// autojunk is disabled, as it skips frequent lines, such as closing brackets, in large documents
+	matcher := difflib.NewMatcherWithJunk(aLines, bLines, false, nil)
+
+	var buf bytes.Buffer
+	p.writeDiffLine(&buf, colorRed, "--- a")
+	p.writeDiffLine(&buf, colorGreen, "+++ b")
+	for _, group := range matcher.GetGroupedOpCodes(diffContextLines) {
+		p.writeDiffLine(&buf, colorCyan, hunkHeader(group))
+		for _, c := range group {
+			if c.Tag == 'e' {
+				for _, line := range aLines[c.I1:c.I2] {
+					p.writeDiffLine(&buf, "", " "+line)
+				}
+				continue
+			}
+			if c.Tag == 'r' || c.Tag == 'd' {
+				for _, line := range aLines[c.I1:c.I2] {
+					p.writeDiffLine(&buf, colorRed, "-"+line)
+				}
+			}
+			if c.Tag == 'r' || c.Tag == 'i' {
+				for _, line := range bLines[c.J1:c.J2] {
+					p.writeDiffLine(&buf, colorGreen, "+"+line)
+				}
+			}
+		}
+	}
+
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		return true, NewJsonLdError(IOError, err)
+	}
+	return true, nil
+}
+
+func (p *DocumentPrinter) writeDiffLine(buf *bytes.Buffer, color string, line string) {
+	if p.Color && color != "" {
+		buf.WriteString(color)
+		buf.WriteString(line)
+		buf.WriteString(colorReset)
+	} else {
+		buf.WriteString(line)
+	}
+	buf.WriteByte('\n')
+}
+
+// format returns the indented JSON representation of the document.
+func (p *DocumentPrinter) format(doc interface{}) ([]byte, error) {
+	// convert the document into its generic form, so that structs, *RemoteDocument
+	// and other values are printed the same way as parsed JSON
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, NewJsonLdError(InvalidInput, err)
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err = dec.Decode(&generic); err != nil {
+		return nil, NewJsonLdError(InvalidInput, err)
+	}
+
+	var buf bytes.Buffer
+	if err = p.writeValue(&buf, generic, ""); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *DocumentPrinter) writeValue(buf *bytes.Buffer, v interface{}, indent string) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, key := range p.orderedKeys(val) {
+			buf.WriteString(indent + "  ")
+			if err := writeScalar(buf, key); err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+			if err := p.writeValue(buf, val[key], indent+"  "); err != nil {
+				return err
+			}
+			if i < len(val)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case []interface{}:
+		if len(val) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range val {
+			buf.WriteString(indent + "  ")
+			if err := p.writeValue(buf, item, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(val)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	default:
+		return writeScalar(buf, val)
+	}
+	return nil
+}
+
+// orderedKeys returns the keys of the object in the order they should be printed.
+func (p *DocumentPrinter) orderedKeys(m map[string]interface{}) []string {
+	keys := GetOrderedKeys(m)
+	if !p.KeywordsFirst {
+		return keys
+	}
+
+	rank := func(key string) int {
+		switch {
+		case key == "@context":
+			return 0
+		case key == "@id":
+			return 1
+		case key == "@type":
+			return 2
+		case strings.HasPrefix(key, "@"):
+			return 3
+		default:
+			return 4
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return rank(keys[i]) < rank(keys[j])
+	})
+	return keys
+}
+
+func writeScalar(buf *bytes.Buffer, v interface{}) error {
+	// use an encoder to avoid escaping of HTML characters, such as < and > in IRIs
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return NewJsonLdError(InvalidInput, err)
+	}
+	buf.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+	return nil
+}
+
+// hunkHeader returns the unified diff header of a group of diff operations.
+// Unlike difflib.WriteUnifiedDiff, it always prints both the start line and the line count.
+func hunkHeader(group []difflib.OpCode) string {
+	first, last := group[0], group[len(group)-1]
+	aStart, aLines := first.I1+1, last.I2-first.I1
+	bStart, bLines := first.J1+1, last.J2-first.J1
+	// unified diff format uses the preceding line number for empty ranges
+	if aLines == 0 {
+		aStart--
+	}
+	if bLines == 0 {
+		bStart--
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", aStart, aLines, bStart, bLines)
+}
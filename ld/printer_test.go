@@ -0,0 +1,119 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFprintDocument(t *testing.T) {
+	doc := map[string]interface{}{
+		"name":     "<Jane>",
+		"@type":    "ex:Person",
+		"@id":      "ex:jane",
+		"@context": map[string]interface{}{"ex": "http://example.org/"},
+		"knows":    []interface{}{},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, FprintDocument(&buf, "MSG", doc))
+	assert.Equal(t, `MSG
+{
+  "@context": {
+    "ex": "http://example.org/"
+  },
+  "@id": "ex:jane",
+  "@type": "ex:Person",
+  "knows": [],
+  "name": "<Jane>"
+}
+`, buf.String())
+
+	buf.Reset()
+	p := &DocumentPrinter{KeywordsFirst: true}
+	require.NoError(t, p.Fprint(&buf, "", map[string]interface{}{
+		"a":        1.5,
+		"@value":   "x",
+		"@type":    "ex:T",
+		"@context": "http://example.org/context.jsonld",
+	}))
+	assert.Equal(t, `{
+  "@context": "http://example.org/context.jsonld",
+  "@type": "ex:T",
+  "@value": "x",
+  "a": 1.5
+}
+`, buf.String())
+}
+
+func TestDiffDocuments(t *testing.T) {
+	a := map[string]interface{}{
+		"@id":   "ex:1",
+		"a":     "1",
+		"b":     "2",
+		"c":     "3",
+		"d":     "4",
+		"e":     "5",
+		"f":     "6",
+		"g":     "7",
+		"h":     "8",
+		"items": []interface{}{"x", "y"},
+	}
+	b := CloneDocument(a).(map[string]interface{})
+	b["a"] = "one"
+	b["items"] = []interface{}{"x", "y", "z"}
+
+	var buf bytes.Buffer
+	differ, err := DiffDocuments(&buf, a, a)
+	require.NoError(t, err)
+	assert.False(t, differ)
+	assert.Empty(t, buf.String())
+
+	differ, err = DiffDocuments(&buf, a, b)
+	require.NoError(t, err)
+	assert.True(t, differ)
+	assert.Equal(t, `--- a
++++ b
+@@ -1,6 +1,6 @@
+ {
+   "@id": "ex:1",
+-  "a": "1",
++  "a": "one",
+   "b": "2",
+   "c": "3",
+   "d": "4",
+@@ -10,6 +10,7 @@
+   "h": "8",
+   "items": [
+     "x",
+-    "y"
++    "y",
++    "z"
+   ]
+ }
+`, buf.String())
+
+	buf.Reset()
+	p := &DocumentPrinter{Color: true}
+	_, err = p.Diff(&buf, a, b)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(buf.String(), "\x1b[32m+    \"z\"\x1b[0m\n"))
+}
@@ -15,7 +15,12 @@
 package ld
 
 import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -31,6 +36,10 @@ func NewJsonLdProcessor() *JsonLdProcessor { //nolint:stylecheck
 
 // Compact operation compacts the given input using the context according to the steps
 // in the Compaction algorithm: http://www.w3.org/TR/json-ld-api/#compaction-algorithm
+// The context may also be given as raw JSON: []byte, json.RawMessage or io.Reader.
+// The context is emitted in the result verbatim, with remote contexts kept as references,
+// unless opts.InlineContexts is set (see also opts.AppendContext).
+// Set opts.CompactionReport to find out which terms were used.
 func (jldp *JsonLdProcessor) Compact(input interface{}, context interface{},
 	opts *JsonLdOptions) (map[string]interface{}, error) {
 
@@ -38,36 +47,71 @@ func (jldp *JsonLdProcessor) Compact(input interface{}, context interface{},
 		opts = NewJsonLdOptions("")
 	} else {
 		opts = opts.Copy()
+		opts.RetainSourceContext = false
 	}
 
-	if inputStr, isString := input.(string); isString && opts.Base == "" {
-		opts.Base = inputStr
+	if inputURL := documentURL(input); inputURL != "" && opts.Base == "" {
+		opts.Base = inputURL
 	}
 
 	// 1)
 	// TODO: look into promises
 
 	// 2-6) NOTE: these are all the same steps as in expand
-	expanded, err := jldp.expand(input, opts)
+	var unmapped map[string]interface{}
+	if opts.UnmappedMembersKey != "" {
+		unmapped = make(map[string]interface{})
+	}
+	expanded, _, err := jldp.expandWithContext(input, opts, false, unmapped)
 	if err != nil {
 		return nil, err
 	}
 
 	// 7)
+	context, err = decodeRawJSON(context, InvalidLocalContext, opts.OnDuplicateKey)
+	if err != nil {
+		return nil, err
+	}
 	context = CloneDocument(context)
 	contextMap, isMap := context.(map[string]interface{})
 	innerCtx, hasCtx := contextMap["@context"]
 	if isMap && hasCtx {
 		context = innerCtx
 	}
-	activeCtx := NewContext(nil, opts)
+	if opts.AppendContext != nil {
+		extraCtx, err := decodeRawJSON(opts.AppendContext, InvalidLocalContext, opts.OnDuplicateKey)
+		if err != nil {
+			return nil, err
+		}
+		if context == nil {
+			context = CloneDocument(extraCtx)
+		} else {
+			contexts := append([]interface{}{}, Arrayify(context)...)
+			context = append(contexts, Arrayify(CloneDocument(extraCtx))...)
+		}
+	}
+	ctxOpts := opts
+	var loaded *CachingDocumentLoader
+	if opts.InlineContexts {
+		// keep the remote contexts loaded by Parse for inlining
+		loaded = NewCachingDocumentLoader(opts.DocumentLoader)
+		ctxOpts = opts.Copy()
+		ctxOpts.DocumentLoader = loaded
+	}
+	activeCtx := NewContext(nil, ctxOpts)
 	activeCtx, err = activeCtx.Parse(context)
 	if err != nil {
 		return nil, err
 	}
+	if opts.InlineContexts {
+		if context, err = inlineRemoteContexts(context, loaded, opts.base()); err != nil {
+			return nil, err
+		}
+	}
 
 	// 8)
-	api := NewJsonLdApi()
+	defer reportCompaction(activeCtx, opts)()
+	api := newJsonLdApi(opts)
 	compacted, err := api.Compact(activeCtx, "", expanded, opts.CompactArrays)
 	if err != nil {
 		return nil, err
@@ -98,12 +142,108 @@ func (jldp *JsonLdProcessor) Compact(input interface{}, context interface{},
 		}
 	}
 
+	compactedMap := compacted.(map[string]interface{})
+	if len(unmapped) > 0 {
+		if _, exists := compactedMap[opts.UnmappedMembersKey]; exists {
+			return nil, NewJsonLdError(DuplicateKey,
+				fmt.Sprintf("compacted document already has a member %s for unmapped members", opts.UnmappedMembersKey))
+		}
+		compactedMap[opts.UnmappedMembersKey] = unmapped
+	}
+
 	// 9)
-	return compacted.(map[string]interface{}), nil
+	return compactedMap, nil
+}
+
+// inlineRemoteContexts replaces references to remote contexts in the given context
+// with the contexts they refer to. Array-valued remote contexts are spliced
+// into the resulting array. Relative references in the inlined contexts are resolved
+// against the URL of the remote context, and @base is dropped, as it's ignored
+// in remote contexts.
+func inlineRemoteContexts(context interface{}, loader DocumentLoader, base string) (interface{}, error) {
+	contexts, isArray := context.([]interface{})
+	if !isArray {
+		contexts = []interface{}{context}
+	}
+	inlined := make([]interface{}, 0, len(contexts))
+	for i, ctx := range contexts {
+		uri, isString := ctx.(string)
+		if !isString {
+			inlined = append(inlined, ctx)
+			continue
+		}
+		u := Resolve(base, uri)
+		rd, err := loader.LoadDocument(u)
+		if err != nil {
+			return nil, newRemoteContextError(u, "", i, err)
+		}
+		var remoteCtx interface{}
+		switch doc := rd.Document.(type) {
+		case *Context:
+			serialized, err := doc.Serialize()
+			if err != nil {
+				return nil, err
+			}
+			remoteCtx = serialized["@context"]
+		case map[string]interface{}:
+			remoteCtx = CloneDocument(doc["@context"])
+		}
+		if remoteCtx == nil {
+			return nil, NewJsonLdError(InvalidRemoteContext, uri)
+		}
+		for _, ctx := range Arrayify(remoteCtx) {
+			if ctxMap, isMap := ctx.(map[string]interface{}); isMap {
+				delete(ctxMap, "@base")
+			}
+		}
+		remoteCtx = resolveContextReferences(remoteCtx, u)
+		if remoteContexts, isList := remoteCtx.([]interface{}); isList {
+			inlined = append(inlined, remoteContexts...)
+		} else {
+			inlined = append(inlined, remoteCtx)
+		}
+	}
+	if !isArray && len(inlined) == 1 {
+		return inlined[0], nil
+	}
+	return inlined, nil
+}
+
+// reportCompaction makes the active context record IRI compaction results
+// in opts.CompactionReport, if set. The returned function stops recording.
+func reportCompaction(activeCtx *Context, opts *JsonLdOptions) func() {
+	if opts.CompactionReport == nil {
+		return func() {}
+	}
+	opts.CompactionReport.addDefinedTerms(activeCtx)
+	activeCtx.compactionReport = opts.CompactionReport
+	return func() {
+		activeCtx.compactionReport = nil
+	}
+}
+
+// documentURL returns the URL of the input document, if it's given as a URL or
+// a *RemoteDocument. It returns an empty string otherwise.
+func documentURL(input interface{}) string {
+	switch v := input.(type) {
+	case string:
+		return v
+	case *RemoteDocument:
+		if v != nil {
+			return v.DocumentURL
+		}
+	}
+	return ""
 }
 
 // Expand operation expands the given input according to the steps in the Expansion algorithm:
 // http://www.w3.org/TR/json-ld-api/#expansion-algorithm
+//
+// The input may be a URL, which is dereferenced with opts.DocumentLoader, or an already
+// loaded *RemoteDocument. In both cases, the document URL is used as the base IRI
+// (unless opts.Base is set) and the context URL, if any, as a remote context applied
+// before the document's own context. The same applies to the other operations
+// which expand their input.
 func (jldp *JsonLdProcessor) Expand(input interface{}, opts *JsonLdOptions) ([]interface{}, error) {
 
 	if opts == nil {
@@ -115,7 +255,91 @@ func (jldp *JsonLdProcessor) Expand(input interface{}, opts *JsonLdOptions) ([]i
 	return jldp.expand(input, opts)
 }
 
+// ExpandWithContext expands the given input like Expand and also returns the active
+// context of the top level of the document: the initial context (see JsonLdOptions.Base
+// and JsonLdOptions.ExpandContext) with the top level @context of the document applied.
+// Its EffectiveBase and EffectiveVocab methods report the base IRI and vocabulary mapping
+// that were used to expand the top level node objects.
+//
+// Remote contexts are cached for the duration of the call, so that each of them
+// is retrieved from opts.DocumentLoader only once.
+func (jldp *JsonLdProcessor) ExpandWithContext(input interface{}, opts *JsonLdOptions) ([]interface{}, *Context, error) {
+
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	} else {
+		opts = opts.Copy()
+	}
+	if _, isCaching := opts.DocumentLoader.(*CachingDocumentLoader); !isCaching {
+		opts.DocumentLoader = NewCachingDocumentLoader(opts.DocumentLoader)
+	}
+
+	return jldp.expandWithContext(input, opts, true, nil)
+}
+
+// ExpandNDJSON reads newline-delimited JSON-LD (one document per line) from r, expands
+// every document independently and passes the result to fn, along with the line number
+// (starting from 1). Empty lines are skipped.
+//
+// Lines are decoded with opts.UseJSONNumber and opts.OnDuplicateKey taken into account.
+// If a line can't be parsed or expanded, fn receives the error instead of the result.
+// fn may return nil to continue with the next line, or an error to stop processing,
+// in which case ExpandNDJSON returns that error.
+//
+// Remote contexts are cached for the duration of the call, so that each of them
+// is retrieved from opts.DocumentLoader only once.
+func (jldp *JsonLdProcessor) ExpandNDJSON(r io.Reader, opts *JsonLdOptions,
+	fn func(line int, expanded []interface{}, err error) error) error {
+
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	} else {
+		opts = opts.Copy()
+	}
+	if _, isCaching := opts.DocumentLoader.(*CachingDocumentLoader); !isCaching {
+		opts.DocumentLoader = NewCachingDocumentLoader(opts.DocumentLoader)
+	}
+
+	br := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return NewJsonLdError(IOError, readErr)
+		}
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var expanded []interface{}
+			doc, err := decodeJSON(bytes.NewReader(line), opts.UseJSONNumber, opts.OnDuplicateKey)
+			if err != nil {
+				code := LoadingDocumentFailed
+				if ldErr, isLdError := err.(*JsonLdError); isLdError {
+					code = ldErr.Code
+				}
+				err = NewJsonLdError(code, fmt.Errorf("line %d: %w", lineNum, err))
+			} else {
+				expanded, err = jldp.expand(doc, opts.Copy())
+			}
+			if err = fn(lineNum, expanded, err); err != nil {
+				return err
+			}
+		}
+
+		if readErr != nil {
+			return nil
+		}
+	}
+}
+
 func (jldp *JsonLdProcessor) expand(input interface{}, opts *JsonLdOptions) ([]interface{}, error) {
+	expanded, _, err := jldp.expandWithContext(input, opts, false, nil)
+	return expanded, err
+}
+
+// expandWithContext expands the input document. If withContext is true,
+// it also returns the active context of the top level of the document.
+// If unmapped isn't nil, top-level members of the document dropped by expansion are added to it.
+func (jldp *JsonLdProcessor) expandWithContext(input interface{}, opts *JsonLdOptions,
+	withContext bool, unmapped map[string]interface{}) ([]interface{}, *Context, error) {
 
 	// 1)
 	// TODO: look into promises
@@ -123,16 +347,25 @@ func (jldp *JsonLdProcessor) expand(input interface{}, opts *JsonLdOptions) ([]i
 	var remoteContext string
 
 	// 2)
+	var rd *RemoteDocument
 	if iri, isString := input.(string); isString && strings.Contains(iri, ":") {
-		rd, err := opts.DocumentLoader.LoadDocument(iri)
+		var err error
+		rd, err = opts.DocumentLoader.LoadDocument(iri)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+	} else if doc, isRemoteDoc := input.(*RemoteDocument); isRemoteDoc {
+		if doc == nil {
+			return nil, nil, NewJsonLdError(InvalidInput, "remote document is nil")
+		}
+		rd = doc
+	}
+	if rd != nil {
 		if rd.Document == "" {
-			return nil, NewJsonLdError(LoadingDocumentFailed, err)
+			return nil, nil, NewJsonLdError(LoadingDocumentFailed, rd.DocumentURL)
 		}
 		input = rd.Document
-		iri = rd.DocumentURL
+		iri := rd.DocumentURL
 
 		// if set the base in options should override the base iri in the
 		// active context
@@ -147,8 +380,68 @@ func (jldp *JsonLdProcessor) expand(input interface{}, opts *JsonLdOptions) ([]i
 		}
 	}
 
+	// 3-5)
+	activeCtx, err := initialExpansionContext(opts, remoteContext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// fast path: documents already in expanded form don't need to go through the full algorithm
+	if opts.ExpandContext == nil && remoteContext == "" && opts.ExpandTracer == nil && opts.Budget == nil && isExpandedDocument(input, opts.MaxDepth) {
+		return CloneDocument(input).([]interface{}), activeCtx, nil
+	}
+
+	// 6)
+	api := newJsonLdApi(opts)
+	api.unmappedMembers = unmapped
+	var expanded interface{}
+	if opts.ExpandTracer != nil {
+		expanded, err = api.expandTraced(activeCtx, input, opts)
+	} else {
+		expanded, err = api.Expand(activeCtx, "", input, opts, false, nil)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var topLevelCtx *Context
+	if withContext {
+		topLevelCtx = activeCtx
+		if inputMap, isMap := input.(map[string]interface{}); isMap && inputMap["@context"] != nil {
+			if topLevelCtx, err = activeCtx.Parse(inputMap["@context"]); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// final step of Expansion Algorithm
+	expandedMap, isMap := expanded.(map[string]interface{})
+
+	if isMap && len(expandedMap) == 0 {
+		expanded = nil
+	}
+
+	graph, hasGraph := expandedMap["@graph"]
+	if isMap && hasGraph && len(expandedMap) == 1 {
+		expanded = graph
+	} else if expanded == nil {
+		expanded = make([]interface{}, 0)
+	}
+
+	// normalize to an array
+	if expandedList, isList := expanded.([]interface{}); isList {
+		return expandedList, topLevelCtx, nil
+	}
+
+	return []interface{}{expanded}, topLevelCtx, nil
+}
+
+// initialExpansionContext creates the active context for expansion of a document
+// from JsonLdOptions.ExpandContext and the context URL from the HTTP Link header, if any.
+func initialExpansionContext(opts *JsonLdOptions, remoteContext string) (*Context, error) {
 	// 3)
 	activeCtx := NewContext(nil, opts)
+	activeCtx.tracer = opts.ExpandTracer
 
 	// 4)
 	if opts.ExpandContext != nil {
@@ -174,48 +467,24 @@ func (jldp *JsonLdProcessor) expand(input interface{}, opts *JsonLdOptions) ([]i
 		}
 	}
 
-	// 6)
-	api := NewJsonLdApi()
-	expanded, err := api.Expand(activeCtx, "", input, opts, false, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// final step of Expansion Algorithm
-	expandedMap, isMap := expanded.(map[string]interface{})
-
-	if isMap && len(expandedMap) == 0 {
-		expanded = nil
-	}
-
-	graph, hasGraph := expandedMap["@graph"]
-	if isMap && hasGraph && len(expandedMap) == 1 {
-		expanded = graph
-	} else if expanded == nil {
-		expanded = make([]interface{}, 0)
-	}
-
-	// normalize to an array
-	if expandedList, isList := expanded.([]interface{}); isList {
-		return expandedList, nil
-	}
-
-	return []interface{}{expanded}, nil
+	return activeCtx, nil
 }
 
 // Flatten operation flattens the given input and compacts it using the passed context
 // according to the steps in the Flattening algorithm:
 // http://www.w3.org/TR/json-ld-api/#flattening-algorithm
+// The context may also be given as raw JSON: []byte, json.RawMessage or io.Reader.
 func (jldp *JsonLdProcessor) Flatten(input interface{}, context interface{}, opts *JsonLdOptions) (interface{}, error) {
 
 	if opts == nil {
 		opts = NewJsonLdOptions("")
 	} else {
 		opts = opts.Copy()
+		opts.RetainSourceContext = false
 	}
 
-	if inputStr, isString := input.(string); isString && opts.Base == "" {
-		opts.Base = inputStr
+	if inputURL := documentURL(input); inputURL != "" && opts.Base == "" {
+		opts.Base = inputURL
 	}
 
 	// 2-6) NOTE: these are all the same steps as in expand
@@ -224,6 +493,10 @@ func (jldp *JsonLdProcessor) Flatten(input interface{}, context interface{}, opt
 		return nil, err
 	}
 	// 7)
+	context, err = decodeRawJSON(context, InvalidLocalContext, opts.OnDuplicateKey)
+	if err != nil {
+		return nil, err
+	}
 	contextMap, isMap := context.(map[string]interface{})
 	innerCtx, hasCtx := contextMap["@context"]
 	if isMap && hasCtx {
@@ -238,7 +511,7 @@ func (jldp *JsonLdProcessor) Flatten(input interface{}, context interface{}, opt
 		"@default": make(map[string]interface{}),
 	}
 	// 2)
-	api := NewJsonLdApi()
+	api := newJsonLdApi(opts)
 	issuer := NewIdentifierIssuer("_:b")
 	if _, err = api.GenerateNodeMap(expanded, nodeMap, "@default", issuer, nil, "", nil); err != nil {
 		return nil, err
@@ -293,6 +566,7 @@ func (jldp *JsonLdProcessor) Flatten(input interface{}, context interface{}, opt
 			return nil, err
 		}
 
+		defer reportCompaction(activeCtx, opts)()
 		compacted, err := api.Compact(activeCtx, "", flattened, opts.CompactArrays)
 		if err != nil {
 			return nil, err
@@ -321,22 +595,35 @@ func (jldp *JsonLdProcessor) Flatten(input interface{}, context interface{}, opt
 // input: The input JSON-LD object
 // frame: The frame to use when re-arranging the data of input; either in the form of an JSON object or as IRI.
 //
-// Returns the framed JSON-LD document.
+// Returns the framed JSON-LD document. The output is deterministic: matched subjects
+// are ordered by their identifiers, values of properties keep the order of the input
+// and reverse values are ordered by the identifiers of the referencing subjects.
 func (jldp *JsonLdProcessor) Frame(input interface{}, frame interface{}, opts *JsonLdOptions) (map[string]interface{}, error) {
 
 	if opts == nil {
 		opts = NewJsonLdOptions("")
 	} else {
 		opts = opts.Copy()
+		opts.RetainSourceContext = false
 	}
 
-	if inputStr, isString := input.(string); isString && opts.Base == "" {
-		opts.Base = inputStr
+	if inputURL := documentURL(input); inputURL != "" && opts.Base == "" {
+		opts.Base = inputURL
 	}
 
-	if _, isMap := frame.(map[string]interface{}); isMap {
-		frame = CloneDocument(frame)
+	if frameIRI, isString := frame.(string); isString {
+		rd, err := opts.DocumentLoader.LoadDocument(frameIRI)
+		if err != nil {
+			return nil, err
+		}
+		frame = rd.Document
+	}
+	frameMap, isMap := frame.(map[string]interface{})
+	if !isMap {
+		return nil, NewJsonLdError(InvalidFrame, "Invalid JSON-LD syntax; a JSON-LD frame must be a single object")
 	}
+	frame = CloneDocument(frameMap)
+	frameMap = frame.(map[string]interface{})
 
 	// 2. Set expanded input to the result of using the expand method using input and options.
 	expandedInput, err := jldp.Expand(input, opts)
@@ -359,23 +646,23 @@ func (jldp *JsonLdProcessor) Frame(input interface{}, frame interface{}, opts *J
 
 	// 4. Set context to the value of @context from frame, if it exists, or to a new empty
 	// context, otherwise.
-	api := NewJsonLdApi()
+	api := newJsonLdApi(opts)
 
 	// FIXME should look for aliases of @graph
-	_, graphInFrame := frame.(map[string]interface{})["@graph"]
+	_, graphInFrame := frameMap["@graph"]
 
 	framed, bnodesToClear, err := api.Frame(expandedInput, expandedFrame, opts, !graphInFrame)
 	if err != nil {
 		return nil, err
 	}
 
-	frameMap := frame.(map[string]interface{})
 	activeCtx := NewContext(nil, opts)
 	activeCtx, err = activeCtx.Parse(frameMap["@context"])
 	if err != nil {
 		return nil, err
 	}
 
+	defer reportCompaction(activeCtx, opts)()
 	compacted, err := api.Compact(activeCtx, "", framed, opts.CompactArrays)
 	if err != nil {
 		return nil, err
@@ -384,6 +671,14 @@ func (jldp *JsonLdProcessor) Frame(input interface{}, frame interface{}, opts *J
 	if opts.ProcessingMode == JsonLd_1_0 {
 		// don't prune blank nodes in JSON-LD 1.1 mode
 		bnodesToClear = make([]string, 0)
+	} else if opts.BlankNodeRewriter != nil {
+		// blank node identifiers in the compacted output have been rewritten
+		for i, bnode := range bnodesToClear {
+			bnodesToClear[i], err = activeCtx.CompactIri(bnode, nil, false, false)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	rval, err := activeCtx.Serialize()
@@ -417,10 +712,31 @@ func (jldp *JsonLdProcessor) Frame(input interface{}, frame interface{}, opts *J
 	return rval, nil
 }
 
-var rdfSerializers = map[string]RDFSerializer{
-	"application/n-quads": &NQuadRDFSerializer{},
-	"application/nquads":  &NQuadRDFSerializer{}, // keep this option for backward compatibility
-	"text/turtle":         &TurtleRDFSerializer{},
+// rdfParser returns the serializer which parses RDF in the given format.
+func rdfParser(opts *JsonLdOptions, format string) (RDFSerializer, error) {
+	serializer, found := lookupRDFSerializer(opts, format)
+	if !found || !canParseRDF(serializer) {
+		return nil, NewJsonLdError(UnknownFormat, fmt.Sprintf("unsupported RDF input format: %s", format))
+	}
+	return serializer, nil
+}
+
+// parseRDF parses the input with the given serializer, charging opts.Budget for the parsed quads.
+// N-Quads are charged as they are parsed, other formats once they have been parsed.
+func parseRDF(serializer RDFSerializer, input interface{}, opts *JsonLdOptions) (*RDFDataset, error) {
+	if _, isNQuads := serializer.(*NQuadRDFSerializer); isNQuads {
+		return parseNQuadsFrom(input, opts.Budget)
+	}
+	dataset, err := serializer.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	for _, quads := range dataset.Graphs {
+		if err = opts.Budget.spendQuads(int64(len(quads))); err != nil {
+			return nil, err
+		}
+	}
+	return dataset, nil
 }
 
 // FromRDF converts an RDF dataset to JSON-LD.
@@ -446,7 +762,7 @@ func (jldp *JsonLdProcessor) FromRDF(dataset interface{}, opts *JsonLdOptions) (
 		opts.Format = "application/n-quads"
 	}
 
-	serializer, hasSerializer := rdfSerializers[opts.Format]
+	serializer, hasSerializer := lookupRDFSerializer(opts, opts.Format)
 	if !hasSerializer {
 		return nil, NewJsonLdError(UnknownFormat, opts.Format)
 	}
@@ -457,13 +773,16 @@ func (jldp *JsonLdProcessor) FromRDF(dataset interface{}, opts *JsonLdOptions) (
 
 func (jldp *JsonLdProcessor) fromRDF(input interface{}, opts *JsonLdOptions, serializer RDFSerializer) (interface{}, error) {
 
-	dataset, err := serializer.Parse(input)
+	dataset, err := parseRDF(serializer, input, opts)
 	if err != nil {
 		return nil, err
 	}
+	if opts.DeduplicateBlankNodes {
+		dataset = dataset.MergeIsomorphicBlankNodes()
+	}
 
 	// convert from RDF
-	api := NewJsonLdApi()
+	api := newJsonLdApi(opts)
 	rval, err := api.FromRDF(dataset, opts)
 	if err != nil {
 		return nil, err
@@ -497,6 +816,7 @@ func (jldp *JsonLdProcessor) ToRDF(input interface{}, opts *JsonLdOptions) (inte
 		opts = NewJsonLdOptions("")
 	} else {
 		opts = opts.Copy()
+		opts.RetainSourceContext = false
 	}
 
 	expandedInput, err := jldp.expand(input, opts)
@@ -504,7 +824,7 @@ func (jldp *JsonLdProcessor) ToRDF(input interface{}, opts *JsonLdOptions) (inte
 		return nil, err
 	}
 
-	api := NewJsonLdApi()
+	api := newJsonLdApi(opts)
 	dataset, err := api.ToRDF(expandedInput, opts)
 	if err != nil {
 		return nil, err
@@ -529,7 +849,7 @@ func (jldp *JsonLdProcessor) ToRDF(input interface{}, opts *JsonLdOptions) (inte
 	}
 
 	if opts.Format != "" {
-		serializer, hasSerializer := rdfSerializers[opts.Format]
+		serializer, hasSerializer := lookupRDFSerializer(opts, opts.Format)
 		if !hasSerializer {
 			return nil, NewJsonLdError(UnknownFormat, opts.Format)
 		}
@@ -550,30 +870,174 @@ func (jldp *JsonLdProcessor) Normalize(input interface{}, opts *JsonLdOptions) (
 		opts = opts.Copy()
 	}
 
-	if opts.Algorithm != AlgorithmURDNA2015 && opts.Algorithm != AlgorithmURGNA2012 {
+	// check the output format before doing any work
+	if opts.Format != "" && opts.Format != "application/n-quads" && opts.Format != "application/nquads" {
+		if _, err := canonicalRDFSerializer(opts, opts.Format); err != nil {
+			return nil, err
+		}
+	}
+
+	dataset, err := jldp.normalizationDataset(input, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	api := newJsonLdApi(opts)
+	return api.Normalize(dataset, opts)
+}
+
+// NormalizeQuads performs RDF dataset normalization on the given input
+// and returns the canonical quads in canonical N-Quads order.
+// Unlike Normalize, it ignores opts.Format and doesn't serialize the result.
+func (jldp *JsonLdProcessor) NormalizeQuads(input interface{}, opts *JsonLdOptions) ([]*Quad, error) {
+
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	} else {
+		opts = opts.Copy()
+	}
+
+	dataset, err := jldp.normalizationDataset(input, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	algo, err := canonicalize(dataset, opts)
+	if err != nil {
+		return nil, err
+	}
+	return algo.CanonicalQuads(), nil
+}
+
+// NormalizeDigest performs RDF dataset normalization on the given input and returns
+// the digest of the canonical N-Quads, calculated with the given hash function.
+// The canonical lines are written directly into the hash, without building
+// the N-Quads string in memory. opts.Format is ignored.
+//
+// The hash function must be linked into the binary, for example by importing crypto/sha256.
+func (jldp *JsonLdProcessor) NormalizeDigest(input interface{}, opts *JsonLdOptions, hash crypto.Hash) ([]byte, error) {
+
+	if !hash.Available() {
+		return nil, NewJsonLdError(InvalidInput, fmt.Sprintf("hash function %v is not available", hash))
+	}
+
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	} else {
+		opts = opts.Copy()
+	}
+
+	dataset, err := jldp.normalizationDataset(input, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	algo, err := canonicalize(dataset, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hash.New()
+	if err = algo.WriteNQuads(h); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// NormalizeFrame produces a deterministic framed JSON-LD document: the input is canonicalized
+// at the RDF level (see Normalize), converted back into JSON-LD (see FromRDF) and framed
+// with the given frame (see Frame). Blank nodes get canonical identifiers, so that equivalent
+// inputs produce the same document, which is useful for stable storage formats.
+//
+// The options are validated before any processing (see JsonLdOptions.Validate).
+// opts.Algorithm and opts.InputFormat apply as in Normalize, opts.UseNativeTypes and
+// opts.UseRdfType as in FromRDF, and the remaining options as in Frame.
+func (jldp *JsonLdProcessor) NormalizeFrame(input interface{}, frame interface{},
+	opts *JsonLdOptions) (map[string]interface{}, error) {
+
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	} else {
+		opts = opts.Copy()
+	}
+
+	canonical, err := jldp.canonicalDocument(input, opts)
+	if err != nil {
+		return nil, err
+	}
+	return jldp.Frame(canonical, frame, opts)
+}
+
+// NormalizeCompact is like NormalizeFrame, but compacts the canonical document
+// with the given context instead of framing it (see Compact).
+func (jldp *JsonLdProcessor) NormalizeCompact(input interface{}, context interface{},
+	opts *JsonLdOptions) (map[string]interface{}, error) {
+
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	} else {
+		opts = opts.Copy()
+	}
+
+	canonical, err := jldp.canonicalDocument(input, opts)
+	if err != nil {
+		return nil, err
+	}
+	return jldp.Compact(canonical, context, opts)
+}
+
+// canonicalDocument validates the options, normalizes the input and converts
+// the canonical dataset into expanded JSON-LD. It resets opts.InputFormat,
+// as the result is no longer in that format.
+func (jldp *JsonLdProcessor) canonicalDocument(input interface{}, opts *JsonLdOptions) ([]interface{}, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	dataset, err := jldp.normalizationDataset(input, opts)
+	if err != nil {
+		return nil, err
+	}
+	opts.InputFormat = ""
+
+	algo, err := canonicalize(dataset, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newJsonLdApi(opts).FromRDF(algo.Dataset(), opts)
+}
+
+// normalizationDataset validates normalization options and converts the input
+// into an RDF dataset ready for normalization.
+func (jldp *JsonLdProcessor) normalizationDataset(input interface{}, opts *JsonLdOptions) (*RDFDataset, error) {
+	if _, found := lookupCanonicalizer(opts.Algorithm); !found {
 		return nil, NewJsonLdError(InvalidInput, fmt.Sprintf("Unknown normalization algorithm: %s",
 			opts.Algorithm))
 	}
 
 	var dataset *RDFDataset
 	if opts.InputFormat != "" {
-		if opts.InputFormat != "application/n-quads" && opts.InputFormat != "application/nquads" {
-			return nil, NewJsonLdError(UnknownFormat, "Unknown normalization input format")
+		// the input is parsed with the serializer of InputFormat, regardless of Format,
+		// which only selects the output format
+		serializer, err := rdfParser(opts, opts.InputFormat)
+		if err != nil {
+			return nil, err
 		}
-		serializer, hasSerializer := rdfSerializers[opts.Format]
-		if !hasSerializer {
-			return nil, NewJsonLdError(UnknownFormat, opts.Format)
+		switch input.(type) {
+		case string, []byte, io.Reader:
+		default:
+			return nil, NewJsonLdError(InvalidInput,
+				fmt.Sprintf("input must be a string, []byte or io.Reader when InputFormat is set, got %T", input))
 		}
-		var err error
-		if dataset, err = serializer.Parse(input); err != nil {
+		if dataset, err = parseRDF(serializer, input, opts); err != nil {
 			return nil, err
 		}
 	} else {
-		toRDFOpts := NewJsonLdOptions(opts.Base)
-		toRDFOpts.ProcessingMode = opts.ProcessingMode
+		// all options apply to the conversion, except for the output and input formats
+		toRDFOpts := opts.Copy()
 		toRDFOpts.Format = ""
-		// it's important to pass the original DocumentLoader. The default one will be used otherwise!
-		toRDFOpts.DocumentLoader = opts.DocumentLoader
+		toRDFOpts.InputFormat = ""
 
 		datasetObj, err := jldp.ToRDF(input, toRDFOpts)
 		if err != nil {
@@ -582,6 +1046,18 @@ func (jldp *JsonLdProcessor) Normalize(input interface{}, opts *JsonLdOptions) (
 		dataset = datasetObj.(*RDFDataset)
 	}
 
-	api := NewJsonLdApi()
-	return api.Normalize(dataset, opts)
+	if len(opts.NormalizeGraphs) > 0 {
+		selected := make(map[string][]*Quad, len(opts.NormalizeGraphs)+1)
+		if quads, hasDefault := dataset.Graphs["@default"]; hasDefault {
+			selected["@default"] = quads
+		}
+		for _, name := range opts.NormalizeGraphs {
+			if quads, hasGraph := dataset.Graphs[name]; hasGraph {
+				selected[name] = quads
+			}
+		}
+		dataset = &RDFDataset{Graphs: selected, context: dataset.context}
+	}
+
+	return dataset, nil
 }
@@ -17,680 +17,984 @@ package ld_test
 import (
 	"encoding/json"
 	"fmt"
-	"log"
-	"net/http"
-	"net/http/httptest"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
-	"time"
 
 	. "github.com/piprate/json-gold/ld"
+	"github.com/piprate/json-gold/ld/testsuite"
 	"github.com/stretchr/testify/assert"
 )
 
-// RewriteHostTransport is an http.RoundTripper that rewrites requests
-// using the provided Host. The Opaque field is untouched.
-// If Transport is nil, http.DefaultTransport is used
-type RewriteHostTransport struct {
-	Transport http.RoundTripper
-	Host      string
-}
+func TestSuite(t *testing.T) {
+	testDir := "testdata"
 
-func (t RewriteHostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// save the original host
-	origHost := req.URL.Host
-	// rewrite the host
-	req.URL.Host = t.Host
+	globalManifestBytes, err := os.ReadFile(filepath.Join(testDir, "manifest.jsonld"))
+	assert.NoError(t, err)
 
-	rt := t.Transport
-	if rt == nil {
-		rt = http.DefaultTransport
-	}
-	res, err := rt.RoundTrip(req)
+	var globalManifest map[string]interface{}
+	err = json.Unmarshal(globalManifestBytes, &globalManifest)
+	assert.NoError(t, err)
 
-	if err == nil {
-		// restore the original host to ensure the client doesn't know the response
-		// came from a MockServer instance
-		res.Request.URL.Host = origHost
+	// JSON-LD 1.1 official test suite
+
+	manifestList := make([]string, 0)
+	for _, val := range globalManifest["sequence"].([]interface{}) {
+		manifestList = append(manifestList, filepath.Join(testDir, val.(string)))
 	}
-	return res, err
-}
 
-// MockServer uses httptest package to mock live HTTP calls.
-type MockServer struct {
-	Base       string
-	TestFolder string
+	manifestList = append(manifestList,
+		// Framing and Normalisation test suites
+		filepath.Join(testDir, "frame-manifest.jsonld"),
+		filepath.Join(testDir, "normalization", "manifest-urgna2012.jsonld"),
+		filepath.Join(testDir, "normalization", "manifest-urdna2015.jsonld"),
+		// extra tests that aren't covered by the official test suite
+		filepath.Join(testDir, "extra-manifest.jsonld"),
+	)
+
+	// local manifests, for example in-house regression suites, may be added
+	// with EXTRA_MANIFESTS (a list of paths, separated as in PATH)
+	if extraManifests := os.Getenv("EXTRA_MANIFESTS"); extraManifests != "" {
+		manifestList = append(manifestList, filepath.SplitList(extraManifests)...)
+	}
 
-	ContentType string
-	HTTPLink    []string
-	HTTPStatus  int
-	RedirectTo  string
+	earlReport := testsuite.NewEarlReport(os.Getenv("VERSION"))
+	suite := &testsuite.Suite{
+		Manifests:   manifestList,
+		BaseDir:     testDir,
+		Skipped:     skippedTests,
+		Report:      earlReport,
+		FullRun:     os.Getenv("FULL_RUN") == "true",
+		FailSkipped: os.Getenv("SKIP_MODE") == "fail",
+	}
+	suite.Run(t)
+	earlReport.Write("earl.jsonld")
+}
 
-	server *httptest.Server
+type countingDocumentLoader struct {
+	DocumentLoader
+	calls int
+}
 
-	DocumentLoader DocumentLoader
+func (l *countingDocumentLoader) LoadDocument(u string) (*RemoteDocument, error) {
+	l.calls++
+	return l.DocumentLoader.LoadDocument(u)
 }
 
-// NewMockServer creates a new instance of MockServer.
-func NewMockServer(base string, testFolder string) *MockServer {
-	mockServer := &MockServer{
-		Base:       base,
-		TestFolder: testFolder,
-	}
-
-	var ts *httptest.Server
-	mockFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if mockServer.HTTPStatus != 0 {
-			// must be a redirect
-			w.Header().Set("Location", mockServer.Base+mockServer.RedirectTo)
-			w.WriteHeader(mockServer.HTTPStatus)
-		} else {
-			u := r.URL.String()
-
-			if strings.HasPrefix(u, mockServer.Base) {
-				contentType := mockServer.ContentType
-				if contentType == "" {
-					if strings.HasSuffix(u, ".jsonld") {
-						contentType = "application/ld+json"
-					} else if strings.HasSuffix(u, ".html") {
-						contentType = "text/html"
-					} else {
-						contentType = "application/json"
-					}
-				}
-
-				fileName := filepath.Join(mockServer.TestFolder, u[len(mockServer.Base):])
-				inputBytes, err := os.ReadFile(fileName)
-				if err == nil {
-					w.Header().Set("Content-Type", contentType)
-					if mockServer.HTTPLink != nil {
-						w.Header().Set("Link", strings.Join(mockServer.HTTPLink, ", "))
-					}
-					w.WriteHeader(http.StatusOK)
-					_, _ = w.Write(inputBytes)
-				} else {
-					w.WriteHeader(http.StatusNotFound)
-				}
+func TestJsonLdProcessor_ExpandNDJSON(t *testing.T) {
+	loader := &countingDocumentLoader{
+		DocumentLoader: NewMapDocumentLoader(map[string]interface{}{
+			"http://example.com/context.jsonld": `{"@context": {"name": "http://schema.org/name"}}`,
+		}),
+	}
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = loader
+
+	input := `{"@context": "http://example.com/context.jsonld", "@id": "http://example.com/a", "name": "A"}
+
+{"@context": "http://example.com/context.jsonld", "@id": "http://example.com/b", "name": "B"}
+{"broken
+{"@context": "http://example.com/context.jsonld", "@id": "http://example.com/c", "name": "C"}`
+
+	results := make(map[int]interface{})
+	var failedLines []int
+	err := NewJsonLdProcessor().ExpandNDJSON(strings.NewReader(input), opts,
+		func(line int, expanded []interface{}, err error) error {
+			if err != nil {
+				failedLines = append(failedLines, line)
+				return nil
 			}
+			results[line] = expanded[0].(map[string]interface{})["@id"]
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]interface{}{
+		1: "http://example.com/a",
+		3: "http://example.com/b",
+		5: "http://example.com/c",
+	}, results)
+	assert.Equal(t, []int{4}, failedLines)
+	assert.Equal(t, 1, loader.calls)
+
+	stopErr := fmt.Errorf("stop")
+	err = NewJsonLdProcessor().ExpandNDJSON(strings.NewReader(input), opts,
+		func(line int, expanded []interface{}, err error) error {
+			return stopErr
+		})
+	assert.Equal(t, stopErr, err)
+
+	// lines are decoded with the JSON number and duplicate key options
+	opts = NewJsonLdOptions("")
+	opts.UseJSONNumber = true
+	opts.OnDuplicateKey = RejectDuplicateKeys
+	input = `{"@id": "http://example.com/a", "http://example.com/id": 9007199254740993}
+{"@id": "http://example.com/b", "@id": "http://example.com/c"}`
+	var values []interface{}
+	var errs []error
+	err = NewJsonLdProcessor().ExpandNDJSON(strings.NewReader(input), opts,
+		func(line int, expanded []interface{}, err error) error {
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+			node := expanded[0].(map[string]interface{})
+			values = append(values, node["http://example.com/id"].([]interface{})[0].(map[string]interface{})["@value"])
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{json.Number("9007199254740993")}, values)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, DuplicateKey, errs[0].(*JsonLdError).Code)
+		assert.Contains(t, errs[0].Error(), "line 2")
+	}
+}
 
-		}
-
-		// reset the context for the second call so that it succeeds.
-		// currently there are no tests where it needs to work in a different way
-		mockServer.HTTPStatus = 0
-		mockServer.HTTPLink = nil
+func TestJsonLdProcessor_BaseOverride(t *testing.T) {
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("http://example.com/ignored/")
+	opts.DocumentLoader = NewMapDocumentLoader(map[string]interface{}{
+		"http://example.com/remote/doc.jsonld": `{"@id": "item", "http://schema.org/url": {"@id": "other"}}`,
 	})
 
-	if strings.HasPrefix(base, "https") {
-		ts = httptest.NewTLSServer(mockFunc)
-	} else {
-		ts = httptest.NewServer(mockFunc)
-	}
+	// the URL of the remote document isn't used as the base
+	expanded, err := proc.Expand("http://example.com/remote/doc.jsonld", opts.WithBase("http://example.org/logical/"))
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id": "http://example.org/logical/item",
+			"http://schema.org/url": []interface{}{
+				map[string]interface{}{"@id": "http://example.org/logical/other"},
+			},
+		},
+	}, expanded)
 
-	// get httptest.Server's URL
+	compacted, err := proc.Compact("http://example.com/remote/doc.jsonld", map[string]interface{}{},
+		opts.WithBase("http://example.org/logical/"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"@id":                   "item",
+		"http://schema.org/url": map[string]interface{}{"@id": "other"},
+	}, compacted)
 
-	tsURL, err := url.Parse(ts.URL)
-	if err != nil {
-		log.Fatalln("failed to parse httptest.Server URL:", err)
-	}
+	// WithBase doesn't modify the original options
+	assert.Equal(t, "", opts.BaseOverride)
 
-	// update base URL with httptest.Server's host
+	expanded, err = NewJsonLdProcessor().Expand(map[string]interface{}{"@id": "item", "@type": "Thing"},
+		(*JsonLdOptions)(nil).WithBase("http://example.org/"))
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"@id": "http://example.org/item", "@type": []interface{}{"http://example.org/Thing"}},
+	}, expanded)
+}
 
-	baseURL, err := url.Parse(base)
-	if err != nil {
-		log.Fatalln("failed to parse base URL:", err)
+func TestJsonLdProcessor_ListsOfListsRoundTrip(t *testing.T) {
+	proc := NewJsonLdProcessor()
+	context := map[string]interface{}{
+		"@version": 1.1,
+		"ex":       "http://example.org/",
+		"l":        map[string]interface{}{"@id": "ex:l", "@container": "@list"},
+	}
+	doc := map[string]interface{}{
+		"@context": context,
+		"@id":      "ex:s",
+		"l": []interface{}{
+			[]interface{}{"1", "2"},
+			[]interface{}{},
+			[]interface{}{[]interface{}{"a"}, map[string]interface{}{"@id": "ex:o"}},
+			"x",
+		},
 	}
-	baseURL.Host = tsURL.Host
-	mockServer.Base = baseURL.Path
 
-	client := ts.Client()
+	opts := NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	nquads, err := proc.ToRDF(doc, opts)
+	assert.NoError(t, err)
 
-	client.Transport = RewriteHostTransport{
-		Transport: client.Transport,
-		Host:      tsURL.Host,
+	opts = NewJsonLdOptions("")
+	opts.UseNativeTypes = true
+	fromRDF, err := proc.FromRDF(nquads, opts)
+	assert.NoError(t, err)
+
+	compacted, err := proc.Compact(fromRDF, context, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, doc, compacted)
+}
+
+func TestJsonLdProcessor_UpgradeProcessingMode(t *testing.T) {
+	proc := NewJsonLdProcessor()
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@version": 1.1,
+			"ex":       "http://example.com/",
+			"labels":   map[string]interface{}{"@id": "ex:label", "@container": "@language"},
+		},
+		"@id":    "ex:s",
+		"labels": map[string]interface{}{"en": "Hello", "@none": "Hi"},
 	}
 
-	mockServer.server = ts
-	mockServer.DocumentLoader = NewDefaultDocumentLoader(client)
+	opts := NewJsonLdOptions("")
+	opts.ProcessingMode = JsonLd_1_0
+	_, err := proc.Expand(doc, opts)
+	assert.Error(t, err)
+	assert.Equal(t, ProcessingModeConflict, err.(*JsonLdError).Code)
+
+	warnings := make([]*JsonLdError, 0)
+	opts.UpgradeProcessingMode = true
+	opts.WarningHandler = func(warning *JsonLdError) {
+		warnings = append(warnings, warning)
+	}
+	expanded, err := proc.Expand(doc, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id": "http://example.com/s",
+			"http://example.com/label": []interface{}{
+				map[string]interface{}{"@value": "Hi"},
+				map[string]interface{}{"@value": "Hello", "@language": "en"},
+			},
+		},
+	}, expanded)
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, ProcessingModeConflict, warnings[0].Code)
+	}
 
-	return mockServer
+	// the upgrade doesn't affect other documents processed with the same options
+	_, err = proc.Expand(map[string]interface{}{
+		"@context": map[string]interface{}{
+			"labels": map[string]interface{}{"@id": "http://example.com/label", "@container": "@id"},
+		},
+		"labels": map[string]interface{}{},
+	}, opts)
+	assert.Error(t, err)
+	assert.Equal(t, JsonLd_1_0, opts.ProcessingMode)
 }
 
-func (ms *MockServer) SetExpectedBehaviour(contentType string, httpLink []string, httpStatus int, redirectTo string) {
-	ms.ContentType = contentType
-	ms.HTTPLink = httpLink
-	ms.HTTPStatus = httpStatus
-	ms.RedirectTo = redirectTo
+func TestJsonLdProcessor_FromRDFCompoundLiterals(t *testing.T) {
+	nquads := `<http://example.com/a> <http://example.com/label> _:c1 .
+<http://example.com/b> <http://example.com/label> _:c1 .
+<http://example.com/b> <http://example.com/list> _:l1 .
+_:l1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> _:c2 .
+_:l1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .
+_:c1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#value> "Hello" .
+_:c1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#language> "en" .
+_:c1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#direction> "ltr" .
+_:c2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#value> "مرحبا" .
+_:c2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#direction> "rtl" .
+<http://example.com/c> <http://example.com/label> _:x .
+_:x <http://www.w3.org/1999/02/22-rdf-syntax-ns#value> "not a compound literal" .
+_:x <http://www.w3.org/1999/02/22-rdf-syntax-ns#direction> "rtl" .
+_:x <http://example.com/note> "extra" .
+`
+	hello := map[string]interface{}{"@value": "Hello", "@language": "en", "@direction": "ltr"}
+
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.RdfDirection = RdfDirectionCompoundLiteral
+	result, err := proc.FromRDF(nquads, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id": "_:x",
+			"http://example.com/note": []interface{}{
+				map[string]interface{}{"@value": "extra"},
+			},
+			RDFDirection: []interface{}{map[string]interface{}{"@value": "rtl"}},
+			RDFValue:     []interface{}{map[string]interface{}{"@value": "not a compound literal"}},
+		},
+		map[string]interface{}{
+			"@id":                      "http://example.com/a",
+			"http://example.com/label": []interface{}{hello},
+		},
+		map[string]interface{}{
+			"@id":                      "http://example.com/b",
+			"http://example.com/label": []interface{}{hello},
+			"http://example.com/list": []interface{}{
+				map[string]interface{}{"@list": []interface{}{
+					map[string]interface{}{"@value": "مرحبا", "@direction": "rtl"},
+				}},
+			},
+		},
+		map[string]interface{}{
+			"@id":                      "http://example.com/c",
+			"http://example.com/label": []interface{}{map[string]interface{}{"@id": "_:x"}},
+		},
+	}, result)
+
+	// without the option, compound literals are ordinary blank nodes
+	result, err = proc.FromRDF(nquads, nil)
+	assert.NoError(t, err)
+	assert.Len(t, result, 6)
 }
 
-func (ms *MockServer) Close() {
-	if ms.server != nil {
-		ms.server.Close()
+func TestJsonLdProcessor_ToRDFDirection(t *testing.T) {
+	doc := map[string]interface{}{
+		"@id": "http://example.com/a",
+		"http://example.com/label": map[string]interface{}{
+			"@value": "Hello", "@language": "en-US", "@direction": "ltr",
+		},
 	}
-}
 
-type TestDefinition struct {
-	ID               string
-	Name             string
-	Type             string
-	EvaluationType   string
-	InputURL         string
-	InputFileName    string
-	ExpectedFileName string
-	Option           map[string]interface{}
-	Raw              map[string]interface{}
-	Skip             bool
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	opts.RdfDirection = RdfDirectionCompoundLiteral
+	result, err := proc.ToRDF(doc, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, `_:b0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#value> "Hello" .
+_:b0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#language> "en-us" .
+_:b0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#direction> "ltr" .
+<http://example.com/a> <http://example.com/label> _:b0 .
+`, result)
+
+	// without RdfDirection, base direction is dropped with a warning
+	var warnings []*JsonLdError
+	opts = NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	opts.WarningHandler = func(warning *JsonLdError) {
+		warnings = append(warnings, warning)
+	}
+	result, err = proc.ToRDF(doc, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "<http://example.com/a> <http://example.com/label> \"Hello\"@en-us .\n", result)
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, BaseDirectionDropped, warnings[0].Code)
+	}
+
+	// in safe mode, it's an error
+	opts = NewJsonLdOptions("")
+	opts.SafeMode = true
+	_, err = proc.ToRDF(doc, opts)
+	if assert.Error(t, err) {
+		assert.Equal(t, BaseDirectionDropped, err.(*JsonLdError).Code)
+		details := err.(*JsonLdError).Details.(*ValueErrorDetails)
+		assert.Equal(t, "http://example.com/label", details.Property)
+		assert.Equal(t, "http://example.com/a", details.NodeID)
+	}
 }
 
-func TestSuite(t *testing.T) {
-	testDir := "testdata"
+func TestJsonLdProcessor_NormalizeDirection(t *testing.T) {
+	doc := map[string]interface{}{
+		"@id": "http://example.com/a",
+		"http://example.com/label": map[string]interface{}{
+			"@value": "Hello", "@language": "en-US", "@direction": "ltr",
+		},
+	}
 
-	globalManifestBytes, err := os.ReadFile(filepath.Join(testDir, "manifest.jsonld"))
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	opts.Algorithm = AlgorithmURDNA2015
+	opts.RdfDirection = RdfDirectionCompoundLiteral
+	result, err := proc.Normalize(doc, opts)
 	assert.NoError(t, err)
+	assert.Equal(t, `<http://example.com/a> <http://example.com/label> _:c14n0 .
+_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#direction> "ltr" .
+_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#language> "en-us" .
+_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#value> "Hello" .
+`, result)
+
+	// options controlling base direction are passed to the RDF conversion
+	var warnings []*JsonLdError
+	opts = NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	opts.Algorithm = AlgorithmURDNA2015
+	opts.WarningHandler = func(warning *JsonLdError) {
+		warnings = append(warnings, warning)
+	}
+	_, err = proc.Normalize(doc, opts)
+	assert.NoError(t, err)
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, BaseDirectionDropped, warnings[0].Code)
+	}
 
-	var globalManifest map[string]interface{}
-	err = json.Unmarshal(globalManifestBytes, &globalManifest)
+	opts = NewJsonLdOptions("")
+	opts.Algorithm = AlgorithmURDNA2015
+	opts.SafeMode = true
+	_, err = proc.Normalize(doc, opts)
+	if assert.Error(t, err) {
+		assert.Equal(t, BaseDirectionDropped, err.(*JsonLdError).Code)
+	}
+}
+
+func TestJsonLdProcessor_NormalizeNumberOptions(t *testing.T) {
+	dec := json.NewDecoder(strings.NewReader(`{
+		"@id": "http://example.com/a",
+		"http://example.com/big": 1e19,
+		"http://example.com/id": 12345678901234567891
+	}`))
+	dec.UseNumber()
+	var numberDoc interface{}
+	assert.NoError(t, dec.Decode(&numberDoc))
+
+	proc := NewJsonLdProcessor()
+
+	opts := NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	opts.Algorithm = AlgorithmURDNA2015
+	opts.LegacyNumberFormat = true
+	result, err := proc.Normalize(map[string]interface{}{
+		"@id":                    "http://example.com/a",
+		"http://example.com/big": 1e19,
+	}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "<http://example.com/a> <http://example.com/big> "+
+		"\"1.0E19\"^^<http://www.w3.org/2001/XMLSchema#double> .\n", result)
+
+	opts = NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	opts.Algorithm = AlgorithmURDNA2015
+	opts.UseJSONNumber = true
+	result, err = proc.Normalize(numberDoc, opts)
 	assert.NoError(t, err)
+	assert.Contains(t, result, "<http://example.com/a> <http://example.com/id> "+
+		"\"12345678901234567891\"^^<http://www.w3.org/2001/XMLSchema#integer> .\n")
+}
 
-	// JSON-LD 1.1 official test suite
+func TestJsonLdProcessor_MaxDepth(t *testing.T) {
+	nested := func(depth int) map[string]interface{} {
+		var value interface{} = "leaf"
+		for i := 0; i < depth; i++ {
+			value = map[string]interface{}{"http://example.com/p": []interface{}{value}}
+		}
+		return value.(map[string]interface{})
+	}
 
-	manifestList := make([]string, 0)
-	for _, val := range globalManifest["sequence"].([]interface{}) {
-		manifestList = append(manifestList, filepath.Join(testDir, val.(string)))
+	proc := NewJsonLdProcessor()
+
+	// deeply nested arrays
+	var deepArray interface{} = "leaf"
+	for i := 0; i < 100000; i++ {
+		deepArray = []interface{}{deepArray}
+	}
+	_, err := proc.Expand(map[string]interface{}{"http://example.com/p": deepArray}, nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, MaxDepthExceeded, err.(*JsonLdError).Code)
 	}
 
-	manifestList = append(manifestList,
-		// Framing and Normalisation test suites
-		filepath.Join(testDir, "frame-manifest.jsonld"),
-		filepath.Join(testDir, "normalization", "manifest-urgna2012.jsonld"),
-		filepath.Join(testDir, "normalization", "manifest-urdna2015.jsonld"),
-		// extra tests that aren't covered by the official test suite
-		filepath.Join(testDir, "extra-manifest.jsonld"),
-	)
+	// deeply nested nodes in expanded form bypass the fast path
+	_, err = proc.Expand([]interface{}{nested(100000)}, nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, MaxDepthExceeded, err.(*JsonLdError).Code)
+	}
+
+	opts := NewJsonLdOptions("")
+	opts.MaxDepth = 20
+	_, err = proc.Flatten(nested(9), nil, opts)
+	assert.NoError(t, err)
+	_, err = proc.Flatten(nested(10), nil, opts)
+	if assert.Error(t, err) {
+		assert.Equal(t, MaxDepthExceeded, err.(*JsonLdError).Code)
+	}
 
-	dl := NewDefaultDocumentLoader(nil)
+	// the limits also apply to JsonLdApi used directly
+	api := NewJsonLdApi()
+	expanded, err := proc.Expand(nested(25), nil)
+	assert.NoError(t, err)
+	_, err = api.Compact(NewContext(nil, opts), "", expanded, true)
+	if assert.Error(t, err) {
+		assert.Equal(t, MaxDepthExceeded, err.(*JsonLdError).Code)
+	}
+	unlimitedOpts := NewJsonLdOptions("")
+	unlimitedOpts.MaxDepth = 0
+	expanded, err = proc.Expand(nested(DefaultMaxDepth), unlimitedOpts)
+	assert.NoError(t, err)
+	_, err = api.GenerateNodeMap(expanded, map[string]interface{}{"@default": map[string]interface{}{}},
+		"@default", NewIdentifierIssuer("_:b"), nil, "", nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, MaxDepthExceeded, err.(*JsonLdError).Code)
+	}
+}
+
+func TestJsonLdProcessor_IncludedInGraphContainers(t *testing.T) {
 	proc := NewJsonLdProcessor()
-	earlReport := NewEarlReport()
+	context := map[string]interface{}{
+		"@version": 1.1,
+		"@vocab":   "http://example.org/",
+		"input":    map[string]interface{}{"@container": "@graph"},
+	}
 
-	for _, manifestName := range manifestList {
-		inputBytes, err := os.ReadFile(manifestName)
+	toNQuads := func(doc map[string]interface{}) []string {
+		opts := NewJsonLdOptions("")
+		opts.Format = "application/n-quads"
+		nquads, err := proc.ToRDF(doc, opts)
 		assert.NoError(t, err)
+		return strings.Split(strings.TrimSpace(nquads.(string)), "\n")
+	}
 
-		var manifest map[string]interface{}
-		err = json.Unmarshal(inputBytes, &manifest)
-		assert.NoError(t, err)
+	// a graph container holding a value object produces an empty graph;
+	// included nodes next to it end up in the default graph
+	doc := map[string]interface{}{
+		"@context": context,
+		"@id":      "http://example.org/s",
+		"input":    map[string]interface{}{"@value": "x"},
+		"@included": []interface{}{
+			map[string]interface{}{"@id": "http://example.org/inc", "p": "v"},
+		},
+	}
+	assert.ElementsMatch(t, []string{
+		`<http://example.org/inc> <http://example.org/p> "v" .`,
+		`<http://example.org/s> <http://example.org/input> _:b0 .`,
+	}, toNQuads(doc))
 
-		baseIRI := ""
-		testListKey := "entries"
-		if baseValue, hasBase := manifest["baseIri"]; hasBase {
-			baseIRI = baseValue.(string)
-			// it must be a JSON-LD test manifest
-			testListKey = "sequence"
-		}
-		manifestPart := strings.Split(strings.Split(manifestName, "/")[1], ".")[0]
-		manifestURI := baseIRI + manifestPart
-		manifestBaseDir := filepath.Dir(manifestName)
-
-		// start a mock HTTP server
-		mockServer := NewMockServer(baseIRI, manifestBaseDir)
-		defer mockServer.Close()
-
-		testsToSkip := skippedTests[manifestName]
-
-		testList := make([]*TestDefinition, 0)
-
-		for _, testData := range manifest[testListKey].([]interface{}) {
-			testMap := testData.(map[string]interface{})
-
-			var (
-				testID             string
-				testType           string
-				testEvaluationType string
-				inputURL           string
-				inputFileName      string
-			)
-			expectedFileName := ""
-			if baseIRI != "" {
-				// JSON-LD test manifest
-				testID = testMap["@id"].(string)
-
-				testTypes := testMap["@type"].([]interface{})
-				testType = testTypes[len(testTypes)-1].(string)
-
-				testEvaluationType = testMap["@type"].([]interface{})[0].(string)
-				inputURL = baseIRI + testMap["input"].(string)
-				inputFileName = testMap["input"].(string)
-				if testEvaluationType != "jld:PositiveSyntaxTest" && testEvaluationType != "jld:NegativeEvaluationTest" {
-					expectedFileName = testMap["expect"].(string)
-				}
-			} else {
-				// Normalisation test manifest
-				testID = testMap["id"].(string)
-				testType = testMap["type"].(string)
-				testEvaluationType = "jld:PositiveEvaluationTest"
-				inputFileName = testMap["action"].(string)
-				expectedFileName = testMap["result"].(string)
-			}
+	flattened, err := proc.Flatten(doc, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, flattened, 3)
+
+	// included nodes nested inside the graph container share its graph
+	doc = map[string]interface{}{
+		"@context": context,
+		"@id":      "http://example.org/s",
+		"input": map[string]interface{}{
+			"@id": "http://example.org/n",
+			"@included": []interface{}{
+				map[string]interface{}{"@id": "http://example.org/inc", "p": "v"},
+			},
+		},
+	}
+	assert.ElementsMatch(t, []string{
+		`<http://example.org/inc> <http://example.org/p> "v" _:b0 .`,
+		`<http://example.org/s> <http://example.org/input> _:b0 .`,
+	}, toNQuads(doc))
+}
 
-			skip := false
+func TestJsonLdProcessor_RetainSourceContext(t *testing.T) {
+	proc := NewJsonLdProcessor()
+	innerCtx := map[string]interface{}{"name": "http://xmlns.com/foaf/0.1/name"}
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@vocab": "http://schema.org/",
+		},
+		"@id":  "http://example.com/alice",
+		"name": "Alice",
+		"knows": map[string]interface{}{
+			"@context": innerCtx,
+			"@id":      "http://example.com/bob",
+			"name":     "Bob",
+		},
+		"address": map[string]interface{}{
+			"streetAddress": "Main St",
+		},
+	}
 
-			for _, prefix := range testsToSkip {
-				if strings.HasPrefix(testID, prefix) {
-					skip = true
-					break
-				}
-			}
+	opts := NewJsonLdOptions("")
+	opts.RetainSourceContext = true
+	expanded, err := proc.Expand(doc, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			SourceContextKey:         map[string]interface{}{"@vocab": "http://schema.org/"},
+			"@id":                    "http://example.com/alice",
+			"http://schema.org/name": []interface{}{map[string]interface{}{"@value": "Alice"}},
+			"http://schema.org/knows": []interface{}{
+				map[string]interface{}{
+					SourceContextKey:                 innerCtx,
+					"@id":                            "http://example.com/bob",
+					"http://xmlns.com/foaf/0.1/name": []interface{}{map[string]interface{}{"@value": "Bob"}},
+				},
+			},
+			"http://schema.org/address": []interface{}{
+				map[string]interface{}{
+					"http://schema.org/streetAddress": []interface{}{map[string]interface{}{"@value": "Main St"}},
+				},
+			},
+		},
+	}, expanded)
 
-			if skipVal, hasSkip := testMap["skip"]; hasSkip {
-				skip = skipVal.(bool)
-			}
+	// the recorded context is a copy
+	innerCtx["name"] = "http://example.com/name"
+	knows := expanded[0].(map[string]interface{})["http://schema.org/knows"].([]interface{})
+	assert.Equal(t, "http://xmlns.com/foaf/0.1/name",
+		knows[0].(map[string]interface{})[SourceContextKey].(map[string]interface{})["name"])
 
-			testName := testID
-			if strings.HasPrefix(testName, "#") {
-				testName = manifestURI + testName
-			}
+	// other operations ignore the option
+	compacted, err := proc.Compact(doc, map[string]interface{}{"@vocab": "http://schema.org/"}, opts)
+	assert.NoError(t, err)
+	assert.NotContains(t, compacted, SourceContextKey)
+
+	// a top-level object which only wraps @graph is still unwrapped,
+	// its context is recorded in the nodes of the graph
+	graphDoc := map[string]interface{}{
+		"@context": map[string]interface{}{"@vocab": "http://schema.org/"},
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "http://example.com/alice", "name": "Alice"},
+			map[string]interface{}{"@context": innerCtx, "@id": "http://example.com/bob", "name": "Bob"},
+		},
+	}
+	expanded, err = proc.Expand(graphDoc, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			SourceContextKey:         map[string]interface{}{"@vocab": "http://schema.org/"},
+			"@id":                    "http://example.com/alice",
+			"http://schema.org/name": []interface{}{map[string]interface{}{"@value": "Alice"}},
+		},
+		map[string]interface{}{
+			SourceContextKey:          map[string]interface{}{"name": "http://example.com/name"},
+			"@id":                     "http://example.com/bob",
+			"http://example.com/name": []interface{}{map[string]interface{}{"@value": "Bob"}},
+		},
+	}, expanded)
+}
 
-			td := &TestDefinition{
-				ID:               testID,
-				Name:             testName,
-				Type:             testType,
-				EvaluationType:   testEvaluationType,
-				InputURL:         inputURL,
-				InputFileName:    filepath.Join(manifestBaseDir, inputFileName),
-				ExpectedFileName: filepath.Join(manifestBaseDir, expectedFileName),
-				Raw:              testMap,
-				Skip:             skip,
-			}
-			if optionVal, optionsPresent := testMap["option"]; optionsPresent {
-				td.Option = optionVal.(map[string]interface{})
-			}
-			testList = append(testList, td)
-		}
+func TestJsonLdProcessor_UseJSONNumber(t *testing.T) {
+	dec := json.NewDecoder(strings.NewReader(`{
+		"@context": {"@vocab": "http://example.com/"},
+		"@id": "http://example.com/row/1",
+		"id": 9007199254740993,
+		"bigId": 18446744073709551615,
+		"negative": -9223372036854775808,
+		"exponent": 1e3,
+		"ratio": 1.5,
+		"huge": 1e21
+	}`))
+	dec.UseNumber()
+	var doc interface{}
+	assert.NoError(t, dec.Decode(&doc))
 
-	SequenceLoop:
-		for _, td := range testList {
-			// ToRDF tests with a reference to RFC3986 don't agree with Go implementation of RFC 3986
-			// (see url.URL.ResolveReference(). Skipping for now, as other JSON-LD implementations do.
-			purpose := td.Raw["purpose"]
-			if purpose != nil && strings.Contains(purpose.(string), "RFC3986") {
-				log.Println("Skipping RFC3986 test", td.ID, ":", td.Name)
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	opts.UseJSONNumber = true
 
-				earlReport.addAssertion(td.Name, true, false)
+	nquads, err := proc.ToRDF(doc, opts)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		`<http://example.com/row/1> <http://example.com/bigId> "18446744073709551615"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		`<http://example.com/row/1> <http://example.com/exponent> "1000"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		`<http://example.com/row/1> <http://example.com/huge> "1.0E21"^^<http://www.w3.org/2001/XMLSchema#double> .`,
+		`<http://example.com/row/1> <http://example.com/id> "9007199254740993"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		`<http://example.com/row/1> <http://example.com/negative> "-9223372036854775808"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		`<http://example.com/row/1> <http://example.com/ratio> "1.5E0"^^<http://www.w3.org/2001/XMLSchema#double> .`,
+	}, strings.Split(strings.TrimSpace(nquads.(string)), "\n"))
+
+	// native integers are converted back to json.Number values
+	opts.Format = ""
+	opts.UseNativeTypes = true
+	fromRDF, err := proc.FromRDF(nquads, opts)
+	assert.NoError(t, err)
+	node := fromRDF.([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"@value": json.Number("9007199254740993")}},
+		node["http://example.com/id"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"@value": json.Number("18446744073709551615")}},
+		node["http://example.com/bigId"])
+
+	// and round-trip without losing precision
+	opts.Format = "application/n-quads"
+	roundTrip, err := proc.ToRDF(fromRDF, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, nquads, roundTrip)
+}
 
-				continue
-			}
+func TestJsonLdProcessor_NativeIntegersToRDF(t *testing.T) {
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.UseNativeTypes = true
+	fromRDF, err := proc.FromRDF(`<http://example.com/s> <http://example.com/small> "42"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://example.com/s> <http://example.com/big> "12345678901234567891"^^<http://www.w3.org/2001/XMLSchema#integer> .
+`, opts)
+	assert.NoError(t, err)
+	node := fromRDF.([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"@value": int64(42)}}, node["http://example.com/small"])
+	// integers which don't fit into int64 keep their datatype
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"@value": "12345678901234567891",
+		"@type":  XSDInteger,
+	}}, node["http://example.com/big"])
+
+	opts = NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	nquads, err := proc.ToRDF(fromRDF, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, `<http://example.com/s> <http://example.com/big> "12345678901234567891"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://example.com/s> <http://example.com/small> "42"^^<http://www.w3.org/2001/XMLSchema#integer> .
+`, nquads)
+}
 
-			if td.Skip {
-				log.Println("Test marked as skipped:", td.ID, ":", td.Name)
+func TestJsonLdProcessor_RawJSONContext(t *testing.T) {
+	proc := NewJsonLdProcessor()
+	doc := map[string]interface{}{
+		"@id":                    "http://example.com/a",
+		"http://schema.org/name": "A",
+	}
+	rawContext := `{"@context": {"@vocab": "http://schema.org/"}}`
 
-				if os.Getenv("SKIP_MODE") == "fail" {
-					earlReport.addAssertion(td.Name, false, false)
-				} else {
-					earlReport.addAssertion(td.Name, true, false)
-				}
+	compacted, err := proc.Compact(doc, json.RawMessage(rawContext), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"@context": map[string]interface{}{"@vocab": "http://schema.org/"},
+		"@id":      "http://example.com/a",
+		"name":     "A",
+	}, compacted)
 
-				continue
-			}
+	flattened, err := proc.Flatten(doc, strings.NewReader(rawContext), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"@context": map[string]interface{}{"@vocab": "http://schema.org/"},
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "http://example.com/a", "name": "A"},
+		},
+	}, flattened)
 
-			// read 'option' section and initialise JsonLdOptions and expected HTTP server responses
-
-			options := NewJsonLdOptions("")
-
-			var returnContentType string
-			var returnHTTPStatus int
-			var returnRedirectTo string
-			var returnHTTPLink []string
-
-			if td.Option != nil {
-				testOpts := td.Option
-
-				if value, hasValue := testOpts["specVersion"]; hasValue {
-					if value == JsonLd_1_0 {
-						log.Println("Skipping JSON-LD 1.0 test:", td.ID, ":", td.Name)
-						continue
-					}
-				}
-
-				if value, hasValue := testOpts["processingMode"]; hasValue {
-					options.ProcessingMode = value.(string)
-					if options.ProcessingMode == JsonLd_1_1 {
-						options.OmitGraph = true
-					}
-				}
-
-				if value, hasValue := testOpts["base"]; hasValue {
-					options.Base = value.(string)
-				}
-				if value, hasValue := testOpts["expandContext"]; hasValue {
-					contextDoc, err := dl.LoadDocument(filepath.Join(testDir, value.(string)))
-					assert.NoError(t, err)
-					options.ExpandContext = contextDoc.Document
-				}
-				if value, hasValue := testOpts["compactArrays"]; hasValue {
-					options.CompactArrays = value.(bool)
-				}
-				if value, hasValue := testOpts["omitGraph"]; hasValue {
-					options.OmitGraph = value.(bool)
-				}
-				if value, hasValue := testOpts["useNativeTypes"]; hasValue {
-					options.UseNativeTypes = value.(bool)
-				}
-				if value, hasValue := testOpts["useRdfType"]; hasValue {
-					options.UseRdfType = value.(bool)
-				}
-				if value, hasValue := testOpts["produceGeneralizedRdf"]; hasValue {
-					options.ProduceGeneralizedRdf = value.(bool)
-				}
-
-				if value, hasValue := testOpts["contentType"]; hasValue {
-					returnContentType = value.(string)
-				}
-				if value, hasValue := testOpts["httpStatus"]; hasValue {
-					returnHTTPStatus = int(value.(float64))
-				}
-				if value, hasValue := testOpts["redirectTo"]; hasValue {
-					returnRedirectTo = value.(string)
-				}
-				if value, hasValue := testOpts["httpLink"]; hasValue {
-					returnHTTPLink = make([]string, 0)
-					if valueList, isList := value.([]interface{}); isList {
-						for _, link := range valueList {
-							returnHTTPLink = append(returnHTTPLink, link.(string))
-						}
-					} else {
-						returnHTTPLink = append(returnHTTPLink, value.(string))
-					}
-				}
-			}
+	_, err = proc.Compact(doc, []byte("{"), nil)
+	assert.Error(t, err)
+}
 
-			mockServer.SetExpectedBehaviour(returnContentType, returnHTTPLink, returnHTTPStatus, returnRedirectTo)
-
-			options.DocumentLoader = mockServer.DocumentLoader
-
-			var result interface{}
-			var opError error
-
-			switch td.Type {
-			case "jld:ExpandTest":
-				log.Println("Running Expand test", td.ID, ":", td.Name)
-				result, opError = proc.Expand(td.InputURL, options)
-			case "jld:CompactTest":
-				log.Println("Running Compact test", td.ID, ":", td.Name)
-
-				contextFilename := td.Raw["context"].(string)
-				contextDoc, err := dl.LoadDocument(filepath.Join(manifestBaseDir, contextFilename))
-				assert.NoError(t, err)
-
-				result, opError = proc.Compact(td.InputURL, contextDoc.Document, options)
-			case "jld:FlattenTest":
-				log.Println("Running Flatten test", td.ID, ":", td.Name)
-
-				var ctxDoc interface{}
-				if ctxVal, hasContext := td.Raw["context"]; hasContext {
-					contextFilename := ctxVal.(string)
-					contextDoc, err := dl.LoadDocument(filepath.Join(manifestBaseDir, contextFilename))
-					assert.NoError(t, err)
-					ctxDoc = contextDoc.Document
-				}
-
-				result, opError = proc.Flatten(td.InputURL, ctxDoc, options)
-			case "jld:FrameTest":
-				log.Println("Running Frame test", td.ID, ":", td.Name)
-
-				frameFilename := td.Raw["frame"].(string)
-				frameDoc, err := dl.LoadDocument(filepath.Join(manifestBaseDir, frameFilename))
-				assert.NoError(t, err)
-
-				result, opError = proc.Frame(td.InputURL, frameDoc.Document, options)
-			case "jld:FromRDFTest":
-				log.Println("Running FromRDF test", td.ID, ":", td.Name)
-
-				inputBytes, err := os.ReadFile(td.InputFileName)
-				assert.NoError(t, err)
-				input := string(inputBytes)
-
-				result, opError = proc.FromRDF(input, options)
-			case "jld:ToRDFTest":
-				log.Println("Running ToRDF test", td.ID, ":", td.Name)
-
-				options.Format = "application/n-quads"
-				result, opError = proc.ToRDF(td.InputURL, options)
-			case "jld:HtmlTest":
-				log.Println("Running HTML test", td.ID, ":", td.Name)
-				// TODO
-				result, opError = proc.Expand(td.InputURL, options)
-			case "rdfn:Urgna2012EvalTest":
-				log.Println("Running URGNA2012 test", td.ID, ":", td.Name)
-
-				inputBytes, err := os.ReadFile(td.InputFileName)
-				assert.NoError(t, err)
-				input := string(inputBytes)
-				options.InputFormat = "application/n-quads"
-				options.Format = "application/n-quads"
-				options.Algorithm = AlgorithmURGNA2012
-				result, opError = proc.Normalize(input, options)
-			case "rdfn:Urdna2015EvalTest":
-				log.Println("Running URDNA2015 test", td.Name)
-
-				inputBytes, err := os.ReadFile(td.InputFileName)
-				assert.NoError(t, err)
-				input := string(inputBytes)
-				options.InputFormat = "application/n-quads"
-				options.Format = "application/n-quads"
-				options.Algorithm = AlgorithmURDNA2015
-				result, opError = proc.Normalize(input, options)
-			default:
-				break SequenceLoop
-			}
+func TestJsonLdProcessor_BinaryValues(t *testing.T) {
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+
+	doc := map[string]interface{}{
+		"@id":                        "http://example.com/credential",
+		"http://example.com/proof":   []byte("signature"),
+		"http://example.com/digest":  map[string]interface{}{"@value": []byte{0xca, 0xfe}, "@type": XSDHexBinary},
+		"http://example.com/comment": "plain",
+	}
+	nquads, err := proc.ToRDF(doc, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, `<http://example.com/credential> <http://example.com/comment> "plain" .
+<http://example.com/credential> <http://example.com/digest> "CAFE"^^<http://www.w3.org/2001/XMLSchema#hexBinary> .
+<http://example.com/credential> <http://example.com/proof> "c2lnbmF0dXJl"^^<http://www.w3.org/2001/XMLSchema#base64Binary> .
+`, nquads)
+
+	opts.Format = ""
+	opts.UseNativeBinary = true
+	fromRDF, err := proc.FromRDF(nquads.(string)+
+		`<http://example.com/credential> <http://example.com/broken> "not base64!"^^<http://www.w3.org/2001/XMLSchema#base64Binary> .`, opts)
+	assert.NoError(t, err)
+	node := fromRDF.([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"@value": []byte("signature"), "@type": XSDBase64Binary}},
+		node["http://example.com/proof"])
+	// hexBinary values are kept as strings, as []byte values would be marshalled as base64
+	assert.Equal(t, []interface{}{map[string]interface{}{"@value": "CAFE", "@type": XSDHexBinary}},
+		node["http://example.com/digest"])
+	// invalid literals are kept as is
+	assert.Equal(t, []interface{}{map[string]interface{}{"@value": "not base64!", "@type": XSDBase64Binary}},
+		node["http://example.com/broken"])
+
+	// binary values round-trip
+	opts.Format = "application/n-quads"
+	delete(node, "http://example.com/broken")
+	roundTrip, err := proc.ToRDF(fromRDF, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, nquads, roundTrip)
 
-			var expected interface{}
-			var expectedType string
-			if td.EvaluationType == "jld:PositiveEvaluationTest" {
-				// we don't expect any errors here
-				if !assert.NoError(t, opError, td.Name) {
-					earlReport.addAssertion(td.Name, false, false)
-					continue
-				}
-
-				// load expected document
-				expectedType = filepath.Ext(td.ExpectedFileName)
-				if expectedType == ".jsonld" || expectedType == ".json" {
-					// load as JSON-LD/JSON
-					rdOut, err := dl.LoadDocument(td.ExpectedFileName)
-					assert.NoError(t, err)
-					expected = rdOut.Document
-
-					// marshal/unmarshal the result to avoid any differences due to formatting & key sequences
-					resultBytes, _ := json.MarshalIndent(result, "", "  ")
-					_ = json.Unmarshal(resultBytes, &result)
-				} else if expectedType == ".nq" {
-					// load as N-Quads
-					expectedBytes, err := os.ReadFile(td.ExpectedFileName)
-					assert.NoError(t, err)
-
-					// we sort for the actual and the expected results to ignore differences in the order.
-					result = sortNQuads(result.(string))
-					expected = sortNQuads(string(expectedBytes))
-
-					if Isomorphic(string(expectedBytes), result.(string)) {
-						expected = "_equal_"
-						result = "_equal_"
-					}
-				}
-			} else if td.EvaluationType == "jld:NegativeEvaluationTest" {
-				if v, found := td.Raw["expectErrorCode"]; found {
-					expected = v.(string)
-				} else if v, found := td.Raw["expect"]; found {
-					expected = v.(string)
-				}
-
-				if opError != nil {
-					result = string(opError.(*JsonLdError).Code) //nolint:errorlint
-				} else {
-					//PrintDocument("RESULT", result)
-					result = ""
-				}
-			} else if td.EvaluationType == "jld:PositiveSyntaxTest" {
-				if opError != nil {
-					result = string(opError.(*JsonLdError).Code) //nolint:errorlint
-				} else {
-					result = ""
-				}
-
-				expected = ""
-			}
+	// including through JSON
+	fromRDFBytes, err := json.Marshal(fromRDF)
+	assert.NoError(t, err)
+	var fromJSON interface{}
+	assert.NoError(t, json.Unmarshal(fromRDFBytes, &fromJSON))
+	roundTrip, err = proc.ToRDF(fromJSON, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, nquads, roundTrip)
+}
 
-			if !assert.True(t, DeepCompare(expected, result, true)) {
-				// print out expected vs. actual results in a human readable form
-				if expectedType == ".jsonld" || expectedType == ".json" {
-					log.Println("==== ACTUAL ====")
-					b, _ := json.MarshalIndent(result, "", "  ")
-					_, _ = os.Stdout.Write(b)
-					_, _ = os.Stdout.WriteString("\n")
-					log.Println("==== EXPECTED ====")
-					b, _ = json.MarshalIndent(expected, "", "  ")
-					_, _ = os.Stdout.Write(b)
-					_, _ = os.Stdout.WriteString("\n")
-
-				} else if expectedType == ".nq" {
-					log.Println("==== ACTUAL ====")
-					_, _ = os.Stdout.WriteString(result.(string))
-					_, _ = os.Stdout.WriteString("\n\n")
-					log.Println("==== EXPECTED ====")
-					_, _ = os.Stdout.WriteString(expected.(string))
-					_, _ = os.Stdout.WriteString("\n\n")
-				} else {
-					log.Println("==== ACTUAL ====")
-					_, _ = os.Stdout.WriteString(result.(string))
-					_, _ = os.Stdout.WriteString("\n")
-					log.Println("==== EXPECTED ====")
-					_, _ = os.Stdout.WriteString(expected.(string))
-					_, _ = os.Stdout.WriteString("\n")
-				}
-				log.Println("Error when running", td.ID, "for", td.Type)
-				earlReport.addAssertion(td.Name, false, false)
-				if os.Getenv("FULL_RUN") != "true" {
-					return
-				}
-			} else {
-				//assert.Fail(t, "XX")
-				earlReport.addAssertion(td.Name, false, true)
-			}
-		}
+func TestJsonLdProcessor_ExpandWithContext(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@base":  "docs/",
+			"@vocab": "http://schema.org/",
+		},
+		"@id":  "alice",
+		"name": "Alice",
 	}
-	earlReport.write("earl.jsonld")
-}
 
-const (
-	assertor     = "https://github.com/kazarena"
-	assertorName = "Stan Nazarenko"
-)
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("http://example.com/")
+	expanded, ctx, err := proc.ExpandWithContext(doc, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com/docs/", ctx.EffectiveBase())
+	assert.Equal(t, "http://schema.org/", ctx.EffectiveVocab())
+
+	expected, err := proc.Expand(doc, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, expanded)
+	assert.Equal(t, "http://example.com/docs/alice", expanded[0].(map[string]interface{})["@id"])
+
+	// without a context, the initial context is returned
+	_, ctx, err = proc.ExpandWithContext(map[string]interface{}{"@id": "bob"}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com/", ctx.EffectiveBase())
+	assert.Equal(t, "", ctx.EffectiveVocab())
 
-// EarlReport generates an EARL report.
-type EarlReport struct {
-	report map[string]interface{}
+	doc["@context"] = map[string]interface{}{"@base": nil}
+	_, ctx, err = proc.ExpandWithContext(doc, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "", ctx.EffectiveBase())
 }
 
-func NewEarlReport() *EarlReport {
-	version := os.Getenv("VERSION")
-	if version == "" {
-		version = "v0.3.0"
+func TestJsonLdProcessor_RemoteDocumentInput(t *testing.T) {
+	rd := &RemoteDocument{
+		DocumentURL: "http://example.com/people/alice.json",
+		Document: map[string]interface{}{
+			"id":    "#me",
+			"name":  "Alice",
+			"knows": "bob.json#me",
+		},
+		ContextURL: "http://example.com/context.jsonld",
 	}
-	rval := &EarlReport{
-		report: map[string]interface{}{
+
+	opts := NewJsonLdOptions("")
+	opts.DocumentLoader = NewMapDocumentLoader(map[string]interface{}{
+		"http://example.com/context.jsonld": map[string]interface{}{
 			"@context": map[string]interface{}{
-				"doap":            "http://usefulinc.com/ns/doap#",
-				"foaf":            "http://xmlns.com/foaf/0.1/",
-				"dc":              "http://purl.org/dc/terms/",
-				"earl":            "http://www.w3.org/ns/earl#",
-				"xsd":             "http://www.w3.org/2001/XMLSchema#",
-				"doap:homepage":   map[string]interface{}{"@type": "@id"},
-				"doap:license":    map[string]interface{}{"@type": "@id"},
-				"dc:creator":      map[string]interface{}{"@type": "@id"},
-				"foaf:homepage":   map[string]interface{}{"@type": "@id"},
-				"subjectOf":       map[string]interface{}{"@reverse": "earl:subject"},
-				"earl:assertedBy": map[string]interface{}{"@type": "@id"},
-				"earl:mode":       map[string]interface{}{"@type": "@id"},
-				"earl:test":       map[string]interface{}{"@type": "@id"},
-				"earl:outcome":    map[string]interface{}{"@type": "@id"},
-				"dc:date":         map[string]interface{}{"@type": "xsd:date"},
-			},
-			"@id": "https://github.com/piprate/json-gold",
-			"@type": []interface{}{
-				"doap:Project",
-				"earl:TestSubject",
-				"earl:Software",
+				"id":    "@id",
+				"name":  "http://schema.org/name",
+				"knows": map[string]interface{}{"@id": "http://schema.org/knows", "@type": "@id"},
 			},
-			"doap:name":                 "JSON-goLD",
-			"dc:title":                  "JSON-goLD",
-			"doap:homepage":             "https://github.com/piprate/json-gold",
-			"doap:license":              "https://github.com/piprate/json-gold/blob/master/LICENSE",
-			"doap:description":          "A JSON-LD processor for Go",
-			"doap:programming-language": "Go",
-			"dc:creator":                assertor,
-			"doap:developer": map[string]interface{}{
-				"@id": assertor,
-				"@type": []interface{}{
-					"foaf:Person",
-					"earl:Assertor",
-				},
-				"foaf:name":     assertorName,
-				"foaf:homepage": assertor,
-			},
-			"doap:release": map[string]interface{}{
-				"@id":           fmt.Sprintf("https://github.com/piprate/json-gold/tree/%s", version),
-				"@type":         "doap:Version",
-				"doap:revision": version,
-				"doap:name":     fmt.Sprintf("json-gold-%s", version),
-				"doap:created": map[string]interface{}{
-					"@value": time.Now().Format("2006-01-02"),
-					"@type":  "xsd:date",
-				},
+		},
+	})
+
+	proc := NewJsonLdProcessor()
+	expanded, err := proc.Expand(rd, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id": "http://example.com/people/alice.json#me",
+			"http://schema.org/name": []interface{}{
+				map[string]interface{}{"@value": "Alice"},
 			},
-			"dc:date": map[string]interface{}{
-				"@value": time.Now().Format("2006-01-02"),
-				"@type":  "xsd:date",
+			"http://schema.org/knows": []interface{}{
+				map[string]interface{}{"@id": "http://example.com/people/bob.json#me"},
 			},
-			"subjectOf": make([]interface{}, 0),
 		},
+	}, expanded)
+	assert.Equal(t, "", opts.Base)
+
+	// other operations accept remote documents too
+	compacted, err := proc.Compact(rd, map[string]interface{}{"@vocab": "http://schema.org/"}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "#me", compacted["@id"])
+
+	quads, err := proc.ToRDF(rd, opts)
+	assert.NoError(t, err)
+	assert.Len(t, quads.(*RDFDataset).GetQuads("@default"), 2)
+
+	_, err = proc.Expand((*RemoteDocument)(nil), opts)
+	assert.Error(t, err)
+}
+
+func TestJsonLdProcessor_StrictIRICoercion(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"knows": map[string]interface{}{"@id": "http://schema.org/knows", "@type": "@id"},
+			"kind":  map[string]interface{}{"@id": "http://schema.org/kind", "@type": "@vocab"},
+		},
+		"@id":   "http://example.com/alice",
+		"knows": []interface{}{"http://example.com/bob", 42},
+		"kind":  true,
+	}
+
+	proc := NewJsonLdProcessor()
+
+	// by default, values which aren't strings are kept as literals and reported
+	var warnings []*JsonLdError
+	opts := NewJsonLdOptions("")
+	opts.WarningHandler = func(warning *JsonLdError) {
+		warnings = append(warnings, warning)
+	}
+	expanded, err := proc.Expand(doc, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"@id": "http://example.com/bob"},
+		map[string]interface{}{"@value": 42},
+	}, expanded[0].(map[string]interface{})["http://schema.org/knows"])
+	if assert.Len(t, warnings, 2) {
+		// keys are expanded in sorted order
+		assert.Equal(t, InvalidTypedValue, warnings[0].Code)
+		assert.Equal(t, "kind", warnings[0].Details.(*ValueErrorDetails).Property)
+		assert.Equal(t, "knows", warnings[1].Details.(*ValueErrorDetails).Property)
+		assert.Equal(t, 42, warnings[1].Details.(*ValueErrorDetails).Value)
 	}
 
-	return rval
+	opts = NewJsonLdOptions("")
+	opts.StrictIRICoercion = true
+	_, err = proc.Expand(doc, opts)
+	if assert.Error(t, err) {
+		assert.Equal(t, InvalidTypedValue, err.(*JsonLdError).Code)
+	}
 }
 
-func (er *EarlReport) addAssertion(testName string, skipped bool, success bool) {
-	var outcome string
-	if skipped {
-		outcome = "earl:untested"
-	} else if success {
-		outcome = "earl:passed"
-	} else {
-		outcome = "earl:failed"
-	}
-	er.report["subjectOf"] = append(
-		er.report["subjectOf"].([]interface{}),
-		map[string]interface{}{
-			"@type":           "earl:Assertion",
-			"earl:assertedBy": assertor,
-			"earl:mode":       "earl:automatic",
-			"earl:test":       testName,
-			"earl:result": map[string]interface{}{
-				"@type":        "earl:TestResult",
-				"dc:date":      time.Now().Format("2006-01-02T15:04:05.999999"),
-				"earl:outcome": outcome,
-			},
+func TestJsonLdProcessor_UnmappedMembers(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"name": "http://schema.org/name",
 		},
-	)
+		"@id":      "http://example.com/alice",
+		"name":     "Alice",
+		"revision": 7,
+		"_links":   map[string]interface{}{"self": "/people/alice"},
+	}
+	context := map[string]interface{}{
+		"name": "http://schema.org/name",
+	}
+
+	proc := NewJsonLdProcessor()
+
+	compacted, err := proc.Compact(doc, context, nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, compacted, "revision")
+	assert.NotContains(t, compacted, "_links")
+
+	opts := NewJsonLdOptions("")
+	opts.UnmappedMembersKey = "_unmapped"
+	compacted, err = proc.Compact(doc, context, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"@context": context,
+		"@id":      "http://example.com/alice",
+		"name":     "Alice",
+		"_unmapped": map[string]interface{}{
+			"revision": 7,
+			"_links":   map[string]interface{}{"self": "/people/alice"},
+		},
+	}, compacted)
+
+	// nothing is attached if all members are mapped
+	delete(doc, "revision")
+	delete(doc, "_links")
+	compacted, err = proc.Compact(doc, context, opts)
+	assert.NoError(t, err)
+	assert.NotContains(t, compacted, "_unmapped")
+
+	// existing members aren't overwritten
+	doc["_links"] = "/people/alice"
+	opts.UnmappedMembersKey = "name"
+	_, err = proc.Compact(doc, context, opts)
+	if assert.Error(t, err) {
+		assert.Equal(t, DuplicateKey, err.(*JsonLdError).Code)
+	}
 }
 
-func (er *EarlReport) write(filename string) {
-	b, _ := json.MarshalIndent(er.report, "", "  ")
+func TestJsonLdProcessor_UnmappedMembersScopedContexts(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@version": 1.1,
+			"name":     "http://schema.org/name",
+			"revision": "http://example.com/revision",
+			"Person": map[string]interface{}{
+				"@id": "http://schema.org/Person",
+				"@context": map[string]interface{}{
+					"nick":     "http://example.com/nick",
+					"revision": nil,
+				},
+			},
+			"meta": "@nest",
+		},
+		"@type":    "Person",
+		"name":     "Alice",
+		"nick":     "Al",
+		"revision": 7,
+		"meta":     map[string]interface{}{"etag": "abc"},
+	}
 
-	f, _ := os.Create(filename)
-	defer f.Close()
-	_, _ = f.Write(b)
-	_, _ = f.WriteString("\n")
+	opts := NewJsonLdOptions("")
+	opts.UnmappedMembersKey = "_unmapped"
+	compacted, err := NewJsonLdProcessor().Compact(doc, nil, opts)
+	assert.NoError(t, err)
+	// nick is defined by the type-scoped context, revision is removed by it,
+	// etag isn't a top-level member
+	assert.Equal(t, map[string]interface{}{"revision": 7}, compacted["_unmapped"])
+	assert.Equal(t, "Al", compacted["http://example.com/nick"])
 }
@@ -0,0 +1,181 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"strings"
+	"sync"
+)
+
+// quadAllocatorBlockSize is the number of quads or nodes in a block allocated by QuadAllocator.
+const quadAllocatorBlockSize = 1024
+
+// QuadAllocator allocates quads and RDF nodes in blocks instead of one by one,
+// which greatly reduces the number of heap allocations made by ToRDF for large documents.
+// To use it, set JsonLdOptions.QuadAllocator:
+//
+//	alloc := ld.NewQuadAllocator()
+//	opts.QuadAllocator = alloc
+//	dataset, err := proc.ToRDF(doc, opts)
+//	// use dataset
+//	alloc.Release()
+//
+// Release makes the memory of all quads and nodes allocated since the previous call
+// available for reuse by subsequent conversions, so they must not be used after Release
+// is called. Quads must not be retained beyond that point; copy them if necessary.
+//
+// QuadAllocator isn't safe for concurrent use. Its methods may be called on a nil
+// QuadAllocator, in which case quads and nodes are allocated individually.
+type QuadAllocator struct {
+	quads      blockList[Quad]
+	iris       blockList[IRI]
+	blankNodes blockList[BlankNode]
+	literals   blockList[Literal]
+}
+
+var (
+	quadBlocks      = sync.Pool{New: func() interface{} { return newBlock[Quad]() }}
+	iriBlocks       = sync.Pool{New: func() interface{} { return newBlock[IRI]() }}
+	blankNodeBlocks = sync.Pool{New: func() interface{} { return newBlock[BlankNode]() }}
+	literalBlocks   = sync.Pool{New: func() interface{} { return newBlock[Literal]() }}
+)
+
+// NewQuadAllocator creates a new QuadAllocator.
+func NewQuadAllocator() *QuadAllocator {
+	return &QuadAllocator{
+		quads:      blockList[Quad]{pool: &quadBlocks},
+		iris:       blockList[IRI]{pool: &iriBlocks},
+		blankNodes: blockList[BlankNode]{pool: &blankNodeBlocks},
+		literals:   blockList[Literal]{pool: &literalBlocks},
+	}
+}
+
+// NewQuad creates a new instance of Quad, see NewQuad.
+func (a *QuadAllocator) NewQuad(subject Node, predicate Node, object Node, graph string) *Quad {
+	if a == nil {
+		return NewQuad(subject, predicate, object, graph)
+	}
+
+	q := a.quads.alloc()
+	q.Subject = subject
+	q.Predicate = predicate
+	q.Object = object
+
+	if graph != "" && graph != "@default" {
+		if strings.HasPrefix(graph, "_:") {
+			q.Graph = a.NewBlankNode(graph)
+		} else {
+			q.Graph = a.NewIRI(graph)
+		}
+	}
+	return q
+}
+
+// NewIRI creates a new instance of IRI.
+func (a *QuadAllocator) NewIRI(iri string) *IRI {
+	if a == nil {
+		return NewIRI(iri)
+	}
+
+	i := a.iris.alloc()
+	i.Value = iri
+	return i
+}
+
+// NewBlankNode creates a new instance of BlankNode.
+func (a *QuadAllocator) NewBlankNode(attribute string) *BlankNode {
+	if a == nil {
+		return NewBlankNode(attribute)
+	}
+
+	bn := a.blankNodes.alloc()
+	bn.Attribute = attribute
+	return bn
+}
+
+// NewLiteral creates a new instance of Literal, see NewLiteral.
+func (a *QuadAllocator) NewLiteral(value string, datatype string, language string) *Literal {
+	if a == nil {
+		return NewLiteral(value, datatype, language)
+	}
+
+	l := a.literals.alloc()
+	l.Value = value
+	l.Language = language
+	if datatype != "" {
+		l.Datatype = datatype
+	} else {
+		l.Datatype = XSDString
+	}
+	return l
+}
+
+// Release makes the memory of all quads and nodes allocated by this QuadAllocator
+// available for reuse. They must not be used after this call.
+func (a *QuadAllocator) Release() {
+	if a == nil {
+		return
+	}
+
+	a.quads.release()
+	a.iris.release()
+	a.blankNodes.release()
+	a.literals.release()
+}
+
+// blockList is a list of blocks of values of type T, taken from a pool.
+type blockList[T any] struct {
+	pool    *sync.Pool
+	current *[]T
+	used    []*[]T
+}
+
+func newBlock[T any]() *[]T {
+	b := make([]T, 0, quadAllocatorBlockSize)
+	return &b
+}
+
+// alloc returns a pointer to a zero value of type T.
+func (b *blockList[T]) alloc() *T {
+	if b.current == nil || len(*b.current) == cap(*b.current) {
+		if b.current != nil {
+			b.used = append(b.used, b.current)
+		}
+		b.current = b.pool.Get().(*[]T)
+	}
+
+	*b.current = (*b.current)[:len(*b.current)+1]
+	return &(*b.current)[len(*b.current)-1]
+}
+
+// release clears all blocks and returns them to the pool.
+func (b *blockList[T]) release() {
+	if b.current != nil {
+		b.used = append(b.used, b.current)
+	}
+
+	var zero T
+	for _, blk := range b.used {
+		// don't let the pool retain the values referenced by the released nodes
+		for i := range *blk {
+			(*blk)[i] = zero
+		}
+		*blk = (*blk)[:0]
+		b.pool.Put(blk)
+	}
+
+	b.current = nil
+	b.used = nil
+}
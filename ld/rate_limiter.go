@@ -0,0 +1,114 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"sync"
+	"time"
+)
+
+// HostRateLimiter limits requests made by document loaders to each host, so that
+// processing large batches of documents which reference many contexts on the same
+// registry doesn't overload it. The request rate is limited with a token bucket
+// per host, and the number of concurrent requests per host may be capped too.
+//
+// A HostRateLimiter is safe for concurrent use and may be shared between loaders.
+// See WithRateLimiter and RFC7324CachingDocumentLoader.SetRateLimiter.
+type HostRateLimiter struct {
+	rate          float64
+	burst         float64
+	maxConcurrent int
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimit
+}
+
+type hostLimit struct {
+	tokens float64
+	last   time.Time
+	slots  chan struct{}
+}
+
+// NewHostRateLimiter creates a limiter which allows up to requestsPerSecond requests
+// per second to each host, with bursts of up to burst requests, and no more than
+// maxConcurrent simultaneous requests to each host. Zero requestsPerSecond disables
+// rate limiting, and zero maxConcurrent allows any number of concurrent requests.
+func NewHostRateLimiter(requestsPerSecond float64, burst int, maxConcurrent int) *HostRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &HostRateLimiter{
+		rate:          requestsPerSecond,
+		burst:         float64(burst),
+		maxConcurrent: maxConcurrent,
+		hosts:         make(map[string]*hostLimit),
+	}
+}
+
+// acquire blocks until a request to the given host is allowed, and returns
+// a function which must be called when the request has completed.
+// The returned function may be called more than once. A nil limiter allows all requests.
+func (l *HostRateLimiter) acquire(host string) func() {
+	if l == nil {
+		return func() {}
+	}
+
+	l.mu.Lock()
+	hl, found := l.hosts[host]
+	if !found {
+		hl = &hostLimit{tokens: l.burst}
+		if l.maxConcurrent > 0 {
+			hl.slots = make(chan struct{}, l.maxConcurrent)
+		}
+		l.hosts[host] = hl
+	}
+	l.mu.Unlock()
+
+	if hl.slots != nil {
+		hl.slots <- struct{}{}
+	}
+
+	if l.rate > 0 {
+		l.mu.Lock()
+		now := time.Now()
+		if !hl.last.IsZero() {
+			hl.tokens += now.Sub(hl.last).Seconds() * l.rate
+			if hl.tokens > l.burst {
+				hl.tokens = l.burst
+			}
+		}
+		hl.last = now
+		// take a token, possibly reserving one which isn't available yet
+		hl.tokens--
+		var wait time.Duration
+		if hl.tokens < 0 {
+			wait = time.Duration(-hl.tokens / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		if wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if hl.slots != nil {
+				<-hl.slots
+			}
+		})
+	}
+}
@@ -28,6 +28,9 @@ const (
 	XSDAnyURI  string = XSDNS + "anyURI"
 	XSDString  string = XSDNS + "string"
 
+	XSDBase64Binary string = XSDNS + "base64Binary"
+	XSDHexBinary    string = XSDNS + "hexBinary"
+
 	RDFType         string = RDFSyntaxNS + "type"
 	RDFFirst        string = RDFSyntaxNS + "first"
 	RDFRest         string = RDFSyntaxNS + "rest"
@@ -38,4 +41,7 @@ const (
 	RDFObject       string = RDFSyntaxNS + "object"
 	RDFLangString   string = RDFSyntaxNS + "langString"
 	RDFList         string = RDFSyntaxNS + "List"
+	RDFValue        string = RDFSyntaxNS + "value"
+	RDFLanguage     string = RDFSyntaxNS + "language"
+	RDFDirection    string = RDFSyntaxNS + "direction"
 )
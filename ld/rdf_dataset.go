@@ -16,9 +16,11 @@ package ld
 
 import (
 	"fmt"
+	"hash/fnv"
 	"io"
 	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"unicode/utf8"
@@ -223,6 +225,16 @@ var nilIRI = NewIRI(RDFNil)
 // GraphToRDF creates an array of RDF triples for the given graph.
 func (ds *RDFDataset) GraphToRDF(graphName string, graph map[string]interface{}, issuer *IdentifierIssuer,
 	produceGeneralizedRdf bool) {
+	opts := NewJsonLdOptions("")
+	opts.ProduceGeneralizedRdf = produceGeneralizedRdf
+	// with default options, the conversion doesn't fail
+	_ = ds.graphToRDF(graphName, graph, issuer, opts)
+}
+
+func (ds *RDFDataset) graphToRDF(graphName string, graph map[string]interface{}, issuer *IdentifierIssuer,
+	opts *JsonLdOptions) error {
+	produceGeneralizedRdf := opts.ProduceGeneralizedRdf
+	alloc := opts.QuadAllocator
 	// 4.2)
 	triples := make([]*Quad, 0)
 	// 4.3)
@@ -232,7 +244,13 @@ func (ds *RDFDataset) GraphToRDF(graphName string, graph map[string]interface{},
 		}
 
 		node := graph[id].(map[string]interface{})
-		for _, property := range GetOrderedKeys(node) {
+		var properties []string
+		if opts.SkipSorting {
+			properties = GetKeys(node)
+		} else {
+			properties = GetOrderedKeys(node)
+		}
+		for _, property := range properties {
 			var values []interface{}
 			// 4.3.2.1)
 			if property == "@type" {
@@ -254,37 +272,162 @@ func (ds *RDFDataset) GraphToRDF(graphName string, graph map[string]interface{},
 			var subject Node
 			if strings.Index(id, "_:") == 0 {
 				// NOTE: don't rename, just set it as a blank node
-				subject = NewBlankNode(id)
+				subject = alloc.NewBlankNode(id)
 			} else {
-				subject = NewIRI(id)
+				subject = alloc.NewIRI(id)
 			}
 
 			// RDF predicates
 			var predicate Node
 			if strings.HasPrefix(property, "_:") {
-				predicate = NewBlankNode(property)
+				predicate = alloc.NewBlankNode(property)
 			} else {
-				predicate = NewIRI(property)
+				predicate = alloc.NewIRI(property)
 			}
 
 			for _, item := range values {
 				var object Node
-				object, triples = objectToRDF(item, issuer, graphName, triples)
+				var err error
+				produced := len(triples)
+				object, triples, err = objectToRDF(item, issuer, graphName, triples, opts)
+				if err != nil {
+					if ldErr, isLdErr := err.(*JsonLdError); isLdErr {
+						if details, ok := ldErr.Details.(*ValueErrorDetails); ok {
+							details.Property = property
+							details.NodeID = id
+						}
+					}
+					return err
+				}
 				if object != nil {
-					triples = append(triples, NewQuad(subject, predicate, object, graphName))
+					triples = append(triples, alloc.NewQuad(subject, predicate, object, graphName))
+				}
+				// charge the budget as quads are produced, including those of lists
+				if err = opts.Budget.spendQuads(int64(len(triples) - produced)); err != nil {
+					return err
 				}
 			}
 		}
 	}
 
-	// drop invalid statements (other than IRIs)
+	// drop invalid statements (other than IRIs) and, unless requested otherwise,
+	// duplicate statements, as an RDF graph is a set of triples
+	var seen map[tripleKey]struct{}
+	if !opts.KeepDuplicateQuads {
+		seen = make(map[tripleKey]struct{}, len(triples))
+	}
 	sanitisedTriples := make([]*Quad, 0, len(triples))
 	for _, t := range triples {
-		if t.Valid() {
-			sanitisedTriples = append(sanitisedTriples, t)
+		if !t.Valid() {
+			continue
 		}
+		if seen != nil {
+			key := newTripleKey(t)
+			if _, isDuplicate := seen[key]; isDuplicate {
+				continue
+			}
+			seen[key] = struct{}{}
+		}
+		sanitisedTriples = append(sanitisedTriples, t)
 	}
 	ds.Graphs[graphName] = sanitisedTriples
+	return nil
+}
+
+// tripleKey identifies a triple within a graph. Blank node identifiers
+// are distinguished from IRIs by their "_:" prefix.
+type tripleKey struct {
+	subject   string
+	predicate string
+	object    string
+	literal   bool
+	datatype  string
+	language  string
+}
+
+func newTripleKey(q *Quad) tripleKey {
+	key := tripleKey{
+		subject:   q.Subject.GetValue(),
+		predicate: q.Predicate.GetValue(),
+		object:    q.Object.GetValue(),
+	}
+	if literal, isLiteral := q.Object.(*Literal); isLiteral {
+		key.literal = true
+		key.datatype = literal.Datatype
+		key.language = literal.Language
+	}
+	return key
+}
+
+// QuadsToNode converts quads about a single subject into an expanded node object,
+// without constructing an RDFDataset. Graph names of the quads are ignored.
+// Objects are converted as in FromRDF, subject to opts.UseRdfType and opts.UseNativeTypes.
+// RDF lists aren't reconstructed, i.e. their blank nodes remain node references.
+func QuadsToNode(quads []*Quad, opts *JsonLdOptions) (map[string]interface{}, error) {
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	}
+	if len(quads) == 0 {
+		return nil, NewJsonLdError(InvalidInput, "no quads to convert")
+	}
+
+	subject := quads[0].Subject.GetValue()
+	node := map[string]interface{}{"@id": subject}
+	for _, quad := range quads {
+		if quad.Subject.GetValue() != subject {
+			return nil, NewJsonLdError(InvalidInput,
+				fmt.Sprintf("quads have different subjects: %s and %s", subject, quad.Subject.GetValue()))
+		}
+
+		predicate := quad.Predicate.GetValue()
+		object := quad.Object
+		if predicate == RDFType && (IsIRI(object) || IsBlankNode(object)) && !opts.UseRdfType {
+			MergeValue(node, "@type", object.GetValue())
+			continue
+		}
+
+		value, err := rdfToObject(object, opts)
+		if err != nil {
+			return nil, err
+		}
+		MergeValue(node, predicate, value)
+	}
+
+	return node, nil
+}
+
+// NodeToQuads converts an expanded node object into quads in the given graph
+// ("@default" for the default graph), without constructing a full document.
+// Embedded node objects and lists produce additional quads. Blank nodes are labelled
+// by the given issuer, which may be shared between calls to keep labels consistent
+// across batches; if it's nil, a new issuer is used.
+func NodeToQuads(node map[string]interface{}, graphName string, issuer *IdentifierIssuer,
+	opts *JsonLdOptions) ([]*Quad, error) {
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	}
+	if issuer == nil {
+		issuer = NewIdentifierIssuer("_:b")
+	}
+	if graphName == "" {
+		graphName = "@default"
+	}
+	if _, hasGraph := node["@graph"]; hasGraph {
+		return nil, NewJsonLdError(InvalidInput, "node objects with @graph can't be converted to quads of a single graph")
+	}
+
+	nodeMap := map[string]interface{}{
+		"@default": make(map[string]interface{}),
+	}
+	if _, err := newJsonLdApi(opts).GenerateNodeMap(node, nodeMap, "@default", issuer, "", "", nil); err != nil {
+		return nil, err
+	}
+
+	ds := NewRDFDataset()
+	if err := ds.graphToRDF(graphName, nodeMap["@default"].(map[string]interface{}), issuer, opts); err != nil {
+		return nil, err
+	}
+	return ds.Graphs[graphName], nil
 }
 
 // GetQuads returns a list of quads for the given graph
@@ -292,6 +435,83 @@ func (ds *RDFDataset) GetQuads(graphName string) []*Quad {
 	return ds.Graphs[graphName]
 }
 
+// Partition splits the quads of the dataset into n shards, so that callers can process
+// them in parallel. Quads with the same subject, as well as quads sharing blank nodes
+// (as subjects, objects or graph names), always end up in the same shard. Such groups
+// of quads are assigned to shards by the hash of their least node identifier,
+// so the result doesn't depend on the order of quads. Some shards may be empty.
+func (ds *RDFDataset) Partition(n int) [][]*Quad {
+	if n < 1 {
+		n = 1
+	}
+
+	graphNames := make([]string, 0, len(ds.Graphs))
+	for graphName := range ds.Graphs {
+		graphNames = append(graphNames, graphName)
+	}
+	sort.Strings(graphNames)
+
+	// union-find structure over node identifiers
+	parent := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		p, found := parent[id]
+		if !found {
+			parent[id] = id
+			return id
+		}
+		if p == id {
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	union := func(a, b string) {
+		rootA, rootB := find(a), find(b)
+		if rootA == rootB {
+			return
+		}
+		// keep the least identifier as the root of each component
+		if rootA < rootB {
+			parent[rootB] = rootA
+		} else {
+			parent[rootA] = rootB
+		}
+	}
+
+	for _, graphName := range graphNames {
+		for _, q := range ds.Graphs[graphName] {
+			subject := q.Subject.GetValue()
+			find(subject)
+			if IsBlankNode(q.Object) {
+				union(subject, q.Object.GetValue())
+			}
+			if strings.HasPrefix(graphName, "_:") {
+				union(subject, graphName)
+			}
+		}
+	}
+
+	shards := make([][]*Quad, n)
+	shardOf := make(map[string]int)
+	for _, graphName := range graphNames {
+		for _, q := range ds.Graphs[graphName] {
+			root := find(q.Subject.GetValue())
+			shard, found := shardOf[root]
+			if !found {
+				h := fnv.New32a()
+				_, _ = h.Write([]byte(root))
+				shard = int(h.Sum32() % uint32(n))
+				shardOf[root] = shard
+			}
+			shards[shard] = append(shards[shard], q)
+		}
+	}
+
+	return shards
+}
+
 var canonicalDoubleRegEx = regexp.MustCompile(`(\d)0*E\+?(-)?0*(\d)`)
 
 // GetCanonicalDouble returns a canonical string representation of a float64 number.
@@ -15,10 +15,13 @@
 package ld_test
 
 import (
+	"fmt"
+	"math"
 	"testing"
 
 	. "github.com/piprate/json-gold/ld"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetCanonicalDouble(t *testing.T) {
@@ -27,3 +30,316 @@ func TestGetCanonicalDouble(t *testing.T) {
 	assert.Equal(t, "7.5E-1", GetCanonicalDouble(0.75))
 	assert.Equal(t, "-7.5E-1", GetCanonicalDouble(-0.75))
 }
+
+func TestToRDFNativeNumberFormat(t *testing.T) {
+	negativeZero := math.Copysign(0, -1)
+	doc := map[string]interface{}{
+		"@id": "http://example.com/s",
+		"http://example.com/p": []interface{}{
+			1e20,
+			1e21,
+			negativeZero,
+			5.3,
+		},
+		"http://example.com/d": map[string]interface{}{"@value": negativeZero, "@type": XSDDouble},
+	}
+
+	literals := func(opts *JsonLdOptions) map[string]string {
+		res, err := NewJsonLdProcessor().ToRDF(doc, opts)
+		require.NoError(t, err)
+		rval := make(map[string]string)
+		for _, q := range res.(*RDFDataset).GetQuads("@default") {
+			lit := q.Object.(*Literal)
+			rval[lit.Value] = lit.Datatype
+		}
+		return rval
+	}
+
+	assert.Equal(t, map[string]string{
+		"100000000000000000000": XSDInteger,
+		"1.0E21":                XSDDouble,
+		"0":                     XSDInteger,
+		"5.3E0":                 XSDDouble,
+		"0.0E0":                 XSDDouble,
+	}, literals(NewJsonLdOptions("")))
+
+	legacyOpts := NewJsonLdOptions("")
+	legacyOpts.LegacyNumberFormat = true
+	assert.Equal(t, map[string]string{
+		"1.0E20": XSDDouble,
+		"1.0E21": XSDDouble,
+		"0":      XSDInteger,
+		"5.3E0":  XSDDouble,
+		"-0.0E0": XSDDouble,
+	}, literals(legacyOpts))
+}
+
+func benchmarkDocument() map[string]interface{} {
+	nodes := make([]interface{}, 0, 100)
+	for i := 0; i < 100; i++ {
+		nodes = append(nodes, map[string]interface{}{
+			"@id":                          fmt.Sprintf("http://example.com/node/%d", i),
+			"@type":                        "http://example.com/Thing",
+			"http://example.com/name":      fmt.Sprintf("Node %d", i),
+			"http://example.com/index":     float64(i),
+			"http://example.com/active":    i%2 == 0,
+			"http://example.com/next":      map[string]interface{}{"@id": fmt.Sprintf("http://example.com/node/%d", i+1)},
+			"http://example.com/createdAt": map[string]interface{}{"@value": "2017-01-01", "@type": XSDNS + "date"},
+		})
+	}
+	return map[string]interface{}{"@graph": nodes}
+}
+
+func benchmarkToRDF(b *testing.B, skipSorting bool) {
+	b.Helper()
+
+	doc := benchmarkDocument()
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.SkipSorting = skipSorting
+	opts.Format = "application/n-quads"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := proc.ToRDF(doc, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkToRDFDataset(b *testing.B, alloc *QuadAllocator) {
+	b.Helper()
+
+	opts := NewJsonLdOptions("")
+	opts.SkipSorting = true
+	opts.QuadAllocator = alloc
+	expanded, err := NewJsonLdProcessor().Expand(benchmarkDocument(), opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	api := NewJsonLdApi()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := api.ToRDF(expanded, opts); err != nil {
+			b.Fatal(err)
+		}
+		alloc.Release()
+	}
+}
+
+func BenchmarkToRDF(b *testing.B) {
+	benchmarkToRDF(b, false)
+}
+
+func BenchmarkToRDF_SkipSorting(b *testing.B) {
+	benchmarkToRDF(b, true)
+}
+
+func BenchmarkToRDF_Dataset(b *testing.B) {
+	benchmarkToRDFDataset(b, nil)
+}
+
+func BenchmarkToRDF_QuadAllocator(b *testing.B) {
+	benchmarkToRDFDataset(b, NewQuadAllocator())
+}
+
+func TestQuadAllocator(t *testing.T) {
+	doc := benchmarkDocument()
+	proc := NewJsonLdProcessor()
+	serializer := &NQuadRDFSerializer{}
+
+	opts := NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	expected, err := proc.ToRDF(doc, opts)
+	require.NoError(t, err)
+
+	alloc := NewQuadAllocator()
+	opts.Format = ""
+	opts.QuadAllocator = alloc
+	for i := 0; i < 3; i++ {
+		dataset, err := proc.ToRDF(doc, opts)
+		require.NoError(t, err)
+		actual, err := serializer.Serialize(dataset.(*RDFDataset))
+		require.NoError(t, err)
+		// subjects are converted in no particular order
+		assert.Equal(t, sortNQuads(expected.(string)), sortNQuads(actual.(string)))
+
+		// the memory is reused by the next conversion
+		alloc.Release()
+	}
+
+	// nil allocator falls back to individual allocations
+	var nilAlloc *QuadAllocator
+	q := nilAlloc.NewQuad(nilAlloc.NewBlankNode("_:b0"), nilAlloc.NewIRI(RDFType),
+		nilAlloc.NewLiteral("a", "", "en"), "http://example.com/g")
+	assert.True(t, q.Equal(NewQuad(NewBlankNode("_:b0"), NewIRI(RDFType), NewLiteral("a", XSDString, "en"),
+		"http://example.com/g")))
+	nilAlloc.Release()
+}
+
+func TestQuadsToNodeAndBack(t *testing.T) {
+	quads := []*Quad{
+		NewQuad(NewIRI("http://example.com/a"), NewIRI(RDFType), NewIRI("http://example.com/Person"), "@default"),
+		NewQuad(NewIRI("http://example.com/a"), NewIRI("http://example.com/name"), NewLiteral("Alice", XSDString, ""), "@default"),
+		NewQuad(NewIRI("http://example.com/a"), NewIRI("http://example.com/age"), NewLiteral("42", XSDInteger, ""), "@default"),
+		NewQuad(NewIRI("http://example.com/a"), NewIRI("http://example.com/knows"), NewBlankNode("_:b0"), "@default"),
+	}
+
+	node, err := QuadsToNode(quads, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"@id":   "http://example.com/a",
+		"@type": []interface{}{"http://example.com/Person"},
+		"http://example.com/name": []interface{}{
+			map[string]interface{}{"@value": "Alice"},
+		},
+		"http://example.com/age": []interface{}{
+			map[string]interface{}{"@value": "42", "@type": XSDInteger},
+		},
+		"http://example.com/knows": []interface{}{
+			map[string]interface{}{"@id": "_:b0"},
+		},
+	}, node)
+
+	issuer := NewIdentifierIssuer("_:b")
+	result, err := NodeToQuads(node, "http://example.com/graph", issuer, nil)
+	require.NoError(t, err)
+	require.Len(t, result, len(quads))
+	for _, q := range quads {
+		found := false
+		for _, r := range result {
+			if r.Subject.Equal(q.Subject) && r.Predicate.Equal(q.Predicate) && r.Object.Equal(q.Object) {
+				found = true
+				assert.Equal(t, "http://example.com/graph", r.Graph.GetValue())
+			}
+		}
+		assert.True(t, found, "quad not found: %v", q)
+	}
+
+	_, err = QuadsToNode(append(quads,
+		NewQuad(NewIRI("http://example.com/b"), NewIRI(RDFType), NewIRI("http://example.com/Person"), "@default")), nil)
+	assert.Error(t, err)
+}
+
+func TestRDFDataset_Partition(t *testing.T) {
+	nquads := `<http://example.com/a> <http://example.com/p> _:b0 .
+_:b0 <http://example.com/q> _:b1 .
+_:b1 <http://example.com/r> "x" .
+<http://example.com/c> <http://example.com/p> "y" .
+<http://example.com/c> <http://example.com/q> <http://example.com/a> .
+_:b2 <http://example.com/p> "z" _:g .
+<http://example.com/d> <http://example.com/p> "w" _:g .
+_:b3 <http://example.com/p> "v" .
+`
+	ds, err := (&NQuadRDFSerializer{}).Parse(nquads)
+	require.NoError(t, err)
+
+	components := [][]string{
+		{"http://example.com/a", "_:b0", "_:b1"},
+		{"http://example.com/c"},
+		{"_:b2", "http://example.com/d"},
+		{"_:b3"},
+	}
+
+	for _, n := range []int{0, 1, 2, 3, 10} {
+		shards := ds.Partition(n)
+		if n < 1 {
+			require.Len(t, shards, 1)
+		} else {
+			require.Len(t, shards, n)
+		}
+
+		total := 0
+		shardOfSubject := make(map[string]int)
+		for i, shard := range shards {
+			total += len(shard)
+			for _, q := range shard {
+				shardOfSubject[q.Subject.GetValue()] = i
+			}
+		}
+		assert.Equal(t, 8, total)
+
+		// all quads of a component are in the same shard
+		for _, component := range components {
+			for _, subject := range component[1:] {
+				assert.Equal(t, shardOfSubject[component[0]], shardOfSubject[subject], "n=%d, %s", n, subject)
+			}
+		}
+	}
+
+	// partitioning doesn't depend on the order of quads
+	reversed := NewRDFDataset()
+	for graphName, quads := range ds.Graphs {
+		for i := len(quads) - 1; i >= 0; i-- {
+			reversed.Graphs[graphName] = append(reversed.Graphs[graphName], quads[i])
+		}
+	}
+	for i, shard := range ds.Partition(3) {
+		assert.ElementsMatch(t, shard, reversed.Partition(3)[i])
+	}
+}
+
+func TestLiteralsEqual(t *testing.T) {
+	const xsdLong = XSDNS + "long"
+
+	for _, tc := range []struct {
+		a, b     *Literal
+		strict   bool
+		semantic bool
+	}{
+		{NewLiteral("1", XSDInteger, ""), NewLiteral("1", XSDInteger, ""), true, true},
+		{NewLiteral("1", XSDInteger, ""), NewLiteral("01", XSDInteger, ""), false, true},
+		{NewLiteral("+1", xsdLong, ""), NewLiteral("1", xsdLong, ""), false, true},
+		{NewLiteral("1", XSDInteger, ""), NewLiteral("1", xsdLong, ""), false, false},
+		{NewLiteral("1", XSDInteger, ""), NewLiteral("1.0", XSDInteger, ""), false, false},
+		{NewLiteral("1.50", XSDDecimal, ""), NewLiteral("01.5", XSDDecimal, ""), false, true},
+		{NewLiteral("1/2", XSDDecimal, ""), NewLiteral("0.5", XSDDecimal, ""), false, false},
+		{NewLiteral("1.5E0", XSDDouble, ""), NewLiteral("15e-1", XSDDouble, ""), false, true},
+		{NewLiteral("INF", XSDFloat, ""), NewLiteral("+INF", XSDFloat, ""), false, true},
+		{NewLiteral("NaN", XSDDouble, ""), NewLiteral("NaN", XSDDouble, ""), true, true},
+		{NewLiteral("1", XSDBoolean, ""), NewLiteral("true", XSDBoolean, ""), false, true},
+		{NewLiteral("0", XSDBoolean, ""), NewLiteral("true", XSDBoolean, ""), false, false},
+		{NewLiteral("a", XSDString, ""), NewLiteral("A", XSDString, ""), false, false},
+		{NewLiteral("chat", RDFLangString, "en-GB"), NewLiteral("chat", RDFLangString, "en-gb"), false, true},
+		{NewLiteral("chat", RDFLangString, "en"), NewLiteral("chat", RDFLangString, "fr"), false, false},
+		{nil, nil, true, true},
+		{NewLiteral("1", XSDInteger, ""), nil, false, false},
+	} {
+		assert.Equal(t, tc.strict, LiteralsEqual(tc.a, tc.b, false), "strict: %v %v", tc.a, tc.b)
+		assert.Equal(t, tc.semantic, LiteralsEqual(tc.a, tc.b, true), "semantic: %v %v", tc.a, tc.b)
+		assert.Equal(t, tc.semantic, LiteralsEqual(tc.b, tc.a, true), "semantic: %v %v", tc.b, tc.a)
+	}
+}
+
+func TestToRDFDeduplication(t *testing.T) {
+	doc := map[string]interface{}{
+		"@id": "http://example.com/s",
+		"http://example.com/p": []interface{}{
+			1.0,
+			map[string]interface{}{"@value": "1", "@type": XSDInteger},
+			true,
+			map[string]interface{}{"@value": "true", "@type": XSDBoolean},
+			map[string]interface{}{"@id": "http://example.com/o"},
+			"http://example.com/o",
+		},
+	}
+
+	toRDF := func(keepDuplicates bool) []*Quad {
+		opts := NewJsonLdOptions("")
+		opts.KeepDuplicateQuads = keepDuplicates
+		dataset, err := NewJsonLdProcessor().ToRDF(doc, opts)
+		require.NoError(t, err)
+		return dataset.(*RDFDataset).GetQuads("@default")
+	}
+
+	quads := toRDF(false)
+	require.Len(t, quads, 4)
+	assert.Equal(t, NewLiteral("1", XSDInteger, ""), quads[0].Object)
+	assert.Equal(t, NewLiteral("true", XSDBoolean, ""), quads[1].Object)
+	assert.Equal(t, NewIRI("http://example.com/o"), quads[2].Object)
+	assert.Equal(t, NewLiteral("http://example.com/o", XSDString, ""), quads[3].Object)
+
+	assert.Len(t, toRDF(true), 6)
+}
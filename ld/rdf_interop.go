@@ -0,0 +1,223 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// TermKind discriminates the kinds of RDF terms in SimpleQuad.
+type TermKind int
+
+const (
+	// NoTerm is the kind of an absent term, such as the graph name of a quad in the default graph.
+	NoTerm TermKind = iota
+	// IRITerm is the kind of an IRI.
+	IRITerm
+	// BlankNodeTerm is the kind of a blank node.
+	BlankNodeTerm
+	// LiteralTerm is the kind of a literal, which may have a datatype or a language.
+	LiteralTerm
+)
+
+func (k TermKind) String() string {
+	switch k {
+	case NoTerm:
+		return "none"
+	case IRITerm:
+		return "IRI"
+	case BlankNodeTerm:
+		return "blank node"
+	case LiteralTerm:
+		return "literal"
+	default:
+		return fmt.Sprintf("TermKind(%d)", int(k))
+	}
+}
+
+// Term is a plain representation of an RDF term. It allows other RDF libraries
+// to consume json-gold output without type switches on Node implementations.
+type Term struct {
+	Kind TermKind
+	// Value is the IRI, the blank node identifier (including the _: prefix)
+	// or the lexical form of the literal.
+	Value string
+	// Datatype and Language are only set for literals.
+	Datatype string
+	Language string
+}
+
+// TermFromNode converts a Node into a Term. A nil node results in a NoTerm term.
+func TermFromNode(n Node) Term {
+	switch v := n.(type) {
+	case *IRI:
+		return Term{Kind: IRITerm, Value: v.Value}
+	case *BlankNode:
+		return Term{Kind: BlankNodeTerm, Value: v.Attribute}
+	case *Literal:
+		return Term{Kind: LiteralTerm, Value: v.Value, Datatype: v.Datatype, Language: v.Language}
+	default:
+		return Term{Kind: NoTerm}
+	}
+}
+
+// Node converts the term back into a Node. It returns nil for NoTerm terms.
+func (t Term) Node() Node {
+	switch t.Kind {
+	case IRITerm:
+		return NewIRI(t.Value)
+	case BlankNodeTerm:
+		return NewBlankNode(t.Value)
+	case LiteralTerm:
+		return NewLiteral(t.Value, t.Datatype, t.Language)
+	default:
+		return nil
+	}
+}
+
+// SimpleQuad is a plain representation of an RDF quad, made of Terms.
+// Graph is a NoTerm term for quads in the default graph.
+type SimpleQuad struct {
+	Subject   Term
+	Predicate Term
+	Object    Term
+	Graph     Term
+}
+
+// NewSimpleQuad converts a Quad into a SimpleQuad.
+func NewSimpleQuad(q *Quad) SimpleQuad {
+	return SimpleQuad{
+		Subject:   TermFromNode(q.Subject),
+		Predicate: TermFromNode(q.Predicate),
+		Object:    TermFromNode(q.Object),
+		Graph:     TermFromNode(q.Graph),
+	}
+}
+
+// Quad converts the simple quad back into a Quad.
+func (sq SimpleQuad) Quad() *Quad {
+	return &Quad{
+		Subject:   sq.Subject.Node(),
+		Predicate: sq.Predicate.Node(),
+		Object:    sq.Object.Node(),
+		Graph:     sq.Graph.Node(),
+	}
+}
+
+// QuadIterator iterates over the quads of an RDFDataset as SimpleQuads.
+// Quads of the default graph come first, followed by quads of named graphs
+// in the lexicographical order of graph names.
+//
+//	it := dataset.Iterator()
+//	for it.Next() {
+//		q := it.Quad()
+//		...
+//	}
+type QuadIterator struct {
+	dataset *RDFDataset
+	graphs  []string
+	graph   int
+	index   int
+	current SimpleQuad
+}
+
+// Iterator returns a new iterator over the quads of the dataset.
+func (ds *RDFDataset) Iterator() *QuadIterator {
+	graphs := make([]string, 0, len(ds.Graphs))
+	for graphName := range ds.Graphs {
+		if graphName != "@default" {
+			graphs = append(graphs, graphName)
+		}
+	}
+	sort.Strings(graphs)
+	if _, hasDefault := ds.Graphs["@default"]; hasDefault {
+		graphs = append([]string{"@default"}, graphs...)
+	}
+	return &QuadIterator{
+		dataset: ds,
+		graphs:  graphs,
+	}
+}
+
+// Next advances the iterator to the next quad. It returns false when there are no more quads.
+func (it *QuadIterator) Next() bool {
+	for it.graph < len(it.graphs) {
+		quads := it.dataset.Graphs[it.graphs[it.graph]]
+		if it.index < len(quads) {
+			it.current = NewSimpleQuad(quads[it.index])
+			it.index++
+			return true
+		}
+		it.graph++
+		it.index = 0
+	}
+	return false
+}
+
+// Quad returns the current quad.
+func (it *QuadIterator) Quad() SimpleQuad {
+	return it.current
+}
+
+// SimpleQuads returns all quads of the dataset as SimpleQuads, in the order of Iterator.
+func (ds *RDFDataset) SimpleQuads() []SimpleQuad {
+	var quads []SimpleQuad
+	for it := ds.Iterator(); it.Next(); {
+		quads = append(quads, it.Quad())
+	}
+	return quads
+}
+
+// countingWriter counts bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// WriteTo writes the dataset to w as N-Quads. It implements io.WriterTo.
+func (ds *RDFDataset) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := ds.WriteFormat(cw, "application/n-quads", nil)
+	return cw.n, err
+}
+
+// WriteFormat writes the dataset to w in the given format, for example
+// "application/n-quads" or "text/turtle" (see ToRDF for supported formats).
+// Serializers in opts.RDFSerializers take precedence over registered ones. opts may be nil.
+func (ds *RDFDataset) WriteFormat(w io.Writer, format string, opts *JsonLdOptions) error {
+	serializer, hasSerializer := lookupRDFSerializer(opts, format)
+	if !hasSerializer {
+		return NewJsonLdError(UnknownFormat, format)
+	}
+	if serializerTo, canWrite := serializer.(RDFSerializerTo); canWrite {
+		return serializerTo.SerializeTo(w, ds)
+	}
+	serialized, err := serializer.Serialize(ds)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(w, serialized); err != nil {
+		return NewJsonLdError(IOError, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRDFDataset_Iterator(t *testing.T) {
+	dataset, err := ParseNQuads(`<http://example.com/s> <http://example.com/p> "hello"@en <http://example.com/g2> .
+_:b0 <http://example.com/p> "42"^^<http://www.w3.org/2001/XMLSchema#integer> <http://example.com/g1> .
+<http://example.com/s> <http://example.com/p> _:b0 .
+`)
+	require.NoError(t, err)
+
+	iri := func(v string) Term { return Term{Kind: IRITerm, Value: v} }
+	p := iri("http://example.com/p")
+	assert.Equal(t, []SimpleQuad{
+		{Subject: iri("http://example.com/s"), Predicate: p, Object: Term{Kind: BlankNodeTerm, Value: "_:b0"}},
+		{
+			Subject:   Term{Kind: BlankNodeTerm, Value: "_:b0"},
+			Predicate: p,
+			Object:    Term{Kind: LiteralTerm, Value: "42", Datatype: XSDInteger},
+			Graph:     iri("http://example.com/g1"),
+		},
+		{
+			Subject:   iri("http://example.com/s"),
+			Predicate: p,
+			Object:    Term{Kind: LiteralTerm, Value: "hello", Datatype: RDFLangString, Language: "en"},
+			Graph:     iri("http://example.com/g2"),
+		},
+	}, dataset.SimpleQuads())
+
+	// simple quads convert back into equal quads
+	for it := dataset.Iterator(); it.Next(); {
+		q := it.Quad()
+		graphName := "@default"
+		if q.Graph.Kind != NoTerm {
+			graphName = q.Graph.Value
+		}
+		assert.Contains(t, dataset.GetQuads(graphName), q.Quad())
+	}
+
+	assert.Equal(t, "blank node", BlankNodeTerm.String())
+	assert.False(t, NewRDFDataset().Iterator().Next())
+}
+
+func TestRDFDataset_WriteTo(t *testing.T) {
+	input := "<http://example.com/s> <http://example.com/p> \"v\" .\n"
+	dataset, err := ParseNQuads(input)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	n, err := dataset.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, input, buf.String())
+	assert.Equal(t, int64(len(input)), n)
+
+	buf.Reset()
+	require.NoError(t, dataset.WriteFormat(&buf, "text/turtle", nil))
+	assert.Contains(t, buf.String(), "<http://example.com/s> <http://example.com/p> \"v\"")
+
+	err = dataset.WriteFormat(&buf, "application/unknown", nil)
+	require.Error(t, err)
+	assert.Equal(t, UnknownFormat, err.(*JsonLdError).Code)
+
+	// serializers for a single call are used too
+	opts := NewJsonLdOptions("")
+	opts.RDFSerializers = map[string]RDFSerializer{"application/unknown": &NQuadRDFSerializer{}}
+	buf.Reset()
+	require.NoError(t, dataset.WriteFormat(&buf, "application/unknown", opts))
+	assert.Equal(t, input, buf.String())
+}
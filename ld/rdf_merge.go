@@ -0,0 +1,195 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MergeIsomorphicBlankNodes returns a copy of the dataset where blank nodes with identical
+// structure are merged into one. Two blank nodes are merged if they are in the same graph
+// and have the same properties, with values that are the same IRIs and literals, or
+// blank nodes which can be merged themselves. For example, repeated address nodes
+// become a single node referenced from several subjects.
+//
+// Merging assumes that blank nodes with identical structure denote the same resource.
+// This isn't implied by RDF semantics: the result entails the original dataset, but
+// generally not the other way round, so it should only be used where that assumption holds.
+// Blank nodes which have no properties, are used as graph names, appear in more than
+// one graph, take part in cycles or represent list items (rdf:first/rdf:rest) are never
+// merged. The original dataset isn't modified.
+func (ds *RDFDataset) MergeIsomorphicBlankNodes() *RDFDataset {
+	m := &blankNodeMerger{
+		graphOf:    make(map[string]string),
+		properties: make(map[string][]*Quad),
+		excluded:   make(map[string]bool),
+		signatures: make(map[string]string),
+		visiting:   make(map[string]bool),
+	}
+	m.collect(ds)
+
+	// group blank nodes by graph and signature. Blank nodes are visited in order,
+	// so that the same blank nodes are excluded because of cycles every time.
+	ids := make([]string, 0, len(m.graphOf))
+	for id := range m.graphOf {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	groups := make(map[string][]string)
+	for _, id := range ids {
+		if signature, mergeable := m.signature(id); mergeable {
+			key := m.graphOf[id] + "\n" + signature
+			groups[key] = append(groups[key], id)
+		}
+	}
+	replacements := make(map[string]string)
+	for _, ids := range groups {
+		if len(ids) < 2 {
+			continue
+		}
+		sort.Strings(ids)
+		for _, id := range ids[1:] {
+			replacements[id] = ids[0]
+		}
+	}
+
+	rval := NewRDFDataset()
+	for ns, prefix := range ds.context {
+		rval.context[ns] = prefix
+	}
+	for graphName, quads := range ds.Graphs {
+		if len(replacements) == 0 {
+			rval.Graphs[graphName] = quads
+			continue
+		}
+		merged := make([]*Quad, 0, len(quads))
+		seen := make(map[string]bool, len(quads))
+		for _, quad := range quads {
+			subject, replacedSubject := replaceBlankNode(quad.Subject, replacements)
+			object, replacedObject := replaceBlankNode(quad.Object, replacements)
+			if replacedSubject || replacedObject {
+				quad = &Quad{Subject: subject, Predicate: quad.Predicate, Object: object, Graph: quad.Graph}
+			}
+			key := toNQuad(quad, "")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, quad)
+		}
+		rval.Graphs[graphName] = merged
+	}
+	return rval
+}
+
+// blankNodeMerger holds the state of MergeIsomorphicBlankNodes.
+type blankNodeMerger struct {
+	// graphOf maps blank node identifiers to the graph they appear in
+	graphOf map[string]string
+	// properties maps blank node identifiers to quads which have them as the subject
+	properties map[string][]*Quad
+	// excluded blank nodes are never merged
+	excluded   map[string]bool
+	signatures map[string]string
+	visiting   map[string]bool
+}
+
+func (m *blankNodeMerger) collect(ds *RDFDataset) {
+	for graphName, quads := range ds.Graphs {
+		if strings.HasPrefix(graphName, "_:") {
+			m.excluded[graphName] = true
+		}
+		for _, quad := range quads {
+			for _, node := range []Node{quad.Subject, quad.Object} {
+				if !IsBlankNode(node) {
+					continue
+				}
+				id := node.GetValue()
+				if g, found := m.graphOf[id]; found && g != graphName {
+					m.excluded[id] = true
+				}
+				m.graphOf[id] = graphName
+			}
+			if IsBlankNode(quad.Subject) {
+				id := quad.Subject.GetValue()
+				m.properties[id] = append(m.properties[id], quad)
+				if p := quad.Predicate.GetValue(); p == RDFFirst || p == RDFRest {
+					m.excluded[id] = true
+				}
+			}
+		}
+	}
+}
+
+// signature returns a string describing the structure of the blank node, or false
+// if the blank node can't be merged.
+func (m *blankNodeMerger) signature(id string) (string, bool) {
+	if m.excluded[id] {
+		return "", false
+	}
+	if signature, found := m.signatures[id]; found {
+		return signature, true
+	}
+	if m.visiting[id] {
+		// the blank node is part of a cycle
+		m.excluded[id] = true
+		return "", false
+	}
+	m.visiting[id] = true
+	defer delete(m.visiting, id)
+
+	lines := make([]string, 0, len(m.properties[id]))
+	for _, quad := range m.properties[id] {
+		var object string
+		if IsBlankNode(quad.Object) {
+			childSignature, mergeable := m.signature(quad.Object.GetValue())
+			if !mergeable {
+				// a blank node which can't be merged is only equal to itself
+				object = quad.Object.GetValue()
+			} else {
+				object = "[" + childSignature + "]"
+			}
+		} else if literal, isLiteral := quad.Object.(*Literal); isLiteral {
+			object = strconv.Quote(literal.Value) + "^^<" + literal.Datatype + ">@" + literal.Language
+		} else {
+			object = "<" + quad.Object.GetValue() + ">"
+		}
+		lines = append(lines, "<"+quad.Predicate.GetValue()+"> "+object)
+	}
+	if m.excluded[id] {
+		// a cycle through this blank node was detected while visiting its values
+		return "", false
+	}
+	if len(lines) == 0 {
+		// nothing is known about the blank node, so it's only equal to itself
+		m.excluded[id] = true
+		return "", false
+	}
+	sort.Strings(lines)
+	signature := strings.Join(lines, ";")
+	m.signatures[id] = signature
+	return signature, true
+}
+
+func replaceBlankNode(n Node, replacements map[string]string) (Node, bool) {
+	if IsBlankNode(n) {
+		if replacement, found := replacements[n.GetValue()]; found {
+			return NewBlankNode(replacement), true
+		}
+	}
+	return n, false
+}
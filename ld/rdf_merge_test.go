@@ -0,0 +1,128 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sortedNQuads(t *testing.T, dataset *RDFDataset) []string {
+	t.Helper()
+	serialized, err := (&NQuadRDFSerializer{}).Serialize(dataset)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(serialized.(string)), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestRDFDataset_MergeIsomorphicBlankNodes(t *testing.T) {
+	input := `<http://example.com/alice> <http://schema.org/address> _:a1 .
+<http://example.com/bob> <http://schema.org/address> _:a2 .
+_:a1 <http://schema.org/city> "London" .
+_:a1 <http://schema.org/geo> _:g1 .
+_:g1 <http://schema.org/lat> "51.5" .
+_:a2 <http://schema.org/geo> _:g2 .
+_:a2 <http://schema.org/city> "London" .
+_:g2 <http://schema.org/lat> "51.5" .
+<http://example.com/carol> <http://schema.org/address> _:a3 .
+_:a3 <http://schema.org/city> "Paris" .
+_:c1 <http://schema.org/next> _:c2 .
+_:c2 <http://schema.org/next> _:c1 .
+_:c3 <http://schema.org/next> _:c4 .
+_:c4 <http://schema.org/next> _:c3 .
+<http://example.com/list> <http://schema.org/items> _:l1 .
+<http://example.com/list> <http://schema.org/items> _:l2 .
+_:l1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "x" .
+_:l1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .
+_:l2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "x" .
+_:l2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .
+<http://example.com/alice> <http://schema.org/address> _:n1 <http://example.com/g> .
+_:n1 <http://schema.org/city> "London" <http://example.com/g> .
+<http://example.com/dave> <http://schema.org/knows> _:e1 .
+<http://example.com/erin> <http://schema.org/knows> _:e2 .
+<http://example.com/frank> <http://schema.org/owns> _:o1 .
+<http://example.com/frank> <http://schema.org/owns> _:o2 .
+_:o1 <http://schema.org/owner> _:e3 .
+_:o2 <http://schema.org/owner> _:e4 .
+`
+	dataset, err := ParseNQuads(input)
+	require.NoError(t, err)
+	original := sortedNQuads(t, dataset)
+
+	merged := dataset.MergeIsomorphicBlankNodes()
+	assert.Equal(t, []string{
+		`<http://example.com/alice> <http://schema.org/address> _:a1 .`,
+		`<http://example.com/alice> <http://schema.org/address> _:n1 <http://example.com/g> .`,
+		`<http://example.com/bob> <http://schema.org/address> _:a1 .`,
+		`<http://example.com/carol> <http://schema.org/address> _:a3 .`,
+		`<http://example.com/dave> <http://schema.org/knows> _:e1 .`,
+		`<http://example.com/erin> <http://schema.org/knows> _:e2 .`,
+		`<http://example.com/frank> <http://schema.org/owns> _:o1 .`,
+		`<http://example.com/frank> <http://schema.org/owns> _:o2 .`,
+		`<http://example.com/list> <http://schema.org/items> _:l1 .`,
+		`<http://example.com/list> <http://schema.org/items> _:l2 .`,
+		`_:a1 <http://schema.org/city> "London" .`,
+		`_:a1 <http://schema.org/geo> _:g1 .`,
+		`_:a3 <http://schema.org/city> "Paris" .`,
+		`_:c1 <http://schema.org/next> _:c2 .`,
+		`_:c2 <http://schema.org/next> _:c1 .`,
+		`_:c3 <http://schema.org/next> _:c4 .`,
+		`_:c4 <http://schema.org/next> _:c3 .`,
+		`_:g1 <http://schema.org/lat> "51.5" .`,
+		`_:l1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "x" .`,
+		`_:l1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .`,
+		`_:l2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "x" .`,
+		`_:l2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .`,
+		`_:n1 <http://schema.org/city> "London" <http://example.com/g> .`,
+		`_:o1 <http://schema.org/owner> _:e3 .`,
+		`_:o2 <http://schema.org/owner> _:e4 .`,
+	}, sortedNQuads(t, merged))
+
+	// the original dataset isn't modified
+	assert.Equal(t, original, sortedNQuads(t, dataset))
+}
+
+func TestJsonLdProcessor_FromRDFDeduplicateBlankNodes(t *testing.T) {
+	input := `<http://example.com/alice> <http://schema.org/address> _:a1 .
+<http://example.com/bob> <http://schema.org/address> _:a2 .
+_:a1 <http://schema.org/city> "London" .
+_:a2 <http://schema.org/city> "London" .
+`
+	proc := NewJsonLdProcessor()
+	opts := NewJsonLdOptions("")
+	opts.DeduplicateBlankNodes = true
+	res, err := proc.FromRDF(input, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id":                    "_:a1",
+			"http://schema.org/city": []interface{}{map[string]interface{}{"@value": "London"}},
+		},
+		map[string]interface{}{
+			"@id":                       "http://example.com/alice",
+			"http://schema.org/address": []interface{}{map[string]interface{}{"@id": "_:a1"}},
+		},
+		map[string]interface{}{
+			"@id":                       "http://example.com/bob",
+			"http://schema.org/address": []interface{}{map[string]interface{}{"@id": "_:a1"}},
+		},
+	}, res)
+}
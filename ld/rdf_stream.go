@@ -0,0 +1,129 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"bufio"
+	"io"
+)
+
+// QuadSource is an iterator over quads, such as NQuadsReader.
+// Next returns io.EOF when there are no more quads.
+type QuadSource interface {
+	Next() (*Quad, error)
+}
+
+// NQuadsReader parses N-Quads from an io.Reader one quad at a time, without
+// building an RDFDataset. Unlike ParseNQuadsFrom, it doesn't remove duplicate quads.
+type NQuadsReader struct {
+	scanner    *bufio.Scanner
+	lineNumber int
+}
+
+// NewNQuadsReader creates a new instance of NQuadsReader.
+func NewNQuadsReader(r io.Reader) *NQuadsReader {
+	return &NQuadsReader{
+		scanner: bufio.NewScanner(r),
+	}
+}
+
+// Next returns the next quad from the input, or io.EOF at the end of the input.
+func (r *NQuadsReader) Next() (*Quad, error) {
+	for r.scanner.Scan() {
+		line := r.scanner.Bytes()
+		r.lineNumber++
+
+		// skip empty lines
+		if regexEmpty.Match(line) {
+			continue
+		}
+
+		quad, _, err := parseNQuad(line, r.lineNumber)
+		return quad, err
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, NewJsonLdError(IOError, err)
+	}
+	return nil, io.EOF
+}
+
+// FromRDFStream converts quads from the given source into expanded node objects
+// and passes them to emit one at a time, as soon as all quads of their subject
+// have been read. Memory use is proportional to the number of quads of a single
+// subject rather than to the size of the dataset.
+//
+// The source must provide quads grouped by graph and subject, as in sorted N-Quads.
+// If quads of a subject aren't contiguous, the subject is emitted several times,
+// once for each run of its quads; consumers which need a single node object per
+// subject have to merge them. Nodes of named graphs are emitted wrapped into
+// a graph object: {"@id": graphName, "@graph": [node]}.
+//
+// emit is called synchronously, so a slow consumer slows down reading of the source.
+// If emit returns an error, the conversion stops and the error is returned.
+//
+// Objects are converted as in QuadsToNode. In particular, RDF lists aren't reconstructed
+// and opts.OutputForm isn't supported.
+func (jldp *JsonLdProcessor) FromRDFStream(source QuadSource, opts *JsonLdOptions,
+	emit func(node map[string]interface{}) error) error {
+
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	}
+
+	var pending []*Quad
+	var pendingGraph string
+
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		node, err := QuadsToNode(pending, opts)
+		if err != nil {
+			return err
+		}
+		if pendingGraph != "" {
+			node = map[string]interface{}{
+				"@id":    pendingGraph,
+				"@graph": []interface{}{node},
+			}
+		}
+		pending = pending[:0]
+		return emit(node)
+	}
+
+	for {
+		quad, err := source.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		graph := ""
+		if quad.Graph != nil {
+			graph = quad.Graph.GetValue()
+		}
+		if len(pending) > 0 && (graph != pendingGraph ||
+			quad.Subject.GetValue() != pending[0].Subject.GetValue()) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		pending = append(pending, quad)
+		pendingGraph = graph
+	}
+
+	return flush()
+}
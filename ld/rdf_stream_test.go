@@ -0,0 +1,92 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJsonLdProcessor_FromRDFStream(t *testing.T) {
+	input := `<http://example.com/a> <http://example.com/name> "A" .
+<http://example.com/a> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example.com/Thing> .
+
+_:b0 <http://example.com/knows> <http://example.com/a> .
+<http://example.com/a> <http://example.com/age> "42"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://example.com/b> <http://example.com/name> "B" <http://example.com/g> .
+`
+
+	var nodes []map[string]interface{}
+	opts := NewJsonLdOptions("")
+	opts.UseNativeTypes = true
+	err := NewJsonLdProcessor().FromRDFStream(NewNQuadsReader(strings.NewReader(input)), opts,
+		func(node map[string]interface{}) error {
+			nodes = append(nodes, node)
+			return nil
+		})
+	require.NoError(t, err)
+
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"@id":                     "http://example.com/a",
+			"@type":                   []interface{}{"http://example.com/Thing"},
+			"http://example.com/name": []interface{}{map[string]interface{}{"@value": "A"}},
+		},
+		{
+			"@id":                      "_:b0",
+			"http://example.com/knows": []interface{}{map[string]interface{}{"@id": "http://example.com/a"}},
+		},
+		// quads of a subject which aren't contiguous produce several nodes
+		{
+			"@id":                    "http://example.com/a",
+			"http://example.com/age": []interface{}{map[string]interface{}{"@value": int64(42)}},
+		},
+		{
+			"@id": "http://example.com/g",
+			"@graph": []interface{}{map[string]interface{}{
+				"@id":                     "http://example.com/b",
+				"http://example.com/name": []interface{}{map[string]interface{}{"@value": "B"}},
+			}},
+		},
+	}, nodes)
+
+	// errors returned by the consumer stop the conversion
+	stop := errors.New("stop")
+	calls := 0
+	err = NewJsonLdProcessor().FromRDFStream(NewNQuadsReader(strings.NewReader(input)), nil,
+		func(node map[string]interface{}) error {
+			calls++
+			return stop
+		})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+
+	// syntax errors are reported with the line number
+	reader := NewNQuadsReader(strings.NewReader("<http://example.com/a> <http://example.com/p> .\n"))
+	_, err = reader.Next()
+	if assert.Error(t, err) {
+		assert.Equal(t, SyntaxError, err.(*JsonLdError).Code)
+	}
+
+	reader = NewNQuadsReader(strings.NewReader(""))
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
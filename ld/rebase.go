@@ -0,0 +1,116 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"strings"
+)
+
+// Rebase moves a document from oldBase to newBase, for example when content is migrated
+// between hosts. The document is expanded against oldBase, node identifiers located under
+// oldBase (the base IRI itself and IRIs which are relative to it without "../" segments),
+// including values of terms coerced with "@type": "@id", are rewritten to the same locations
+// under newBase, and the result is compacted again with the document's top-level @context,
+// using newBase as the base IRI. Thus relative IRIs keep their meaning, while IRIs outside
+// oldBase are kept unchanged. Properties, types and datatypes are vocabulary IRIs
+// and aren't rebased.
+//
+// opts may be nil. Its Base is ignored, other options apply to both expansion and compaction.
+func Rebase(doc interface{}, oldBase, newBase string, opts *JsonLdOptions) (map[string]interface{}, error) {
+	if opts == nil {
+		opts = NewJsonLdOptions("")
+	}
+	if !IsAbsoluteIri(oldBase) || !IsAbsoluteIri(newBase) {
+		return nil, NewJsonLdError(InvalidBaseIRI, "Rebase requires absolute base IRIs")
+	}
+
+	proc := NewJsonLdProcessor()
+
+	expandOpts := opts.Copy()
+	expandOpts.Base = oldBase
+	expandOpts.BaseOverride = ""
+	expanded, err := proc.Expand(doc, expandOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &rebaser{oldBase: oldBase, newBase: newBase}
+	rebased := r.rebaseElement(expanded)
+
+	var context interface{}
+	if docMap, isMap := doc.(map[string]interface{}); isMap {
+		context = docMap["@context"]
+	}
+
+	compactOpts := opts.Copy()
+	compactOpts.Base = newBase
+	compactOpts.BaseOverride = ""
+	return proc.Compact(rebased, context, compactOpts)
+}
+
+type rebaser struct {
+	oldBase string
+	newBase string
+}
+
+// rebaseIRI returns the location of the given IRI under the new base,
+// or the IRI itself if it isn't located under the old base.
+func (r *rebaser) rebaseIRI(iri string) string {
+	if iri == r.oldBase {
+		return r.newBase
+	}
+	if strings.HasPrefix(iri, "_:") {
+		return iri
+	}
+	rel := RemoveBase(r.oldBase, iri)
+	if rel == iri || strings.HasPrefix(rel, "../") || IsAbsoluteIri(rel) {
+		return iri
+	}
+	return Resolve(r.newBase, rel)
+}
+
+// rebaseElement returns a copy of the given expanded element with all node identifiers rebased.
+func (r *rebaser) rebaseElement(element interface{}) interface{} {
+	switch v := element.(type) {
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = r.rebaseElement(item)
+		}
+		return result
+	case map[string]interface{}:
+		if IsValue(v) {
+			return CloneDocument(v)
+		}
+		result := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			switch key {
+			case "@id":
+				if id, isString := val.(string); isString {
+					result[key] = r.rebaseIRI(id)
+				} else {
+					result[key] = val
+				}
+			case "@type":
+				result[key] = CloneDocument(val)
+			default:
+				result[key] = r.rebaseElement(val)
+			}
+		}
+		return result
+	default:
+		return element
+	}
+}
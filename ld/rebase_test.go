@@ -0,0 +1,92 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebase(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@vocab": "http://schema.org/",
+			"knows":  map[string]interface{}{"@type": "@id"},
+			"image":  map[string]interface{}{"@type": "@id"},
+		},
+		"@id":   "people/alice",
+		"@type": "Person",
+		"knows": []interface{}{"people/bob", "http://other.example/carol", "../outside/dave"},
+		"image": "./",
+		"name":  "Alice",
+	}
+
+	rebased, err := Rebase(doc, "http://old.example/site/", "https://new.example/content/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, doc["@context"], rebased["@context"])
+	assert.Equal(t, "people/alice", rebased["@id"])
+	assert.Equal(t, "Person", rebased["@type"])
+	assert.Equal(t, []interface{}{
+		"people/bob",
+		"http://other.example/carol",
+		"http://old.example/outside/dave",
+	}, rebased["knows"])
+	assert.Equal(t, "./", rebased["image"])
+	assert.Equal(t, "Alice", rebased["name"])
+
+	// compare expanded forms
+	proc := NewJsonLdProcessor()
+	expanded, err := proc.Expand(rebased, NewJsonLdOptions("https://new.example/content/"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://new.example/content/people/alice", expanded[0].(map[string]interface{})["@id"])
+
+	_, err = Rebase(doc, "site/", "https://new.example/content/", nil)
+	assert.Error(t, err)
+}
+
+func TestRebase_VocabularyIRIs(t *testing.T) {
+	// the vocabulary is located under the old base, but it isn't moved with the document
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@vocab": "http://old.example/site/vocab#",
+			"score":  map[string]interface{}{"@type": "http://old.example/site/vocab#Score"},
+		},
+		"@id":    "items/1",
+		"@type":  "Item",
+		"rating": 5,
+		"score":  "10",
+	}
+
+	rebased, err := Rebase(doc, "http://old.example/site/", "https://new.example/content/", nil)
+	require.NoError(t, err)
+
+	proc := NewJsonLdProcessor()
+	expanded, err := proc.Expand(rebased, NewJsonLdOptions("https://new.example/content/"))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id":                                  "https://new.example/content/items/1",
+			"@type":                                []interface{}{"http://old.example/site/vocab#Item"},
+			"http://old.example/site/vocab#rating": []interface{}{map[string]interface{}{"@value": 5}},
+			"http://old.example/site/vocab#score": []interface{}{map[string]interface{}{
+				"@type":  "http://old.example/site/vocab#Score",
+				"@value": "10",
+			}},
+		},
+	}, expanded)
+}
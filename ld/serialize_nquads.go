@@ -20,7 +20,9 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // NQuadRDFSerializer parses and serializes N-Quads.
@@ -108,22 +110,109 @@ func toNQuad(triple *Quad, graphName string) string {
 	return quad
 }
 
+// unescape decodes ECHAR (\t, \b, \n, \r, \f, \", \', \\) and UCHAR (\uXXXX, \UXXXXXXXX)
+// escape sequences. Invalid sequences are kept as they are.
 func unescape(str string) string {
-	str = strings.ReplaceAll(str, "\\\\", "\\")
-	str = strings.ReplaceAll(str, "\\\"", "\"")
-	str = strings.ReplaceAll(str, "\\n", "\n")
-	str = strings.ReplaceAll(str, "\\r", "\r")
-	str = strings.ReplaceAll(str, "\\t", "\t")
-	return str
+	if strings.IndexByte(str, '\\') == -1 {
+		return str
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(str))
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		if c != '\\' || i+1 == len(str) {
+			sb.WriteByte(c)
+			continue
+		}
+		switch next := str[i+1]; next {
+		case 't':
+			sb.WriteByte('\t')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case '"', '\'', '\\':
+			sb.WriteByte(next)
+		case 'u', 'U':
+			size := 4
+			if next == 'U' {
+				size = 8
+			}
+			if i+2+size > len(str) {
+				sb.WriteByte(c)
+				continue
+			}
+			code, err := strconv.ParseUint(str[i+2:i+2+size], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				sb.WriteByte(c)
+				continue
+			}
+			sb.WriteRune(rune(code))
+			i += size
+		default:
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+	}
+	return sb.String()
 }
 
+// escape encodes a string for use in N-Quads according to the canonical form
+// defined in https://www.w3.org/TR/rdf12-n-quads/#canonical-quads (as required by RDFC-1.0):
+// U+0008, U+0009, U+000A, U+000C, U+000D, U+0022 and U+005C are encoded as ECHAR,
+// other characters in the ranges U+0000-U+001F and U+007F are encoded as \uXXXX
+// with upper case hexadecimal digits. All other characters, including characters
+// outside the Basic Multilingual Plane, are written as is.
 func escape(str string) string {
-	str = strings.ReplaceAll(str, "\\", "\\\\")
-	str = strings.ReplaceAll(str, "\"", "\\\"")
-	str = strings.ReplaceAll(str, "\n", "\\n")
-	str = strings.ReplaceAll(str, "\r", "\\r")
-	str = strings.ReplaceAll(str, "\t", "\\t")
-	return str
+	i := 0
+	for i < len(str) && !needsEscaping(str[i]) {
+		i++
+	}
+	if i == len(str) {
+		return str
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(str) + 8)
+	sb.WriteString(str[:i])
+	for ; i < len(str); i++ {
+		c := str[i]
+		switch c {
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		default:
+			if needsEscaping(c) {
+				fmt.Fprintf(&sb, `\u%04X`, c)
+			} else {
+				sb.WriteByte(c)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// needsEscaping reports whether the given byte must be escaped in canonical N-Quads.
+// All such characters are ASCII, so multi-byte UTF-8 sequences are never affected.
+func needsEscaping(c byte) bool {
+	return c < 0x20 || c == 0x7F || c == '"' || c == '\\'
 }
 
 const (
@@ -233,6 +322,12 @@ func newScannerFor(o interface{}) (lineScanner, error) {
 
 // ParseNQuadsFrom parses RDF in the form of N-Quads from io.Reader, []byte or string.
 func ParseNQuadsFrom(o interface{}) (*RDFDataset, error) {
+	return parseNQuadsFrom(o, nil)
+}
+
+// parseNQuadsFrom parses N-Quads like ParseNQuadsFrom, charging the given budget
+// (which may be nil) for every parsed quad.
+func parseNQuadsFrom(o interface{}, budget *Budget) (*RDFDataset, error) {
 
 	// build RDF dataset
 	dataset := NewRDFDataset()
@@ -253,53 +348,14 @@ func ParseNQuadsFrom(o interface{}) (*RDFDataset, error) {
 			continue
 		}
 
-		// parse quad
-		if !regexQuad.Match(line) {
-			return nil, NewJsonLdError(SyntaxError, fmt.Errorf("error while parsing N-Quads; invalid quad. line: %d", lineNumber))
+		triple, name, err := parseNQuad(line, lineNumber)
+		if err != nil {
+			return nil, err
 		}
-		match := regexQuad.FindStringSubmatch(string(line))
-
-		// get subject
-		var subject Node
-		if match[1] != "" {
-			subject = NewIRI(unescape(match[1]))
-		} else {
-			subject = NewBlankNode(unescape(match[2]))
+		if err = budget.spendQuads(1); err != nil {
+			return nil, err
 		}
 
-		// get predicate
-		predicate := NewIRI(unescape(match[3]))
-
-		// get object
-		var object Node
-		if match[4] != "" {
-			object = NewIRI(unescape(match[4]))
-		} else if match[5] != "" {
-			object = NewBlankNode(unescape(match[5]))
-		} else {
-			language := unescape(match[8])
-			var datatype string
-			if match[7] != "" {
-				datatype = unescape(match[7])
-			} else if match[8] != "" {
-				datatype = RDFLangString
-			} else {
-				datatype = XSDString
-			}
-			unescaped := unescape(match[6])
-			object = NewLiteral(unescaped, datatype, language)
-		}
-
-		// get graph name ('@default' is used for the default graph)
-		name := "@default"
-		if match[9] != "" {
-			name = unescape(match[9])
-		} else if match[10] != "" {
-			name = unescape(match[10])
-		}
-
-		triple := NewQuad(subject, predicate, object, name)
-
 		// initialise graph in dataset
 		triples, present := dataset.Graphs[name]
 		if !present {
@@ -325,6 +381,57 @@ func ParseNQuadsFrom(o interface{}) (*RDFDataset, error) {
 	return dataset, nil
 }
 
+// parseNQuad parses a single non-empty line of N-Quads. It returns the quad
+// and the name of its graph ("@default" for the default graph).
+func parseNQuad(line []byte, lineNumber int) (*Quad, string, error) {
+	// parse quad
+	if !regexQuad.Match(line) {
+		return nil, "", NewJsonLdError(SyntaxError, fmt.Errorf("error while parsing N-Quads; invalid quad. line: %d", lineNumber))
+	}
+	match := regexQuad.FindStringSubmatch(string(line))
+
+	// get subject
+	var subject Node
+	if match[1] != "" {
+		subject = NewIRI(unescape(match[1]))
+	} else {
+		subject = NewBlankNode(unescape(match[2]))
+	}
+
+	// get predicate
+	predicate := NewIRI(unescape(match[3]))
+
+	// get object
+	var object Node
+	if match[4] != "" {
+		object = NewIRI(unescape(match[4]))
+	} else if match[5] != "" {
+		object = NewBlankNode(unescape(match[5]))
+	} else {
+		language := unescape(match[8])
+		var datatype string
+		if match[7] != "" {
+			datatype = unescape(match[7])
+		} else if match[8] != "" {
+			datatype = RDFLangString
+		} else {
+			datatype = XSDString
+		}
+		unescaped := unescape(match[6])
+		object = NewLiteral(unescaped, datatype, language)
+	}
+
+	// get graph name ('@default' is used for the default graph)
+	name := "@default"
+	if match[9] != "" {
+		name = unescape(match[9])
+	} else if match[10] != "" {
+		name = unescape(match[10])
+	}
+
+	return NewQuad(subject, predicate, object, name), name, nil
+}
+
 // ParseNQuads parses RDF in the form of N-Quads.
 func ParseNQuads(input string) (*RDFDataset, error) {
 	return ParseNQuadsFrom(input)
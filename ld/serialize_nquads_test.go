@@ -0,0 +1,101 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNQuadRDFSerializer_Escaping(t *testing.T) {
+	expected := map[rune]string{
+		'\b': `\b`,
+		'\t': `\t`,
+		'\n': `\n`,
+		'\f': `\f`,
+		'\r': `\r`,
+		'"':  `\"`,
+		'\\': `\\`,
+	}
+	for c := rune(0); c < 0x20; c++ {
+		if _, found := expected[c]; !found {
+			expected[c] = fmt.Sprintf(`\u%04X`, c)
+		}
+	}
+	expected[0x7F] = `\u007F`
+	// characters which aren't escaped
+	for _, c := range []rune{' ', '\'', 'a', 0x80, 0xFEFF, 0x221E, 0x1F600, 0x10FFFF} {
+		expected[c] = string(c)
+	}
+
+	serializer := &NQuadRDFSerializer{}
+	for c, escaped := range expected {
+		ds := NewRDFDataset()
+		ds.Graphs["@default"] = []*Quad{
+			NewQuad(NewIRI("urn:ex:s"), NewIRI("urn:ex:p"), NewLiteral("a"+string(c)+"b", XSDString, ""), "@default"),
+		}
+		out, err := serializer.Serialize(ds)
+		require.NoError(t, err)
+		line := `<urn:ex:s> <urn:ex:p> "a` + escaped + `b" .` + "\n"
+		assert.Equal(t, line, out, "character U+%04X", c)
+
+		// round trip
+		parsed, err := ParseNQuads(line)
+		require.NoError(t, err)
+		assert.Equal(t, "a"+string(c)+"b", parsed.Graphs["@default"][0].Object.GetValue(), "character U+%04X", c)
+	}
+}
+
+func TestNQuadRDFSerializer_UnescapeUCHAR(t *testing.T) {
+	parsed, err := ParseNQuads(`<urn:ex:s> <urn:ex:p> "\u0022\\\U0001F600∞\\u0039\q\u00" .` + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, "\"\\\U0001F600∞\\u0039\\q\\u00", parsed.Graphs["@default"][0].Object.GetValue())
+}
+
+func TestNormalizeCanonicalEscaping(t *testing.T) {
+	// RDFC-1.0 test060: escaping of literals in canonical N-Quads
+	input, err := os.ReadFile("testdata/normalization/test060-in.nq")
+	require.NoError(t, err)
+
+	opts := NewJsonLdOptions("")
+	opts.InputFormat = "application/n-quads"
+	opts.Format = "application/n-quads"
+	opts.Algorithm = AlgorithmURDNA2015
+	out, err := NewJsonLdProcessor().Normalize(string(input), opts)
+	require.NoError(t, err)
+	assert.Equal(t, `<urn:ex:s> <urn:ex:000:empty> "" .
+<urn:ex:s> <urn:ex:001:simple> "simple" .
+<urn:ex:s> <urn:ex:002:quote> "\"" .
+<urn:ex:s> <urn:ex:003:backslash> "\\" .
+<urn:ex:s> <urn:ex:004:nl> "\n" .
+<urn:ex:s> <urn:ex:005:cr> "\r" .
+<urn:ex:s> <urn:ex:006:all> "\"\\\n\r" .
+<urn:ex:s> <urn:ex:007:uchar> "\"\\" .
+<urn:ex:s> <urn:ex:008:echar> "\t\b\n\r\f\"'\\" .
+<urn:ex:s> <urn:ex:009> "\\u0039" .
+<urn:ex:s> <urn:ex:010> "\\n" .
+<urn:ex:s> <urn:ex:011> "\\\\" .
+<urn:ex:s> <urn:ex:012> "\"\"" .
+<urn:ex:s> <urn:ex:013> "\\\\\\" .
+<urn:ex:s> <urn:ex:014> "\"\"\"" .
+<urn:ex:s> <urn:ex:015> "∞" .
+<urn:ex:s> <urn:ex:016> "∞" .
+`, out)
+}
@@ -0,0 +1,90 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	rdfSerializersMu sync.RWMutex
+	rdfSerializers   = map[string]RDFSerializer{
+		"application/n-quads": &NQuadRDFSerializer{},
+		"application/nquads":  &NQuadRDFSerializer{}, // keep this option for backward compatibility
+		"text/turtle":         &TurtleRDFSerializer{},
+		"application/trig":    &TurtleRDFSerializer{TriG: true},
+	}
+)
+
+// RegisterRDFSerializer makes a serializer available under the given content type
+// (e.g. "application/trix") to ToRDF, FromRDF, Normalize and RDFDataset.WriteFormat
+// via the Format and InputFormat options. Registering a serializer for a content type
+// which already has one replaces it. A nil serializer removes the content type.
+//
+// To use a serializer for a single call only, see JsonLdOptions.RDFSerializers.
+func RegisterRDFSerializer(contentType string, serializer RDFSerializer) {
+	rdfSerializersMu.Lock()
+	defer rdfSerializersMu.Unlock()
+
+	if serializer == nil {
+		delete(rdfSerializers, contentType)
+	} else {
+		rdfSerializers[contentType] = serializer
+	}
+}
+
+// lookupRDFSerializer returns the serializer for the given format: the one from
+// opts.RDFSerializers, if any, or the one registered with RegisterRDFSerializer.
+// opts may be nil.
+func lookupRDFSerializer(opts *JsonLdOptions, format string) (RDFSerializer, bool) {
+	if opts != nil {
+		if serializer, found := opts.RDFSerializers[format]; found && serializer != nil {
+			return serializer, true
+		}
+	}
+
+	rdfSerializersMu.RLock()
+	defer rdfSerializersMu.RUnlock()
+
+	serializer, found := rdfSerializers[format]
+	return serializer, found
+}
+
+// canonicalRDFSerializer returns the serializer which produces the output of Normalize
+// in the given format other than N-Quads. Turtle serializers aren't used, as they don't
+// produce canonical output.
+func canonicalRDFSerializer(opts *JsonLdOptions, format string) (RDFSerializer, error) {
+	serializer, found := lookupRDFSerializer(opts, format)
+	if _, isTurtle := serializer.(*TurtleRDFSerializer); !found || isTurtle {
+		return nil, NewJsonLdError(UnknownFormat,
+			fmt.Sprintf("unsupported normalization output format: %s", format))
+	}
+	return serializer, nil
+}
+
+// rdfSerializerFormats returns the sorted content types of registered serializers.
+func rdfSerializerFormats() []string {
+	rdfSerializersMu.RLock()
+	defer rdfSerializersMu.RUnlock()
+
+	formats := make([]string, 0, len(rdfSerializers))
+	for format := range rdfSerializers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
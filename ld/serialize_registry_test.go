@@ -0,0 +1,106 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// taggedNQuadSerializer writes N-Quads preceded by a header line.
+// It's only useful for testing the registry.
+type taggedNQuadSerializer struct {
+	nquads NQuadRDFSerializer
+	tag    string
+}
+
+func (s *taggedNQuadSerializer) Parse(input interface{}) (*RDFDataset, error) {
+	str, isString := input.(string)
+	if !isString || !strings.HasPrefix(str, s.tag+"\n") {
+		return nil, NewJsonLdError(InvalidInput, "missing header")
+	}
+	return s.nquads.Parse(strings.TrimPrefix(str, s.tag+"\n"))
+}
+
+func (s *taggedNQuadSerializer) Serialize(dataset *RDFDataset) (interface{}, error) {
+	nquads, err := s.nquads.Serialize(dataset)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("%s\n%s", s.tag, nquads), nil
+}
+
+func TestRegisterRDFSerializer(t *testing.T) {
+	const format = "application/x-tagged-nquads"
+	doc := map[string]interface{}{
+		"@id":                  "http://example.com/s",
+		"http://example.com/p": map[string]interface{}{"@id": "_:b0"},
+	}
+	proc := NewJsonLdProcessor()
+
+	opts := NewJsonLdOptions("")
+	opts.Format = format
+	_, err := proc.ToRDF(doc, opts)
+	require.Error(t, err)
+	assert.Equal(t, UnknownFormat, err.(*JsonLdError).Code)
+
+	// a serializer for a single call
+	opts.RDFSerializers = map[string]RDFSerializer{format: &taggedNQuadSerializer{tag: "# call"}}
+	serialized, err := proc.ToRDF(doc, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "# call\n<http://example.com/s> <http://example.com/p> _:b0 .\n", serialized)
+
+	fromRDF, err := proc.FromRDF(serialized, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id":                  "http://example.com/s",
+			"http://example.com/p": []interface{}{map[string]interface{}{"@id": "_:b0"}},
+		},
+	}, fromRDF)
+
+	opts.InputFormat = format
+	normalized, err := proc.Normalize(serialized, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "# call\n<http://example.com/s> <http://example.com/p> _:c14n0 .\n", normalized)
+	assert.NotContains(t, SupportedFeatures().RDFFormats, format)
+
+	// a serializer registered for all calls
+	RegisterRDFSerializer(format, &taggedNQuadSerializer{tag: "# global"})
+	defer RegisterRDFSerializer(format, nil)
+
+	assert.Contains(t, SupportedFeatures().RDFFormats, format)
+	assert.Contains(t, Capabilities().RDFInputFormats, format)
+
+	serialized, err = proc.ToRDF(doc, opts)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(serialized.(string), "# call\n"))
+
+	opts.RDFSerializers = nil
+	serialized, err = proc.ToRDF(doc, opts)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(serialized.(string), "# global\n"))
+
+	dataset, err := proc.ToRDF(doc, nil)
+	require.NoError(t, err)
+	var sb strings.Builder
+	require.NoError(t, dataset.(*RDFDataset).WriteFormat(&sb, format, nil))
+	assert.Equal(t, serialized, sb.String())
+}
@@ -14,8 +14,21 @@
 
 package ld
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
 // TurtleRDFSerializer parses and serializes Turtle data.
+//
+// Turtle has no syntax for named graphs. By default, serializing a dataset
+// which contains named graphs fails with an error listing these graphs.
+// If TriG is true, named graphs are written as TriG graph blocks instead.
 type TurtleRDFSerializer struct {
+	TriG bool
 }
 
 // Parse Turtle from string into an RDFDataset
@@ -23,7 +36,102 @@ func (s *TurtleRDFSerializer) Parse(input interface{}) (*RDFDataset, error) {
 	return nil, NewJsonLdError(NotImplemented, "Turtle not supported")
 }
 
+// SerializeTo writes RDFDataset as Turtle (or TriG, if enabled) into a writer.
+func (s *TurtleRDFSerializer) SerializeTo(w io.Writer, dataset *RDFDataset) error {
+	graphNames := make([]string, 0, len(dataset.Graphs))
+	for graphName, triples := range dataset.Graphs {
+		if graphName != "@default" && len(triples) > 0 {
+			graphNames = append(graphNames, graphName)
+		}
+	}
+	sort.Strings(graphNames)
+
+	if len(graphNames) > 0 && !s.TriG {
+		return NewJsonLdError(InvalidInput,
+			fmt.Sprintf("Turtle can't represent named graphs, use TriG to serialize graphs: %s",
+				strings.Join(graphNames, ", ")))
+	}
+
+	if err := writeTurtleTriples(w, dataset.Graphs["@default"], ""); err != nil {
+		return err
+	}
+
+	for _, graphName := range graphNames {
+		if _, err := fmt.Fprintf(w, "%s {\n", turtleResource(graphName)); err != nil {
+			return NewJsonLdError(IOError, err)
+		}
+		if err := writeTurtleTriples(w, dataset.Graphs[graphName], "  "); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(w, "}\n"); err != nil {
+			return NewJsonLdError(IOError, err)
+		}
+	}
+
+	return nil
+}
+
 // Serialize an RDFDataset into a Turtle string.
 func (s *TurtleRDFSerializer) Serialize(dataset *RDFDataset) (interface{}, error) {
-	return nil, NewJsonLdError(NotImplemented, "Turtle not supported")
+	buf := bytes.NewBuffer(nil)
+	if err := s.SerializeTo(buf, dataset); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+// writeTurtleTriples writes the given triples, grouped by subject, in the order
+// subjects first appear in the list.
+func writeTurtleTriples(w io.Writer, triples []*Quad, indent string) error {
+	subjects := make([]string, 0)
+	bySubject := make(map[string][]*Quad)
+	for _, triple := range triples {
+		subject := turtleTerm(triple.Subject)
+		if _, seen := bySubject[subject]; !seen {
+			subjects = append(subjects, subject)
+		}
+		bySubject[subject] = append(bySubject[subject], triple)
+	}
+
+	for _, subject := range subjects {
+		var sb strings.Builder
+		sb.WriteString(indent + subject)
+		for i, triple := range bySubject[subject] {
+			if i > 0 {
+				sb.WriteString(" ;\n" + indent + "   ")
+			}
+			predicate := turtleTerm(triple.Predicate)
+			if triple.Predicate.GetValue() == RDFType {
+				predicate = "a"
+			}
+			sb.WriteString(" " + predicate + " " + turtleTerm(triple.Object))
+		}
+		sb.WriteString(" .\n")
+		if _, err := fmt.Fprint(w, sb.String()); err != nil {
+			return NewJsonLdError(IOError, err)
+		}
+	}
+	return nil
+}
+
+// turtleTerm returns the Turtle representation of an IRI, blank node or literal.
+func turtleTerm(node Node) string {
+	if literal, isLiteral := node.(*Literal); isLiteral {
+		term := "\"" + escape(literal.GetValue()) + "\""
+		if literal.Datatype == RDFLangString {
+			term += "@" + literal.Language
+		} else if literal.Datatype != XSDString {
+			term += "^^<" + escape(literal.Datatype) + ">"
+		}
+		return term
+	}
+	return turtleResource(node.GetValue())
+}
+
+// turtleResource returns the Turtle representation of an IRI or blank node identifier.
+func turtleResource(id string) string {
+	if strings.HasPrefix(id, "_:") {
+		return id
+	}
+	return "<" + escape(id) + ">"
 }
@@ -0,0 +1,64 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTurtleRDFSerializer_Serialize(t *testing.T) {
+	dataset := NewRDFDataset()
+	dataset.Graphs["@default"] = []*Quad{
+		NewQuad(NewIRI("http://example.com/a"), NewIRI(RDFType), NewIRI("http://example.com/Thing"), "@default"),
+		NewQuad(NewIRI("http://example.com/a"), NewIRI("http://example.com/name"), NewLiteral("A \"quoted\" name", XSDString, ""), "@default"),
+		NewQuad(NewBlankNode("_:b0"), NewIRI("http://example.com/label"), NewLiteral("hello", RDFLangString, "en"), "@default"),
+	}
+
+	expectedTurtle := "<http://example.com/a> a <http://example.com/Thing> ;\n" +
+		"    <http://example.com/name> \"A \\\"quoted\\\" name\" .\n" +
+		"_:b0 <http://example.com/label> \"hello\"@en .\n"
+
+	serializer := &TurtleRDFSerializer{}
+	result, err := serializer.Serialize(dataset)
+	require.NoError(t, err)
+	assert.Equal(t, expectedTurtle, result)
+
+	dataset.Graphs["http://example.com/g2"] = []*Quad{
+		NewQuad(NewIRI("http://example.com/b"), NewIRI("http://example.com/age"), NewLiteral("5", XSDInteger, ""), "http://example.com/g2"),
+	}
+	dataset.Graphs["_:g1"] = []*Quad{
+		NewQuad(NewIRI("http://example.com/c"), NewIRI("http://example.com/p"), NewIRI("http://example.com/d"), "_:g1"),
+	}
+
+	_, err = serializer.Serialize(dataset)
+	require.Error(t, err)
+	assert.Equal(t, InvalidInput, err.(*JsonLdError).Code)
+	assert.Contains(t, err.Error(), "_:g1, http://example.com/g2")
+
+	serializer.TriG = true
+	result, err = serializer.Serialize(dataset)
+	require.NoError(t, err)
+	assert.Equal(t, expectedTurtle+
+		"_:g1 {\n"+
+		"  <http://example.com/c> <http://example.com/p> <http://example.com/d> .\n"+
+		"}\n"+
+		"<http://example.com/g2> {\n"+
+		"  <http://example.com/b> <http://example.com/age> \"5\"^^<http://www.w3.org/2001/XMLSchema#integer> .\n"+
+		"}\n", result)
+}
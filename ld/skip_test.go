@@ -15,8 +15,6 @@ var skippedTests = map[string][]string{
 		"#tpr39", // TODO
 		"#t0122", // TODO
 		"#t0123", // TODO
-		"#tc032", // TODO
-		"#tc033", // TODO
 		"#tec02", // TODO
 		"#ter52", // TODO
 	},
@@ -24,24 +22,15 @@ var skippedTests = map[string][]string{
 	"testdata/fromRdf-manifest.jsonld": {
 		"#tdi05", // No support for i18n-datatype yet
 		"#tdi06", // No support for i18n-datatype yet
-		"#tdi11", // No support for compound-literal yet
-		"#tdi12", // No support for compound-literal yet
 		"#tjs",   // @json not yet supported
 	},
 	"testdata/remote-doc-manifest.jsonld": {
 		"#t0013", // HTML documents aren't supported yet
 	},
 	"testdata/toRdf-manifest.jsonld": {
-		"#tc032", // TODO
-		"#tc033", // TODO
 		"#tdi09", // No support for i18n-datatype yet
 		"#tdi10", // No support for i18n-datatype yet
-		"#tdi11", // No support for compound-literal yet
-		"#tdi12", // No support for compound-literal yet
 		"#te075", // No support for GeneralizedRdf
-		"#te085", // test passes, bug in isomorphism check
-		"#te086", // test passes, bug in isomorphism check
-		"#te087", // test passes, bug in isomorphism check
 		"#te111", // TODO
 		"#te112", // TODO
 		"#tjs03", // TODO numeric format
@@ -0,0 +1,171 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testsuite
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// permutations calls f with each permutation of a.
+func permutations(a []string, f func([]string) bool) {
+	perm(a, f, 0)
+}
+
+// Permute the values at index i to len(a)-1.
+func perm(a []string, f func([]string) bool, i int) bool {
+	if i > len(a) {
+		return f(a)
+	}
+	if perm(a, f, i+1) {
+		// stop
+		return true
+	}
+	for j := i + 1; j < len(a); j++ {
+		a[i], a[j] = a[j], a[i]
+		if perm(a, f, i+1) {
+			// stop
+			return true
+		}
+		a[i], a[j] = a[j], a[i]
+	}
+	return false
+}
+
+func getBlankNodes(quads []*ld.Quad) []string {
+	blankNodeSet := make(map[string]interface{})
+	for _, quad := range quads {
+		if ld.IsBlankNode(quad.Object) {
+			blankNodeSet[quad.Object.GetValue()] = nil
+		}
+		if ld.IsBlankNode(quad.Subject) {
+			blankNodeSet[quad.Subject.GetValue()] = nil
+		}
+	}
+	return ld.GetKeys(blankNodeSet)
+}
+
+func mapBlankNodes(quads []*ld.Quad, actualBlankNodes []string, mappedBlankNodes []string) []*ld.Quad {
+	nodeMap := make(map[string]string, len(actualBlankNodes))
+	for i := 0; i < len(actualBlankNodes); i++ {
+		nodeMap[actualBlankNodes[i]] = mappedBlankNodes[i]
+	}
+	res := make([]*ld.Quad, 0, len(quads))
+	for _, q := range quads {
+		obj := q.Object
+		if ld.IsBlankNode(q.Object) {
+			obj = ld.NewBlankNode(nodeMap[q.Object.GetValue()])
+		}
+		subj := q.Subject
+		if ld.IsBlankNode(q.Subject) {
+			subj = ld.NewBlankNode(nodeMap[q.Subject.GetValue()])
+		}
+		graph := ""
+		if q.Graph != nil {
+			graph = q.Graph.GetValue()
+		}
+		res = append(res, ld.NewQuad(subj, q.Predicate, obj, graph))
+	}
+	return res
+}
+
+func sortNQuads(input string) string {
+	temp := strings.Split(input, "\n")
+	if temp[len(temp)-1] == "" {
+		temp = temp[:len(temp)-1]
+	}
+	sort.Strings(temp)
+	temp = append(temp, "")
+	return strings.Join(temp, "\n")
+}
+
+// Isomorphic returns true if two given sets of n-quads are isomorphic.
+// This is a lazy implementation and it should only be used for testing.
+// We build all possible permutations of blank node IDs and try them one
+// by one. Minimal optimisations are applied.
+func Isomorphic(expectedStr, actualStr string) bool {
+	expected := sortNQuads(expectedStr)
+	actual := sortNQuads(actualStr)
+
+	// if quads are identical, exit early
+	if ld.DeepCompare(expected, actual, true) {
+		return true
+	}
+
+	serializer := &ld.NQuadRDFSerializer{}
+
+	expectedDS, err := serializer.Parse(expectedStr)
+	if err != nil {
+		log.Printf("Error when parsing expected quads: %s\n", err.Error())
+		return false
+	}
+	actualDS, err := serializer.Parse(actualStr)
+	if err != nil {
+		log.Printf("Error when parsing actual quads: %s\n", err.Error())
+		return false
+	}
+
+	if len(expectedDS.Graphs) != len(actualDS.Graphs) {
+		log.Println("Number of graphs doesn't match")
+		return false
+	}
+
+	for graphName, quads := range expectedDS.Graphs {
+		actualQuads := actualDS.Graphs[graphName]
+		if len(quads) != len(actualQuads) {
+			log.Printf("Number of quads doesn't match in graph %s\n", graphName)
+			return false
+		}
+		expectedBlankNodes := getBlankNodes(quads)
+		actualBlankNodes := getBlankNodes(actualQuads)
+		if len(expectedBlankNodes) != len(actualBlankNodes) {
+			log.Printf("Number of blank nodes doesn't match in graph %s\n", graphName)
+			return false
+		}
+
+		expectedGraphDS := &ld.RDFDataset{
+			Graphs: map[string][]*ld.Quad{
+				graphName: quads,
+			},
+		}
+		expectedObj, _ := serializer.Serialize(expectedGraphDS)
+		expectedGraph := sortNQuads(expectedObj.(string))
+
+		isomorphic := false
+		permutations(expectedBlankNodes, func(perm []string) bool {
+			permutedDS := &ld.RDFDataset{
+				Graphs: map[string][]*ld.Quad{
+					graphName: mapBlankNodes(actualQuads, actualBlankNodes, perm),
+				},
+			}
+			permutedObj, _ := serializer.Serialize(permutedDS)
+			permutedGraph := sortNQuads(permutedObj.(string))
+
+			if ld.DeepCompare(expectedGraph, permutedGraph, true) {
+				isomorphic = true
+				return true
+			}
+			return false
+		})
+		if isomorphic {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testsuite
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	assertor     = "https://github.com/kazarena"
+	assertorName = "Stan Nazarenko"
+)
+
+// EarlReport generates an EARL report.
+type EarlReport struct {
+	report map[string]interface{}
+}
+
+// NewEarlReport creates a new EarlReport for the given version of json-gold.
+func NewEarlReport(version string) *EarlReport {
+	if version == "" {
+		version = "v0.3.0"
+	}
+	rval := &EarlReport{
+		report: map[string]interface{}{
+			"@context": map[string]interface{}{
+				"doap":            "http://usefulinc.com/ns/doap#",
+				"foaf":            "http://xmlns.com/foaf/0.1/",
+				"dc":              "http://purl.org/dc/terms/",
+				"earl":            "http://www.w3.org/ns/earl#",
+				"xsd":             "http://www.w3.org/2001/XMLSchema#",
+				"doap:homepage":   map[string]interface{}{"@type": "@id"},
+				"doap:license":    map[string]interface{}{"@type": "@id"},
+				"dc:creator":      map[string]interface{}{"@type": "@id"},
+				"foaf:homepage":   map[string]interface{}{"@type": "@id"},
+				"subjectOf":       map[string]interface{}{"@reverse": "earl:subject"},
+				"earl:assertedBy": map[string]interface{}{"@type": "@id"},
+				"earl:mode":       map[string]interface{}{"@type": "@id"},
+				"earl:test":       map[string]interface{}{"@type": "@id"},
+				"earl:outcome":    map[string]interface{}{"@type": "@id"},
+				"dc:date":         map[string]interface{}{"@type": "xsd:date"},
+			},
+			"@id": "https://github.com/piprate/json-gold",
+			"@type": []interface{}{
+				"doap:Project",
+				"earl:TestSubject",
+				"earl:Software",
+			},
+			"doap:name":                 "JSON-goLD",
+			"dc:title":                  "JSON-goLD",
+			"doap:homepage":             "https://github.com/piprate/json-gold",
+			"doap:license":              "https://github.com/piprate/json-gold/blob/master/LICENSE",
+			"doap:description":          "A JSON-LD processor for Go",
+			"doap:programming-language": "Go",
+			"dc:creator":                assertor,
+			"doap:developer": map[string]interface{}{
+				"@id": assertor,
+				"@type": []interface{}{
+					"foaf:Person",
+					"earl:Assertor",
+				},
+				"foaf:name":     assertorName,
+				"foaf:homepage": assertor,
+			},
+			"doap:release": map[string]interface{}{
+				"@id":           fmt.Sprintf("https://github.com/piprate/json-gold/tree/%s", version),
+				"@type":         "doap:Version",
+				"doap:revision": version,
+				"doap:name":     fmt.Sprintf("json-gold-%s", version),
+				"doap:created": map[string]interface{}{
+					"@value": time.Now().Format("2006-01-02"),
+					"@type":  "xsd:date",
+				},
+			},
+			"dc:date": map[string]interface{}{
+				"@value": time.Now().Format("2006-01-02"),
+				"@type":  "xsd:date",
+			},
+			"subjectOf": make([]interface{}, 0),
+		},
+	}
+
+	return rval
+}
+
+// AddAssertion adds the outcome of the given test to the report.
+func (er *EarlReport) AddAssertion(testName string, skipped bool, success bool) {
+	var outcome string
+	if skipped {
+		outcome = "earl:untested"
+	} else if success {
+		outcome = "earl:passed"
+	} else {
+		outcome = "earl:failed"
+	}
+	er.report["subjectOf"] = append(
+		er.report["subjectOf"].([]interface{}),
+		map[string]interface{}{
+			"@type":           "earl:Assertion",
+			"earl:assertedBy": assertor,
+			"earl:mode":       "earl:automatic",
+			"earl:test":       testName,
+			"earl:result": map[string]interface{}{
+				"@type":        "earl:TestResult",
+				"dc:date":      time.Now().Format("2006-01-02T15:04:05.999999"),
+				"earl:outcome": outcome,
+			},
+		},
+	)
+}
+
+// Write writes the report into the given file.
+func (er *EarlReport) Write(filename string) {
+	b, _ := json.MarshalIndent(er.report, "", "  ")
+
+	f, _ := os.Create(filename)
+	defer f.Close()
+	_, _ = f.Write(b)
+	_, _ = f.WriteString("\n")
+}
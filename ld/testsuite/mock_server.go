@@ -0,0 +1,168 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testsuite
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// RewriteHostTransport is an http.RoundTripper that rewrites requests
+// using the provided Host. The Opaque field is untouched.
+// If Transport is nil, http.DefaultTransport is used
+type RewriteHostTransport struct {
+	Transport http.RoundTripper
+	Host      string
+}
+
+func (t RewriteHostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// save the original host
+	origHost := req.URL.Host
+	// rewrite the host
+	req.URL.Host = t.Host
+
+	rt := t.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	res, err := rt.RoundTrip(req)
+
+	if err == nil {
+		// restore the original host to ensure the client doesn't know the response
+		// came from a MockServer instance
+		res.Request.URL.Host = origHost
+	}
+	return res, err
+}
+
+// MockServer uses httptest package to mock live HTTP calls.
+type MockServer struct {
+	Base       string
+	TestFolder string
+
+	ContentType string
+	HTTPLink    []string
+	HTTPStatus  int
+	RedirectTo  string
+
+	server *httptest.Server
+
+	DocumentLoader ld.DocumentLoader
+}
+
+// NewMockServer creates a new instance of MockServer.
+func NewMockServer(base string, testFolder string) *MockServer {
+	mockServer := &MockServer{
+		Base:       base,
+		TestFolder: testFolder,
+	}
+
+	var ts *httptest.Server
+	mockFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mockServer.HTTPStatus != 0 {
+			// must be a redirect
+			w.Header().Set("Location", mockServer.Base+mockServer.RedirectTo)
+			w.WriteHeader(mockServer.HTTPStatus)
+		} else {
+			u := r.URL.String()
+
+			if strings.HasPrefix(u, mockServer.Base) {
+				contentType := mockServer.ContentType
+				if contentType == "" {
+					if strings.HasSuffix(u, ".jsonld") {
+						contentType = "application/ld+json"
+					} else if strings.HasSuffix(u, ".html") {
+						contentType = "text/html"
+					} else {
+						contentType = "application/json"
+					}
+				}
+
+				fileName := filepath.Join(mockServer.TestFolder, u[len(mockServer.Base):])
+				inputBytes, err := os.ReadFile(fileName)
+				if err == nil {
+					w.Header().Set("Content-Type", contentType)
+					if mockServer.HTTPLink != nil {
+						w.Header().Set("Link", strings.Join(mockServer.HTTPLink, ", "))
+					}
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write(inputBytes)
+				} else {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+
+		}
+
+		// reset the context for the second call so that it succeeds.
+		// currently there are no tests where it needs to work in a different way
+		mockServer.HTTPStatus = 0
+		mockServer.HTTPLink = nil
+	})
+
+	if strings.HasPrefix(base, "https") {
+		ts = httptest.NewTLSServer(mockFunc)
+	} else {
+		ts = httptest.NewServer(mockFunc)
+	}
+
+	// get httptest.Server's URL
+
+	tsURL, err := url.Parse(ts.URL)
+	if err != nil {
+		log.Fatalln("failed to parse httptest.Server URL:", err)
+	}
+
+	// update base URL with httptest.Server's host
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		log.Fatalln("failed to parse base URL:", err)
+	}
+	baseURL.Host = tsURL.Host
+	mockServer.Base = baseURL.Path
+
+	client := ts.Client()
+
+	client.Transport = RewriteHostTransport{
+		Transport: client.Transport,
+		Host:      tsURL.Host,
+	}
+
+	mockServer.server = ts
+	mockServer.DocumentLoader = ld.NewDefaultDocumentLoader(client)
+
+	return mockServer
+}
+
+func (ms *MockServer) SetExpectedBehaviour(contentType string, httpLink []string, httpStatus int, redirectTo string) {
+	ms.ContentType = contentType
+	ms.HTTPLink = httpLink
+	ms.HTTPStatus = httpStatus
+	ms.RedirectTo = redirectTo
+}
+
+func (ms *MockServer) Close() {
+	if ms.server != nil {
+		ms.server.Close()
+	}
+}
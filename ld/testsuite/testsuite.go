@@ -0,0 +1,141 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testsuite runs JSON-LD, Framing and RDF Dataset Normalization test manifests
+// against the ld package. It's used by the test suite of the ld package and may also run
+// in-house regression suites: runners for custom test types may be added with RegisterTestType
+// and the manifests are run with Suite.
+package testsuite
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// TestDefinition describes a test from a test manifest.
+type TestDefinition struct {
+	ID               string
+	Name             string
+	Type             string
+	EvaluationType   string
+	InputURL         string
+	InputFileName    string
+	ExpectedFileName string
+	Option           map[string]interface{}
+	Raw              map[string]interface{}
+	Skip             bool
+	// BaseDir is the directory of the manifest, against which file names
+	// of the test's documents are resolved.
+	BaseDir string
+}
+
+// Runner runs a test of a particular type from a test manifest
+// and returns the result of the operation under test.
+type Runner func(proc *ld.JsonLdProcessor, td *TestDefinition, options *ld.JsonLdOptions) (interface{}, error)
+
+// runners holds the runners of the supported test types,
+// keyed by the test type as it appears in manifests.
+var runners = make(map[string]Runner)
+
+// RegisterTestType makes the test suite run tests of the given type with the given runner.
+// A runner registered for a type which is already known replaces the previous one.
+// It isn't safe for concurrent use and is meant to be called from init functions.
+func RegisterTestType(testType string, runner Runner) {
+	runners[testType] = runner
+}
+
+// GetRunner returns the runner registered for the given test type.
+func GetRunner(testType string) (Runner, bool) {
+	runner, found := runners[testType]
+	return runner, found
+}
+
+// LoadDocument loads a JSON document referenced by the given key of the test's manifest entry.
+func LoadDocument(td *TestDefinition, key string) (interface{}, error) {
+	rd, err := ld.NewDefaultDocumentLoader(nil).LoadDocument(filepath.Join(td.BaseDir, td.Raw[key].(string)))
+	if err != nil {
+		return nil, err
+	}
+	return rd.Document, nil
+}
+
+// normalizeRunner returns a runner for normalization tests with the given algorithm.
+func normalizeRunner(algorithm string) Runner {
+	return func(proc *ld.JsonLdProcessor, td *TestDefinition, options *ld.JsonLdOptions) (interface{}, error) {
+		inputBytes, err := os.ReadFile(td.InputFileName)
+		if err != nil {
+			return nil, err
+		}
+		options.InputFormat = "application/n-quads"
+		options.Format = "application/n-quads"
+		options.Algorithm = algorithm
+		return proc.Normalize(string(inputBytes), options)
+	}
+}
+
+func init() {
+	RegisterTestType("jld:ExpandTest", func(proc *ld.JsonLdProcessor, td *TestDefinition,
+		options *ld.JsonLdOptions) (interface{}, error) {
+		return proc.Expand(td.InputURL, options)
+	})
+	RegisterTestType("jld:CompactTest", func(proc *ld.JsonLdProcessor, td *TestDefinition,
+		options *ld.JsonLdOptions) (interface{}, error) {
+		contextDoc, err := LoadDocument(td, "context")
+		if err != nil {
+			return nil, err
+		}
+		return proc.Compact(td.InputURL, contextDoc, options)
+	})
+	RegisterTestType("jld:FlattenTest", func(proc *ld.JsonLdProcessor, td *TestDefinition,
+		options *ld.JsonLdOptions) (interface{}, error) {
+		var contextDoc interface{}
+		if _, hasContext := td.Raw["context"]; hasContext {
+			var err error
+			if contextDoc, err = LoadDocument(td, "context"); err != nil {
+				return nil, err
+			}
+		}
+		return proc.Flatten(td.InputURL, contextDoc, options)
+	})
+	RegisterTestType("jld:FrameTest", func(proc *ld.JsonLdProcessor, td *TestDefinition,
+		options *ld.JsonLdOptions) (interface{}, error) {
+		frameDoc, err := LoadDocument(td, "frame")
+		if err != nil {
+			return nil, err
+		}
+		return proc.Frame(td.InputURL, frameDoc, options)
+	})
+	RegisterTestType("jld:FromRDFTest", func(proc *ld.JsonLdProcessor, td *TestDefinition,
+		options *ld.JsonLdOptions) (interface{}, error) {
+		inputBytes, err := os.ReadFile(td.InputFileName)
+		if err != nil {
+			return nil, err
+		}
+		return proc.FromRDF(string(inputBytes), options)
+	})
+	RegisterTestType("jld:ToRDFTest", func(proc *ld.JsonLdProcessor, td *TestDefinition,
+		options *ld.JsonLdOptions) (interface{}, error) {
+		options.Format = "application/n-quads"
+		return proc.ToRDF(td.InputURL, options)
+	})
+	RegisterTestType("jld:HtmlTest", func(proc *ld.JsonLdProcessor, td *TestDefinition,
+		options *ld.JsonLdOptions) (interface{}, error) {
+		// TODO
+		return proc.Expand(td.InputURL, options)
+	})
+	RegisterTestType("rdfn:Urgna2012EvalTest", normalizeRunner(ld.AlgorithmURGNA2012))
+	RegisterTestType("rdfn:Urdna2015EvalTest", normalizeRunner(ld.AlgorithmURDNA2015))
+}
@@ -244,7 +244,11 @@ func Resolve(baseURI string, pathToResolve string) string {
 		return baseURI
 	}
 
-	uri, _ := url.Parse(baseURI)
+	uri, err := url.Parse(baseURI)
+	if err != nil {
+		// the base can't be used for resolution, leave the path as is
+		return pathToResolve
+	}
 	// query string parsing
 	if strings.HasPrefix(pathToResolve, "?") {
 		// drop fragment from uri if it has one
@@ -253,7 +257,11 @@ func Resolve(baseURI string, pathToResolve string) string {
 		return uri.String()
 	}
 
-	pathToResolveURL, _ := url.Parse(pathToResolve)
+	pathToResolveURL, err := url.Parse(pathToResolve)
+	if err != nil {
+		// malformed references are left unresolved
+		return pathToResolve
+	}
 	uri = uri.ResolveReference(pathToResolveURL)
 	// java doesn't discard unnecessary dot segments
 	if uri.Path != "" {
@@ -17,6 +17,8 @@ package ld
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
 	"net/url"
 	"os"
 	"sort"
@@ -37,8 +39,45 @@ func IsKeyword(key interface{}) bool {
 		key == "@vocab"
 }
 
+// NumberComparison defines how DeepCompareWith and CompareValuesWith compare numbers.
+type NumberComparison int
+
+const (
+	// NumbersAsText compares numbers by their fixed-point representation with six decimal places.
+	// This is what DeepCompare and CompareValues do. It loses precision of large numbers
+	// and makes numbers which differ by less than 1e-6 equal.
+	NumbersAsText NumberComparison = iota
+	// NumbersExact compares numbers as float64 values.
+	NumbersExact
+	// NumbersWithinEpsilon treats numbers as equal if they differ by no more than CompareOptions.Epsilon.
+	NumbersWithinEpsilon
+	// NumbersAsRational compares numbers as arbitrary precision rational numbers (see big.Rat),
+	// so that json.Number values which can't be represented as float64 are compared exactly.
+	NumbersAsRational
+)
+
+// CompareOptions configures DeepCompareWith and CompareValuesWith.
+type CompareOptions struct {
+	// ListOrderMatters makes DeepCompareWith compare arrays in order.
+	ListOrderMatters bool
+	// Numbers defines how numbers (float64, integer and json.Number values) are compared.
+	Numbers NumberComparison
+	// Epsilon is the maximum difference between equal numbers if Numbers is NumbersWithinEpsilon.
+	Epsilon float64
+}
+
 // DeepCompare returns true if v1 equals v2.
 func DeepCompare(v1 interface{}, v2 interface{}, listOrderMatters bool) bool {
+	return DeepCompareWith(v1, v2, &CompareOptions{ListOrderMatters: listOrderMatters})
+}
+
+// DeepCompareWith returns true if v1 equals v2, according to the given options.
+// If opts is nil, it behaves as DeepCompare with listOrderMatters set to false.
+func DeepCompareWith(v1 interface{}, v2 interface{}, opts *CompareOptions) bool {
+	if opts == nil {
+		opts = &CompareOptions{}
+	}
+
 	if v1 == nil {
 		return v2 == nil
 	} else if v2 == nil {
@@ -54,7 +93,7 @@ func DeepCompare(v1 interface{}, v2 interface{}, listOrderMatters bool) bool {
 			return false
 		}
 		for _, key := range GetKeys(m1) {
-			if val2, present := m2[key]; !present || !DeepCompare(m1[key], val2, listOrderMatters) {
+			if val2, present := m2[key]; !present || !DeepCompareWith(m1[key], val2, opts) {
 				return false
 			}
 		}
@@ -69,11 +108,11 @@ func DeepCompare(v1 interface{}, v2 interface{}, listOrderMatters bool) bool {
 		for i := 0; i < len(l1); i++ {
 			o1 := l1[i]
 			gotMatch := false
-			if listOrderMatters {
-				gotMatch = DeepCompare(o1, l2[i], listOrderMatters)
+			if opts.ListOrderMatters {
+				gotMatch = DeepCompareWith(o1, l2[i], opts)
 			} else {
 				for j := 0; j < len(l2); j++ {
-					if !alreadyMatched[j] && DeepCompare(o1, l2[j], listOrderMatters) {
+					if !alreadyMatched[j] && DeepCompareWith(o1, l2[j], opts) {
 						alreadyMatched[j] = true
 						gotMatch = true
 						break
@@ -86,15 +125,37 @@ func DeepCompare(v1 interface{}, v2 interface{}, listOrderMatters bool) bool {
 		}
 		return true
 	} else {
-		if v1 != v2 {
-			// perform additional checks. If the client code sets UseNumber() property
-			// of json.Decoder to decode numbers (see https://golang.org/pkg/encoding/json/#Decoder.UseNumber ),
-			// simple comparison will fail.
-			return normalizeValue(v1) == normalizeValue(v2)
-		} else {
-			return true
+		return opts.primitivesEqual(v1, v2)
+	}
+}
+
+// primitivesEqual compares two values which aren't maps or arrays.
+func (opts *CompareOptions) primitivesEqual(v1 interface{}, v2 interface{}) bool {
+	if opts.Numbers == NumbersAsText {
+		// perform additional checks if values differ. If the client code sets UseNumber() property
+		// of json.Decoder to decode numbers (see https://golang.org/pkg/encoding/json/#Decoder.UseNumber ),
+		// simple comparison will fail.
+		return v1 == v2 || normalizeValue(v1) == normalizeValue(v2)
+	}
+
+	if opts.Numbers == NumbersAsRational {
+		r1, isNum1 := ratValue(v1)
+		r2, isNum2 := ratValue(v2)
+		if isNum1 && isNum2 {
+			return r1.Cmp(r2) == 0
 		}
+		return !isNum1 && !isNum2 && v1 == v2
 	}
+
+	f1, isNum1 := floatValue(v1)
+	f2, isNum2 := floatValue(v2)
+	if isNum1 && isNum2 {
+		if opts.Numbers == NumbersWithinEpsilon {
+			return math.Abs(f1-f2) <= opts.Epsilon
+		}
+		return f1 == f2
+	}
+	return !isNum1 && !isNum2 && v1 == v2
 }
 
 // normalizeValue allows comparisons between json.Number and float/integer values.
@@ -117,6 +178,46 @@ func normalizeValue(v interface{}) string {
 	}
 }
 
+// floatValue converts a numeric value into float64.
+func floatValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// ratValue converts a numeric value into big.Rat without losing precision.
+func ratValue(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case float64:
+		if math.IsInf(n, 0) || math.IsNaN(n) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(n), true
+	case float32:
+		return ratValue(float64(n))
+	case int:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int64:
+		return new(big.Rat).SetInt64(n), true
+	case json.Number:
+		return new(big.Rat).SetString(n.String())
+	default:
+		return nil, false
+	}
+}
+
 func deepContains(values []interface{}, value interface{}) bool {
 	for _, item := range values {
 		if DeepCompare(item, value, false) {
@@ -233,6 +334,41 @@ func IsValue(v interface{}) bool {
 	return isMap && containsValue
 }
 
+// NewValue returns a JSON-LD value object for the given value.
+// Empty typ, lang and dir arguments are omitted from the result.
+// Note that a value object may not have a type alongside a language or direction.
+func NewValue(v interface{}, typ, lang, dir string) map[string]interface{} {
+	rval := map[string]interface{}{
+		"@value": v,
+	}
+	if typ != "" {
+		rval["@type"] = typ
+	}
+	if lang != "" {
+		rval["@language"] = lang
+	}
+	if dir != "" {
+		rval["@direction"] = dir
+	}
+	return rval
+}
+
+// NewRef returns a node reference (an object with @id only) for the given IRI.
+func NewRef(iri string) map[string]interface{} {
+	return map[string]interface{}{
+		"@id": iri,
+	}
+}
+
+// NewList returns a JSON-LD list object containing the given items.
+func NewList(items ...interface{}) map[string]interface{} {
+	list := make([]interface{}, 0, len(items))
+	list = append(list, items...)
+	return map[string]interface{}{
+		"@list": list,
+	}
+}
+
 // Arrayify returns v, if v is an array, otherwise returns an array
 // containing v as the only element.
 func Arrayify(v interface{}) []interface{} {
@@ -303,7 +439,170 @@ func isEmptyObject(v interface{}) bool {
 	return isMap && len(vMap) == 0
 }
 
+// isContainerMap returns true if values of the given property are compacted
+// into a map keyed by @index, @id, @type or @language.
+func isContainerMap(ctx *Context, prop string) bool {
+	return ctx.HasContainerMapping(prop, "@index") || ctx.HasContainerMapping(prop, "@id") ||
+		ctx.HasContainerMapping(prop, "@type") || ctx.HasContainerMapping(prop, "@language")
+}
+
+// removePreserveFromMap applies RemovePreserve to the values of a container map
+// of the given property. The keys of such map aren't terms, so arrays are unwrapped
+// according to the container mapping of the property which holds the map.
+func removePreserveFromMap(ctx *Context, contexts *nodeContextCache, prop string, input map[string]interface{},
+	bnodesToClear []string, compactArrays bool) (interface{}, error) {
+	isSetContainer := ctx.HasContainerMapping(prop, "@set")
+	isTypeContainer := ctx.HasContainerMapping(prop, "@type")
+	for key, val := range input {
+		// keys of type maps are types of the nodes, which may bring type-scoped contexts
+		mapType := ""
+		if isTypeContainer {
+			mapType = key
+		}
+		result, err := removePreserve(ctx, contexts, prop, mapType, val, bnodesToClear, compactArrays)
+		if err != nil {
+			return nil, err
+		}
+		if resultList, isList := result.([]interface{}); isList && len(resultList) == 1 && !isSetContainer {
+			result = resultList[0]
+		}
+		input[key] = result
+	}
+	return input, nil
+}
+
+// nodeContextCache keeps the contexts computed by nodeContext, so that scoped contexts
+// are parsed once per property and set of types instead of once per node.
+type nodeContextCache struct {
+	property map[propertyContextKey]*propertyContexts
+	typed    map[typedContextKey]*Context
+}
+
+type propertyContextKey struct {
+	ctx      *Context
+	property string
+}
+
+// propertyContexts holds the contexts for the value of a property: input is the context
+// with the property-scoped context applied, active is the one used for node objects.
+type propertyContexts struct {
+	input  *Context
+	active *Context
+}
+
+type typedContextKey struct {
+	propertyContextKey
+	types string
+}
+
+func newNodeContextCache() *nodeContextCache {
+	return &nodeContextCache{
+		property: make(map[propertyContextKey]*propertyContexts),
+		typed:    make(map[typedContextKey]*Context),
+	}
+}
+
+// propertyContexts returns the contexts for the value of activeProperty in ctx:
+// ctx with the property-scoped context applied and the same after reverting
+// non-propagated contexts.
+func (cache *nodeContextCache) propertyContexts(ctx *Context, activeProperty string) (*propertyContexts, error) {
+	key := propertyContextKey{ctx: ctx, property: activeProperty}
+	if pc, found := cache.property[key]; found {
+		return pc, nil
+	}
+
+	var err error
+	inputCtx := ctx
+	if scopedCtx, hasCtx := ctx.GetTermDefinition(activeProperty)["@context"]; hasCtx {
+		inputCtx, err = ctx.parse(scopedCtx, make([]string, 0), false, true, false, true, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	activeCtx := inputCtx.RevertToPreviousContext()
+	if scopedCtx, hasCtx := inputCtx.GetTermDefinition(activeProperty)["@context"]; hasCtx {
+		activeCtx, err = activeCtx.parse(scopedCtx, nil, false, true, false, true, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pc := &propertyContexts{input: inputCtx, active: activeCtx}
+	cache.property[key] = pc
+	return pc, nil
+}
+
+// nodeContext returns the context which compaction used for the keys of the given compacted
+// node object, the value of activeProperty in ctx: ctx with property-scoped and type-scoped
+// contexts applied, after reverting non-propagated contexts. mapType is the key of the type map
+// which holds the node, if any. See JsonLdApi.Compact.
+func (cache *nodeContextCache) nodeContext(ctx *Context, activeProperty string, mapType string,
+	node map[string]interface{}) (*Context, error) {
+
+	pc, err := cache.propertyContexts(ctx, activeProperty)
+	if err != nil {
+		return nil, err
+	}
+
+	// node references are compacted without reverting the context
+	if len(node) == 1 {
+		idAlias, err := pc.input.CompactIri("@id", nil, true, false)
+		if err != nil {
+			return nil, err
+		}
+		if _, isRef := node[idAlias]; isRef {
+			return pc.input, nil
+		}
+	}
+
+	typeAlias, err := pc.active.CompactIri("@type", nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	types := make([]string, 0)
+	if mapType != "" {
+		types = append(types, mapType)
+	}
+	for _, t := range Arrayify(node[typeAlias]) {
+		if typeStr, isString := t.(string); isString {
+			types = append(types, typeStr)
+		}
+	}
+	if len(types) == 0 {
+		return pc.active, nil
+	}
+
+	// process in lexicographical order, as compaction does
+	sort.Strings(types)
+	key := typedContextKey{
+		propertyContextKey: propertyContextKey{ctx: ctx, property: activeProperty},
+		types:              strings.Join(types, " "),
+	}
+	if typedCtx, found := cache.typed[key]; found {
+		return typedCtx, nil
+	}
+
+	activeCtx := pc.active
+	for _, t := range types {
+		if scopedCtx, hasCtx := pc.input.GetTermDefinition(t)["@context"]; hasCtx {
+			activeCtx, err = activeCtx.parse(scopedCtx, nil, false, false, false, false, false)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	cache.typed[key] = activeCtx
+	return activeCtx, nil
+}
+
+func isMatchNone(v interface{}) bool {
+	vList, isList := v.([]interface{})
+	return isList && len(vList) == 0
+}
+
 // RemovePreserve removes the @preserve keywords as the last step of the framing algorithm.
+// Properties with empty values are then dropped according to the OmitEmpty option of ctx.
 //
 // ctx: the active context used to compact the input
 // input: the framed, compacted output
@@ -312,13 +611,22 @@ func isEmptyObject(v interface{}) bool {
 //
 // Returns the resulting output.
 func RemovePreserve(ctx *Context, input interface{}, bnodesToClear []string, compactArrays bool) (interface{}, error) {
+	return removePreserve(ctx, newNodeContextCache(), "", "", input, bnodesToClear, compactArrays)
+}
+
+// removePreserve implements RemovePreserve for a value of activeProperty. As in compaction,
+// the context is updated with scoped contexts of properties and types along the way,
+// so that aliases of keywords introduced by these contexts are recognised.
+// Contexts computed for nodes are kept in the given cache.
+func removePreserve(ctx *Context, contexts *nodeContextCache, activeProperty string, mapType string, input interface{},
+	bnodesToClear []string, compactArrays bool) (interface{}, error) {
 
 	// recurse through arrays
 	switch v := input.(type) {
 	case []interface{}:
 		output := make([]interface{}, 0)
 		for _, i := range v {
-			result, err := RemovePreserve(ctx, i, bnodesToClear, compactArrays)
+			result, err := removePreserve(ctx, contexts, activeProperty, mapType, i, bnodesToClear, compactArrays)
 			if err != nil {
 				return nil, err
 			}
@@ -338,15 +646,30 @@ func RemovePreserve(ctx *Context, input interface{}, bnodesToClear []string, com
 			return preserveVal, nil
 		}
 
+		ctx, err := contexts.nodeContext(ctx, activeProperty, mapType, v)
+		if err != nil {
+			return nil, err
+		}
+
 		// skip @values
+		valueAlias, err := ctx.CompactIri("@value", nil, true, false)
+		if err != nil {
+			return nil, err
+		}
+		if _, hasValue := v[valueAlias]; hasValue {
+			return input, nil
+		}
 		if _, hasValue := v["@value"]; hasValue {
 			return input, nil
 		}
 
 		// recurse through @lists
-		if listVal, hasList := v["@list"]; hasList {
-			var err error
-			v["@list"], err = RemovePreserve(ctx, listVal, bnodesToClear, compactArrays)
+		listAlias, err := ctx.CompactIri("@list", nil, true, false)
+		if err != nil {
+			return nil, err
+		}
+		if listVal, hasList := v[listAlias]; hasList {
+			v[listAlias], err = removePreserve(ctx, contexts, activeProperty, "", listVal, bnodesToClear, compactArrays)
 			if err != nil {
 				return nil, err
 			}
@@ -354,7 +677,7 @@ func RemovePreserve(ctx *Context, input interface{}, bnodesToClear []string, com
 		}
 
 		// potentially remove the id, if it is an unreference bnode
-		idAlias, err := ctx.CompactIri("@id", nil, false, false)
+		idAlias, err := ctx.CompactIri("@id", nil, true, false)
 		if err != nil {
 			return nil, err
 		}
@@ -366,15 +689,22 @@ func RemovePreserve(ctx *Context, input interface{}, bnodesToClear []string, com
 			}
 		}
 		// recurse through properties
-		graphAlias, err := ctx.CompactIri("@graph", nil, false, false)
+		graphAlias, err := ctx.CompactIri("@graph", nil, true, false)
 		if err != nil {
 			return nil, err
 		}
 		for prop, propVal := range v {
-			result, err := RemovePreserve(ctx, propVal, bnodesToClear, compactArrays)
+			var result interface{}
+			if propMap, isMap := propVal.(map[string]interface{}); isMap && isContainerMap(ctx, prop) {
+				result, err = removePreserveFromMap(ctx, contexts, prop, propMap, bnodesToClear, compactArrays)
+			} else {
+				result, err = removePreserve(ctx, contexts, prop, "", propVal, bnodesToClear, compactArrays)
+			}
 			if err != nil {
 				return nil, err
 			}
+			// only unwrap arrays which compaction would have unwrapped: values of container maps
+			// are handled above, @set and @list containers always keep their arrays
 			isListContainer := ctx.HasContainerMapping(prop, "@list")
 			isSetContainer := ctx.HasContainerMapping(prop, "@set")
 			resultList, isList := result.([]interface{})
@@ -383,6 +713,13 @@ func RemovePreserve(ctx *Context, input interface{}, bnodesToClear []string, com
 			}
 			v[prop] = result
 		}
+
+		// properties set to null by @default are only known now
+		if ctx.options != nil && ctx.options.OmitEmpty != 0 {
+			if err = omitEmptyValues(ctx, v, ctx.options.OmitEmpty); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return input, nil
@@ -497,16 +834,30 @@ func RemoveValue(subject interface{}, property string, value interface{}, proper
 // 1. They are both primitives of the same type and value.
 // 2. They are both @values with the same @value, @type, and @language, OR
 // 3. They both have @ids they are the same.
+//
+// The @value entries of JSON literals (@type: @json) are compared structurally.
+// Numbers are compared with NumbersAsText, see CompareValuesWith.
 func CompareValues(v1 interface{}, v2 interface{}) bool {
+	return CompareValuesWith(v1, v2, nil)
+}
+
+// CompareValuesWith compares two JSON-LD values for equality in the same way as CompareValues,
+// except that primitive values and @value entries are compared according to the given options.
+// If opts is nil, the default options are used. ListOrderMatters is ignored.
+func CompareValuesWith(v1 interface{}, v2 interface{}, opts *CompareOptions) bool {
+	if opts == nil {
+		opts = &CompareOptions{}
+	}
+
 	v1Map, isv1Map := v1.(map[string]interface{})
 	v2Map, isv2Map := v2.(map[string]interface{})
 
-	if !isv1Map && !isv2Map && v1 == v2 {
+	if !isv1Map && !isv2Map && DeepCompareWith(v1, v2, opts) {
 		return true
 	}
 
 	if IsValue(v1) && IsValue(v2) {
-		if v1Map["@value"] == v2Map["@value"] &&
+		if compareValueEntries(v1Map, v2Map, opts) &&
 			v1Map["@type"] == v2Map["@type"] &&
 			v1Map["@language"] == v2Map["@language"] &&
 			v1Map["@index"] == v2Map["@index"] {
@@ -523,6 +874,17 @@ func CompareValues(v1 interface{}, v2 interface{}) bool {
 	return false
 }
 
+// compareValueEntries compares @value entries of two value objects. JSON literals
+// (values of type @json) are compared structurally, with array order being significant.
+func compareValueEntries(v1Map, v2Map map[string]interface{}, opts *CompareOptions) bool {
+	if v1Map["@type"] == "@json" || v2Map["@type"] == "@json" {
+		jsonOpts := *opts
+		jsonOpts.ListOrderMatters = true
+		return DeepCompareWith(v1Map["@value"], v2Map["@value"], &jsonOpts)
+	}
+	return DeepCompareWith(v1Map["@value"], v2Map["@value"], opts)
+}
+
 // CloneDocument returns a cloned instance of the given document
 func CloneDocument(value interface{}) interface{} {
 	if value == nil {
@@ -580,11 +942,5 @@ func GetOrderedKeys(m map[string]interface{}) []string {
 
 // PrintDocument prints a JSON-LD document. This is useful for debugging.
 func PrintDocument(msg string, doc interface{}) {
-	b, _ := json.MarshalIndent(doc, "", "  ")
-	if msg != "" {
-		_, _ = os.Stdout.WriteString(msg)
-		_, _ = os.Stdout.WriteString("\n")
-	}
-	_, _ = os.Stdout.Write(b)
-	_, _ = os.Stdout.WriteString("\n")
+	_ = FprintDocument(os.Stdout, msg, doc)
 }
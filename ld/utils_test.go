@@ -0,0 +1,144 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueConstructors(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{"@value": "v"}, NewValue("v", "", "", ""))
+	assert.Equal(t,
+		map[string]interface{}{"@value": "5", "@type": XSDInteger},
+		NewValue("5", XSDInteger, "", ""),
+	)
+	assert.Equal(t,
+		map[string]interface{}{"@value": "v", "@language": "en", "@direction": "ltr"},
+		NewValue("v", "", "en", "ltr"),
+	)
+	assert.True(t, IsValue(NewValue(nil, "", "", "")))
+
+	assert.Equal(t, map[string]interface{}{"@id": "http://example.com/1"}, NewRef("http://example.com/1"))
+	assert.True(t, IsSubjectReference(NewRef("http://example.com/1")))
+
+	assert.Equal(t, map[string]interface{}{"@list": []interface{}{}}, NewList())
+	assert.True(t, IsList(NewList("a", NewRef("http://example.com/1"))))
+
+	doc := map[string]interface{}{
+		"http://example.com/p": NewList(NewValue("a", "", "en", ""), NewRef("http://example.com/2")),
+	}
+	expanded, err := NewJsonLdProcessor().Expand(doc, NewJsonLdOptions(""))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"http://example.com/p": []interface{}{
+				map[string]interface{}{
+					"@list": []interface{}{
+						map[string]interface{}{"@value": "a", "@language": "en"},
+						map[string]interface{}{"@id": "http://example.com/2"},
+					},
+				},
+			},
+		},
+	}, expanded)
+}
+
+func TestDeepCompareWith(t *testing.T) {
+	doc := func(n interface{}) interface{} {
+		return map[string]interface{}{"v": []interface{}{n, "a"}}
+	}
+	large1 := json.Number("12345678901234567890.1")
+	large2 := json.Number("12345678901234567890.2")
+
+	// DeepCompare formats numbers with 6 decimal places
+	assert.True(t, DeepCompare(doc(1.0), doc(json.Number("1")), false))
+	assert.True(t, DeepCompare(doc(1.0), doc(1.0000001), false))
+	assert.True(t, DeepCompare(doc(large1), doc(large2), false))
+
+	exact := &CompareOptions{Numbers: NumbersExact}
+	assert.True(t, DeepCompareWith(doc(1.0), doc(json.Number("1")), exact))
+	assert.True(t, DeepCompareWith(doc(1.0), doc(1), exact))
+	assert.False(t, DeepCompareWith(doc(1.0), doc(1.0000001), exact))
+	assert.False(t, DeepCompareWith(doc(1.0), doc("1"), exact))
+
+	epsilon := &CompareOptions{Numbers: NumbersWithinEpsilon, Epsilon: 1e-3}
+	assert.True(t, DeepCompareWith(doc(1.0), doc(1.0005), epsilon))
+	assert.False(t, DeepCompareWith(doc(1.0), doc(1.002), epsilon))
+
+	rational := &CompareOptions{Numbers: NumbersAsRational}
+	assert.True(t, DeepCompareWith(doc(0.5), doc(json.Number("0.50")), rational))
+	assert.False(t, DeepCompareWith(doc(large1), doc(large2), rational))
+	assert.True(t, DeepCompareWith(doc(large1), doc(json.Number("1234567890123456789.01e1")), rational))
+
+	ordered := &CompareOptions{ListOrderMatters: true, Numbers: NumbersExact}
+	assert.True(t, DeepCompareWith([]interface{}{1.0, 2.0}, []interface{}{2.0, 1.0}, exact))
+	assert.False(t, DeepCompareWith([]interface{}{1.0, 2.0}, []interface{}{2.0, 1.0}, ordered))
+}
+
+func TestCompareValuesWith(t *testing.T) {
+	v1 := map[string]interface{}{"@value": 1.0, "@type": XSDDouble}
+	v2 := map[string]interface{}{"@value": json.Number("1.0000001"), "@type": XSDDouble}
+
+	assert.True(t, CompareValues(1.0, 1.0))
+	assert.True(t, CompareValues(v1, v2))
+	assert.True(t, CompareValuesWith(v1, v2, nil))
+	assert.False(t, CompareValuesWith(v1, v2, &CompareOptions{Numbers: NumbersExact}))
+	assert.True(t, CompareValuesWith(v1, v2, &CompareOptions{Numbers: NumbersWithinEpsilon, Epsilon: 1e-6}))
+	assert.True(t, CompareValuesWith(NewRef("http://example.com/1"), NewRef("http://example.com/1"), nil))
+}
+
+func TestCompareValuesJSONLiterals(t *testing.T) {
+	jsonLiteral := func(v interface{}) map[string]interface{} {
+		return map[string]interface{}{"@value": v, "@type": "@json"}
+	}
+	obj1 := map[string]interface{}{"a": 1.0, "b": []interface{}{true, "x"}}
+	obj2 := map[string]interface{}{"b": []interface{}{true, "x"}, "a": 1.0}
+
+	assert.True(t, CompareValues(jsonLiteral(obj1), jsonLiteral(obj2)))
+	assert.True(t, CompareValuesWith(jsonLiteral(obj1), jsonLiteral(obj2), nil))
+	assert.False(t, CompareValues(jsonLiteral(obj1), jsonLiteral(map[string]interface{}{"a": 1.0})))
+	// array order is significant in JSON literals
+	assert.False(t, CompareValues(jsonLiteral([]interface{}{1.0, 2.0}), jsonLiteral([]interface{}{2.0, 1.0})))
+	assert.False(t, CompareValuesWith(jsonLiteral([]interface{}{1.0, 2.0}), jsonLiteral([]interface{}{2.0, 1.0}), nil))
+	assert.False(t, CompareValues(jsonLiteral("x"), map[string]interface{}{"@value": "x"}))
+
+	// duplicate JSON literals are removed when nodes are merged
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{
+			"data": map[string]interface{}{"@id": "http://example.com/data", "@type": "@json"},
+		},
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "http://example.com/s", "data": obj1},
+			map[string]interface{}{"@id": "http://example.com/s", "data": obj2},
+			map[string]interface{}{"@id": "http://example.com/s", "data": []interface{}{1.0, 2.0}},
+		},
+	}
+	flattened, err := NewJsonLdProcessor().Flatten(doc, nil, NewJsonLdOptions(""))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"@id": "http://example.com/s",
+			"http://example.com/data": []interface{}{
+				jsonLiteral(obj1),
+				jsonLiteral([]interface{}{1.0, 2.0}),
+			},
+		},
+	}, flattened)
+}
@@ -0,0 +1,77 @@
+// Copyright 2015-2017 Piprate Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ld
+
+import (
+	"runtime/debug"
+)
+
+// modulePath is the path of the json-gold module.
+const modulePath = "github.com/piprate/json-gold"
+
+// Version is the version of the json-gold module the program was built with (such as "v0.5.0"),
+// as recorded in the build information of the program. It's "(devel)" if json-gold
+// is the main module, and empty if the build information isn't available.
+var Version = moduleVersion(debug.ReadBuildInfo())
+
+// moduleVersion returns the version of the json-gold module from the given build information.
+func moduleVersion(info *debug.BuildInfo, ok bool) string {
+	if !ok {
+		return ""
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// ProcessorCapabilities describes the version and the features of json-gold.
+// It allows services to advertise capabilities of their JSON-LD processor
+// (for example, in issuer metadata) without hardcoding them.
+type ProcessorCapabilities struct {
+	// Version is the version of the library, see Version.
+	Version string `json:"version"`
+	// SpecVersions lists the versions of JSON-LD specifications implemented by the library.
+	SpecVersions []string `json:"specVersions"`
+	// RDFInputFormats lists RDF formats which can be converted into JSON-LD.
+	RDFInputFormats []string `json:"rdfInputFormats"`
+
+	Features
+}
+
+// Capabilities returns the version and the features of this build of json-gold.
+func Capabilities() *ProcessorCapabilities {
+	inputFormats := make([]string, 0)
+	for _, format := range rdfSerializerFormats() {
+		if isSupportedRDFFormat(nil, format, true) {
+			inputFormats = append(inputFormats, format)
+		}
+	}
+
+	return &ProcessorCapabilities{
+		Version:         Version,
+		SpecVersions:    []string{"1.0", "1.1"},
+		RDFInputFormats: inputFormats,
+		Features:        *SupportedFeatures(),
+	}
+}